@@ -8,7 +8,7 @@ import (
 
 // ClassDAL handles database operations for Class entities.
 type ClassDAL struct {
-	db    *sql.DB
+	db    DBTX
 	cache CacheInterface
 }
 
@@ -17,7 +17,7 @@ func (d *ClassDAL) Cache() CacheInterface {
 }
 
 // NewClassDAL creates a new ClassDAL.
-func NewClassDAL(db *sql.DB, cache CacheInterface) *ClassDAL {
+func NewClassDAL(db DBTX, cache CacheInterface) *ClassDAL {
 	return &ClassDAL{db: db, cache: cache}
 }
 
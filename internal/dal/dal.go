@@ -2,14 +2,20 @@ package dal
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"mud/internal/models"
 )
 
 // DAL is a struct that holds all the data access layers.
 type DAL struct {
 	RoomDAL               RoomDALInterface
 	ItemDAL               ItemDALInterface
+	ItemInstanceDAL       ItemInstanceDALInterface
 	NpcDAL                NPCDALInterface
 	OwnerDAL              OwnerDALInterface
 	LoreDAL               LoreDALInterface
@@ -25,15 +31,31 @@ type DAL struct {
 	PlayerSkillDAL        PlayerSkillDALInterface
 	ClassDAL              ClassDALInterface
 	PlayerClassDAL        PlayerClassDALInterface
+	StatusEffectDAL       StatusEffectDALInterface
+	InfluenceDAL          InfluenceDALInterface
+	WorldDAL              WorldDALInterface
+
+	// db and cache are what Begin starts units of work on; a transaction's
+	// DAL has neither.
+	db    *sql.DB
+	cache CacheInterface
 }
 
 // NewDAL creates a new DAL instance with all its sub-DALs.
 func NewDAL(db *sql.DB) *DAL {
 	newCache := NewCache()
+	d := newDAL(db, newCache)
+	d.db, d.cache = db, newCache
+	return d
+}
+
+// newDAL creates the sub-DALs on a database or transaction and a cache.
+func newDAL(db DBTX, newCache CacheInterface) *DAL {
 	itemDAL := NewItemDAL(db, newCache)
 	return &DAL{
 		RoomDAL:               NewRoomDAL(db, newCache),
 		ItemDAL:               itemDAL,
+		ItemInstanceDAL:       NewItemInstanceDAL(db, newCache, itemDAL),
 		NpcDAL:                NewNPCDAL(db, newCache),
 		OwnerDAL:              NewOwnerDAL(db, newCache),
 		LoreDAL:               NewLoreDAL(db, newCache),
@@ -49,6 +71,9 @@ func NewDAL(db *sql.DB) *DAL {
 		PlayerSkillDAL:        NewPlayerSkillDAL(db, newCache),
 		ClassDAL:              NewClassDAL(db, newCache),
 		PlayerClassDAL:        NewPlayerClassDAL(db, newCache),
+		StatusEffectDAL:       NewStatusEffectDAL(db, newCache),
+		InfluenceDAL:          NewInfluenceDAL(db, newCache),
+		WorldDAL:              NewWorldDAL(db, newCache),
 	}
 }
 
@@ -85,6 +110,8 @@ func InitDB(dataSourceName string) (*sql.DB, error) {
 		current_room_id TEXT NOT NULL,
 		health INTEGER NOT NULL,
 		max_health INTEGER NOT NULL,
+		energy INTEGER NOT NULL DEFAULT 0,
+		max_energy INTEGER NOT NULL DEFAULT 0,
 		inventory TEXT NOT NULL,
 		visited_room_ids TEXT NOT NULL,
 		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
@@ -111,6 +138,83 @@ func InitDB(dataSourceName string) (*sql.DB, error) {
 		properties JSON
 	);
 
+	CREATE TABLE IF NOT EXISTS item_instances (
+		id TEXT PRIMARY KEY NOT NULL,
+		template_id TEXT NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		properties JSON NOT NULL DEFAULT '{}',
+		quantity INTEGER NOT NULL DEFAULT 1,
+		location_type TEXT NOT NULL,
+		location_id TEXT NOT NULL,
+		slot TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (template_id) REFERENCES Items(id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_item_instances_location ON item_instances (location_type, location_id);
+
+	CREATE TABLE IF NOT EXISTS status_effects (
+		id TEXT PRIMARY KEY NOT NULL,
+		target_type TEXT NOT NULL,
+		target_id TEXT NOT NULL,
+		source_type TEXT NOT NULL DEFAULT '',
+		source_id TEXT NOT NULL DEFAULT '',
+		type TEXT NOT NULL,
+		key TEXT NOT NULL,
+		magnitude REAL NOT NULL DEFAULT 0,
+		stacking TEXT NOT NULL DEFAULT 'refresh',
+		stacks INTEGER NOT NULL DEFAULT 1,
+		max_stacks INTEGER NOT NULL DEFAULT 0,
+		applied_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_status_effects_target ON status_effects (target_type, target_id);
+	CREATE INDEX IF NOT EXISTS idx_status_effects_expiry ON status_effects (expires_at);
+
+	CREATE TABLE IF NOT EXISTS influence_entries (
+		id TEXT PRIMARY KEY NOT NULL,
+		entity_type TEXT NOT NULL,
+		entity_id TEXT NOT NULL,
+		amount REAL NOT NULL,
+		balance REAL NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		refused BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_influence_entries_entity ON influence_entries (entity_type, entity_id);
+
+	CREATE TABLE IF NOT EXISTS world_events (
+		id TEXT PRIMARY KEY NOT NULL,
+		event_type TEXT NOT NULL,
+		details TEXT NOT NULL DEFAULT '',
+		source_type TEXT NOT NULL DEFAULT '',
+		source_id TEXT NOT NULL DEFAULT '',
+		room_id TEXT NOT NULL DEFAULT '',
+		territory_id TEXT NOT NULL DEFAULT '',
+		started_at TIMESTAMP NOT NULL,
+		ends_at TIMESTAMP NOT NULL,
+		ended BOOLEAN NOT NULL DEFAULT FALSE
+	);
+
+	CREATE TABLE IF NOT EXISTS room_overlays (
+		id TEXT PRIMARY KEY NOT NULL,
+		room_id TEXT NOT NULL,
+		source_type TEXT NOT NULL DEFAULT '',
+		source_id TEXT NOT NULL DEFAULT '',
+		description TEXT NOT NULL DEFAULT '',
+		description_add TEXT NOT NULL DEFAULT '',
+		properties TEXT NOT NULL DEFAULT '',
+		previous_description TEXT NOT NULL DEFAULT '',
+		previous_properties TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		reverted BOOLEAN NOT NULL DEFAULT FALSE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_room_overlays_room ON room_overlays (room_id);
+
 	CREATE TABLE IF NOT EXISTS NPCs (
 		id TEXT PRIMARY KEY NOT NULL,
 		name TEXT NOT NULL,
@@ -126,7 +230,8 @@ func InitDB(dataSourceName string) (*sql.DB, error) {
 		behavior_state JSON,
 		reaction_threshold INTEGER NOT NULL DEFAULT 0,
 		race_id TEXT,
-		profession_id TEXT
+		profession_id TEXT,
+		llm_provider TEXT NOT NULL DEFAULT ''
 	);
 
 	CREATE TABLE IF NOT EXISTS Owners (
@@ -142,7 +247,8 @@ func InitDB(dataSourceName string) (*sql.DB, error) {
 		budget_regen_rate REAL NOT NULL,
 		available_tools JSON NOT NULL,
 		initiated_quests JSON NOT NULL,
-		reaction_threshold INTEGER NOT NULL DEFAULT 0
+		reaction_threshold INTEGER NOT NULL DEFAULT 0,
+		llm_provider TEXT NOT NULL DEFAULT ''
 	);
 
 	CREATE TABLE IF NOT EXISTS Quests (
@@ -165,7 +271,8 @@ func InitDB(dataSourceName string) (*sql.DB, error) {
 		budget_regen_rate REAL NOT NULL,
 		memories_about_players JSON NOT NULL,
 		available_tools JSON NOT NULL,
-		reaction_threshold INTEGER NOT NULL DEFAULT 0
+		reaction_threshold INTEGER NOT NULL DEFAULT 0,
+		llm_provider TEXT NOT NULL DEFAULT ''
 	);
 
 	CREATE TABLE IF NOT EXISTS QuestOwners (
@@ -176,7 +283,8 @@ func InitDB(dataSourceName string) (*sql.DB, error) {
 		current_influence_budget REAL NOT NULL,
 		max_influence_budget REAL NOT NULL,
 		budget_regen_rate REAL NOT NULL,
-		associated_questmaker_ids JSON NOT NULL
+		associated_questmaker_ids JSON NOT NULL,
+		available_tools JSON NOT NULL DEFAULT '[]'
 	);
 
 	CREATE TABLE IF NOT EXISTS PlayerQuestStates (
@@ -254,6 +362,7 @@ func InitDB(dataSourceName string) (*sql.DB, error) {
 		class_id TEXT NOT NULL,
 		level INTEGER NOT NULL DEFAULT 1,
 		experience INTEGER NOT NULL DEFAULT 0,
+		practice_points INTEGER NOT NULL DEFAULT 0,
 		PRIMARY KEY (player_id, class_id)
 	);
 
@@ -277,5 +386,169 @@ func InitDB(dataSourceName string) (*sql.DB, error) {
 		logrus.Fatalf("Error creating tables: %v", err)
 	}
 
+	for _, column := range addedColumns {
+		if err := addColumnIfMissing(db, column.table, column.name, column.definition); err != nil {
+			return nil, err
+		}
+	}
+	if err := migrateLegacyItems(db); err != nil {
+		return nil, err
+	}
+
 	return db, nil
-}
\ No newline at end of file
+}
+
+// addedColumns are the columns added to tables after they were first
+// created. CREATE TABLE IF NOT EXISTS leaves existing tables alone, so
+// databases made before a column was added gain it here.
+var addedColumns = []struct {
+	table, name, definition string
+}{
+	{"player_characters", "energy", "INTEGER NOT NULL DEFAULT 0"},
+	{"player_characters", "max_energy", "INTEGER NOT NULL DEFAULT 0"},
+	{"PlayerClasses", "practice_points", "INTEGER NOT NULL DEFAULT 0"},
+	{"QuestOwners", "available_tools", "JSON NOT NULL DEFAULT '[]'"},
+	{"NPCs", "llm_provider", "TEXT NOT NULL DEFAULT ''"},
+	{"Owners", "llm_provider", "TEXT NOT NULL DEFAULT ''"},
+	{"Questmakers", "llm_provider", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// addColumnIfMissing adds a column to a table unless it already has one of
+// that name.
+func addColumnIfMissing(db *sql.DB, table, name, definition string) error {
+	exists, err := hasColumn(db, table, name)
+	if err != nil || exists {
+		return err
+	}
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, name, definition)); err != nil {
+		return fmt.Errorf("failed to add column %s to %s: %w", name, table, err)
+	}
+	logrus.Infof("Added column %s to %s", name, table)
+	return nil
+}
+
+// hasColumn reports whether a table has a column of that name.
+func hasColumn(db DBTX, table, name string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			column, typ      string
+			defaultValue     sql.NullString
+		)
+		if err := rows.Scan(&cid, &column, &typ, &notNull, &defaultValue, &pk); err != nil {
+			return false, fmt.Errorf("failed to scan column of %s: %w", table, err)
+		}
+		if column == name {
+			return true, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	return false, nil
+}
+
+// legacyItems are the items a character held before item instances: the
+// template IDs in its inventory column and, in databases made while
+// equipment was kept on characters, the template IDs in its equipment
+// column keyed by slot.
+type legacyItems struct {
+	characterID string
+	inventory   []string
+	equipment   map[string]string
+}
+
+// migrateLegacyItems converts the items characters hold in their inventory
+// and equipment columns into item instances, clearing the inventory and
+// dropping the equipment column. It is done in one transaction, so a
+// character's items are converted exactly once.
+func migrateLegacyItems(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin item migration: %w", err)
+	}
+	defer tx.Rollback()
+
+	hasEquipment, err := hasColumn(tx, "player_characters", "equipment")
+	if err != nil {
+		return err
+	}
+	query := `SELECT id, inventory, '{}' FROM player_characters WHERE inventory NOT IN ('', '[]')`
+	if hasEquipment {
+		query = `SELECT id, inventory, equipment FROM player_characters WHERE inventory NOT IN ('', '[]') OR equipment NOT IN ('', '{}')`
+	}
+	held, err := readLegacyItems(tx, query)
+	if err != nil {
+		return err
+	}
+
+	insert := `INSERT INTO item_instances (id, template_id, location_type, location_id, slot, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	now := time.Now()
+	for _, items := range held {
+		for _, templateID := range items.inventory {
+			if _, err := tx.Exec(insert, uuid.New().String(), templateID, models.LocationCharacter, items.characterID, "", now); err != nil {
+				return fmt.Errorf("failed to convert item %s of character %s: %w", templateID, items.characterID, err)
+			}
+		}
+		for slot, templateID := range items.equipment {
+			if _, err := tx.Exec(insert, uuid.New().String(), templateID, models.LocationCharacter, items.characterID, slot, now); err != nil {
+				return fmt.Errorf("failed to convert equipped item %s of character %s: %w", templateID, items.characterID, err)
+			}
+		}
+		if _, err := tx.Exec(`UPDATE player_characters SET inventory = '[]' WHERE id = ?`, items.characterID); err != nil {
+			return fmt.Errorf("failed to clear inventory of character %s: %w", items.characterID, err)
+		}
+	}
+	if hasEquipment {
+		if _, err := tx.Exec(`ALTER TABLE player_characters DROP COLUMN equipment`); err != nil {
+			return fmt.Errorf("failed to drop equipment column: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit item migration: %w", err)
+	}
+	if len(held) > 0 {
+		logrus.Infof("Converted the items of %d characters into item instances", len(held))
+	}
+	return nil
+}
+
+// readLegacyItems reads the items of the characters a query selects, as
+// rows of ID, inventory and equipment.
+func readLegacyItems(db DBTX, query string) ([]legacyItems, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read legacy items: %w", err)
+	}
+	defer rows.Close()
+
+	var held []legacyItems
+	for rows.Next() {
+		var id, inventory, equipment string
+		if err := rows.Scan(&id, &inventory, &equipment); err != nil {
+			return nil, fmt.Errorf("failed to scan legacy items: %w", err)
+		}
+		items := legacyItems{characterID: id}
+		if inventory != "" {
+			if err := json.Unmarshal([]byte(inventory), &items.inventory); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal inventory of character %s: %w", id, err)
+			}
+		}
+		if equipment != "" {
+			if err := json.Unmarshal([]byte(equipment), &items.equipment); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal equipment of character %s: %w", id, err)
+			}
+		}
+		held = append(held, items)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read legacy items: %w", err)
+	}
+	return held, nil
+}
@@ -0,0 +1,158 @@
+package dal
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/models"
+)
+
+// olderSchema is what tables looked like before columns were added to them.
+const olderSchema = `
+	CREATE TABLE player_characters (
+		id TEXT PRIMARY KEY NOT NULL,
+		player_account_id TEXT NOT NULL,
+		name TEXT NOT NULL UNIQUE,
+		race_id TEXT NOT NULL,
+		profession_id TEXT NOT NULL,
+		current_room_id TEXT NOT NULL,
+		health INTEGER NOT NULL,
+		max_health INTEGER NOT NULL,
+		inventory TEXT NOT NULL,
+		visited_room_ids TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		last_played_at TIMESTAMP
+	);
+	INSERT INTO player_characters (id, player_account_id, name, race_id, profession_id, current_room_id, health, max_health, inventory, visited_room_ids)
+		VALUES ('hero', 'account', 'Hero', 'human', 'warrior', 'square', 10, 10, '[]', '[]');
+
+	CREATE TABLE PlayerClasses (
+		player_id TEXT NOT NULL,
+		class_id TEXT NOT NULL,
+		level INTEGER NOT NULL DEFAULT 1,
+		experience INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (player_id, class_id)
+	);
+	INSERT INTO PlayerClasses (player_id, class_id) VALUES ('hero', 'warrior');
+
+	CREATE TABLE QuestOwners (
+		id TEXT PRIMARY KEY NOT NULL,
+		name TEXT NOT NULL,
+		description TEXT NOT NULL,
+		llm_prompt_context TEXT NOT NULL,
+		current_influence_budget REAL NOT NULL,
+		max_influence_budget REAL NOT NULL,
+		budget_regen_rate REAL NOT NULL,
+		associated_questmaker_ids JSON NOT NULL
+	);
+	INSERT INTO QuestOwners VALUES ('shadow', 'The Shadow', '', '', 10, 10, 1, '[]');
+
+	CREATE TABLE Questmakers (
+		id TEXT PRIMARY KEY NOT NULL,
+		name TEXT NOT NULL,
+		llm_prompt_context TEXT NOT NULL,
+		current_influence_budget REAL NOT NULL,
+		max_influence_budget REAL NOT NULL,
+		budget_regen_rate REAL NOT NULL,
+		memories_about_players JSON NOT NULL,
+		available_tools JSON NOT NULL,
+		reaction_threshold INTEGER NOT NULL DEFAULT 0
+	);
+	INSERT INTO Questmakers VALUES ('hunt', 'The Hunt', '', 10, 10, 1, '{}', '[]', 0);
+`
+
+func TestInitDB_AddsMissingColumns(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "testdb_*.sqlite")
+	assert.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	older, err := sql.Open("sqlite3", tmpfile.Name())
+	assert.NoError(t, err)
+	_, err = older.Exec(olderSchema)
+	assert.NoError(t, err)
+	older.Close()
+
+	db, err := InitDB(tmpfile.Name())
+	assert.NoError(t, err)
+	var energy, maxEnergy int
+	assert.NoError(t, db.QueryRow("SELECT energy, max_energy FROM player_characters WHERE id = 'hero'").Scan(&energy, &maxEnergy))
+	assert.Equal(t, 0, energy)
+	assert.Equal(t, 0, maxEnergy)
+	var practicePoints int
+	assert.NoError(t, db.QueryRow("SELECT practice_points FROM PlayerClasses WHERE player_id = 'hero'").Scan(&practicePoints))
+	assert.Equal(t, 0, practicePoints)
+	dals := NewDAL(db)
+	questOwner, err := dals.QuestOwnerDAL.GetQuestOwnerByID("shadow")
+	assert.NoError(t, err)
+	if assert.NotNil(t, questOwner) {
+		assert.Empty(t, questOwner.AvailableTools)
+	}
+	questmaker, err := dals.QuestmakerDAL.GetQuestmakerByID("hunt")
+	assert.NoError(t, err)
+	if assert.NotNil(t, questmaker) {
+		assert.Equal(t, "", questmaker.LLMProvider, "Entities made before providers use the default")
+	}
+	db.Close()
+
+	db, err = InitDB(tmpfile.Name())
+	assert.NoError(t, err, "Columns already added are left alone")
+	db.Close()
+}
+
+func TestInitDB_ConvertsLegacyItems(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "testdb_*.sqlite")
+	assert.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	older, err := sql.Open("sqlite3", tmpfile.Name())
+	assert.NoError(t, err)
+	// Characters once carried template IDs, and for a while kept what they
+	// had equipped in a column of their own.
+	_, err = older.Exec(`
+	CREATE TABLE player_characters (
+		id TEXT PRIMARY KEY NOT NULL,
+		player_account_id TEXT NOT NULL,
+		name TEXT NOT NULL UNIQUE,
+		race_id TEXT NOT NULL,
+		profession_id TEXT NOT NULL,
+		current_room_id TEXT NOT NULL,
+		health INTEGER NOT NULL,
+		max_health INTEGER NOT NULL,
+		inventory TEXT NOT NULL,
+		equipment TEXT NOT NULL DEFAULT '{}',
+		visited_room_ids TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		last_played_at TIMESTAMP
+	);
+	INSERT INTO player_characters (id, player_account_id, name, race_id, profession_id, current_room_id, health, max_health, inventory, equipment, visited_room_ids)
+		VALUES ('hero', 'account', 'Hero', 'human', 'warrior', 'square', 10, 10, '["apple"]', '{"wielded": "sword"}', '[]');
+	`)
+	assert.NoError(t, err)
+	older.Close()
+
+	db, err := InitDB(tmpfile.Name())
+	assert.NoError(t, err)
+	dals := NewDAL(db)
+	held, err := dals.ItemInstanceDAL.GetInstancesByLocation(models.LocationCharacter, "hero")
+	assert.NoError(t, err)
+	slots := make(map[string]string)
+	for _, instance := range held {
+		slots[instance.TemplateID] = instance.Slot
+	}
+	assert.Equal(t, map[string]string{"apple": "", "sword": "wielded"}, slots)
+	character, err := dals.PlayerCharacterDAL.GetCharacterByID("hero")
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", character.Inventory)
+	hasEquipment, err := hasColumn(db, "player_characters", "equipment")
+	assert.NoError(t, err)
+	assert.False(t, hasEquipment)
+	db.Close()
+
+	db, err = InitDB(tmpfile.Name())
+	assert.NoError(t, err)
+	dals = NewDAL(db)
+	held, err = dals.ItemInstanceDAL.GetInstancesByLocation(models.LocationCharacter, "hero")
+	assert.NoError(t, err)
+	assert.Len(t, held, 2, "Items are converted once")
+	db.Close()
+}
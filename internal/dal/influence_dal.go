@@ -0,0 +1,95 @@
+package dal
+
+import (
+	"fmt"
+	"mud/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InfluenceDAL handles database operations for the influence audit log.
+type InfluenceDAL struct {
+	db    DBTX
+	cache CacheInterface
+}
+
+func (d *InfluenceDAL) Cache() CacheInterface {
+	return d.cache
+}
+
+// NewInfluenceDAL creates a new InfluenceDAL.
+func NewInfluenceDAL(db DBTX, cache CacheInterface) *InfluenceDAL {
+	return &InfluenceDAL{db: db, cache: cache}
+}
+
+const influenceEntryColumns = `id, entity_type, entity_id, amount, balance, reason, refused, created_at`
+
+// CreateInfluenceEntry appends an entry to the audit log. An ID and creation
+// time are set when missing.
+func (d *InfluenceDAL) CreateInfluenceEntry(entry *models.InfluenceEntry) error {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	query := `INSERT INTO influence_entries (` + influenceEntryColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := d.db.Exec(query,
+		entry.ID,
+		entry.EntityType,
+		entry.EntityID,
+		entry.Amount,
+		entry.Balance,
+		entry.Reason,
+		entry.Refused,
+		entry.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create influence entry: %w", err)
+	}
+	return nil
+}
+
+// GetInfluenceEntries retrieves up to limit audit entries, newest first. An
+// empty entityType or entityID matches every entity; a limit of zero or
+// less returns them all.
+func (d *InfluenceDAL) GetInfluenceEntries(entityType, entityID string, limit int) ([]*models.InfluenceEntry, error) {
+	query := `SELECT ` + influenceEntryColumns + ` FROM influence_entries
+	WHERE (? = '' OR entity_type = ?) AND (? = '' OR entity_id = ?)
+	ORDER BY created_at DESC, rowid DESC`
+	args := []interface{}{entityType, entityType, entityID, entityID}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get influence entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.InfluenceEntry
+	for rows.Next() {
+		entry := &models.InfluenceEntry{}
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.EntityType,
+			&entry.EntityID,
+			&entry.Amount,
+			&entry.Balance,
+			&entry.Reason,
+			&entry.Refused,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan influence entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating through influence entries: %w", err)
+	}
+	return entries, nil
+}
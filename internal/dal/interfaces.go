@@ -11,12 +11,15 @@ type PlayerCharacterDALInterface interface {
 	GetAllCharacters() ([]*models.PlayerCharacter, error)
 	CreateCharacter(character *models.PlayerCharacter) error
 	UpdateCharacter(character *models.PlayerCharacter) error
+	AdjustCharacterVitals(id string, healthDelta, energyDelta int) (*models.PlayerCharacter, error)
+	RespawnCharacter(id, roomID string) (*models.PlayerCharacter, error)
+	UpdateCharacterRoom(id, roomID string) error
 	DeleteCharacter(id string) error
-	GetCharacterInventory(characterID string) ([]*models.Item, error)
 	GetCharacterSkills(characterID string) ([]*models.PlayerSkill, error)
 	GetCharacterClass(characterID string) (*models.PlayerClass, error)
 	GetCharacterQuestState(characterID, questID string) (*models.PlayerQuestState, error)
 	GetCharactersByAccountID(accountID string) ([]*models.PlayerCharacter, error)
+	GetCharacterByName(name string) (*models.PlayerCharacter, error)
 	Cache() CacheInterface
 }
 
@@ -121,6 +124,50 @@ type ItemDALInterface interface {
 	Cache() CacheInterface
 }
 
+// ItemInstanceDALInterface defines the methods for ItemInstanceDAL.
+type ItemInstanceDALInterface interface {
+	GetInstanceByID(id string) (*models.ItemInstance, error)
+	GetInstancesByLocation(locationType, locationID string) ([]*models.ItemInstance, error)
+	CreateInstance(instance *models.ItemInstance) error
+	UpdateInstance(instance *models.ItemInstance) error
+	UpdateInstanceIfUnchanged(was, instance *models.ItemInstance) error
+	DeleteInstance(id string) error
+	DeleteInstanceIfUnchanged(was *models.ItemInstance) error
+	AddInstanceQuantity(into *models.ItemInstance, quantity int) error
+	MergeInstance(was, into *models.ItemInstance) error
+	Cache() CacheInterface
+}
+
+// StatusEffectDALInterface defines the methods for StatusEffectDAL.
+type StatusEffectDALInterface interface {
+	GetStatusEffectByID(id string) (*models.StatusEffect, error)
+	GetStatusEffectsByTarget(targetType, targetID string) ([]*models.StatusEffect, error)
+	GetExpiredStatusEffects(now time.Time) ([]*models.StatusEffect, error)
+	CreateStatusEffect(effect *models.StatusEffect) error
+	UpdateStatusEffect(effect *models.StatusEffect) error
+	DeleteStatusEffect(id string) error
+	Cache() CacheInterface
+}
+
+// InfluenceDALInterface defines the methods for InfluenceDAL.
+type InfluenceDALInterface interface {
+	CreateInfluenceEntry(entry *models.InfluenceEntry) error
+	GetInfluenceEntries(entityType, entityID string, limit int) ([]*models.InfluenceEntry, error)
+	Cache() CacheInterface
+}
+
+// WorldDALInterface defines the methods for WorldDAL.
+type WorldDALInterface interface {
+	CreateWorldEvent(event *models.WorldEvent) error
+	UpdateWorldEvent(event *models.WorldEvent) error
+	GetActiveWorldEvents() ([]*models.WorldEvent, error)
+	CreateRoomOverlay(overlay *models.RoomOverlay) error
+	UpdateRoomOverlay(overlay *models.RoomOverlay) error
+	GetRoomOverlayByID(id string) (*models.RoomOverlay, error)
+	GetActiveRoomOverlays(roomID string) ([]*models.RoomOverlay, error)
+	Cache() CacheInterface
+}
+
 // LoreDALInterface defines the methods for LoreDAL.
 type LoreDALInterface interface {
 	GetLoreByID(id string) (*models.Lore, error)
@@ -135,6 +182,7 @@ type LoreDALInterface interface {
 type PlayerClassDALInterface interface {
 	GetPlayerClassByID(playerID, classID string) (*models.PlayerClass, error)
 	GetAllPlayerClasses() ([]*models.PlayerClass, error)
+	GetPlayerClassesByPlayerID(playerID string) ([]*models.PlayerClass, error)
 	CreatePlayerClass(playerClass *models.PlayerClass) error
 	UpdatePlayerClass(playerClass *models.PlayerClass) error
 	DeletePlayerClass(playerID, classID string) error
@@ -145,6 +193,7 @@ type PlayerClassDALInterface interface {
 type PlayerQuestStateDALInterface interface {
 	GetPlayerQuestStateByID(playerID, questID string) (*models.PlayerQuestState, error)
 	GetAllPlayerQuestStates() ([]*models.PlayerQuestState, error)
+	GetPlayerQuestStatesByPlayerID(playerID string) ([]*models.PlayerQuestState, error)
 	CreatePlayerQuestState(playerQuestState *models.PlayerQuestState) error
 	UpdatePlayerQuestState(playerQuestState *models.PlayerQuestState) error
 	DeletePlayerQuestState(playerID, questID string) error
@@ -155,6 +204,7 @@ type PlayerQuestStateDALInterface interface {
 type PlayerSkillDALInterface interface {
 	GetPlayerSkillByID(playerID, skillID string) (*models.PlayerSkill, error)
 	GetAllPlayerSkills() ([]*models.PlayerSkill, error)
+	GetPlayerSkillsByPlayerID(playerID string) ([]*models.PlayerSkill, error)
 	CreatePlayerSkill(playerSkill *models.PlayerSkill) error
 	UpdatePlayerSkill(playerSkill *models.PlayerSkill) error
 	DeletePlayerSkill(playerID, skillID string) error
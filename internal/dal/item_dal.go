@@ -8,7 +8,7 @@ import (
 
 // ItemDAL handles database operations for Item entities.
 type ItemDAL struct {
-	db    *sql.DB
+	db    DBTX
 	cache CacheInterface
 }
 
@@ -17,7 +17,7 @@ func (d *ItemDAL) Cache() CacheInterface {
 }
 
 // NewItemDAL creates a new ItemDAL.
-func NewItemDAL(db *sql.DB, cache CacheInterface) *ItemDAL {
+func NewItemDAL(db DBTX, cache CacheInterface) *ItemDAL {
 	return &ItemDAL{db: db, cache: cache}
 }
 
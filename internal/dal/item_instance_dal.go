@@ -0,0 +1,309 @@
+package dal
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mud/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInstanceChanged is returned when an item instance is no longer where, or
+// as many, as it was when read, such as when someone else moved it first.
+var ErrInstanceChanged = errors.New("item instance changed")
+
+// ItemInstanceDAL handles database operations for ItemInstance entities.
+// Instances returned by its getters have their Template resolved.
+type ItemInstanceDAL struct {
+	db      DBTX
+	cache   CacheInterface
+	itemDAL ItemDALInterface
+}
+
+func (d *ItemInstanceDAL) Cache() CacheInterface {
+	return d.cache
+}
+
+// NewItemInstanceDAL creates a new ItemInstanceDAL.
+func NewItemInstanceDAL(db DBTX, cache CacheInterface, itemDAL ItemDALInterface) *ItemInstanceDAL {
+	return &ItemInstanceDAL{db: db, cache: cache, itemDAL: itemDAL}
+}
+
+const itemInstanceColumns = `id, template_id, name, properties, quantity, location_type, location_id, slot, created_at`
+
+// CreateInstance inserts a new item instance into the database. An ID is
+// generated when none is set, and the quantity defaults to one.
+func (d *ItemInstanceDAL) CreateInstance(instance *models.ItemInstance) error {
+	if instance.ID == "" {
+		instance.ID = uuid.New().String()
+	}
+	if instance.Quantity <= 0 {
+		instance.Quantity = 1
+	}
+	if instance.Properties == "" {
+		instance.Properties = "{}"
+	}
+	if instance.CreatedAt.IsZero() {
+		instance.CreatedAt = time.Now()
+	}
+
+	query := `
+	INSERT INTO item_instances (` + itemInstanceColumns + `)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := d.db.Exec(query,
+		instance.ID,
+		instance.TemplateID,
+		instance.Name,
+		instance.Properties,
+		instance.Quantity,
+		instance.LocationType,
+		instance.LocationID,
+		instance.Slot,
+		instance.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create item instance: %w", err)
+	}
+	if err := d.resolveTemplate(instance); err != nil {
+		return err
+	}
+	d.Cache().Set(instance.ID, instance, 300) // Cache for 5 minutes
+	return nil
+}
+
+// GetInstanceByID retrieves an item instance by its ID.
+func (d *ItemInstanceDAL) GetInstanceByID(id string) (*models.ItemInstance, error) {
+	if cachedInstance, found := d.Cache().Get(id); found {
+		if instance, ok := cachedInstance.(*models.ItemInstance); ok {
+			return instance, nil
+		}
+	}
+
+	query := `SELECT ` + itemInstanceColumns + ` FROM item_instances WHERE id = ?`
+	row := d.db.QueryRow(query, id)
+
+	instance := &models.ItemInstance{}
+	err := row.Scan(
+		&instance.ID,
+		&instance.TemplateID,
+		&instance.Name,
+		&instance.Properties,
+		&instance.Quantity,
+		&instance.LocationType,
+		&instance.LocationID,
+		&instance.Slot,
+		&instance.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Instance not found
+		}
+		return nil, fmt.Errorf("failed to get item instance by ID: %w", err)
+	}
+	if err := d.resolveTemplate(instance); err != nil {
+		return nil, err
+	}
+
+	d.Cache().Set(instance.ID, instance, 300) // Cache for 5 minutes
+	return instance, nil
+}
+
+// GetInstancesByLocation retrieves the item instances at a location, oldest first.
+func (d *ItemInstanceDAL) GetInstancesByLocation(locationType, locationID string) ([]*models.ItemInstance, error) {
+	query := `SELECT ` + itemInstanceColumns + ` FROM item_instances WHERE location_type = ? AND location_id = ? ORDER BY created_at, rowid`
+	rows, err := d.db.Query(query, locationType, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item instances by location: %w", err)
+	}
+	defer rows.Close()
+
+	var instances []*models.ItemInstance
+	for rows.Next() {
+		instance := &models.ItemInstance{}
+		err := rows.Scan(
+			&instance.ID,
+			&instance.TemplateID,
+			&instance.Name,
+			&instance.Properties,
+			&instance.Quantity,
+			&instance.LocationType,
+			&instance.LocationID,
+			&instance.Slot,
+			&instance.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan item instance: %w", err)
+		}
+		instances = append(instances, instance)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating through item instances: %w", err)
+	}
+
+	for _, instance := range instances {
+		if err := d.resolveTemplate(instance); err != nil {
+			return nil, err
+		}
+	}
+	return instances, nil
+}
+
+// UpdateInstance updates an existing item instance in the database.
+func (d *ItemInstanceDAL) UpdateInstance(instance *models.ItemInstance) error {
+	query := `
+	UPDATE item_instances
+	SET template_id = ?, name = ?, properties = ?, quantity = ?, location_type = ?, location_id = ?, slot = ?
+	WHERE id = ?
+	`
+
+	result, err := d.db.Exec(query,
+		instance.TemplateID,
+		instance.Name,
+		instance.Properties,
+		instance.Quantity,
+		instance.LocationType,
+		instance.LocationID,
+		instance.Slot,
+		instance.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update item instance: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("item instance with ID %s not found for update", instance.ID)
+	}
+	d.Cache().Delete(instance.ID) // Invalidate cache on update
+	return nil
+}
+
+// UpdateInstanceIfUnchanged updates an item instance like UpdateInstance,
+// but only while it still has the location, slot and quantity it had when
+// was was read. It returns ErrInstanceChanged otherwise.
+func (d *ItemInstanceDAL) UpdateInstanceIfUnchanged(was, instance *models.ItemInstance) error {
+	query := `
+	UPDATE item_instances
+	SET template_id = ?, name = ?, properties = ?, quantity = ?, location_type = ?, location_id = ?, slot = ?
+	WHERE id = ? AND location_type = ? AND location_id = ? AND slot = ? AND quantity = ?
+	`
+
+	result, err := d.db.Exec(query,
+		instance.TemplateID,
+		instance.Name,
+		instance.Properties,
+		instance.Quantity,
+		instance.LocationType,
+		instance.LocationID,
+		instance.Slot,
+		was.ID,
+		was.LocationType,
+		was.LocationID,
+		was.Slot,
+		was.Quantity,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update item instance: %w", err)
+	}
+	return d.checkUnchanged(result, was.ID)
+}
+
+// DeleteInstanceIfUnchanged deletes an item instance, but only while it
+// still has the location, slot and quantity it had when was was read. It
+// returns ErrInstanceChanged otherwise.
+func (d *ItemInstanceDAL) DeleteInstanceIfUnchanged(was *models.ItemInstance) error {
+	query := `DELETE FROM item_instances WHERE id = ? AND location_type = ? AND location_id = ? AND slot = ? AND quantity = ?`
+	result, err := d.db.Exec(query, was.ID, was.LocationType, was.LocationID, was.Slot, was.Quantity)
+	if err != nil {
+		return fmt.Errorf("failed to delete item instance: %w", err)
+	}
+	return d.checkUnchanged(result, was.ID)
+}
+
+// AddInstanceQuantity adds quantity units to an item instance, but only
+// while it is still where it was when into was read, whatever has been added
+// to or taken from it since. It returns ErrInstanceChanged otherwise.
+func (d *ItemInstanceDAL) AddInstanceQuantity(into *models.ItemInstance, quantity int) error {
+	return d.addQuantity(d.db, into, quantity)
+}
+
+// MergeInstance merges an item instance into another stack in one
+// transaction: was is deleted while it is unchanged since it was read, and
+// its units are added to into while that is still where it was read. It
+// returns ErrInstanceChanged, and changes nothing, otherwise.
+func (d *ItemInstanceDAL) MergeInstance(was, into *models.ItemInstance) error {
+	return atomically(d.db, func(db DBTX) error {
+		query := `DELETE FROM item_instances WHERE id = ? AND location_type = ? AND location_id = ? AND slot = ? AND quantity = ?`
+		result, err := db.Exec(query, was.ID, was.LocationType, was.LocationID, was.Slot, was.Quantity)
+		if err != nil {
+			return fmt.Errorf("failed to delete item instance: %w", err)
+		}
+		if err := d.checkUnchanged(result, was.ID); err != nil {
+			return err
+		}
+		return d.addQuantity(db, into, was.Quantity)
+	})
+}
+
+func (d *ItemInstanceDAL) addQuantity(db DBTX, into *models.ItemInstance, quantity int) error {
+	query := `
+	UPDATE item_instances
+	SET quantity = quantity + ?
+	WHERE id = ? AND location_type = ? AND location_id = ? AND slot = ?
+	`
+	result, err := db.Exec(query, quantity, into.ID, into.LocationType, into.LocationID, into.Slot)
+	if err != nil {
+		return fmt.Errorf("failed to update item instance: %w", err)
+	}
+	return d.checkUnchanged(result, into.ID)
+}
+
+// checkUnchanged returns ErrInstanceChanged if a conditional change to an
+// instance found nothing to change, and invalidates its cache entry.
+func (d *ItemInstanceDAL) checkUnchanged(result sql.Result, id string) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	d.Cache().Delete(id)
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", ErrInstanceChanged, id)
+	}
+	return nil
+}
+
+// DeleteInstance deletes an item instance from the database by its ID.
+func (d *ItemInstanceDAL) DeleteInstance(id string) error {
+	query := `DELETE FROM item_instances WHERE id = ?`
+	result, err := d.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete item instance: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("item instance with ID %s not found for deletion", id)
+	}
+	d.Cache().Delete(id) // Invalidate cache on delete
+	return nil
+}
+
+// resolveTemplate loads the instance's Item template.
+func (d *ItemInstanceDAL) resolveTemplate(instance *models.ItemInstance) error {
+	template, err := d.itemDAL.GetItemByID(instance.TemplateID)
+	if err != nil {
+		return fmt.Errorf("failed to get template %s for item instance %s: %w", instance.TemplateID, instance.ID, err)
+	}
+	instance.Template = template
+	return nil
+}
@@ -8,7 +8,7 @@ import (
 
 // LoreDAL handles database operations for Lore entities.
 type LoreDAL struct {
-	db    *sql.DB
+	db    DBTX
 	cache CacheInterface
 }
 
@@ -17,7 +17,7 @@ func (d *LoreDAL) Cache() CacheInterface {
 }
 
 // NewLoreDAL creates a new LoreDAL.
-func NewLoreDAL(db *sql.DB, cache CacheInterface) *LoreDAL {
+func NewLoreDAL(db DBTX, cache CacheInterface) *LoreDAL {
 	return &LoreDAL{db: db, cache: cache}
 }
 
@@ -9,7 +9,7 @@ import (
 
 // NPCDAL handles database operations for NPC entities.
 type NPCDAL struct {
-	db    *sql.DB
+	db    DBTX
 	cache CacheInterface
 }
 
@@ -18,7 +18,7 @@ func (d *NPCDAL) Cache() CacheInterface {
 }
 
 // NewNPCDAL creates a new NPCDAL.
-func NewNPCDAL(db *sql.DB, cache CacheInterface) *NPCDAL {
+func NewNPCDAL(db DBTX, cache CacheInterface) *NPCDAL {
 	return &NPCDAL{db: db, cache: cache}
 }
 
@@ -42,8 +42,8 @@ func (d *NPCDAL) CreateNPC(npc *models.NPC) error {
 	}
 
 	query := `
-	INSERT INTO NPCs (id, name, description, current_room_id, health, max_health, inventory, owner_ids, memories_about_players, personality_prompt, available_tools, behavior_state, reaction_threshold, race_id, profession_id)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO NPCs (id, name, description, current_room_id, health, max_health, inventory, owner_ids, memories_about_players, personality_prompt, available_tools, behavior_state, reaction_threshold, race_id, profession_id, llm_provider)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err = d.db.Exec(query,
@@ -62,6 +62,7 @@ func (d *NPCDAL) CreateNPC(npc *models.NPC) error {
 		npc.ReactionThreshold,
 		npc.RaceID,
 		npc.ProfessionID,
+		npc.LLMProvider,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create NPC: %w", err)
@@ -78,7 +79,7 @@ func (d *NPCDAL) GetNPCByID(id string) (*models.NPC, error) {
 		}
 	}
 
-	query := `SELECT id, name, description, current_room_id, health, max_health, inventory, owner_ids, memories_about_players, personality_prompt, available_tools, behavior_state, reaction_threshold, race_id, profession_id FROM NPCs WHERE id = ?`
+	query := `SELECT id, name, description, current_room_id, health, max_health, inventory, owner_ids, memories_about_players, personality_prompt, available_tools, behavior_state, reaction_threshold, race_id, profession_id, llm_provider FROM NPCs WHERE id = ?`
 	row := d.db.QueryRow(query, id)
 
 	npc := &models.NPC{}
@@ -99,6 +100,7 @@ func (d *NPCDAL) GetNPCByID(id string) (*models.NPC, error) {
 		&npc.ReactionThreshold,
 		&npc.RaceID,
 		&npc.ProfessionID,
+		&npc.LLMProvider,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -145,7 +147,7 @@ func (d *NPCDAL) UpdateNPC(npc *models.NPC) error {
 
 	query := `
 	UPDATE NPCs
-	SET name = ?, description = ?, current_room_id = ?, health = ?, max_health = ?, inventory = ?, owner_ids = ?, memories_about_players = ?, personality_prompt = ?, available_tools = ?, behavior_state = ?, reaction_threshold = ?, race_id = ?, profession_id = ?
+	SET name = ?, description = ?, current_room_id = ?, health = ?, max_health = ?, inventory = ?, owner_ids = ?, memories_about_players = ?, personality_prompt = ?, available_tools = ?, behavior_state = ?, reaction_threshold = ?, race_id = ?, profession_id = ?, llm_provider = ?
 	WHERE id = ?
 	`
 
@@ -164,6 +166,7 @@ func (d *NPCDAL) UpdateNPC(npc *models.NPC) error {
 		npc.ReactionThreshold,
 		npc.RaceID,
 		npc.ProfessionID,
+		npc.LLMProvider,
 		npc.ID,
 	)
 	if err != nil {
@@ -203,7 +206,7 @@ func (d *NPCDAL) DeleteNPC(id string) error {
 // GetNPCsByRoom retrieves all NPCs in a given room.
 func (d *NPCDAL) GetNPCsByRoom(roomID string) ([]*models.NPC, error) {
 	// For list queries, caching is more complex. For now, we won't cache list results.
-	query := `SELECT id, name, description, current_room_id, health, max_health, inventory, owner_ids, memories_about_players, personality_prompt, available_tools, behavior_state, reaction_threshold, race_id, profession_id FROM NPCs WHERE current_room_id = ?`
+	query := `SELECT id, name, description, current_room_id, health, max_health, inventory, owner_ids, memories_about_players, personality_prompt, available_tools, behavior_state, reaction_threshold, race_id, profession_id, llm_provider FROM NPCs WHERE current_room_id = ?`
 	rows, err := d.db.Query(query, roomID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get NPCs by room: %w", err)
@@ -230,6 +233,7 @@ func (d *NPCDAL) GetNPCsByRoom(roomID string) ([]*models.NPC, error) {
 			&npc.ReactionThreshold,
 			&npc.RaceID,
 			&npc.ProfessionID,
+			&npc.LLMProvider,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan NPC row: %w", err)
@@ -256,7 +260,7 @@ func (d *NPCDAL) GetNPCsByRoom(roomID string) ([]*models.NPC, error) {
 
 // GetNPCsByOwner retrieves all NPCs associated with a given owner.
 func (d *NPCDAL) GetNPCsByOwner(ownerID string) ([]*models.NPC, error) {
-	query := `SELECT id, name, description, current_room_id, health, max_health, inventory, owner_ids, memories_about_players, personality_prompt, available_tools, behavior_state, reaction_threshold, race_id, profession_id FROM NPCs WHERE INSTR(owner_ids, ?)`
+	query := `SELECT id, name, description, current_room_id, health, max_health, inventory, owner_ids, memories_about_players, personality_prompt, available_tools, behavior_state, reaction_threshold, race_id, profession_id, llm_provider FROM NPCs WHERE INSTR(owner_ids, ?)`
 	rows, err := d.db.Query(query, `"`+ownerID+`"`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get NPCs by owner: %w", err)
@@ -283,6 +287,7 @@ func (d *NPCDAL) GetNPCsByOwner(ownerID string) ([]*models.NPC, error) {
 			&npc.ReactionThreshold,
 			&npc.RaceID,
 			&npc.ProfessionID,
+			&npc.LLMProvider,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan NPC row: %w", err)
@@ -309,7 +314,7 @@ func (d *NPCDAL) GetNPCsByOwner(ownerID string) ([]*models.NPC, error) {
 
 // GetAllNPCs retrieves all NPCs from the database.
 func (d *NPCDAL) GetAllNPCs() ([]*models.NPC, error) {
-	query := `SELECT id, name, description, current_room_id, health, max_health, inventory, owner_ids, memories_about_players, personality_prompt, available_tools, behavior_state, reaction_threshold, race_id, profession_id FROM NPCs`
+	query := `SELECT id, name, description, current_room_id, health, max_health, inventory, owner_ids, memories_about_players, personality_prompt, available_tools, behavior_state, reaction_threshold, race_id, profession_id, llm_provider FROM NPCs`
 	rows, err := d.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all NPCs: %w", err)
@@ -336,6 +341,7 @@ func (d *NPCDAL) GetAllNPCs() ([]*models.NPC, error) {
 			&npc.ReactionThreshold,
 			&npc.RaceID,
 			&npc.ProfessionID,
+			&npc.LLMProvider,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan NPC: %w", err)
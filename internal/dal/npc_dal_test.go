@@ -13,7 +13,7 @@ func TestNPCDAL_AdvancedQueries(t *testing.T) {
 	npcDAL := NewNPCDAL(db, testutils.NewMockCache())
 
 	// Seed with test data
-	npc1 := &models.NPC{ID: "npc1", Name: "NPC 1", CurrentRoomID: "roomA", OwnerIDs: []string{"owner1"}}
+	npc1 := &models.NPC{ID: "npc1", Name: "NPC 1", CurrentRoomID: "roomA", OwnerIDs: []string{"owner1"}, LLMProvider: "local"}
 	npc2 := &models.NPC{ID: "npc2", Name: "NPC 2", CurrentRoomID: "roomA", OwnerIDs: []string{"owner2"}}
 	npc3 := &models.NPC{ID: "npc3", Name: "NPC 3", CurrentRoomID: "roomB", OwnerIDs: []string{"owner1", "owner2"}}
 
@@ -29,6 +29,11 @@ func TestNPCDAL_AdvancedQueries(t *testing.T) {
 	if len(roomANPCs) != 2 {
 		t.Errorf("Expected 2 NPCs in roomA, got %d", len(roomANPCs))
 	}
+	for _, npc := range roomANPCs {
+		if npc.ID == "npc1" && npc.LLMProvider != "local" {
+			t.Errorf("Expected npc1 to use LLM provider local, got %q", npc.LLMProvider)
+		}
+	}
 
 	// Test GetNPCsByOwner
 	_, err = npcDAL.GetNPCsByOwner("owner1")
@@ -9,7 +9,7 @@ import (
 
 // OwnerDAL handles database operations for Owner entities.
 type OwnerDAL struct {
-	db    *sql.DB
+	db    DBTX
 	cache CacheInterface
 }
 
@@ -18,7 +18,7 @@ func (d *OwnerDAL) Cache() CacheInterface {
 }
 
 // NewOwnerDAL creates a new OwnerDAL.
-func NewOwnerDAL(db *sql.DB, cache CacheInterface) *OwnerDAL {
+func NewOwnerDAL(db DBTX, cache CacheInterface) *OwnerDAL {
 	return &OwnerDAL{db: db, cache: cache}
 }
 
@@ -38,8 +38,8 @@ func (d *OwnerDAL) CreateOwner(owner *models.Owner) error {
 	}
 
 	query := `
-	INSERT INTO Owners (id, name, description, monitored_aspect, associated_id, llm_prompt_context, memories_about_players, current_influence_budget, max_influence_budget, budget_regen_rate, available_tools, initiated_quests, reaction_threshold)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO Owners (id, name, description, monitored_aspect, associated_id, llm_prompt_context, memories_about_players, current_influence_budget, max_influence_budget, budget_regen_rate, available_tools, initiated_quests, reaction_threshold, llm_provider)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err = d.db.Exec(query,
@@ -56,6 +56,7 @@ func (d *OwnerDAL) CreateOwner(owner *models.Owner) error {
 		string(availableToolsJSON),
 		string(initiatedQuestsJSON),
 		owner.ReactionThreshold,
+		owner.LLMProvider,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create owner: %w", err)
@@ -72,7 +73,7 @@ func (d *OwnerDAL) GetOwnerByID(id string) (*models.Owner, error) {
 		}
 	}
 
-	query := `SELECT id, name, description, monitored_aspect, associated_id, llm_prompt_context, memories_about_players, current_influence_budget, max_influence_budget, budget_regen_rate, available_tools, initiated_quests, reaction_threshold FROM Owners WHERE id = ?`
+	query := `SELECT id, name, description, monitored_aspect, associated_id, llm_prompt_context, memories_about_players, current_influence_budget, max_influence_budget, budget_regen_rate, available_tools, initiated_quests, reaction_threshold, llm_provider FROM Owners WHERE id = ?`
 	row := d.db.QueryRow(query, id)
 
 	owner := &models.Owner{}
@@ -91,6 +92,7 @@ func (d *OwnerDAL) GetOwnerByID(id string) (*models.Owner, error) {
 		&availableToolsJSON,
 		&initiatedQuestsJSON,
 		&owner.ReactionThreshold,
+		&owner.LLMProvider,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -130,7 +132,7 @@ func (d *OwnerDAL) UpdateOwner(owner *models.Owner) error {
 
 	query := `
 	UPDATE Owners
-	SET name = ?, description = ?, monitored_aspect = ?, associated_id = ?, llm_prompt_context = ?, memories_about_players = ?, current_influence_budget = ?, max_influence_budget = ?, budget_regen_rate = ?, available_tools = ?, initiated_quests = ?, reaction_threshold = ?
+	SET name = ?, description = ?, monitored_aspect = ?, associated_id = ?, llm_prompt_context = ?, memories_about_players = ?, current_influence_budget = ?, max_influence_budget = ?, budget_regen_rate = ?, available_tools = ?, initiated_quests = ?, reaction_threshold = ?, llm_provider = ?
 	WHERE id = ?
 	`
 
@@ -147,6 +149,7 @@ func (d *OwnerDAL) UpdateOwner(owner *models.Owner) error {
 		string(availableToolsJSON),
 		string(initiatedQuestsJSON),
 		owner.ReactionThreshold,
+		owner.LLMProvider,
 		owner.ID,
 	)
 	if err != nil {
@@ -185,7 +188,7 @@ func (d *OwnerDAL) DeleteOwner(id string) error {
 
 // GetOwnersByMonitoredAspect retrieves owners by their monitored aspect and associated ID.
 func (d *OwnerDAL) GetOwnersByMonitoredAspect(aspectType string, associatedID string) ([]*models.Owner, error) {
-	query := `SELECT id, name, description, monitored_aspect, associated_id, llm_prompt_context, memories_about_players, current_influence_budget, max_influence_budget, budget_regen_rate, available_tools, initiated_quests, reaction_threshold FROM Owners WHERE monitored_aspect = ? AND associated_id = ?`
+	query := `SELECT id, name, description, monitored_aspect, associated_id, llm_prompt_context, memories_about_players, current_influence_budget, max_influence_budget, budget_regen_rate, available_tools, initiated_quests, reaction_threshold, llm_provider FROM Owners WHERE monitored_aspect = ? AND associated_id = ?`
 	rows, err := d.db.Query(query, aspectType, associatedID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get owners by monitored aspect: %w", err)
@@ -210,6 +213,7 @@ func (d *OwnerDAL) GetOwnersByMonitoredAspect(aspectType string, associatedID st
 			&availableToolsJSON,
 			&initiatedQuestsJSON,
 			&owner.ReactionThreshold,
+			&owner.LLMProvider,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan owner row: %w", err)
@@ -237,7 +241,7 @@ func (d *OwnerDAL) GetOwnersByMonitoredAspect(aspectType string, associatedID st
 
 // GetAllOwners retrieves all owners from the database.
 func (d *OwnerDAL) GetAllOwners() ([]*models.Owner, error) {
-	query := `SELECT id, name, description, monitored_aspect, associated_id, llm_prompt_context, memories_about_players, current_influence_budget, max_influence_budget, budget_regen_rate, available_tools, initiated_quests, reaction_threshold FROM Owners`
+	query := `SELECT id, name, description, monitored_aspect, associated_id, llm_prompt_context, memories_about_players, current_influence_budget, max_influence_budget, budget_regen_rate, available_tools, initiated_quests, reaction_threshold, llm_provider FROM Owners`
 	rows, err := d.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all owners: %w", err)
@@ -262,6 +266,7 @@ func (d *OwnerDAL) GetAllOwners() ([]*models.Owner, error) {
 			&availableToolsJSON,
 			&initiatedQuestsJSON,
 			&owner.ReactionThreshold,
+			&owner.LLMProvider,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan owner: %w", err)
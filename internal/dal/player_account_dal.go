@@ -20,11 +20,11 @@ type PlayerAccountDALInterface interface {
 
 // PlayerAccountDAL implements the PlayerAccountDALInterface.
 type PlayerAccountDAL struct {
-	DB *sql.DB
+	DB DBTX
 }
 
 // NewPlayerAccountDAL creates a new PlayerAccountDAL.
-func NewPlayerAccountDAL(db *sql.DB) *PlayerAccountDAL {
+func NewPlayerAccountDAL(db DBTX) *PlayerAccountDAL {
 	return &PlayerAccountDAL{DB: db}
 }
 
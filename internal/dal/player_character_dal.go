@@ -2,7 +2,6 @@ package dal
 
 import (
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"mud/internal/models"
 	"time"
@@ -10,7 +9,7 @@ import (
 
 // PlayerCharacterDAL handles database operations for PlayerCharacter entities.
 type PlayerCharacterDAL struct {
-	db      *sql.DB
+	db      DBTX
 	cache   CacheInterface
 	itemDAL ItemDALInterface
 }
@@ -20,15 +19,15 @@ func (d *PlayerCharacterDAL) Cache() CacheInterface {
 }
 
 // NewPlayerCharacterDAL creates a new PlayerCharacterDAL.
-func NewPlayerCharacterDAL(db *sql.DB, cache CacheInterface, itemDAL ItemDALInterface) *PlayerCharacterDAL {
+func NewPlayerCharacterDAL(db DBTX, cache CacheInterface, itemDAL ItemDALInterface) *PlayerCharacterDAL {
 	return &PlayerCharacterDAL{db: db, cache: cache, itemDAL: itemDAL}
 }
 
 // CreateCharacter inserts a new player character into the database.
 func (d *PlayerCharacterDAL) CreateCharacter(character *models.PlayerCharacter) error {
 	query := `
-	INSERT INTO player_characters (id, player_account_id, name, race_id, profession_id, current_room_id, health, max_health, inventory, visited_room_ids, created_at, last_played_at)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO player_characters (id, player_account_id, name, race_id, profession_id, current_room_id, health, max_health, energy, max_energy, inventory, visited_room_ids, created_at, last_played_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := d.db.Exec(query,
@@ -40,6 +39,8 @@ func (d *PlayerCharacterDAL) CreateCharacter(character *models.PlayerCharacter)
 		character.CurrentRoomID,
 		character.Health,
 		character.MaxHealth,
+		character.Energy,
+		character.MaxEnergy,
 		character.Inventory,
 		character.VisitedRoomIDs,
 		character.CreatedAt,
@@ -48,7 +49,7 @@ func (d *PlayerCharacterDAL) CreateCharacter(character *models.PlayerCharacter)
 	if err != nil {
 		return fmt.Errorf("failed to create player character: %w", err)
 	}
-	d.cache.Set(character.ID, character, 300*time.Second)
+	d.cacheCopy(character)
 	return nil
 }
 
@@ -60,7 +61,7 @@ func (d *PlayerCharacterDAL) GetCharacterByID(id string) (*models.PlayerCharacte
 		}
 	}
 
-	query := `SELECT id, player_account_id, name, race_id, profession_id, current_room_id, health, max_health, inventory, visited_room_ids, created_at, last_played_at FROM player_characters WHERE id = ?`
+	query := `SELECT id, player_account_id, name, race_id, profession_id, current_room_id, health, max_health, energy, max_energy, inventory, visited_room_ids, created_at, last_played_at FROM player_characters WHERE id = ?`
 	row := d.db.QueryRow(query, id)
 
 	character := &models.PlayerCharacter{}
@@ -75,6 +76,8 @@ func (d *PlayerCharacterDAL) GetCharacterByID(id string) (*models.PlayerCharacte
 		&character.CurrentRoomID,
 		&character.Health,
 		&character.MaxHealth,
+		&character.Energy,
+		&character.MaxEnergy,
 		&character.Inventory,
 		&character.VisitedRoomIDs,
 		&character.CreatedAt,
@@ -94,9 +97,46 @@ func (d *PlayerCharacterDAL) GetCharacterByID(id string) (*models.PlayerCharacte
 	return character, nil
 }
 
+// GetCharacterByName retrieves a player character by name, ignoring case.
+func (d *PlayerCharacterDAL) GetCharacterByName(name string) (*models.PlayerCharacter, error) {
+	query := `SELECT id, player_account_id, name, race_id, profession_id, current_room_id, health, max_health, energy, max_energy, inventory, visited_room_ids, created_at, last_played_at FROM player_characters WHERE name = ? COLLATE NOCASE`
+	row := d.db.QueryRow(query, name)
+
+	character := &models.PlayerCharacter{}
+	var lastPlayed sql.NullTime
+
+	err := row.Scan(
+		&character.ID,
+		&character.PlayerAccountID,
+		&character.Name,
+		&character.RaceID,
+		&character.ProfessionID,
+		&character.CurrentRoomID,
+		&character.Health,
+		&character.MaxHealth,
+		&character.Energy,
+		&character.MaxEnergy,
+		&character.Inventory,
+		&character.VisitedRoomIDs,
+		&character.CreatedAt,
+		&lastPlayed,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Character not found
+		}
+		return nil, fmt.Errorf("failed to get player character by name: %w", err)
+	}
+	if lastPlayed.Valid {
+		character.LastPlayedAt = lastPlayed.Time
+	}
+
+	return character, nil
+}
+
 // GetCharactersByAccountID retrieves all characters associated with a player account.
 func (d *PlayerCharacterDAL) GetCharactersByAccountID(accountID string) ([]*models.PlayerCharacter, error) {
-	query := `SELECT id, player_account_id, name, race_id, profession_id, current_room_id, health, max_health, inventory, visited_room_ids, created_at, last_played_at FROM player_characters WHERE player_account_id = ?`
+	query := `SELECT id, player_account_id, name, race_id, profession_id, current_room_id, health, max_health, energy, max_energy, inventory, visited_room_ids, created_at, last_played_at FROM player_characters WHERE player_account_id = ?`
 	rows, err := d.db.Query(query, accountID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get characters by account ID: %w", err)
@@ -116,6 +156,8 @@ func (d *PlayerCharacterDAL) GetCharactersByAccountID(accountID string) ([]*mode
 			&character.CurrentRoomID,
 			&character.Health,
 			&character.MaxHealth,
+			&character.Energy,
+			&character.MaxEnergy,
 			&character.Inventory,
 			&character.VisitedRoomIDs,
 			&character.CreatedAt,
@@ -141,7 +183,7 @@ func (d *PlayerCharacterDAL) GetCharactersByAccountID(accountID string) ([]*mode
 func (d *PlayerCharacterDAL) UpdateCharacter(character *models.PlayerCharacter) error {
 	query := `
 	UPDATE player_characters
-	SET name = ?, race_id = ?, profession_id = ?, current_room_id = ?, health = ?, max_health = ?, inventory = ?, visited_room_ids = ?, last_played_at = ?
+	SET name = ?, race_id = ?, profession_id = ?, current_room_id = ?, health = ?, max_health = ?, energy = ?, max_energy = ?, inventory = ?, visited_room_ids = ?, last_played_at = ?
 	WHERE id = ?
 	`
 
@@ -152,6 +194,8 @@ func (d *PlayerCharacterDAL) UpdateCharacter(character *models.PlayerCharacter)
 		character.CurrentRoomID,
 		character.Health,
 		character.MaxHealth,
+		character.Energy,
+		character.MaxEnergy,
 		character.Inventory,
 		character.VisitedRoomIDs,
 		character.LastPlayedAt,
@@ -169,10 +213,90 @@ func (d *PlayerCharacterDAL) UpdateCharacter(character *models.PlayerCharacter)
 		return fmt.Errorf("player character with ID %s not found for update", character.ID)
 	}
 
-	d.cache.Set(character.ID, character, 300*time.Second)
+	d.cacheCopy(character)
+	return nil
+}
+
+// AdjustCharacterVitals adds healthDelta and energyDelta to a character's
+// health and energy, without raising either above its maximum or energy
+// below zero, and returns the character as stored afterwards. Only the vitals
+// are written, so changes made meanwhile to the rest of the character are
+// kept, and adjustments made at the same time add up.
+func (d *PlayerCharacterDAL) AdjustCharacterVitals(id string, healthDelta, energyDelta int) (*models.PlayerCharacter, error) {
+	query := `
+	UPDATE player_characters
+	SET health = MIN(MAX(health, max_health), health + ?), energy = MAX(0, MIN(MAX(energy, max_energy), energy + ?))
+	WHERE id = ?
+	`
+	if err := d.updateColumns(id, query, healthDelta, energyDelta, id); err != nil {
+		return nil, fmt.Errorf("failed to adjust vitals of player character: %w", err)
+	}
+	return d.reloadCharacter(id)
+}
+
+// RespawnCharacter restores a character to full health, moving them to
+// roomID unless it is empty, and returns the character as stored afterwards.
+func (d *PlayerCharacterDAL) RespawnCharacter(id, roomID string) (*models.PlayerCharacter, error) {
+	query := `
+	UPDATE player_characters
+	SET health = max_health, current_room_id = COALESCE(NULLIF(?, ''), current_room_id)
+	WHERE id = ?
+	`
+	if err := d.updateColumns(id, query, roomID, id); err != nil {
+		return nil, fmt.Errorf("failed to respawn player character: %w", err)
+	}
+	return d.reloadCharacter(id)
+}
+
+// UpdateCharacterRoom moves a character to another room, leaving the rest of
+// the character as stored.
+func (d *PlayerCharacterDAL) UpdateCharacterRoom(id, roomID string) error {
+	query := `UPDATE player_characters SET current_room_id = ? WHERE id = ?`
+	if err := d.updateColumns(id, query, roomID, id); err != nil {
+		return fmt.Errorf("failed to update room of player character: %w", err)
+	}
 	return nil
 }
 
+// updateColumns runs an update of some of a character's columns and drops the
+// character from the cache, so that the next read sees every column as stored.
+func (d *PlayerCharacterDAL) updateColumns(id, query string, args ...interface{}) error {
+	result, err := d.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	d.cache.Delete(id)
+	if rowsAffected == 0 {
+		return fmt.Errorf("player character with ID %s not found", id)
+	}
+	return nil
+}
+
+// reloadCharacter returns a copy of a character as stored, which the caller
+// may keep and change.
+func (d *PlayerCharacterDAL) reloadCharacter(id string) (*models.PlayerCharacter, error) {
+	character, err := d.GetCharacterByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if character == nil {
+		return nil, fmt.Errorf("player character with ID %s not found", id)
+	}
+	copied := *character
+	return &copied, nil
+}
+
+// cacheCopy caches a copy of a character, so that the caller's character is
+// not shared with everyone who reads it from the cache.
+func (d *PlayerCharacterDAL) cacheCopy(character *models.PlayerCharacter) {
+	copied := *character
+	d.cache.Set(character.ID, &copied, 300*time.Second)
+}
+
 // DeleteCharacter deletes a player character from the database by their ID.
 func (d *PlayerCharacterDAL) DeleteCharacter(id string) error {
 	query := `DELETE FROM player_characters WHERE id = ?`
@@ -195,7 +319,7 @@ func (d *PlayerCharacterDAL) DeleteCharacter(id string) error {
 
 // GetAllCharacters retrieves all player characters from the database.
 func (d *PlayerCharacterDAL) GetAllCharacters() ([]*models.PlayerCharacter, error) {
-	query := `SELECT id, player_account_id, name, race_id, profession_id, current_room_id, health, max_health, inventory, visited_room_ids, created_at, last_played_at FROM player_characters`
+	query := `SELECT id, player_account_id, name, race_id, profession_id, current_room_id, health, max_health, energy, max_energy, inventory, visited_room_ids, created_at, last_played_at FROM player_characters`
 	rows, err := d.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all player characters: %w", err)
@@ -215,6 +339,8 @@ func (d *PlayerCharacterDAL) GetAllCharacters() ([]*models.PlayerCharacter, erro
 			&character.CurrentRoomID,
 			&character.Health,
 			&character.MaxHealth,
+			&character.Energy,
+			&character.MaxEnergy,
 			&character.Inventory,
 			&character.VisitedRoomIDs,
 			&character.CreatedAt,
@@ -236,35 +362,6 @@ func (d *PlayerCharacterDAL) GetAllCharacters() ([]*models.PlayerCharacter, erro
 	return characters, nil
 }
 
-// GetCharacterInventory retrieves all items in a character's inventory.
-func (d *PlayerCharacterDAL) GetCharacterInventory(characterID string) ([]*models.Item, error) {
-	character, err := d.GetCharacterByID(characterID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get character for inventory: %w", err)
-	}
-	if character == nil {
-		return nil, fmt.Errorf("character with ID %s not found", characterID)
-	}
-
-	var itemIDs []string
-	if err := json.Unmarshal([]byte(character.Inventory), &itemIDs); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal character inventory: %w", err)
-	}
-
-	var items []*models.Item
-	for _, itemID := range itemIDs {
-		item, err := d.itemDAL.GetItemByID(itemID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get item %s from inventory: %w", itemID, err)
-		}
-		if item != nil {
-			items = append(items, item)
-		}
-	}
-
-	return items, nil
-}
-
 // GetCharacterClass retrieves the class for a given character.
 func (d *PlayerCharacterDAL) GetCharacterClass(characterID string) (*models.PlayerClass, error) {
 	query := `SELECT player_id, class_id, level, experience FROM PlayerClasses WHERE player_id = ?`
@@ -53,12 +53,25 @@ func setupPlayerCharacterTestDB(t *testing.T) (*sql.DB, func()) {
 			current_room_id TEXT NOT NULL,
 			health INTEGER NOT NULL,
 			max_health INTEGER NOT NULL,
+			energy INTEGER NOT NULL DEFAULT 0,
+			max_energy INTEGER NOT NULL DEFAULT 0,
 			inventory TEXT NOT NULL,
 			visited_room_ids TEXT NOT NULL,
 			created_at TIMESTAMP NOT NULL,
 			last_played_at TIMESTAMP,
 			FOREIGN KEY (player_account_id) REFERENCES player_accounts(id)
 		);
+		CREATE TABLE item_instances (
+			id TEXT PRIMARY KEY,
+			template_id TEXT NOT NULL,
+			name TEXT NOT NULL DEFAULT '',
+			properties TEXT NOT NULL DEFAULT '{}',
+			quantity INTEGER NOT NULL DEFAULT 1,
+			location_type TEXT NOT NULL,
+			location_id TEXT NOT NULL,
+			slot TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL
+		);
 	`)
 	if err != nil {
 		t.Fatalf("Failed to create tables: %v", err)
@@ -100,6 +113,8 @@ func TestPlayerCharacterDAL_CRUD(t *testing.T) {
 		CurrentRoomID:   "start_room",
 		Health:          100,
 		MaxHealth:       100,
+		Energy:          100,
+		MaxEnergy:       100,
 		Inventory:       string(inventoryJSON),
 		VisitedRoomIDs:  string(visitedRoomsJSON),
 		CreatedAt:       time.Now(),
@@ -137,6 +152,7 @@ func TestPlayerCharacterDAL_CRUD(t *testing.T) {
 
 	// Test Update
 	retrievedChar.Health = 90
+	retrievedChar.Energy = 40
 	retrievedChar.LastPlayedAt = time.Now()
 	err = characterDAL.UpdateCharacter(retrievedChar)
 	if err != nil {
@@ -150,6 +166,9 @@ func TestPlayerCharacterDAL_CRUD(t *testing.T) {
 	if updatedChar.Health != 90 {
 		t.Errorf("Expected health 90, got %d", updatedChar.Health)
 	}
+	if updatedChar.Energy != 40 || updatedChar.MaxEnergy != 100 {
+		t.Errorf("Expected energy 40/100, got %d/%d", updatedChar.Energy, updatedChar.MaxEnergy)
+	}
 
 	// Test Delete
 	err = characterDAL.DeleteCharacter(newCharacter.ID)
@@ -166,56 +185,70 @@ func TestPlayerCharacterDAL_CRUD(t *testing.T) {
 	}
 }
 
-func TestPlayerCharacterDAL_GetCharacterInventory(t *testing.T) {
+func TestPlayerCharacterDAL_ColumnUpdates(t *testing.T) {
 	db, cleanup := setupPlayerCharacterTestDB(t)
 	defer cleanup()
 
-	mockCache := testutils.NewMockCache()
-	mockItemDAL := &MockItemDALForCharacter{
-		GetItemByIDFunc: func(id string) (*models.Item, error) {
-			if id == "sword_of_testing" {
-				return &models.Item{ID: "sword_of_testing", Name: "Sword of Testing", Type: "weapon", Properties: "{}"}, nil
-			}
-			return nil, nil
-		},
-	}
-	characterDAL := NewPlayerCharacterDAL(db, mockCache, mockItemDAL)
+	characterDAL := NewPlayerCharacterDAL(db, testutils.NewMockCache(), &MockItemDALForCharacter{})
 	accountDAL := NewPlayerAccountDAL(db)
-
-	account, err := accountDAL.CreateAccount("inv_user", "password", "inv@test.com")
+	account, err := accountDAL.CreateAccount("vitals_user", "password", "vitals@test.com")
 	if err != nil {
 		t.Fatalf("Failed to create account: %v", err)
 	}
-
-	inventory := []string{"sword_of_testing"}
-	inventoryJSON, _ := json.Marshal(inventory)
-
-	newCharacter := &models.PlayerCharacter{
+	character := &models.PlayerCharacter{
 		ID:              uuid.New().String(),
 		PlayerAccountID: account.ID,
-		Name:            "InvCharacter",
-		RaceID:          "elf",
-		ProfessionID:    "ranger",
-		CurrentRoomID:   "forest",
-		Health:          100,
+		Name:            "VitalsCharacter",
+		RaceID:          "human",
+		ProfessionID:    "warrior",
+		CurrentRoomID:   "square",
+		Health:          50,
 		MaxHealth:       100,
-		Inventory:       string(inventoryJSON),
+		Energy:          10,
+		MaxEnergy:       20,
+		Inventory:       "[]",
 		VisitedRoomIDs:  "[]",
 		CreatedAt:       time.Now(),
 	}
-	err = characterDAL.CreateCharacter(newCharacter)
+	if err := characterDAL.CreateCharacter(character); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	// Health and energy stop at their maximums, and energy at zero.
+	adjusted, err := characterDAL.AdjustCharacterVitals(character.ID, 80, -15)
 	if err != nil {
-		t.Fatalf("Failed to create character for inventory test: %v", err)
+		t.Fatalf("AdjustCharacterVitals failed: %v", err)
+	}
+	if adjusted.Health != 100 || adjusted.Energy != 0 {
+		t.Errorf("Expected 100 health and 0 energy, got %d and %d", adjusted.Health, adjusted.Energy)
+	}
+	if _, err := characterDAL.AdjustCharacterVitals(character.ID, -30, 5); err != nil {
+		t.Fatalf("AdjustCharacterVitals failed: %v", err)
 	}
 
-	items, err := characterDAL.GetCharacterInventory(newCharacter.ID)
+	// Moving writes only the room, so the adjusted vitals are kept.
+	if err := characterDAL.UpdateCharacterRoom(character.ID, "inn"); err != nil {
+		t.Fatalf("UpdateCharacterRoom failed: %v", err)
+	}
+	stored, err := characterDAL.GetCharacterByID(character.ID)
 	if err != nil {
-		t.Fatalf("GetCharacterInventory failed: %v", err)
+		t.Fatalf("GetCharacterByID failed: %v", err)
 	}
-	if len(items) != 1 {
-		t.Fatalf("Expected 1 item in inventory, got %d", len(items))
+	if stored.Health != 70 || stored.Energy != 5 || stored.CurrentRoomID != "inn" {
+		t.Errorf("Expected 70 health, 5 energy in the inn, got %d, %d in %s", stored.Health, stored.Energy, stored.CurrentRoomID)
 	}
-	if items[0].Name != "Sword of Testing" {
-		t.Errorf("Expected item 'Sword of Testing', got '%s'", items[0].Name)
+	if character.Health != 50 || character.CurrentRoomID != "square" {
+		t.Errorf("Expected the created character to be left alone, got %d health in %s", character.Health, character.CurrentRoomID)
 	}
-}
\ No newline at end of file
+
+	respawned, err := characterDAL.RespawnCharacter(character.ID, "temple")
+	if err != nil {
+		t.Fatalf("RespawnCharacter failed: %v", err)
+	}
+	if respawned.Health != 100 || respawned.CurrentRoomID != "temple" {
+		t.Errorf("Expected full health in the temple, got %d in %s", respawned.Health, respawned.CurrentRoomID)
+	}
+	if _, err := characterDAL.AdjustCharacterVitals("nobody", 1, 1); err == nil {
+		t.Error("Expected an error adjusting a missing character")
+	}
+}
@@ -8,7 +8,7 @@ import (
 
 // PlayerClassDAL handles database operations for PlayerClass entities.
 type PlayerClassDAL struct {
-	db    *sql.DB
+	db    DBTX
 	cache CacheInterface
 }
 
@@ -17,15 +17,15 @@ func (d *PlayerClassDAL) Cache() CacheInterface {
 }
 
 // NewPlayerClassDAL creates a new PlayerClassDAL.
-func NewPlayerClassDAL(db *sql.DB, cache CacheInterface) *PlayerClassDAL {
+func NewPlayerClassDAL(db DBTX, cache CacheInterface) *PlayerClassDAL {
 	return &PlayerClassDAL{db: db, cache: cache}
 }
 
 // CreatePlayerClass inserts a new player class entry into the database.
 func (d *PlayerClassDAL) CreatePlayerClass(pc *models.PlayerClass) error {
 	query := `
-	INSERT INTO PlayerClasses (player_id, class_id, level, experience)
-	VALUES (?, ?, ?, ?)
+	INSERT INTO PlayerClasses (player_id, class_id, level, experience, practice_points)
+	VALUES (?, ?, ?, ?, ?)
 	`
 
 	_, err := d.db.Exec(query,
@@ -33,6 +33,7 @@ func (d *PlayerClassDAL) CreatePlayerClass(pc *models.PlayerClass) error {
 		pc.ClassID,
 		pc.Level,
 		pc.Experience,
+		pc.PracticePoints,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create player class: %w", err)
@@ -42,7 +43,7 @@ func (d *PlayerClassDAL) CreatePlayerClass(pc *models.PlayerClass) error {
 
 // GetPlayerClass retrieves a player class entry by player and class ID.
 func (d *PlayerClassDAL) GetPlayerClassByID(playerID, classID string) (*models.PlayerClass, error) {
-	query := `SELECT player_id, class_id, level, experience FROM PlayerClasses WHERE player_id = ? AND class_id = ?`
+	query := `SELECT player_id, class_id, level, experience, practice_points FROM PlayerClasses WHERE player_id = ? AND class_id = ?`
 	row := d.db.QueryRow(query, playerID, classID)
 
 	pc := &models.PlayerClass{}
@@ -51,6 +52,7 @@ func (d *PlayerClassDAL) GetPlayerClassByID(playerID, classID string) (*models.P
 		&pc.ClassID,
 		&pc.Level,
 		&pc.Experience,
+		&pc.PracticePoints,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -62,17 +64,50 @@ func (d *PlayerClassDAL) GetPlayerClassByID(playerID, classID string) (*models.P
 	return pc, nil
 }
 
+// GetPlayerClassesByPlayerID retrieves all classes a player has acquired.
+func (d *PlayerClassDAL) GetPlayerClassesByPlayerID(playerID string) ([]*models.PlayerClass, error) {
+	query := `SELECT player_id, class_id, level, experience, practice_points FROM PlayerClasses WHERE player_id = ?`
+	rows, err := d.db.Query(query, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get player classes by player ID: %w", err)
+	}
+	defer rows.Close()
+
+	var playerClasses []*models.PlayerClass
+	for rows.Next() {
+		pc := &models.PlayerClass{}
+		err := rows.Scan(
+			&pc.PlayerID,
+			&pc.ClassID,
+			&pc.Level,
+			&pc.Experience,
+			&pc.PracticePoints,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan player class: %w", err)
+		}
+		playerClasses = append(playerClasses, pc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating through player classes: %w", err)
+	}
+
+	return playerClasses, nil
+}
+
 // UpdatePlayerClass updates an existing player class entry in the database.
 func (d *PlayerClassDAL) UpdatePlayerClass(pc *models.PlayerClass) error {
 	query := `
 	UPDATE PlayerClasses
-	SET level = ?, experience = ?
+	SET level = ?, experience = ?, practice_points = ?
 	WHERE player_id = ? AND class_id = ?
 	`
 
 	result, err := d.db.Exec(query,
 		pc.Level,
 		pc.Experience,
+		pc.PracticePoints,
 		pc.PlayerID,
 		pc.ClassID,
 	)
@@ -112,7 +147,7 @@ func (d *PlayerClassDAL) DeletePlayerClass(playerID, classID string) error {
 
 // GetAllPlayerClasses retrieves all player class entries from the database.
 func (d *PlayerClassDAL) GetAllPlayerClasses() ([]*models.PlayerClass, error) {
-	query := `SELECT player_id, class_id, level, experience FROM PlayerClasses`
+	query := `SELECT player_id, class_id, level, experience, practice_points FROM PlayerClasses`
 	rows, err := d.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all player classes: %w", err)
@@ -127,6 +162,7 @@ func (d *PlayerClassDAL) GetAllPlayerClasses() ([]*models.PlayerClass, error) {
 			&pc.ClassID,
 			&pc.Level,
 			&pc.Experience,
+			&pc.PracticePoints,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan player class: %w", err)
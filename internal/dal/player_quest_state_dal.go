@@ -9,7 +9,7 @@ import (
 
 // PlayerQuestStateDAL handles database operations for PlayerQuestState entities.
 type PlayerQuestStateDAL struct {
-	db    *sql.DB
+	db    DBTX
 	cache CacheInterface
 }
 
@@ -18,7 +18,7 @@ func (d *PlayerQuestStateDAL) Cache() CacheInterface {
 }
 
 // NewPlayerQuestStateDAL creates a new PlayerQuestStateDAL.
-func NewPlayerQuestStateDAL(db *sql.DB, cache CacheInterface) *PlayerQuestStateDAL {
+func NewPlayerQuestStateDAL(db DBTX, cache CacheInterface) *PlayerQuestStateDAL {
 	return &PlayerQuestStateDAL{db: db, cache: cache}
 }
 
@@ -148,4 +148,37 @@ func (d *PlayerQuestStateDAL) GetAllPlayerQuestStates() ([]*models.PlayerQuestSt
 	}
 
 	return playerQuestStates, nil
-}
\ No newline at end of file
+}
+
+// GetPlayerQuestStatesByPlayerID retrieves the states of every quest a player has been offered or taken on.
+func (d *PlayerQuestStateDAL) GetPlayerQuestStatesByPlayerID(playerID string) ([]*models.PlayerQuestState, error) {
+	query := `SELECT player_id, quest_id, current_progress, last_action_timestamp, questmaker_influence_accumulated, status FROM PlayerQuestStates WHERE player_id = ?`
+	rows, err := d.db.Query(query, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get player quest states by player ID: %w", err)
+	}
+	defer rows.Close()
+
+	var playerQuestStates []*models.PlayerQuestState
+	for rows.Next() {
+		pqs := &models.PlayerQuestState{}
+		err := rows.Scan(
+			&pqs.PlayerID,
+			&pqs.QuestID,
+			&pqs.CurrentProgress,
+			&pqs.LastActionTimestamp,
+			&pqs.QuestmakerInfluenceAccumulated,
+			&pqs.Status,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan player quest state: %w", err)
+		}
+		playerQuestStates = append(playerQuestStates, pqs)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating through player quest states: %w", err)
+	}
+
+	return playerQuestStates, nil
+}
@@ -8,7 +8,7 @@ import (
 
 // PlayerSkillDAL handles database operations for PlayerSkill entities.
 type PlayerSkillDAL struct {
-	db    *sql.DB
+	db    DBTX
 	cache CacheInterface
 }
 
@@ -17,7 +17,7 @@ func (d *PlayerSkillDAL) Cache() CacheInterface {
 }
 
 // NewPlayerSkillDAL creates a new PlayerSkillDAL.
-func NewPlayerSkillDAL(db *sql.DB, cache CacheInterface) *PlayerSkillDAL {
+func NewPlayerSkillDAL(db DBTX, cache CacheInterface) *PlayerSkillDAL {
 	return &PlayerSkillDAL{db: db, cache: cache}
 }
 
@@ -95,6 +95,38 @@ func (d *PlayerSkillDAL) GetAllPlayerSkills() ([]*models.PlayerSkill, error) {
 	return playerSkills, nil
 }
 
+// GetPlayerSkillsByPlayerID retrieves all skills known by a specific player character.
+func (d *PlayerSkillDAL) GetPlayerSkillsByPlayerID(playerID string) ([]*models.PlayerSkill, error) {
+	query := `SELECT player_id, skill_id, percentage, granted_by_entity_type, granted_by_entity_id FROM PlayerSkills WHERE player_id = ?`
+	rows, err := d.db.Query(query, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get player skills by player ID: %w", err)
+	}
+	defer rows.Close()
+
+	var playerSkills []*models.PlayerSkill
+	for rows.Next() {
+		ps := &models.PlayerSkill{}
+		err := rows.Scan(
+			&ps.PlayerID,
+			&ps.SkillID,
+			&ps.Percentage,
+			&ps.GrantedByEntityType,
+			&ps.GrantedByEntityID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan player skill: %w", err)
+		}
+		playerSkills = append(playerSkills, ps)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating through player skills: %w", err)
+	}
+
+	return playerSkills, nil
+}
+
 // UpdatePlayerSkill updates an existing player skill in the database.
 func (d *PlayerSkillDAL) UpdatePlayerSkill(ps *models.PlayerSkill) error {
 	query := `
@@ -9,7 +9,7 @@ import (
 
 // ProfessionDAL handles database operations for Profession entities.
 type ProfessionDAL struct {
-	db    *sql.DB
+	db    DBTX
 	cache CacheInterface
 }
 
@@ -18,7 +18,7 @@ func (d *ProfessionDAL) Cache() CacheInterface {
 }
 
 // NewProfessionDAL creates a new ProfessionDAL.
-func NewProfessionDAL(db *sql.DB, cache CacheInterface) *ProfessionDAL {
+func NewProfessionDAL(db DBTX, cache CacheInterface) *ProfessionDAL {
 	return &ProfessionDAL{db: db, cache: cache}
 }
 
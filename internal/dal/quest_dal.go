@@ -8,7 +8,7 @@ import (
 
 // QuestDAL handles database operations for Quest entities.
 type QuestDAL struct {
-	db    *sql.DB
+	db    DBTX
 	cache CacheInterface
 }
 
@@ -17,7 +17,7 @@ func (d *QuestDAL) Cache() CacheInterface {
 }
 
 // NewQuestDAL creates a new QuestDAL.
-func NewQuestDAL(db *sql.DB, cache CacheInterface) *QuestDAL {
+func NewQuestDAL(db DBTX, cache CacheInterface) *QuestDAL {
 	return &QuestDAL{db: db, cache: cache}
 }
 
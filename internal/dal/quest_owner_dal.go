@@ -2,13 +2,14 @@ package dal
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"mud/internal/models"
 )
 
 // QuestOwnerDAL handles database operations for QuestOwner entities.
 type QuestOwnerDAL struct {
-	db    *sql.DB
+	db    DBTX
 	cache CacheInterface
 }
 
@@ -17,18 +18,23 @@ func (d *QuestOwnerDAL) Cache() CacheInterface {
 }
 
 // NewQuestOwnerDAL creates a new QuestOwnerDAL.
-func NewQuestOwnerDAL(db *sql.DB, cache CacheInterface) *QuestOwnerDAL {
+func NewQuestOwnerDAL(db DBTX, cache CacheInterface) *QuestOwnerDAL {
 	return &QuestOwnerDAL{db: db, cache: cache}
 }
 
 // CreateQuestOwner inserts a new quest owner into the database.
 func (d *QuestOwnerDAL) CreateQuestOwner(qo *models.QuestOwner) error {
+	availableToolsJSON, err := json.Marshal(qo.AvailableTools)
+	if err != nil {
+		return fmt.Errorf("failed to marshal available tools: %w", err)
+	}
+
 	query := `
-	INSERT INTO QuestOwners (id, name, description, llm_prompt_context, current_influence_budget, max_influence_budget, budget_regen_rate, associated_questmaker_ids)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO QuestOwners (id, name, description, llm_prompt_context, current_influence_budget, max_influence_budget, budget_regen_rate, associated_questmaker_ids, available_tools)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := d.db.Exec(query,
+	_, err = d.db.Exec(query,
 		qo.ID,
 		qo.Name,
 		qo.Description,
@@ -37,6 +43,7 @@ func (d *QuestOwnerDAL) CreateQuestOwner(qo *models.QuestOwner) error {
 		qo.MaxInfluenceBudget,
 		qo.BudgetRegenRate,
 		qo.AssociatedQuestmakerIDs,
+		string(availableToolsJSON),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create quest owner: %w", err)
@@ -53,10 +60,11 @@ func (d *QuestOwnerDAL) GetQuestOwnerByID(id string) (*models.QuestOwner, error)
 		}
 	}
 
-	query := `SELECT id, name, description, llm_prompt_context, current_influence_budget, max_influence_budget, budget_regen_rate, associated_questmaker_ids FROM QuestOwners WHERE id = ?`
+	query := `SELECT id, name, description, llm_prompt_context, current_influence_budget, max_influence_budget, budget_regen_rate, associated_questmaker_ids, available_tools FROM QuestOwners WHERE id = ?`
 	row := d.db.QueryRow(query, id)
 
 	qo := &models.QuestOwner{}
+	var availableToolsJSON []byte
 	err := row.Scan(
 		&qo.ID,
 		&qo.Name,
@@ -66,6 +74,7 @@ func (d *QuestOwnerDAL) GetQuestOwnerByID(id string) (*models.QuestOwner, error)
 		&qo.MaxInfluenceBudget,
 		&qo.BudgetRegenRate,
 		&qo.AssociatedQuestmakerIDs,
+		&availableToolsJSON,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -73,6 +82,9 @@ func (d *QuestOwnerDAL) GetQuestOwnerByID(id string) (*models.QuestOwner, error)
 		}
 		return nil, fmt.Errorf("failed to get quest owner by ID: %w", err)
 	}
+	if err := json.Unmarshal(availableToolsJSON, &qo.AvailableTools); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal available tools: %w", err)
+	}
 
 	d.Cache().Set(qo.ID, qo, 300) // Cache for 5 minutes
 	return qo, nil
@@ -80,9 +92,14 @@ func (d *QuestOwnerDAL) GetQuestOwnerByID(id string) (*models.QuestOwner, error)
 
 // UpdateQuestOwner updates an existing quest owner in the database.
 func (d *QuestOwnerDAL) UpdateQuestOwner(qo *models.QuestOwner) error {
+	availableToolsJSON, err := json.Marshal(qo.AvailableTools)
+	if err != nil {
+		return fmt.Errorf("failed to marshal available tools: %w", err)
+	}
+
 	query := `
 	UPDATE QuestOwners
-	SET name = ?, description = ?, llm_prompt_context = ?, current_influence_budget = ?, max_influence_budget = ?, budget_regen_rate = ?, associated_questmaker_ids = ?
+	SET name = ?, description = ?, llm_prompt_context = ?, current_influence_budget = ?, max_influence_budget = ?, budget_regen_rate = ?, associated_questmaker_ids = ?, available_tools = ?
 	WHERE id = ?
 	`
 
@@ -94,6 +111,7 @@ func (d *QuestOwnerDAL) UpdateQuestOwner(qo *models.QuestOwner) error {
 		qo.MaxInfluenceBudget,
 		qo.BudgetRegenRate,
 		qo.AssociatedQuestmakerIDs,
+		string(availableToolsJSON),
 		qo.ID,
 	)
 	if err != nil {
@@ -132,7 +150,7 @@ func (d *QuestOwnerDAL) DeleteQuestOwner(id string) error {
 
 // GetAllQuestOwners retrieves all quest owners from the database.
 func (d *QuestOwnerDAL) GetAllQuestOwners() ([]*models.QuestOwner, error) {
-	query := `SELECT id, name, description, llm_prompt_context, current_influence_budget, max_influence_budget, budget_regen_rate, associated_questmaker_ids FROM QuestOwners`
+	query := `SELECT id, name, description, llm_prompt_context, current_influence_budget, max_influence_budget, budget_regen_rate, associated_questmaker_ids, available_tools FROM QuestOwners`
 	rows, err := d.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all quest owners: %w", err)
@@ -142,6 +160,7 @@ func (d *QuestOwnerDAL) GetAllQuestOwners() ([]*models.QuestOwner, error) {
 	var questOwners []*models.QuestOwner
 	for rows.Next() {
 		qo := &models.QuestOwner{}
+		var availableToolsJSON []byte
 		err := rows.Scan(
 			&qo.ID,
 			&qo.Name,
@@ -151,10 +170,14 @@ func (d *QuestOwnerDAL) GetAllQuestOwners() ([]*models.QuestOwner, error) {
 			&qo.MaxInfluenceBudget,
 			&qo.BudgetRegenRate,
 			&qo.AssociatedQuestmakerIDs,
+			&availableToolsJSON,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan quest owner: %w", err)
 		}
+		if err := json.Unmarshal(availableToolsJSON, &qo.AvailableTools); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal available tools: %w", err)
+		}
 		questOwners = append(questOwners, qo)
 	}
 
@@ -8,7 +8,7 @@ import (
 )
 
 type QuestmakerDAL struct {
-	db    *sql.DB
+	db    DBTX
 	cache CacheInterface
 }
 
@@ -16,7 +16,7 @@ func (d *QuestmakerDAL) Cache() CacheInterface {
 	return d.cache
 }
 
-func NewQuestmakerDAL(db *sql.DB, cache CacheInterface) *QuestmakerDAL {
+func NewQuestmakerDAL(db DBTX, cache CacheInterface) *QuestmakerDAL {
 	return &QuestmakerDAL{db: db, cache: cache}
 }
 
@@ -31,10 +31,10 @@ func (d *QuestmakerDAL) CreateQuestmaker(qm *models.Questmaker) error {
 	}
 
 	query := `
-	INSERT INTO Questmakers (id, name, llm_prompt_context, current_influence_budget, max_influence_budget, budget_regen_rate, memories_about_players, available_tools, reaction_threshold)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO Questmakers (id, name, llm_prompt_context, current_influence_budget, max_influence_budget, budget_regen_rate, memories_about_players, available_tools, reaction_threshold, llm_provider)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	_, err = d.db.Exec(query, qm.ID, qm.Name, qm.LLMPromptContext, qm.CurrentInfluenceBudget, qm.MaxInfluenceBudget, qm.BudgetRegenRate, string(memoriesJSON), string(toolsJSON), qm.ReactionThreshold)
+	_, err = d.db.Exec(query, qm.ID, qm.Name, qm.LLMPromptContext, qm.CurrentInfluenceBudget, qm.MaxInfluenceBudget, qm.BudgetRegenRate, string(memoriesJSON), string(toolsJSON), qm.ReactionThreshold, qm.LLMProvider)
 	if err != nil {
 		return fmt.Errorf("failed to create questmaker: %w", err)
 	}
@@ -49,12 +49,12 @@ func (d *QuestmakerDAL) GetQuestmakerByID(id string) (*models.Questmaker, error)
 		}
 	}
 
-	query := `SELECT id, name, llm_prompt_context, current_influence_budget, max_influence_budget, budget_regen_rate, memories_about_players, available_tools, reaction_threshold FROM Questmakers WHERE id = ?`
+	query := `SELECT id, name, llm_prompt_context, current_influence_budget, max_influence_budget, budget_regen_rate, memories_about_players, available_tools, reaction_threshold, llm_provider FROM Questmakers WHERE id = ?`
 	row := d.db.QueryRow(query, id)
 
 	qm := &models.Questmaker{}
 	var memoriesJSON, toolsJSON []byte
-	err := row.Scan(&qm.ID, &qm.Name, &qm.LLMPromptContext, &qm.CurrentInfluenceBudget, &qm.MaxInfluenceBudget, &qm.BudgetRegenRate, &memoriesJSON, &toolsJSON, &qm.ReactionThreshold)
+	err := row.Scan(&qm.ID, &qm.Name, &qm.LLMPromptContext, &qm.CurrentInfluenceBudget, &qm.MaxInfluenceBudget, &qm.BudgetRegenRate, &memoriesJSON, &toolsJSON, &qm.ReactionThreshold, &qm.LLMProvider)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -85,10 +85,10 @@ func (d *QuestmakerDAL) UpdateQuestmaker(qm *models.Questmaker) error {
 
 	query := `
 	UPDATE Questmakers
-	SET name = ?, llm_prompt_context = ?, current_influence_budget = ?, max_influence_budget = ?, budget_regen_rate = ?, memories_about_players = ?, available_tools = ?, reaction_threshold = ?
+	SET name = ?, llm_prompt_context = ?, current_influence_budget = ?, max_influence_budget = ?, budget_regen_rate = ?, memories_about_players = ?, available_tools = ?, reaction_threshold = ?, llm_provider = ?
 	WHERE id = ?
 	`
-	_, err = d.db.Exec(query, qm.Name, qm.LLMPromptContext, qm.CurrentInfluenceBudget, qm.MaxInfluenceBudget, qm.BudgetRegenRate, string(memoriesJSON), string(toolsJSON), qm.ReactionThreshold, qm.ID)
+	_, err = d.db.Exec(query, qm.Name, qm.LLMPromptContext, qm.CurrentInfluenceBudget, qm.MaxInfluenceBudget, qm.BudgetRegenRate, string(memoriesJSON), string(toolsJSON), qm.ReactionThreshold, qm.LLMProvider, qm.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update questmaker: %w", err)
 	}
@@ -107,7 +107,7 @@ func (d *QuestmakerDAL) DeleteQuestmaker(id string) error {
 }
 
 func (d *QuestmakerDAL) GetAllQuestmakers() ([]*models.Questmaker, error) {
-	query := `SELECT id, name, llm_prompt_context, current_influence_budget, max_influence_budget, budget_regen_rate, memories_about_players, available_tools, reaction_threshold FROM Questmakers`
+	query := `SELECT id, name, llm_prompt_context, current_influence_budget, max_influence_budget, budget_regen_rate, memories_about_players, available_tools, reaction_threshold, llm_provider FROM Questmakers`
 	rows, err := d.db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all questmakers: %w", err)
@@ -118,7 +118,7 @@ func (d *QuestmakerDAL) GetAllQuestmakers() ([]*models.Questmaker, error) {
 	for rows.Next() {
 		qm := &models.Questmaker{}
 		var memoriesJSON, toolsJSON []byte
-		err := rows.Scan(&qm.ID, &qm.Name, &qm.LLMPromptContext, &qm.CurrentInfluenceBudget, &qm.MaxInfluenceBudget, &qm.BudgetRegenRate, &memoriesJSON, &toolsJSON, &qm.ReactionThreshold)
+		err := rows.Scan(&qm.ID, &qm.Name, &qm.LLMPromptContext, &qm.CurrentInfluenceBudget, &qm.MaxInfluenceBudget, &qm.BudgetRegenRate, &memoriesJSON, &toolsJSON, &qm.ReactionThreshold, &qm.LLMProvider)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan questmaker: %w", err)
 		}
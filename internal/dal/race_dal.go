@@ -9,7 +9,7 @@ import (
 
 // RaceDAL handles database operations for Race entities.
 type RaceDAL struct {
-	db    *sql.DB
+	db    DBTX
 	cache CacheInterface
 }
 
@@ -18,7 +18,7 @@ func (d *RaceDAL) Cache() CacheInterface {
 }
 
 // NewRaceDAL creates a new RaceDAL.
-func NewRaceDAL(db *sql.DB, cache CacheInterface) *RaceDAL {
+func NewRaceDAL(db DBTX, cache CacheInterface) *RaceDAL {
 	return &RaceDAL{db: db, cache: cache}
 }
 
@@ -9,7 +9,7 @@ import (
 
 // RoomDAL handles database operations for Room entities.
 type RoomDAL struct {
-	db    *sql.DB
+	db    DBTX
 	cache CacheInterface
 }
 
@@ -18,7 +18,7 @@ func (d *RoomDAL) Cache() CacheInterface {
 }
 
 // NewRoomDAL creates a new RoomDAL.
-func NewRoomDAL(db *sql.DB, cache CacheInterface) *RoomDAL {
+func NewRoomDAL(db DBTX, cache CacheInterface) *RoomDAL {
 	return &RoomDAL{db: db, cache: cache}
 }
 
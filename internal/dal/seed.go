@@ -33,6 +33,7 @@ func SeedData(db *sql.DB) {
 	// Create DALs
 	roomDAL := NewRoomDAL(db, sharedCache)
 	itemDAL := NewItemDAL(db, sharedCache)
+	itemInstanceDAL := NewItemInstanceDAL(db, sharedCache, itemDAL)
 	npcDAL := NewNPCDAL(db, sharedCache)
 	ownerDAL := NewOwnerDAL(db, sharedCache)
 	loreDAL := NewLoreDAL(db, sharedCache)
@@ -43,6 +44,8 @@ func SeedData(db *sql.DB) {
 	raceDAL := NewRaceDAL(db, sharedCache)
 	professionDAL := NewProfessionDAL(db, sharedCache)
 	skillDAL := NewSkillDAL(db, sharedCache)
+	classDAL := NewClassDAL(db, sharedCache)
+	playerClassDAL := NewPlayerClassDAL(db, sharedCache)
 
 	// Seed Player Account and Character
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
@@ -71,6 +74,8 @@ func SeedData(db *sql.DB) {
 		CurrentRoomID:   "bag_end",
 		Health:          100,
 		MaxHealth:       100,
+		Energy:          100,
+		MaxEnergy:       100,
 		Inventory:       string(inventoryJSON),
 		VisitedRoomIDs:  string(visitedRoomsJSON),
 		CreatedAt:       time.Now(),
@@ -119,7 +124,7 @@ func SeedData(db *sql.DB) {
 		OwnerID:     "shire_spirit",
 		TerritoryID: "shire",
 		PerceptionBiases: map[string]float64{},
-		Properties:  "{}",
+		Properties:  `{"gatherables": ["maggots_prize_mushrooms"]}`,
 		Exits:       string(hobbitonPathExits),
 	}
 	if err := roomDAL.CreateRoom(hobbitonPath); err != nil {
@@ -171,7 +176,7 @@ func SeedData(db *sql.DB) {
 	prancingPonyExits, _ := json.Marshal(map[string]interface{}{
 		"west": map[string]interface{}{"Direction": "west", "target_room_id": "bree_road", "IsLocked": false, "KeyID": ""},
 		"south": map[string]interface{}{"Direction": "south", "target_room_id": "prancing_pony_stables", "IsLocked": false, "KeyID": ""},
-		"east": map[string]interface{}{"Direction": "east", "target_room_id": "prancing_pony_private_room", "IsLocked": false, "KeyID": ""},
+		"east": map[string]interface{}{"Direction": "east", "target_room_id": "prancing_pony_private_room", "is_locked": true, "key_id": "rusty_key", "is_closed": true, "relock_after": 300},
 	})
 	prancingPony := &models.Room{
 		ID:          "prancing_pony",
@@ -211,7 +216,7 @@ func SeedData(db *sql.DB) {
 
 	// Prancing Pony Private Room
 	prancingPonyPrivateRoomExits, _ := json.Marshal(map[string]interface{}{
-		"west": map[string]interface{}{"Direction": "west", "target_room_id": "prancing_pony", "IsLocked": false, "KeyID": ""},
+		"west": map[string]interface{}{"Direction": "west", "target_room_id": "prancing_pony", "is_locked": true, "key_id": "rusty_key", "is_closed": true, "relock_after": 300},
 	})
 	prancingPonyPrivateRoom := &models.Room{
 		ID:          "prancing_pony_private_room",
@@ -423,12 +428,63 @@ func SeedData(db *sql.DB) {
 		Name:        "Farmer Maggot's Prize Mushrooms",
 		Description: "A basket of unusually large and delicious-looking mushrooms.",
 		Type:        "quest_item",
-		Properties:  `{"is_quest_item": true}`,
+		Properties:  `{"is_quest_item": true, "stackable": true}`,
 	}
 	if err := itemDAL.CreateItem(maggotsPrizeMushrooms); err != nil {
 		logrus.Fatalf("Failed to seed item: %v", err)
 	}
 
+	// New Item: Oak Walking Stick
+	oakWalkingStick := &models.Item{
+		ID:          "oak_walking_stick",
+		Name:        "an oak walking stick",
+		Description: "A stout oak walking stick, worn smooth at the grip. It would make a fair cudgel in a pinch.",
+		Type:        "weapon",
+		Properties:  `{"damage": 2}`,
+	}
+	if err := itemDAL.CreateItem(oakWalkingStick); err != nil {
+		logrus.Fatalf("Failed to seed item: %v", err)
+	}
+
+	// New Item: Green Travelling Cloak
+	greenTravellingCloak := &models.Item{
+		ID:          "green_travelling_cloak",
+		Name:        "a green travelling cloak",
+		Description: "A hooded cloak of green wool, the kind hobbits wear on long walks in wet weather.",
+		Type:        "armor",
+		Properties:  `{"slot": "cloak", "armor": 1}`,
+	}
+	if err := itemDAL.CreateItem(greenTravellingCloak); err != nil {
+		logrus.Fatalf("Failed to seed item: %v", err)
+	}
+
+	// Seed Item Instances lying in rooms
+	roomItems := []*models.ItemInstance{
+		{TemplateID: "oak_walking_stick", LocationType: models.LocationRoom, LocationID: "bag_end"},
+		{TemplateID: "green_travelling_cloak", LocationType: models.LocationRoom, LocationID: "bag_end"},
+		{TemplateID: "gaffer_pipe_weed_pouch", LocationType: models.LocationRoom, LocationID: "hobbiton_path"},
+		{TemplateID: "rusty_key", LocationType: models.LocationRoom, LocationID: "prancing_pony_stables"},
+	}
+	for _, instance := range roomItems {
+		if err := itemInstanceDAL.CreateInstance(instance); err != nil {
+			logrus.Fatalf("Failed to seed item instance: %v", err)
+		}
+	}
+
+	// Tools each kind of entity lists; the tool registry describes them
+	npcTools := []models.Tool{{Name: "NPC_memorize"}}
+	ownerTools := []models.Tool{
+		{Name: "OWNER_memorize"}, {Name: "OWNER_memorize_dependables"}, {Name: "initiate_quest"},
+		{Name: "trigger_world_event"}, {Name: "spawn_entity"}, {Name: "change_room_info"},
+	}
+	questmakerTools := []models.Tool{
+		{Name: "send_message"}, {Name: "change_npc_behavior_to_player"}, {Name: "change_npc_stats"},
+		{Name: "grant_player_reward"}, {Name: "grant_passive_skill"}, {Name: "QUESTMAKER_memorize"},
+	}
+	questOwnerTools := []models.Tool{
+		{Name: "initiate_quest"}, {Name: "trigger_world_event"}, {Name: "spawn_entity"}, {Name: "change_room_info"},
+	}
+
 	// Seed Owners
 	// Shire Spirit
 	shireSpiritInitiatedQuests := []string{"shire_census_quest", "missing_pipe_weed_quest", "the_great_mushroom_hunt"}
@@ -443,7 +499,7 @@ func SeedData(db *sql.DB) {
 		CurrentInfluenceBudget: 100.0,
 		MaxInfluenceBudget:     100.0,
 		BudgetRegenRate:        0.1,
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         ownerTools,
 		InitiatedQuests:        shireSpiritInitiatedQuests,
 	}
 	if err := ownerDAL.CreateOwner(shireSpirit); err != nil {
@@ -463,7 +519,7 @@ func SeedData(db *sql.DB) {
 		CurrentInfluenceBudget: 80.0,
 		MaxInfluenceBudget:     80.0,
 		BudgetRegenRate:        0.08,
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         ownerTools,
 		InitiatedQuests:        breeGuardianInitiatedQuests,
 	}
 	if err := ownerDAL.CreateOwner(breeGuardian); err != nil {
@@ -483,7 +539,7 @@ func SeedData(db *sql.DB) {
 		CurrentInfluenceBudget: 120.0,
 		MaxInfluenceBudget:     120.0,
 		BudgetRegenRate:        0.05,
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         ownerTools,
 		InitiatedQuests:        watcherOfWeathertopInitiatedQuests,
 		ReactionThreshold:      10,
 	}
@@ -504,7 +560,7 @@ func SeedData(db *sql.DB) {
 		CurrentInfluenceBudget: 150.0,
 		MaxInfluenceBudget:     150.0,
 		BudgetRegenRate:        0.15,
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         ownerTools,
 		InitiatedQuests:        elrondCouncilInitiatedQuests,
 		ReactionThreshold:      20,
 	}
@@ -525,7 +581,7 @@ func SeedData(db *sql.DB) {
 		CurrentInfluenceBudget: 180.0,
 		MaxInfluenceBudget:     180.0,
 		BudgetRegenRate:        0.03,
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         ownerTools,
 		InitiatedQuests:        moriaAncientSpiritInitiatedQuests,
 		ReactionThreshold:      25,
 	}
@@ -546,7 +602,7 @@ func SeedData(db *sql.DB) {
 		CurrentInfluenceBudget: 100.0,
 		MaxInfluenceBudget:     100.0,
 		BudgetRegenRate:        0.1,
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         ownerTools,
 		InitiatedQuests:        lorekeepersGuildInitiatedQuests,
 		ReactionThreshold:      10,
 	}
@@ -567,7 +623,7 @@ func SeedData(db *sql.DB) {
 		CurrentInfluenceBudget: 90.0,
 		MaxInfluenceBudget:     90.0,
 		BudgetRegenRate:        0.07,
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         ownerTools,
 		InitiatedQuests:        humanElderInitiatedQuests,
 		ReactionThreshold:      10,
 	}
@@ -588,7 +644,7 @@ func SeedData(db *sql.DB) {
 		CurrentInfluenceBudget: 110.0,
 		MaxInfluenceBudget:     110.0,
 		BudgetRegenRate:        0.12,
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         ownerTools,
 		InitiatedQuests:        elvenCouncilOwnerInitiatedQuests,
 		ReactionThreshold:      15,
 	}
@@ -609,7 +665,7 @@ func SeedData(db *sql.DB) {
 		CurrentInfluenceBudget: 95.0,
 		MaxInfluenceBudget:     95.0,
 		BudgetRegenRate:        0.09,
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         ownerTools,
 		InitiatedQuests:        dwarfClanElderInitiatedQuests,
 		ReactionThreshold:      10,
 	}
@@ -630,7 +686,7 @@ func SeedData(db *sql.DB) {
 		CurrentInfluenceBudget: 85.0,
 		MaxInfluenceBudget:     85.0,
 		BudgetRegenRate:        0.1,
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         ownerTools,
 		InitiatedQuests:        hobbitShireCouncilInitiatedQuests,
 		ReactionThreshold:      10,
 	}
@@ -651,7 +707,7 @@ func SeedData(db *sql.DB) {
 		CurrentInfluenceBudget: 105.0,
 		MaxInfluenceBudget:     105.0,
 		BudgetRegenRate:        0.11,
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         ownerTools,
 		InitiatedQuests:        warriorGuildMasterInitiatedQuests,
 		ReactionThreshold:      10,
 	}
@@ -672,7 +728,7 @@ func SeedData(db *sql.DB) {
 		CurrentInfluenceBudget: 130.0,
 		MaxInfluenceBudget:     130.0,
 		BudgetRegenRate:        0.13,
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         ownerTools,
 		InitiatedQuests:        archmageConclaveInitiatedQuests,
 		ReactionThreshold:      15,
 	}
@@ -693,7 +749,7 @@ func SeedData(db *sql.DB) {
 		CurrentInfluenceBudget: 90.0,
 		MaxInfluenceBudget:     90.0,
 		BudgetRegenRate:        0.09,
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         ownerTools,
 		InitiatedQuests:        masterOfShadowsInitiatedQuests,
 		ReactionThreshold:      10,
 	}
@@ -714,7 +770,7 @@ func SeedData(db *sql.DB) {
 		OwnerIDs:             []string{"shire_spirit", "hobbit_shire_council"},
 		MemoriesAboutPlayers: map[string][]string{},
 		PersonalityPrompt:    "You are Frodo Baggins, a kind-hearted hobbit burdened by a great and terrible task. You are secretive about your mission but will seek help from trustworthy individuals.",
-		AvailableTools:       []models.Tool{},
+		AvailableTools:       npcTools,
 		BehaviorState:        "{}",
 		RaceID:               "hobbit",
 		ProfessionID:         "adventurer", // Assuming a default adventurer profession for now
@@ -735,7 +791,7 @@ func SeedData(db *sql.DB) {
 		OwnerIDs:             []string{"shire_spirit", "hobbit_shire_council"},
 		MemoriesAboutPlayers: map[string][]string{},
 		PersonalityPrompt:    "You are Samwise Gamgee, a loyal and steadfast hobbit. You are devoted to your master, Frodo, and are always ready with a kind word or a practical solution.",
-		AvailableTools:       []models.Tool{},
+		AvailableTools:       npcTools,
 		BehaviorState:        "{}",
 		RaceID:               "hobbit",
 		ProfessionID:         "adventurer",
@@ -756,7 +812,7 @@ func SeedData(db *sql.DB) {
 		OwnerIDs:             []string{"shire_spirit", "hobbit_shire_council"},
 		MemoriesAboutPlayers: map[string][]string{},
 		PersonalityPrompt:    "You are Rosie Cotton, a friendly and popular hobbit from Bywater. You enjoy good company and a pint of ale at the Green Dragon.",
-		AvailableTools:       []models.Tool{},
+		AvailableTools:       npcTools,
 		BehaviorState:        "{}",
 		RaceID:               "hobbit",
 		ProfessionID:         "commoner", // Assuming a commoner profession
@@ -777,7 +833,7 @@ func SeedData(db *sql.DB) {
 		OwnerIDs:             []string{"shire_spirit", "hobbit_shire_council"},
 		MemoriesAboutPlayers: map[string][]string{},
 		PersonalityPrompt:    "You are Old Gaffer Gamgee, a traditional hobbit who loves his garden and a good chat. You are wary of outsiders but appreciate politeness.",
-		AvailableTools:       []models.Tool{},
+		AvailableTools:       npcTools,
 		BehaviorState:        "{}",
 		RaceID:               "hobbit",
 		ProfessionID:         "commoner", // Assuming a commoner profession
@@ -798,7 +854,7 @@ func SeedData(db *sql.DB) {
 		OwnerIDs:             []string{"shire_spirit", "hobbit_shire_council"},
 		MemoriesAboutPlayers: map[string][]string{},
 		PersonalityPrompt:    "You are Farmer Maggot, a no-nonsense hobbit farmer who values his land and his mushrooms. You are wary of strangers but fair to those who respect your property. You are protective of your dogs.",
-		AvailableTools:       []models.Tool{},
+		AvailableTools:       npcTools,
 		BehaviorState:        "{}",
 		RaceID:               "hobbit",
 		ProfessionID:         "commoner",
@@ -820,7 +876,7 @@ func SeedData(db *sql.DB) {
 		OwnerIDs:             []string{"bree_guardian", "watcher_of_weathertop", "human_elder", "warrior_guild_master"},
 		MemoriesAboutPlayers: map[string][]string{},
 		PersonalityPrompt:    "You are Strider, a Ranger of the North, watchful and cautious. You are a protector of the innocent and a foe of the Shadow. You speak little but observe much.",
-		AvailableTools:       []models.Tool{},
+		AvailableTools:       npcTools,
 		BehaviorState:        "{}",
 		RaceID:               "human",
 		ProfessionID:         "warrior", // Ranger is a type of warrior
@@ -841,7 +897,7 @@ func SeedData(db *sql.DB) {
 		OwnerIDs:             []string{"bree_guardian"},
 		MemoriesAboutPlayers: map[string][]string{},
 		PersonalityPrompt:    "You are Barliman Butterbur, the innkeeper of The Prancing Pony. You are a bit forgetful but generally kind and concerned for your patrons. You know a lot of local gossip.",
-		AvailableTools:       []models.Tool{},
+		AvailableTools:       npcTools,
 		BehaviorState:        "{}",
 		RaceID:               "human",
 		ProfessionID:         "commoner", // Innkeeper is a type of commoner
@@ -862,8 +918,8 @@ func SeedData(db *sql.DB) {
 		OwnerIDs:             []string{"bree_guardian"},
 		MemoriesAboutPlayers: map[string][]string{},
 		PersonalityPrompt:    "You are Bill Ferny, a petty, malicious man from Bree, often seen with unsavory characters. You are easily bribed and quick to betray.",
-		AvailableTools:       []models.Tool{},
-		BehaviorState:        "{}",
+		AvailableTools:       npcTools,
+		BehaviorState:        `{"trains_class": "rogue"}`,
 		RaceID:               "human",
 		ProfessionID:         "rogue", // He's shifty, so rogue fits
 	}
@@ -883,8 +939,8 @@ func SeedData(db *sql.DB) {
 		OwnerIDs:             []string{"elrond_council", "elven_council_owner", "archmage_conclave"},
 		MemoriesAboutPlayers: map[string][]string{},
 		PersonalityPrompt:    "You are Elrond, Lord of Rivendell. You are wise, ancient, and deeply concerned with the fate of Middle-earth. You offer counsel and aid to those who fight against the Shadow.",
-		AvailableTools:       []models.Tool{},
-		BehaviorState:        "{}",
+		AvailableTools:       npcTools,
+		BehaviorState:        `{"trains_class": "mage"}`,
 		RaceID:               "elf",
 		ProfessionID:         "mage", // He's a powerful magic user
 	}
@@ -904,8 +960,8 @@ func SeedData(db *sql.DB) {
 		OwnerIDs:             []string{"elrond_council", "elven_council_owner", "warrior_guild_master"},
 		MemoriesAboutPlayers: map[string][]string{},
 		PersonalityPrompt:    "You are Glorfindel, a powerful Elf-lord of Gondolin, returned from the Halls of Mandos. You are a formidable warrior and a beacon of hope against the darkness.",
-		AvailableTools:       []models.Tool{},
-		BehaviorState:        "{}",
+		AvailableTools:       npcTools,
+		BehaviorState:        `{"trains_class": "warrior"}`,
 		RaceID:               "elf",
 		ProfessionID:         "warrior",
 	}
@@ -925,7 +981,7 @@ func SeedData(db *sql.DB) {
 		OwnerIDs:             []string{"moria_ancient_spirit", "dwarf_clan_elder", "warrior_guild_master"},
 		MemoriesAboutPlayers: map[string][]string{},
 		PersonalityPrompt:    "You are Gimli, a proud Dwarf of the Lonely Mountain. You value honor, loyalty, and the ancient halls of your kin. You are quick to anger but steadfast in friendship.",
-		AvailableTools:       []models.Tool{},
+		AvailableTools:       npcTools,
 		BehaviorState:        "{}",
 		RaceID:               "dwarf",
 		ProfessionID:         "warrior",
@@ -946,7 +1002,7 @@ func SeedData(db *sql.DB) {
 		OwnerIDs:             []string{"human_elder", "bree_guardian", "warrior_guild_master"},
 		MemoriesAboutPlayers: map[string][]string{},
 		PersonalityPrompt:    "You are Guard Captain Thomas. You uphold the law strictly and observe all activity in your patrol area. You react to significant disturbances or direct interactions.",
-		AvailableTools:       []models.Tool{},
+		AvailableTools:       npcTools,
 		BehaviorState:        "{}",
 		ReactionThreshold:    7,
 		RaceID:               "human",
@@ -968,8 +1024,8 @@ func SeedData(db *sql.DB) {
 		OwnerIDs:             []string{"elrond_council", "lorekeepers_guild", "elven_council_owner", "archmage_conclave"},
 		MemoriesAboutPlayers: map[string][]string{},
 		PersonalityPrompt:    "You are Elara, an elven scholar. You are dedicated to the pursuit of knowledge and the preservation of ancient lore. You are patient and wise, willing to share insights with those who show genuine curiosity.",
-		AvailableTools:       []models.Tool{},
-		BehaviorState:        "{}",
+		AvailableTools:       npcTools,
+		BehaviorState:        `{"trains_class": "scholar"}`,
 		RaceID:               "elf",
 		ProfessionID:         "scholar",
 	}
@@ -989,7 +1045,7 @@ func SeedData(db *sql.DB) {
 		OwnerIDs:             []string{"moria_ancient_spirit", "dwarf_clan_elder"},
 		MemoriesAboutPlayers: map[string][]string{},
 		PersonalityPrompt:    "You are Borin, a dwarf miner. You are gruff but honest, with a deep love for stone and the lost glory of Khazad-dûm. You are suspicious of elves but loyal to your kin.",
-		AvailableTools:       []models.Tool{},
+		AvailableTools:       npcTools,
 		BehaviorState:        "{}",
 		RaceID:               "dwarf",
 		ProfessionID:         "warrior", // Assuming miner is a type of warrior for simplicity
@@ -1153,7 +1209,7 @@ func SeedData(db *sql.DB) {
 		MaxInfluenceBudget:     50.0,
 		BudgetRegenRate:        0.0, // Player-action based
 		MemoriesAboutPlayers:   map[string][]string{},
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         questmakerTools,
 		ReactionThreshold:      5,
 	}
 	if err := questmakerDAL.CreateQuestmaker(urgentMessageQuestmaker); err != nil {
@@ -1169,7 +1225,7 @@ func SeedData(db *sql.DB) {
 		MaxInfluenceBudget:     30.0,
 		BudgetRegenRate:        0.0,
 		MemoriesAboutPlayers:   map[string][]string{},
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         questmakerTools,
 		ReactionThreshold:      5,
 	}
 	if err := questmakerDAL.CreateQuestmaker(missingPonyQuestmaker); err != nil {
@@ -1185,7 +1241,7 @@ func SeedData(db *sql.DB) {
 		MaxInfluenceBudget:     70.0,
 		BudgetRegenRate:        0.0,
 		MemoriesAboutPlayers:   map[string][]string{},
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         questmakerTools,
 		ReactionThreshold:      10,
 	}
 	if err := questmakerDAL.CreateQuestmaker(investigateWeathertopQuestmaker); err != nil {
@@ -1201,7 +1257,7 @@ func SeedData(db *sql.DB) {
 		MaxInfluenceBudget:     90.0,
 		BudgetRegenRate:        0.0,
 		MemoriesAboutPlayers:   map[string][]string{},
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         questmakerTools,
 		ReactionThreshold:      15,
 	}
 	if err := questmakerDAL.CreateQuestmaker(roadToRivendellQuestmaker); err != nil {
@@ -1217,7 +1273,7 @@ func SeedData(db *sql.DB) {
 		MaxInfluenceBudget:     110.0,
 		BudgetRegenRate:        0.0,
 		MemoriesAboutPlayers:   map[string][]string{},
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         questmakerTools,
 		ReactionThreshold:      20,
 	}
 	if err := questmakerDAL.CreateQuestmaker(delvingDarknessQuestmaker); err != nil {
@@ -1233,7 +1289,7 @@ func SeedData(db *sql.DB) {
 		MaxInfluenceBudget:     40.0,
 		BudgetRegenRate:        0.0,
 		MemoriesAboutPlayers:   map[string][]string{},
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         questmakerTools,
 		ReactionThreshold:      5,
 	}
 	if err := questmakerDAL.CreateQuestmaker(shireCensusQuestmaker); err != nil {
@@ -1249,7 +1305,7 @@ func SeedData(db *sql.DB) {
 		MaxInfluenceBudget:     55.0,
 		BudgetRegenRate:        0.0,
 		MemoriesAboutPlayers:   map[string][]string{},
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         questmakerTools,
 		ReactionThreshold:      10,
 	}
 	if err := questmakerDAL.CreateQuestmaker(trainingRegimenQuestmaker); err != nil {
@@ -1265,7 +1321,7 @@ func SeedData(db *sql.DB) {
 		MaxInfluenceBudget:     25.0,
 		BudgetRegenRate:        0.0,
 		MemoriesAboutPlayers:   map[string][]string{},
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         questmakerTools,
 		ReactionThreshold:      5,
 	}
 	if err := questmakerDAL.CreateQuestmaker(missingPipeWeedQuestmaker); err != nil {
@@ -1281,7 +1337,7 @@ func SeedData(db *sql.DB) {
 		MaxInfluenceBudget:     100,
 		BudgetRegenRate:        5,
 		MemoriesAboutPlayers:   make(map[string][]string),
-		AvailableTools:         []models.Tool{},
+		AvailableTools:         questmakerTools,
 	}
 	if err := questmakerDAL.CreateQuestmaker(mushroomHuntQuestmaker); err != nil {
 		logrus.Fatalf("Failed to seed questmaker: %v", err)
@@ -1300,6 +1356,7 @@ func SeedData(db *sql.DB) {
 		MaxInfluenceBudget:      200.0,
 		BudgetRegenRate:         0.2,
 		AssociatedQuestmakerIDs: string(gandalfGrandPlanAssociatedQuestmakerIDsJSON),
+		AvailableTools:          questOwnerTools,
 	}
 	if err := questOwnerDAL.CreateQuestOwner(gandalfGrandPlan); err != nil {
 		logrus.Fatalf("Failed to seed quest owner: %v", err)
@@ -1317,6 +1374,7 @@ func SeedData(db *sql.DB) {
 		MaxInfluenceBudget:      150.0,
 		BudgetRegenRate:         0.15,
 		AssociatedQuestmakerIDs: string(fellowshipJourneyAssociatedQuestmakerIDsJSON),
+		AvailableTools:          questOwnerTools,
 	}
 	if err := questOwnerDAL.CreateQuestOwner(fellowshipJourney); err != nil {
 		logrus.Fatalf("Failed to seed quest owner: %v", err)
@@ -1334,6 +1392,7 @@ func SeedData(db *sql.DB) {
 		MaxInfluenceBudget:      70.0,
 		BudgetRegenRate:         0.1,
 		AssociatedQuestmakerIDs: string(shireLocalGovernanceAssociatedQuestmakerIDsJSON),
+		AvailableTools:          questOwnerTools,
 	}
 	if err := questOwnerDAL.CreateQuestOwner(shireLocalGovernance); err != nil {
 		logrus.Fatalf("Failed to seed quest owner: %v", err)
@@ -1351,6 +1410,7 @@ func SeedData(db *sql.DB) {
 		MaxInfluenceBudget:      75.0,
 		BudgetRegenRate:         0.08,
 		AssociatedQuestmakerIDs: string(breeLocalAffairsAssociatedQuestmakerIDsJSON),
+		AvailableTools:          questOwnerTools,
 	}
 	if err := questOwnerDAL.CreateQuestOwner(breeLocalAffairs); err != nil {
 		logrus.Fatalf("Failed to seed quest owner: %v", err)
@@ -1368,6 +1428,7 @@ func SeedData(db *sql.DB) {
 		MaxInfluenceBudget:      80.0,
 		BudgetRegenRate:         0.11,
 		AssociatedQuestmakerIDs: string(warriorGuildTrialsAssociatedQuestmakerIDsJSON),
+		AvailableTools:          questOwnerTools,
 	}
 	if err := questOwnerDAL.CreateQuestOwner(warriorGuildTrials); err != nil {
 		logrus.Fatalf("Failed to seed quest owner: %v", err)
@@ -1662,104 +1723,140 @@ func SeedData(db *sql.DB) {
 	// Seed Professions
 	// Seed Skills
 	swordMastery := &models.Skill{
-		ID:          "sword_mastery",
-		Name:        "Sword Mastery",
-		Category:    "combat",
-		Description: "Increases proficiency with swords.",
-		Type:        "passive",
-		Effects:     "{}",
+		ID:                "sword_mastery",
+		Name:              "Sword Mastery",
+		Category:          "combat",
+		Description:       "Increases proficiency with swords.",
+		Type:              "passive",
+		AssociatedClassID: "warrior",
+		Effects:           "{}",
 	}
 	if err := skillDAL.CreateSkill(swordMastery); err != nil {
 		logrus.Fatalf("Failed to seed skill: %v", err)
 	}
 
 	shieldBlock := &models.Skill{
-		ID:          "shield_block",
-		Name:        "Shield Block",
-		Category:    "combat",
-		Description: "Allows the wielder to block incoming attacks with a shield.",
-		Type:        "active",
-		Effects:     "{}",
-		Cost:        5,
-		Cooldown:    3,
+		ID:                "shield_block",
+		Name:              "Shield Block",
+		Category:          "combat",
+		Description:       "Allows the wielder to block incoming attacks with a shield.",
+		Type:              "active",
+		AssociatedClassID: "warrior",
+		Effects:           `[{"type": "MODIFY_ATTRIBUTE", "target": "SELF", "attribute": "armor", "value_formula": "1 + skill_percentage * 0.05", "duration_seconds": 10}]`,
+		Cost:              5,
+		Cooldown:          3,
 	}
 	if err := skillDAL.CreateSkill(shieldBlock); err != nil {
 		logrus.Fatalf("Failed to seed skill: %v", err)
 	}
 
 	fireball := &models.Skill{
-		ID:          "fireball",
-		Name:        "Fireball",
-		Category:    "magic",
-		Description: "Hurls a ball of fire at a target.",
-		Type:        "active",
-		Effects:     "{}",
-		Cost:        10,
-		Cooldown:    5,
+		ID:                "fireball",
+		Name:              "Fireball",
+		Category:          "magic",
+		Description:       "Hurls a ball of fire at a target.",
+		Type:              "active",
+		AssociatedClassID: "mage",
+		Effects:           `[{"type": "DAMAGE", "target": "ENEMY", "value_formula": "base_damage + (skill_percentage * 0.5)", "damage_type": "fire"}]`,
+		Cost:              10,
+		Cooldown:          5,
 	}
 	if err := skillDAL.CreateSkill(fireball); err != nil {
 		logrus.Fatalf("Failed to seed skill: %v", err)
 	}
 
 	arcaneShield := &models.Skill{
-		ID:          "arcane_shield",
-		Name:        "Arcane Shield",
-		Category:    "magic",
-		Description: "Creates a magical barrier to absorb damage.",
-		Type:        "active",
-		Effects:     "{}",
-		Cost:        8,
-		Cooldown:    4,
+		ID:                "arcane_shield",
+		Name:              "Arcane Shield",
+		Category:          "magic",
+		Description:       "Creates a magical barrier to absorb damage.",
+		Type:              "active",
+		AssociatedClassID: "mage",
+		Effects:           `[{"type": "MODIFY_ATTRIBUTE", "target": "SELF", "attribute": "armor", "value_formula": "2 + skill_percentage * 0.05", "duration_seconds": "10 + skill_percentage * 0.2"}]`,
+		Cost:              8,
+		Cooldown:          4,
 	}
 	if err := skillDAL.CreateSkill(arcaneShield); err != nil {
 		logrus.Fatalf("Failed to seed skill: %v", err)
 	}
 
 	stealth := &models.Skill{
-		ID:          "stealth",
-		Name:        "Stealth",
-		Category:    "subterfuge",
-		Description: "Allows the user to move unseen and unheard.",
-		Type:        "passive",
-		Effects:     "{}",
+		ID:                "stealth",
+		Name:              "Stealth",
+		Category:          "subterfuge",
+		Description:       "Allows the user to move unseen and unheard.",
+		Type:              "passive",
+		AssociatedClassID: "rogue",
+		Effects:           "{}",
 	}
 	if err := skillDAL.CreateSkill(stealth); err != nil {
 		logrus.Fatalf("Failed to seed skill: %v", err)
 	}
 
 	lockpicking := &models.Skill{
-		ID:          "lockpicking",
-		Name:        "Lockpicking",
+		ID:                "lockpicking",
+		Name:              "Lockpicking",
+		Category:          "subterfuge",
+		Description:       "Enables the user to open locked containers and doors.",
+		Type:              "active",
+		AssociatedClassID: "rogue",
+		Effects:           "{}",
+		Cost:              2,
+		Cooldown:          1,
+	}
+	if err := skillDAL.CreateSkill(lockpicking); err != nil {
+		logrus.Fatalf("Failed to seed skill: %v", err)
+	}
+
+	keenSenses := &models.Skill{
+		ID:          "keen_senses",
+		Name:        "Keen Senses",
 		Category:    "subterfuge",
-		Description: "Enables the user to open locked containers and doors.",
+		Description: "Sharpens the user's eyes and ears, making the actions of others easier to read.",
 		Type:        "active",
-		Effects:     "{}",
-		Cost:        2,
-		Cooldown:    1,
+		Effects:     `[{"type": "STATUS_EFFECT", "target": "SELF", "status_id": "keen_senses", "duration_seconds": "30 + skill_percentage * 0.6"}]`,
+		Cost:        5,
+		Cooldown:    10,
 	}
-	if err := skillDAL.CreateSkill(lockpicking); err != nil {
+	if err := skillDAL.CreateSkill(keenSenses); err != nil {
+		logrus.Fatalf("Failed to seed skill: %v", err)
+	}
+
+	dazzle := &models.Skill{
+		ID:          "dazzle",
+		Name:        "Dazzle",
+		Category:    "magic",
+		Description: "A burst of light that leaves a target struggling to make out what happens around them.",
+		Type:        "active",
+		Effects:     `[{"type": "STATUS_EFFECT", "target": "ENEMY", "status_id": "dazzled", "duration_seconds": "5 + skill_percentage * 0.1", "stacking": "stack", "max_stacks": 2}]`,
+		Cost:        6,
+		Cooldown:    4,
+	}
+	if err := skillDAL.CreateSkill(dazzle); err != nil {
 		logrus.Fatalf("Failed to seed skill: %v", err)
 	}
 
 	ancientLanguages := &models.Skill{
-		ID:          "ancient_languages",
-		Name:        "Ancient Languages",
-		Category:    "knowledge",
-		Description: "Grants understanding of ancient and forgotten tongues.",
-		Type:        "passive",
-		Effects:     "{}",
+		ID:                "ancient_languages",
+		Name:              "Ancient Languages",
+		Category:          "knowledge",
+		Description:       "Grants understanding of ancient and forgotten tongues.",
+		Type:              "passive",
+		AssociatedClassID: "scholar",
+		Effects:           "{}",
 	}
 	if err := skillDAL.CreateSkill(ancientLanguages); err != nil {
 		logrus.Fatalf("Failed to seed skill: %v", err)
 	}
 
 	historyOfMiddleEarth := &models.Skill{
-		ID:          "history_of_middle_earth",
-		Name:        "History of Middle-earth",
-		Category:    "knowledge",
-		Description: "Provides deep knowledge of the history and lore of Middle-earth.",
-		Type:        "passive",
-		Effects:     "{}",
+		ID:                "history_of_middle_earth",
+		Name:              "History of Middle-earth",
+		Category:          "knowledge",
+		Description:       "Provides deep knowledge of the history and lore of Middle-earth.",
+		Type:              "passive",
+		AssociatedClassID: "scholar",
+		Effects:           "{}",
 	}
 	if err := skillDAL.CreateSkill(historyOfMiddleEarth); err != nil {
 		logrus.Fatalf("Failed to seed skill: %v", err)
@@ -1841,5 +1938,46 @@ func SeedData(db *sql.DB) {
 		logrus.Fatalf("Failed to seed profession: %v", err)
 	}
 
+	// Seed Classes
+	// Each profession has a class of the same ID that new characters join.
+	classes := []*models.Class{
+		{
+			ID:             "warrior",
+			Name:           "Warrior",
+			Description:    "The path of arms: every fight hardens the body and sharpens the blade.",
+			TotalLevels:    5,
+			LevelUpRewards: `{"2": {"stats": {"strength": 1}}, "3": {"unlock_skill": "keen_senses"}, "4": {"stats": {"constitution": 1}}}`,
+		},
+		{
+			ID:             "mage",
+			Name:           "Mage",
+			Description:    "The path of the arcane: power grows with every spell woven.",
+			TotalLevels:    5,
+			LevelUpRewards: `{"2": {"unlock_skill": "dazzle"}, "3": {"stats": {"intelligence": 1}}, "4": {"choose_skill": ["keen_senses", "ancient_languages"]}}`,
+		},
+		{
+			ID:             "rogue",
+			Name:           "Rogue",
+			Description:    "The path of shadows: every lock picked and every eye avoided teaches something.",
+			TotalLevels:    5,
+			LevelUpRewards: `{"2": {"unlock_skill": "keen_senses"}, "3": {"stats": {"dexterity": 1}}, "4": {"choose_skill": ["dazzle", "ancient_languages"]}}`,
+		},
+		{
+			ID:             "scholar",
+			Name:           "Scholar",
+			Description:    "The path of learning: knowledge of the world deepens with every discovery.",
+			TotalLevels:    5,
+			LevelUpRewards: `{"2": {"stats": {"wisdom": 1}}, "3": {"choose_skill": ["keen_senses", "dazzle"]}}`,
+		},
+	}
+	for _, class := range classes {
+		if err := classDAL.CreateClass(class); err != nil {
+			logrus.Fatalf("Failed to seed class: %v", err)
+		}
+	}
+	if err := playerClassDAL.CreatePlayerClass(&models.PlayerClass{PlayerID: testCharacter.ID, ClassID: "warrior", Level: 1, PracticePoints: 2}); err != nil {
+		logrus.Fatalf("Failed to seed player class: %v", err)
+	}
+
 	fmt.Println("Database seeding complete.")
 }
\ No newline at end of file
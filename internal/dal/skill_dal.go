@@ -8,7 +8,7 @@ import (
 
 // SkillDAL handles database operations for Skill entities.
 type SkillDAL struct {
-	db    *sql.DB
+	db    DBTX
 	cache CacheInterface
 }
 
@@ -17,7 +17,7 @@ func (d *SkillDAL) Cache() CacheInterface {
 }
 
 // NewSkillDAL creates a new SkillDAL.
-func NewSkillDAL(db *sql.DB, cache CacheInterface) *SkillDAL {
+func NewSkillDAL(db DBTX, cache CacheInterface) *SkillDAL {
 	return &SkillDAL{db: db, cache: cache}
 }
 
@@ -0,0 +1,197 @@
+package dal
+
+import (
+	"database/sql"
+	"fmt"
+	"mud/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatusEffectDAL handles database operations for StatusEffect entities.
+type StatusEffectDAL struct {
+	db    DBTX
+	cache CacheInterface
+}
+
+func (d *StatusEffectDAL) Cache() CacheInterface {
+	return d.cache
+}
+
+// NewStatusEffectDAL creates a new StatusEffectDAL.
+func NewStatusEffectDAL(db DBTX, cache CacheInterface) *StatusEffectDAL {
+	return &StatusEffectDAL{db: db, cache: cache}
+}
+
+const statusEffectColumns = `id, target_type, target_id, source_type, source_id, type, key, magnitude, stacking, stacks, max_stacks, applied_at, expires_at`
+
+// CreateStatusEffect inserts a new status effect into the database. An ID is
+// generated when none is set, and the effect starts with one stack.
+func (d *StatusEffectDAL) CreateStatusEffect(effect *models.StatusEffect) error {
+	if effect.ID == "" {
+		effect.ID = uuid.New().String()
+	}
+	if effect.Stacks <= 0 {
+		effect.Stacks = 1
+	}
+	if effect.AppliedAt.IsZero() {
+		effect.AppliedAt = time.Now()
+	}
+
+	query := `
+	INSERT INTO status_effects (` + statusEffectColumns + `)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := d.db.Exec(query,
+		effect.ID,
+		effect.TargetType,
+		effect.TargetID,
+		effect.SourceType,
+		effect.SourceID,
+		effect.Type,
+		effect.Key,
+		effect.Magnitude,
+		effect.Stacking,
+		effect.Stacks,
+		effect.MaxStacks,
+		effect.AppliedAt.UTC(),
+		effect.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create status effect: %w", err)
+	}
+	return nil
+}
+
+// GetStatusEffectByID retrieves a status effect by its ID.
+func (d *StatusEffectDAL) GetStatusEffectByID(id string) (*models.StatusEffect, error) {
+	query := `SELECT ` + statusEffectColumns + ` FROM status_effects WHERE id = ?`
+	effect, err := scanStatusEffect(d.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Status effect not found
+		}
+		return nil, fmt.Errorf("failed to get status effect by ID: %w", err)
+	}
+	return effect, nil
+}
+
+// GetStatusEffectsByTarget retrieves the status effects on a character or NPC,
+// including expired effects that have not been swept yet, oldest first.
+func (d *StatusEffectDAL) GetStatusEffectsByTarget(targetType, targetID string) ([]*models.StatusEffect, error) {
+	query := `SELECT ` + statusEffectColumns + ` FROM status_effects WHERE target_type = ? AND target_id = ? ORDER BY applied_at, rowid`
+	return d.query(query, targetType, targetID)
+}
+
+// GetExpiredStatusEffects retrieves the status effects that have run out by the given time.
+// Times are stored in UTC so that they compare correctly.
+func (d *StatusEffectDAL) GetExpiredStatusEffects(now time.Time) ([]*models.StatusEffect, error) {
+	query := `SELECT ` + statusEffectColumns + ` FROM status_effects WHERE expires_at <= ? ORDER BY expires_at, rowid`
+	return d.query(query, now.UTC())
+}
+
+// UpdateStatusEffect updates an existing status effect in the database.
+func (d *StatusEffectDAL) UpdateStatusEffect(effect *models.StatusEffect) error {
+	query := `
+	UPDATE status_effects
+	SET target_type = ?, target_id = ?, source_type = ?, source_id = ?, type = ?, key = ?, magnitude = ?, stacking = ?, stacks = ?, max_stacks = ?, applied_at = ?, expires_at = ?
+	WHERE id = ?
+	`
+
+	result, err := d.db.Exec(query,
+		effect.TargetType,
+		effect.TargetID,
+		effect.SourceType,
+		effect.SourceID,
+		effect.Type,
+		effect.Key,
+		effect.Magnitude,
+		effect.Stacking,
+		effect.Stacks,
+		effect.MaxStacks,
+		effect.AppliedAt.UTC(),
+		effect.ExpiresAt.UTC(),
+		effect.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update status effect: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("status effect with ID %s not found for update", effect.ID)
+	}
+	return nil
+}
+
+// DeleteStatusEffect deletes a status effect from the database by its ID.
+func (d *StatusEffectDAL) DeleteStatusEffect(id string) error {
+	query := `DELETE FROM status_effects WHERE id = ?`
+	result, err := d.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete status effect: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("status effect with ID %s not found for deletion", id)
+	}
+	return nil
+}
+
+func (d *StatusEffectDAL) query(query string, args ...interface{}) ([]*models.StatusEffect, error) {
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status effects: %w", err)
+	}
+	defer rows.Close()
+
+	var effects []*models.StatusEffect
+	for rows.Next() {
+		effect, err := scanStatusEffect(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan status effect: %w", err)
+		}
+		effects = append(effects, effect)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating through status effects: %w", err)
+	}
+	return effects, nil
+}
+
+// statusEffectScanner is satisfied by both *sql.Row and *sql.Rows.
+type statusEffectScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanStatusEffect(row statusEffectScanner) (*models.StatusEffect, error) {
+	effect := &models.StatusEffect{}
+	err := row.Scan(
+		&effect.ID,
+		&effect.TargetType,
+		&effect.TargetID,
+		&effect.SourceType,
+		&effect.SourceID,
+		&effect.Type,
+		&effect.Key,
+		&effect.Magnitude,
+		&effect.Stacking,
+		&effect.Stacks,
+		&effect.MaxStacks,
+		&effect.AppliedAt,
+		&effect.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return effect, nil
+}
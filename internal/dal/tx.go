@@ -0,0 +1,261 @@
+package dal
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ErrNestedTx is returned when a transaction is begun on a transaction's DAL.
+var ErrNestedTx = errors.New("transactions cannot be nested")
+
+// DBTX is what the DALs run their queries on: the database itself, or a
+// transaction on it.
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Tx is a unit of work. Its DAL reads and writes within a database
+// transaction, and its cache holds back every change until the transaction
+// commits, so that nothing outside sees the work until it is all done.
+type Tx struct {
+	*DAL
+	tx   *sql.Tx
+	work *UnitOfWork
+	done bool
+}
+
+// Begin starts a unit of work on the database.
+func (d *DAL) Begin() (*Tx, error) {
+	if d.db == nil {
+		return nil, ErrNestedTx
+	}
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	work := NewUnitOfWork(d.cache)
+	return &Tx{DAL: newDAL(tx, work), tx: tx, work: work}, nil
+}
+
+// Commit commits the transaction, then applies its cache changes.
+func (t *Tx) Commit() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	if err := t.tx.Commit(); err != nil {
+		t.work.Discard()
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	t.work.Commit()
+	return nil
+}
+
+// Rollback abandons the transaction and its cache changes. It does nothing
+// once the transaction has been committed or rolled back, so it can be
+// deferred.
+func (t *Tx) Rollback() error {
+	if t.done {
+		return nil
+	}
+	t.done = true
+	t.work.Discard()
+	if err := t.tx.Rollback(); err != nil {
+		return fmt.Errorf("failed to roll back transaction: %w", err)
+	}
+	return nil
+}
+
+// cacheWrite is a change a UnitOfWork holds back.
+type cacheWrite struct {
+	value   interface{}
+	ttl     time.Duration
+	deleted bool
+}
+
+// UnitOfWork is a cache that holds back the changes made through it until
+// they are committed to the cache it wraps, and reads its own changes
+// before that cache. DALs hand out the objects they cache and callers change
+// them in place, so it hands out deep copies of what it reads from the
+// wrapped cache: nothing outside sees those changes, and nothing is left of
+// them when the work is discarded. Keys it read are evicted from the wrapped
+// cache when the work is committed, as the rows they came from may have
+// changed.
+type UnitOfWork struct {
+	base    CacheInterface
+	mu      sync.Mutex
+	writes  map[string]cacheWrite
+	copies  map[string]interface{} // Copies of what was read from base
+	cleared bool
+}
+
+// NewUnitOfWork creates a UnitOfWork on a cache.
+func NewUnitOfWork(base CacheInterface) *UnitOfWork {
+	return &UnitOfWork{base: base, writes: make(map[string]cacheWrite), copies: make(map[string]interface{})}
+}
+
+func (u *UnitOfWork) Get(key string) (interface{}, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if write, ok := u.writes[key]; ok {
+		return write.value, !write.deleted
+	}
+	if u.cleared {
+		return nil, false
+	}
+	if value, ok := u.copies[key]; ok {
+		return value, true
+	}
+	value, found := u.base.Get(key)
+	if !found {
+		return nil, false
+	}
+	value = deepCopy(value)
+	u.copies[key] = value
+	return value, true
+}
+
+func (u *UnitOfWork) Set(key string, value interface{}, ttl time.Duration) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.writes[key] = cacheWrite{value: value, ttl: ttl}
+}
+
+func (u *UnitOfWork) SetMany(items map[string]interface{}, ttl time.Duration) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for key, value := range items {
+		u.writes[key] = cacheWrite{value: value, ttl: ttl}
+	}
+}
+
+func (u *UnitOfWork) Delete(key string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.writes[key] = cacheWrite{deleted: true}
+}
+
+func (u *UnitOfWork) Clear() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.writes = make(map[string]cacheWrite)
+	u.cleared = true
+}
+
+// Commit applies the held back changes to the wrapped cache.
+func (u *UnitOfWork) Commit() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.cleared {
+		u.base.Clear()
+	}
+	for key := range u.copies {
+		if _, written := u.writes[key]; !written {
+			u.base.Delete(key)
+		}
+	}
+	for key, write := range u.writes {
+		if write.deleted {
+			u.base.Delete(key)
+		} else {
+			u.base.Set(key, write.value, write.ttl)
+		}
+	}
+	u.writes = make(map[string]cacheWrite)
+	u.copies = make(map[string]interface{})
+}
+
+// Discard drops the held back changes, leaving the wrapped cache as it was.
+func (u *UnitOfWork) Discard() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.writes = make(map[string]cacheWrite)
+	u.copies = make(map[string]interface{})
+}
+
+// deepCopy returns a copy of a cached value that shares nothing that can be
+// changed with it: what its pointers, slices, maps and interfaces refer to
+// is copied too. Unexported fields are copied as they are.
+func deepCopy(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	original := reflect.ValueOf(value)
+	copied := reflect.New(original.Type()).Elem()
+	copyValue(copied, original)
+	return copied.Interface()
+}
+
+func copyValue(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.New(src.Elem().Type()))
+		copyValue(dst.Elem(), src.Elem())
+	case reflect.Struct:
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				copyValue(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		for i := 0; i < src.Len(); i++ {
+			copyValue(dst.Index(i), src.Index(i))
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+		entries := src.MapRange()
+		for entries.Next() {
+			value := reflect.New(entries.Value().Type()).Elem()
+			copyValue(value, entries.Value())
+			dst.SetMapIndex(entries.Key(), value)
+		}
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		value := reflect.New(src.Elem().Type()).Elem()
+		copyValue(value, src.Elem())
+		dst.Set(value)
+	default:
+		dst.Set(src)
+	}
+}
+
+// atomically runs fn in a transaction on db, or on db itself when it is
+// already a transaction, so that a DAL's multi-statement changes are all or
+// nothing however the DAL was made.
+func atomically(db DBTX, fn func(DBTX) error) error {
+	sqlDB, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
+	}
+	tx, err := sqlDB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
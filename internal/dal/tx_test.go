@@ -0,0 +1,89 @@
+package dal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/models"
+)
+
+func TestTx(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	dals := NewDAL(db)
+	newNPC := func(id string) *models.NPC {
+		return &models.NPC{ID: id, Name: id, CurrentRoomID: "square", Health: 10, MaxHealth: 10, Inventory: []string{}, BehaviorState: "{}"}
+	}
+	assert.NoError(t, dals.NpcDAL.CreateNPC(newNPC("baker")))
+
+	// A rolled back transaction leaves the database and the cache as they were.
+	tx, err := dals.Begin()
+	assert.NoError(t, err)
+	baker, err := tx.NpcDAL.GetNPCByID("baker")
+	assert.NoError(t, err)
+	baker.Health = 1
+	assert.NoError(t, tx.NpcDAL.UpdateNPC(baker))
+	assert.NoError(t, tx.NpcDAL.CreateNPC(newNPC("miller")))
+	miller, err := tx.NpcDAL.GetNPCByID("miller")
+	assert.NoError(t, err)
+	assert.NotNil(t, miller, "A transaction sees its own changes")
+	_, cached := dals.cache.Get("miller")
+	assert.False(t, cached, "Changes are held back from the cache")
+	assert.NoError(t, tx.Rollback())
+	assert.NoError(t, tx.Rollback(), "Rolling back twice does nothing")
+
+	baker, err = dals.NpcDAL.GetNPCByID("baker")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, baker.Health)
+	miller, err = dals.NpcDAL.GetNPCByID("miller")
+	assert.NoError(t, err)
+	assert.Nil(t, miller)
+
+	// A committed transaction updates both.
+	tx, err = dals.Begin()
+	assert.NoError(t, err)
+	_, err = tx.Begin()
+	assert.ErrorIs(t, err, ErrNestedTx)
+	assert.NoError(t, tx.NpcDAL.CreateNPC(newNPC("miller")))
+	assert.NoError(t, tx.Commit())
+	assert.NoError(t, tx.Rollback(), "Rolling back after a commit does nothing")
+	_, cached = dals.cache.Get("miller")
+	assert.True(t, cached)
+	miller, err = dals.NpcDAL.GetNPCByID("miller")
+	assert.NoError(t, err)
+	assert.NotNil(t, miller)
+}
+
+func TestTx_IsolatesCachedObjects(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	dals := NewDAL(db)
+	assert.NoError(t, dals.NpcDAL.CreateNPC(&models.NPC{
+		ID: "baker", Name: "baker", CurrentRoomID: "square", Health: 10, MaxHealth: 10, Inventory: []string{"bread"}, BehaviorState: "{}",
+		MemoriesAboutPlayers: map[string][]string{"hero": {"Bought a loaf."}},
+	}))
+	cached, err := dals.NpcDAL.GetNPCByID("baker")
+	assert.NoError(t, err)
+
+	// Changes made in place inside a transaction are not seen outside it.
+	tx, err := dals.Begin()
+	assert.NoError(t, err)
+	baker, err := tx.NpcDAL.GetNPCByID("baker")
+	assert.NoError(t, err)
+	assert.False(t, baker == cached, "A transaction reads a copy of the cached NPC")
+	baker.MemoriesAboutPlayers["hero"] = append(baker.MemoriesAboutPlayers["hero"], "Stole a loaf.")
+	baker.Inventory[0] = "stolen bread"
+	baker.Health = 1
+	again, err := tx.NpcDAL.GetNPCByID("baker")
+	assert.NoError(t, err)
+	assert.True(t, again == baker, "A transaction sees its own changes")
+	assert.Equal(t, []string{"Bought a loaf."}, cached.MemoriesAboutPlayers["hero"])
+	assert.NoError(t, tx.Rollback())
+
+	fresh, found := dals.cache.Get("baker")
+	assert.True(t, found, "Rolling back leaves the cache as it was")
+	npc := fresh.(*models.NPC)
+	assert.Equal(t, []string{"Bought a loaf."}, npc.MemoriesAboutPlayers["hero"])
+	assert.Equal(t, []string{"bread"}, npc.Inventory)
+	assert.Equal(t, 10, npc.Health)
+}
@@ -0,0 +1,260 @@
+package dal
+
+import (
+	"database/sql"
+	"fmt"
+	"mud/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WorldDAL handles database operations for world events and room overlays.
+type WorldDAL struct {
+	db    DBTX
+	cache CacheInterface
+}
+
+func (d *WorldDAL) Cache() CacheInterface {
+	return d.cache
+}
+
+// NewWorldDAL creates a new WorldDAL.
+func NewWorldDAL(db DBTX, cache CacheInterface) *WorldDAL {
+	return &WorldDAL{db: db, cache: cache}
+}
+
+const worldEventColumns = `id, event_type, details, source_type, source_id, room_id, territory_id, started_at, ends_at, ended`
+
+// CreateWorldEvent inserts a new world event into the database. An ID and
+// start time are set when missing.
+func (d *WorldDAL) CreateWorldEvent(event *models.WorldEvent) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.StartedAt.IsZero() {
+		event.StartedAt = time.Now()
+	}
+
+	query := `INSERT INTO world_events (` + worldEventColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := d.db.Exec(query,
+		event.ID,
+		event.EventType,
+		event.Details,
+		event.SourceType,
+		event.SourceID,
+		event.RoomID,
+		event.TerritoryID,
+		event.StartedAt.UTC(),
+		event.EndsAt.UTC(),
+		event.Ended,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create world event: %w", err)
+	}
+	return nil
+}
+
+// UpdateWorldEvent updates an existing world event in the database.
+func (d *WorldDAL) UpdateWorldEvent(event *models.WorldEvent) error {
+	query := `
+	UPDATE world_events
+	SET event_type = ?, details = ?, source_type = ?, source_id = ?, room_id = ?, territory_id = ?, started_at = ?, ends_at = ?, ended = ?
+	WHERE id = ?
+	`
+
+	result, err := d.db.Exec(query,
+		event.EventType,
+		event.Details,
+		event.SourceType,
+		event.SourceID,
+		event.RoomID,
+		event.TerritoryID,
+		event.StartedAt.UTC(),
+		event.EndsAt.UTC(),
+		event.Ended,
+		event.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update world event: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("world event with ID %s not found for update", event.ID)
+	}
+	return nil
+}
+
+// GetActiveWorldEvents retrieves the world events that have not ended,
+// including ones past their end that have not been swept yet, oldest first.
+func (d *WorldDAL) GetActiveWorldEvents() ([]*models.WorldEvent, error) {
+	query := `SELECT ` + worldEventColumns + ` FROM world_events WHERE ended = FALSE ORDER BY started_at, rowid`
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active world events: %w", err)
+	}
+	defer rows.Close()
+
+	var worldEvents []*models.WorldEvent
+	for rows.Next() {
+		event := &models.WorldEvent{}
+		if err := rows.Scan(
+			&event.ID,
+			&event.EventType,
+			&event.Details,
+			&event.SourceType,
+			&event.SourceID,
+			&event.RoomID,
+			&event.TerritoryID,
+			&event.StartedAt,
+			&event.EndsAt,
+			&event.Ended,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan world event: %w", err)
+		}
+		worldEvents = append(worldEvents, event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating through world events: %w", err)
+	}
+	return worldEvents, nil
+}
+
+const roomOverlayColumns = `id, room_id, source_type, source_id, description, description_add, properties, previous_description, previous_properties, created_at, expires_at, reverted`
+
+// CreateRoomOverlay inserts a new room overlay into the database. An ID and
+// creation time are set when missing.
+func (d *WorldDAL) CreateRoomOverlay(overlay *models.RoomOverlay) error {
+	if overlay.ID == "" {
+		overlay.ID = uuid.New().String()
+	}
+	if overlay.CreatedAt.IsZero() {
+		overlay.CreatedAt = time.Now()
+	}
+
+	query := `INSERT INTO room_overlays (` + roomOverlayColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := d.db.Exec(query,
+		overlay.ID,
+		overlay.RoomID,
+		overlay.SourceType,
+		overlay.SourceID,
+		overlay.Description,
+		overlay.DescriptionAdd,
+		overlay.Properties,
+		overlay.PreviousDescription,
+		overlay.PreviousProperties,
+		overlay.CreatedAt.UTC(),
+		overlay.ExpiresAt.UTC(),
+		overlay.Reverted,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create room overlay: %w", err)
+	}
+	return nil
+}
+
+// UpdateRoomOverlay updates an existing room overlay in the database.
+func (d *WorldDAL) UpdateRoomOverlay(overlay *models.RoomOverlay) error {
+	query := `
+	UPDATE room_overlays
+	SET room_id = ?, source_type = ?, source_id = ?, description = ?, description_add = ?, properties = ?, previous_description = ?, previous_properties = ?, created_at = ?, expires_at = ?, reverted = ?
+	WHERE id = ?
+	`
+
+	result, err := d.db.Exec(query,
+		overlay.RoomID,
+		overlay.SourceType,
+		overlay.SourceID,
+		overlay.Description,
+		overlay.DescriptionAdd,
+		overlay.Properties,
+		overlay.PreviousDescription,
+		overlay.PreviousProperties,
+		overlay.CreatedAt.UTC(),
+		overlay.ExpiresAt.UTC(),
+		overlay.Reverted,
+		overlay.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update room overlay: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("room overlay with ID %s not found for update", overlay.ID)
+	}
+	return nil
+}
+
+// GetRoomOverlayByID retrieves a room overlay by its ID.
+func (d *WorldDAL) GetRoomOverlayByID(id string) (*models.RoomOverlay, error) {
+	query := `SELECT ` + roomOverlayColumns + ` FROM room_overlays WHERE id = ?`
+	overlay, err := scanRoomOverlay(d.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Room overlay not found
+		}
+		return nil, fmt.Errorf("failed to get room overlay by ID: %w", err)
+	}
+	return overlay, nil
+}
+
+// GetActiveRoomOverlays retrieves the overlays on a room that have not been
+// reverted, oldest first. An empty roomID matches every room.
+func (d *WorldDAL) GetActiveRoomOverlays(roomID string) ([]*models.RoomOverlay, error) {
+	query := `SELECT ` + roomOverlayColumns + ` FROM room_overlays
+	WHERE reverted = FALSE AND (? = '' OR room_id = ?)
+	ORDER BY created_at, rowid`
+	rows, err := d.db.Query(query, roomID, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active room overlays: %w", err)
+	}
+	defer rows.Close()
+
+	var overlays []*models.RoomOverlay
+	for rows.Next() {
+		overlay, err := scanRoomOverlay(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan room overlay: %w", err)
+		}
+		overlays = append(overlays, overlay)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating through room overlays: %w", err)
+	}
+	return overlays, nil
+}
+
+// roomOverlayScanner is satisfied by both *sql.Row and *sql.Rows.
+type roomOverlayScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRoomOverlay(row roomOverlayScanner) (*models.RoomOverlay, error) {
+	overlay := &models.RoomOverlay{}
+	err := row.Scan(
+		&overlay.ID,
+		&overlay.RoomID,
+		&overlay.SourceType,
+		&overlay.SourceID,
+		&overlay.Description,
+		&overlay.DescriptionAdd,
+		&overlay.Properties,
+		&overlay.PreviousDescription,
+		&overlay.PreviousProperties,
+		&overlay.CreatedAt,
+		&overlay.ExpiresAt,
+		&overlay.Reverted,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return overlay, nil
+}
@@ -8,6 +8,7 @@ import (
 	"mud/internal/dal"
 	"mud/internal/game/events"
 	"mud/internal/game"
+	"mud/internal/game/llmqueue"
 	"mud/internal/game/perception"
 	"mud/internal/models"
 )
@@ -27,6 +28,7 @@ type ActionSignificanceMonitor struct {
 	questmakerDAL       dal.QuestmakerDALInterface
 	sentientEntityManager game.SentientEntityManagerInterface
 	playerEntityBuffers map[string]map[string]*ActionBuffer // playerID -> entityID -> *ActionBuffer
+	queue               *llmqueue.Queue
 	mu                  sync.RWMutex
 }
 
@@ -64,6 +66,14 @@ func NewMonitor(
 	return m
 }
 
+// UseQueue has reactions run on an LLM work queue rather than on the
+// goroutine handling the action. NPCs answer the player before Owners and
+// Questmakers deliberate, each entity reacts to one player at a time, and a
+// reaction still waiting when the player acts again takes in the new action.
+func (m *ActionSignificanceMonitor) UseQueue(queue *llmqueue.Queue) {
+	m.queue = queue
+}
+
 // HandleActionEvent is the event handler for ActionEvents.
 func (m *ActionSignificanceMonitor) HandleActionEvent(actionEvent *events.ActionEvent) {
 
@@ -222,13 +232,46 @@ func (m *ActionSignificanceMonitor) checkAndTriggerReaction(playerID, observerID
 		logrus.Infof("ActionSignificanceMonitor: Triggering reaction for %s (ID: %s) to player %s. Cumulative Significance: %.2f, Threshold: %d",
 			observerName, observerID, playerID, cumulativeSignificance, reactionThreshold)
 
-		// Trigger reaction via SentientEntityManager
-		err := m.sentientEntityManager.TriggerReaction(observer, m.GetBatchedPerceivedActions(playerID, observerID))
+		if m.queue == nil {
+			m.triggerReaction(playerID, observerID, observerName, observer)
+			return
+		}
+		priority := llmqueue.Deliberation
+		if _, ok := observer.(*models.NPC); ok {
+			priority = llmqueue.Dialogue
+		}
+		// The reaction takes the buffered actions when it runs, so one that
+		// supersedes another waiting for the same player loses none of them,
+		// and the one superseded leaves them be.
+		err := m.queue.Submit(llmqueue.Job{
+			Entity:   observerID,
+			Key:      observerID + ":" + playerID,
+			Priority: priority,
+			Run:      func() { m.triggerReaction(playerID, observerID, observerName, observer) },
+			Drop: func(reason llmqueue.DropReason) {
+				if reason != llmqueue.Superseded {
+					m.clearPerceivedActions(playerID, observerID)
+				}
+			},
+		})
 		if err != nil {
-			logrus.Errorf("ActionSignificanceMonitor: failed to trigger reaction for %s (ID: %s): %v", observerName, observerID, err)
+			logrus.Warnf("ActionSignificanceMonitor: dropping reaction for %s (ID: %s): %v", observerName, observerID, err)
+			m.clearPerceivedActions(playerID, observerID)
 		}
+	}
+}
 
-		m.clearPerceivedActions(playerID, observerID) // Clear buffer after triggering
+// triggerReaction has an observer react to the actions of a player it has
+// buffered, clearing the buffer.
+func (m *ActionSignificanceMonitor) triggerReaction(playerID, observerID, observerName string, observer interface{}) {
+	perceivedActions := m.GetBatchedPerceivedActions(playerID, observerID)
+	if len(perceivedActions) == 0 {
+		return
+	}
+	// Trigger reaction via SentientEntityManager
+	err := m.sentientEntityManager.TriggerReaction(observer, perceivedActions)
+	if err != nil {
+		logrus.Errorf("ActionSignificanceMonitor: failed to trigger reaction for %s (ID: %s): %v", observerName, observerID, err)
 	}
 }
 
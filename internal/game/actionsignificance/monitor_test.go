@@ -1,13 +1,17 @@
 package actionsignificance
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"mud/internal/dal"
 	"mud/internal/game/events"
+	"mud/internal/game/llmqueue"
 	"mud/internal/game/perception"
+	"mud/internal/game/scheduler"
 	"mud/internal/models"
 )
 
@@ -339,4 +343,64 @@ func TestActionSignificanceMonitor_HandleActionEvent(t *testing.T) {
 	// We need to manually call checkAndTriggerReaction as HandleActionEvent doesn't guarantee immediate trigger
 	monitor.checkAndTriggerReaction("player1", "npc1", mockNPCs["npc1"])
 	assert.InDelta(t, 0.0, monitor.getCumulativeSignificance("player1", "npc1"), 0.001, "Buffer should be cleared after reaction")
-}
\ No newline at end of file
+}
+func TestActionSignificanceMonitor_QueuesReactions(t *testing.T) {
+	mockNPCDAL := &MockNPCDAL{npcs: map[string]*models.NPC{
+		"npc1": {ID: "npc1", CurrentRoomID: "room1", ReactionThreshold: 5, Name: "Test NPC 1"},
+	}}
+	mockOwnerDAL := &MockOwnerDAL{owners: map[string]*models.Owner{
+		"owner1": {ID: "owner1", MonitoredAspect: "location", AssociatedID: "room1", ReactionThreshold: 5, Name: "Test Owner 1"},
+	}}
+	mockQuestmakerDAL := &MockQuestmakerDAL{questmakers: map[string]*models.Questmaker{}}
+	mockPerceptionFilter := &MockPerceptionFilter{
+		FilterFunc: func(event *events.ActionEvent, observer interface{}) (*perception.PerceivedAction, error) {
+			return &perception.PerceivedAction{PerceivedActionType: "test_action", Clarity: 1.0, BaseSignificance: 6.0}, nil
+		},
+	}
+
+	// The first reaction is held until released, while the player acts on.
+	var mu sync.Mutex
+	var reactions []string
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	mockSentientEntityManager := &MockSentientEntityManager{
+		TriggerReactionFunc: func(observer interface{}, perceivedActions []perception.PerceivedActionRecord) error {
+			mu.Lock()
+			first := len(reactions) == 0
+			reactions = append(reactions, fmt.Sprintf("%s reacts to %d actions", getObserverID(observer), len(perceivedActions)))
+			mu.Unlock()
+			if first {
+				started <- struct{}{}
+				<-release
+			}
+			return nil
+		},
+	}
+
+	monitor := NewMonitor(events.NewEventBus(), mockPerceptionFilter, mockNPCDAL, mockOwnerDAL, mockQuestmakerDAL, mockSentientEntityManager)
+	queue := llmqueue.NewQueue(scheduler.RealClock{})
+	queue.MaxConcurrent = 1
+	monitor.UseQueue(queue)
+
+	actionEvent := &events.ActionEvent{
+		ActionType: "test_action",
+		Player:     &models.PlayerCharacter{ID: "player1", Name: "TestPlayer"},
+		Room:       &models.Room{ID: "room1"},
+		Timestamp:  time.Now(),
+	}
+	monitor.HandleActionEvent(actionEvent)
+	<-started
+	monitor.HandleActionEvent(actionEvent)
+	monitor.HandleActionEvent(actionEvent)
+	assert.Equal(t, 2, queue.Len(), "Reactions waiting for the same player are superseded rather than queued twice")
+
+	close(release)
+	queue.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{
+		"npc1 reacts to 1 actions",
+		"npc1 reacts to 2 actions",
+		"owner1 reacts to 3 actions",
+	}, reactions, "The NPC answers before the Owner deliberates, and no action is lost")
+}
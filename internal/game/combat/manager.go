@@ -0,0 +1,477 @@
+package combat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"mud/internal/dal"
+	"mud/internal/game/events"
+	"mud/internal/game/scheduler"
+	"mud/internal/game/stats"
+	"mud/internal/models"
+	"mud/internal/presentation"
+)
+
+const (
+	// DefaultTickInterval is how often a combat round is fought.
+	DefaultTickInterval = 2 * time.Second
+	// DefaultNPCRespawnDelay is how long a slain NPC stays dead.
+	DefaultNPCRespawnDelay = 5 * time.Minute
+)
+
+var (
+	// ErrTargetNotFound is returned when no living NPC in the room matches the target.
+	ErrTargetNotFound = errors.New("target not found")
+	// ErrAlreadyFighting is returned when starting a fight while already in one.
+	ErrAlreadyFighting = errors.New("already fighting")
+	// ErrNotFighting is returned when fleeing outside of combat.
+	ErrNotFighting = errors.New("not fighting")
+	// ErrFleeFailed is returned when a character fails to get away.
+	ErrFleeFailed = errors.New("failed to flee")
+	// ErrNowhereToFlee is returned when the room has no open exit.
+	ErrNowhereToFlee = errors.New("nowhere to flee")
+)
+
+// Notifier receives the outcome of combat rounds for the players involved.
+type Notifier interface {
+	// Notify sends a line of combat narration to a character.
+	Notify(characterID, content string)
+	// VitalsChanged is called after a character's health has changed.
+	VitalsChanged(character *models.PlayerCharacter)
+	// Respawned is called after a slain character has been restored in the respawn room.
+	Respawned(character *models.PlayerCharacter)
+}
+
+// Manager runs fights between player characters and NPCs. Each tick resolves
+// one round of every fight: both sides roll initiative, then attack in order.
+// NPCs always fight back. Slain characters respawn at full health in the
+// respawn room; slain NPCs come back after NPCRespawnDelay.
+//
+// Rounds are fought on the scheduler's goroutine, so fights hold character
+// IDs rather than the players' own characters. Each round reads the character
+// as stored and writes back only their health, and the Notifier is handed the
+// character as stored afterwards.
+type Manager struct {
+	characterDAL  dal.PlayerCharacterDALInterface
+	npcDAL        dal.NPCDALInterface
+	roomDAL       dal.RoomDALInterface
+	stats         *stats.Manager
+	eventBus      *events.EventBus
+	notifier      Notifier
+	respawnRoomID string
+	mu            sync.Mutex
+	fights        map[string]string    // Character ID to the ID of the NPC they fight
+	respawns      map[string]time.Time // NPC ID to respawn time
+	roll          func(n int) int      // Returns a number in [0, n); replaced in tests
+
+	TickInterval    time.Duration
+	NPCRespawnDelay time.Duration
+}
+
+// NewManager creates a new Manager. Slain characters respawn in respawnRoomID.
+func NewManager(characterDAL dal.PlayerCharacterDALInterface, npcDAL dal.NPCDALInterface, roomDAL dal.RoomDALInterface, statsManager *stats.Manager, eventBus *events.EventBus, notifier Notifier, respawnRoomID string) *Manager {
+	return &Manager{
+		characterDAL:    characterDAL,
+		npcDAL:          npcDAL,
+		roomDAL:         roomDAL,
+		stats:           statsManager,
+		eventBus:        eventBus,
+		notifier:        notifier,
+		respawnRoomID:   respawnRoomID,
+		fights:          make(map[string]string),
+		respawns:        make(map[string]time.Time),
+		roll:            rand.Intn,
+		TickInterval:    DefaultTickInterval,
+		NPCRespawnDelay: DefaultNPCRespawnDelay,
+	}
+}
+
+// Schedule has the scheduler fight a round every TickInterval. NPCs that
+// are dead when it is called are scheduled to respawn.
+func (m *Manager) Schedule(s *scheduler.Scheduler) *scheduler.Job {
+	m.scheduleDeadNPCs()
+	return s.Every("combat rounds", m.TickInterval, m.Tick)
+}
+
+// Engage starts a fight between the character and a living NPC in their room.
+func (m *Manager) Engage(character *models.PlayerCharacter, query string) (*models.NPC, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, fighting := m.fights[character.ID]; fighting {
+		return nil, ErrAlreadyFighting
+	}
+	npc, err := m.findNPC(character.CurrentRoomID, query)
+	if err != nil {
+		return nil, err
+	}
+	m.fights[character.ID] = npc.ID
+	return npc, nil
+}
+
+// Consider sizes up a living NPC in the character's room.
+func (m *Manager) Consider(character *models.PlayerCharacter, query string) (*models.NPC, string, error) {
+	npc, err := m.findNPC(character.CurrentRoomID, query)
+	if err != nil {
+		return nil, "", err
+	}
+	player, err := m.characterCombatant(character)
+	if err != nil {
+		return nil, "", err
+	}
+	opponent, err := m.npcCombatant(npc)
+	if err != nil {
+		return nil, "", err
+	}
+	return npc, assess(player, character.Health, opponent, npc.Health), nil
+}
+
+// Flee tries to break off the character's fight. On success the fight ends and
+// the direction of a randomly chosen open exit is returned; moving the
+// character is left to the caller. Quick characters get away more easily.
+func (m *Manager) Flee(character *models.PlayerCharacter) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	npcID, fighting := m.fights[character.ID]
+	if !fighting {
+		return "", ErrNotFighting
+	}
+	directions, err := m.openExits(character.CurrentRoomID)
+	if err != nil {
+		return "", err
+	}
+	if len(directions) == 0 {
+		return "", ErrNowhereToFlee
+	}
+
+	chance := 50
+	player, err := m.characterCombatant(character)
+	if err != nil {
+		return "", err
+	}
+	if npc, err := m.npcDAL.GetNPCByID(npcID); err == nil && npc != nil {
+		if opponent, err := m.npcCombatant(npc); err == nil {
+			chance += 5 * (modifier(player.Dexterity) - modifier(opponent.Dexterity))
+		}
+	}
+	chance = max(10, min(90, chance))
+	if m.roll(100) >= chance {
+		return "", ErrFleeFailed
+	}
+
+	delete(m.fights, character.ID)
+	return directions[m.roll(len(directions))], nil
+}
+
+// InCombat reports whether a character is fighting.
+func (m *Manager) InCombat(characterID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, fighting := m.fights[characterID]
+	return fighting
+}
+
+// Disengage ends a character's fight without a winner, for example when the
+// player disconnects.
+func (m *Manager) Disengage(characterID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.fights, characterID)
+}
+
+// Strike deals damage to a living NPC outside of a combat round, as an
+// offensive skill does. The NPC fights back: a character who is not fighting
+// yet is engaged with it. The NPC is returned with its health after the blow.
+func (m *Manager) Strike(character *models.PlayerCharacter, npcID string, damage int) (*models.NPC, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	npc, err := m.npcDAL.GetNPCByID(npcID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NPC %s: %w", npcID, err)
+	}
+	if npc == nil || npc.Health <= 0 || npc.CurrentRoomID != character.CurrentRoomID {
+		return nil, ErrTargetNotFound
+	}
+	if _, fighting := m.fights[character.ID]; !fighting {
+		m.fights[character.ID] = npc.ID
+	}
+
+	npc.Health -= damage
+	if npc.Health <= 0 {
+		return npc, m.slayNPC(character, npc)
+	}
+	if err := m.npcDAL.UpdateNPC(npc); err != nil {
+		return nil, fmt.Errorf("failed to update NPC %s: %w", npc.ID, err)
+	}
+	return npc, nil
+}
+
+// Tick fights one round of every fight and respawns NPCs whose time has come.
+func (m *Manager) Tick() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.respawnDue(time.Now())
+
+	characterIDs := make([]string, 0, len(m.fights))
+	for id := range m.fights {
+		characterIDs = append(characterIDs, id)
+	}
+	sort.Strings(characterIDs)
+	for _, id := range characterIDs {
+		if npcID, found := m.fights[id]; found {
+			if err := m.round(id, npcID); err != nil {
+				logrus.Errorf("Combat: Round for character %s failed: %v", id, err)
+				delete(m.fights, id)
+			}
+		}
+	}
+}
+
+// round resolves one round of a character's fight with an NPC.
+func (m *Manager) round(characterID, npcID string) error {
+	character, err := m.characterDAL.GetCharacterByID(characterID)
+	if err != nil {
+		return fmt.Errorf("failed to get character %s: %w", characterID, err)
+	}
+	npc, err := m.npcDAL.GetNPCByID(npcID)
+	if err != nil {
+		return fmt.Errorf("failed to get NPC %s: %w", npcID, err)
+	}
+	// The fight is over if either side has left or the NPC is already dead.
+	if character == nil || npc == nil || npc.Health <= 0 || npc.CurrentRoomID != character.CurrentRoomID {
+		delete(m.fights, characterID)
+		return nil
+	}
+
+	player, err := m.characterCombatant(character)
+	if err != nil {
+		return err
+	}
+	opponent, err := m.npcCombatant(npc)
+	if err != nil {
+		return err
+	}
+
+	health, damageTaken := character.Health, 0
+	playerFirst := m.roll(20)+modifier(player.Dexterity) >= m.roll(20)+modifier(opponent.Dexterity)
+	for turn := 0; turn < 2; turn++ {
+		if (turn == 0) == playerFirst {
+			if hit, damage := m.attack(player, opponent); hit {
+				npc.Health -= damage
+				m.notifier.Notify(character.ID, fmt.Sprintf("You hit %s for %d damage.", npc.Name, damage))
+			} else {
+				m.notifier.Notify(character.ID, fmt.Sprintf("You miss %s.", npc.Name))
+			}
+			if npc.Health <= 0 {
+				break
+			}
+		} else {
+			if hit, damage := m.attack(opponent, player); hit {
+				health -= damage
+				damageTaken += damage
+				m.notifier.Notify(character.ID, fmt.Sprintf("%s hits you for %d damage.", presentation.Capitalize(npc.Name), damage))
+			} else {
+				m.notifier.Notify(character.ID, fmt.Sprintf("%s misses you.", presentation.Capitalize(npc.Name)))
+			}
+			if health <= 0 {
+				break
+			}
+		}
+	}
+
+	if err := m.npcDAL.UpdateNPC(npc); err != nil {
+		return fmt.Errorf("failed to update NPC %s: %w", npc.ID, err)
+	}
+	if damageTaken > 0 {
+		if character, err = m.characterDAL.AdjustCharacterVitals(characterID, -damageTaken, 0); err != nil {
+			return fmt.Errorf("failed to update character %s: %w", characterID, err)
+		}
+	}
+	m.publish(character, "combat_action", npc)
+
+	switch {
+	case npc.Health <= 0:
+		return m.slayNPC(character, npc)
+	case character.Health <= 0:
+		return m.slayCharacter(character, npc)
+	}
+	m.notifier.VitalsChanged(character)
+	return nil
+}
+
+// attack rolls a d20 to hit and, on a hit, the attacker's damage die.
+func (m *Manager) attack(attacker, defender *Combatant) (bool, int) {
+	toHit := m.roll(20) + 1 + modifier(attacker.Dexterity)
+	if toHit < 10+modifier(defender.Dexterity)+defender.Armor {
+		return false, 0
+	}
+	damage := m.roll(attacker.Damage) + 1 + modifier(attacker.Strength)
+	return true, max(1, damage)
+}
+
+// slayNPC ends every fight with a dead NPC and schedules its respawn.
+func (m *Manager) slayNPC(killer *models.PlayerCharacter, npc *models.NPC) error {
+	npc.Health = 0
+	if err := m.npcDAL.UpdateNPC(npc); err != nil {
+		return fmt.Errorf("failed to update NPC %s: %w", npc.ID, err)
+	}
+	m.respawns[npc.ID] = time.Now().Add(m.NPCRespawnDelay)
+
+	for id, npcID := range m.fights {
+		if npcID != npc.ID {
+			continue
+		}
+		delete(m.fights, id)
+		m.notifier.Notify(id, fmt.Sprintf("%s is dead!", presentation.Capitalize(npc.Name)))
+		if character, err := m.characterDAL.GetCharacterByID(id); err == nil && character != nil {
+			m.notifier.VitalsChanged(character)
+		}
+	}
+	m.publish(killer, "slay_npc", npc)
+	return nil
+}
+
+// slayCharacter ends a character's fight and respawns them at full health.
+func (m *Manager) slayCharacter(character *models.PlayerCharacter, npc *models.NPC) error {
+	delete(m.fights, character.ID)
+	m.notifier.Notify(character.ID, fmt.Sprintf("You have been slain by %s!", npc.Name))
+	m.publish(character, "slain_by_npc", npc)
+
+	respawned, err := m.characterDAL.RespawnCharacter(character.ID, m.respawnRoomID)
+	if err != nil {
+		return fmt.Errorf("failed to update character %s: %w", character.ID, err)
+	}
+	m.notifier.Respawned(respawned)
+	return nil
+}
+
+// respawnDue restores NPCs whose respawn time has passed.
+func (m *Manager) respawnDue(now time.Time) {
+	for id, at := range m.respawns {
+		if now.Before(at) {
+			continue
+		}
+		delete(m.respawns, id)
+		npc, err := m.npcDAL.GetNPCByID(id)
+		if err != nil || npc == nil {
+			logrus.Errorf("Combat: NPC %s not found for respawn or error: %v", id, err)
+			continue
+		}
+		npc.Health = npc.MaxHealth
+		if err := m.npcDAL.UpdateNPC(npc); err != nil {
+			logrus.Errorf("Combat: Failed to respawn NPC %s: %v", id, err)
+		}
+	}
+}
+
+// scheduleDeadNPCs schedules a respawn for every NPC that is dead, so NPCs
+// slain before a restart come back.
+func (m *Manager) scheduleDeadNPCs() {
+	npcs, err := m.npcDAL.GetAllNPCs()
+	if err != nil {
+		logrus.Errorf("Combat: Failed to load NPCs: %v", err)
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, npc := range npcs {
+		if npc.Health <= 0 {
+			if _, scheduled := m.respawns[npc.ID]; !scheduled {
+				m.respawns[npc.ID] = time.Now().Add(m.NPCRespawnDelay)
+			}
+		}
+	}
+}
+
+// publish sends an ActionEvent for the character, targeting the NPC.
+func (m *Manager) publish(character *models.PlayerCharacter, actionType string, npc *models.NPC) {
+	room, err := m.roomDAL.GetRoomByID(npc.CurrentRoomID)
+	if err != nil || room == nil {
+		logrus.Infof("Combat: Room %s not found for NPC %s or error: %v", npc.CurrentRoomID, npc.ID, err)
+		return
+	}
+	m.eventBus.Publish(events.ActionEventType, &events.ActionEvent{
+		Player:     character,
+		ActionType: actionType,
+		Room:       room,
+		Timestamp:  time.Now(),
+		Targets:    []interface{}{npc},
+	})
+}
+
+// findNPC matches a living NPC in a room by ID or name.
+func (m *Manager) findNPC(roomID, query string) (*models.NPC, error) {
+	npcs, err := m.npcDAL.GetNPCsByRoom(roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NPCs in room: %w", err)
+	}
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil, ErrTargetNotFound
+	}
+	var partial *models.NPC
+	for _, npc := range npcs {
+		if npc.Health <= 0 {
+			continue
+		}
+		if strings.ToLower(npc.ID) == query || strings.ToLower(npc.Name) == query {
+			return npc, nil
+		}
+		if partial == nil && strings.Contains(strings.ToLower(npc.Name), query) {
+			partial = npc
+		}
+	}
+	if partial == nil {
+		return nil, ErrTargetNotFound
+	}
+	return partial, nil
+}
+
+// openExits returns the directions out of a room that are neither closed nor locked.
+func (m *Manager) openExits(roomID string) ([]string, error) {
+	room, err := m.roomDAL.GetRoomByID(roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get room %s: %w", roomID, err)
+	}
+	if room == nil {
+		return nil, fmt.Errorf("room with ID %s not found", roomID)
+	}
+	var exits map[string]models.Exit
+	if err := json.Unmarshal([]byte(room.Exits), &exits); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal exits for room %s: %w", roomID, err)
+	}
+	var directions []string
+	for direction, exit := range exits {
+		if !exit.IsLocked && !exit.IsClosed {
+			directions = append(directions, direction)
+		}
+	}
+	sort.Strings(directions)
+	return directions, nil
+}
+
+func (m *Manager) characterCombatant(character *models.PlayerCharacter) (*Combatant, error) {
+	s, err := m.stats.ForCharacter(character)
+	if err != nil {
+		return nil, err
+	}
+	return newCombatant(character.Name, s), nil
+}
+
+func (m *Manager) npcCombatant(npc *models.NPC) (*Combatant, error) {
+	s, err := m.stats.ForNPC(npc)
+	if err != nil {
+		return nil, err
+	}
+	return newCombatant(npc.Name, s), nil
+}
@@ -0,0 +1,173 @@
+package combat
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/dal"
+	"mud/internal/game/events"
+	"mud/internal/game/stats"
+	"mud/internal/models"
+	"mud/internal/testutils"
+	"mud/internal/testutils/testdal"
+)
+
+func setupManager(t *testing.T) (*Manager, *dal.DAL, *models.PlayerCharacter, *testutils.RecordingNotifier, chan interface{}) {
+	t.Helper()
+
+	dals := testdal.New(t)
+	for _, race := range []*models.Race{
+		{ID: "human", Name: "Human", BaseStats: map[string]int{"strength": 12, "dexterity": 10}},
+		{ID: "goblin", Name: "Goblin", BaseStats: map[string]int{"strength": 8, "dexterity": 12}},
+	} {
+		if err := dals.RaceDAL.CreateRace(race); err != nil {
+			t.Fatalf("Failed to create race: %v", err)
+		}
+	}
+	if err := dals.ItemDAL.CreateItem(&models.Item{ID: "sword", Name: "a sword", Type: "weapon", Properties: `{"damage": 8}`}); err != nil {
+		t.Fatalf("Failed to create item: %v", err)
+	}
+	caveExits, _ := json.Marshal(map[string]models.Exit{
+		"north": {Direction: "north", TargetRoomID: "camp"},
+		"south": {Direction: "south", TargetRoomID: "vault", IsLocked: true, IsClosed: true},
+	})
+	for _, room := range []*models.Room{
+		{ID: "cave", Name: "Cave", Exits: string(caveExits), Properties: "{}"},
+		{ID: "camp", Name: "Camp", Exits: "{}", Properties: "{}"},
+	} {
+		if err := dals.RoomDAL.CreateRoom(room); err != nil {
+			t.Fatalf("Failed to create room: %v", err)
+		}
+	}
+	npc := &models.NPC{ID: "goblin", Name: "a goblin", CurrentRoomID: "cave", Health: 10, MaxHealth: 10, RaceID: "goblin", Inventory: []string{}}
+	if err := dals.NpcDAL.CreateNPC(npc); err != nil {
+		t.Fatalf("Failed to create NPC: %v", err)
+	}
+	character := &models.PlayerCharacter{ID: "hero", Name: "Hero", RaceID: "human", CurrentRoomID: "cave", Health: 20, MaxHealth: 20, Inventory: "[]", VisitedRoomIDs: "[]"}
+	if err := dals.PlayerCharacterDAL.CreateCharacter(character); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	eventBus := events.NewEventBus()
+	actionEvents := make(chan interface{}, 100)
+	eventBus.Subscribe(events.ActionEventType, actionEvents)
+	notifier := &testutils.RecordingNotifier{}
+	m := NewManager(dals.PlayerCharacterDAL, dals.NpcDAL, dals.RoomDAL, stats.NewManager(dals.RaceDAL, dals.ClassDAL, dals.PlayerClassDAL, dals.ItemInstanceDAL, nil), eventBus, notifier, "camp")
+	return m, dals, character, notifier, actionEvents
+}
+
+// rolls returns a roll function that plays back values, then repeats the last one.
+func rolls(values ...int) func(n int) int {
+	i := 0
+	return func(n int) int {
+		v := values[min(i, len(values)-1)]
+		i++
+		return min(v, n-1)
+	}
+}
+
+func TestManager_RoundsAndNPCDeath(t *testing.T) {
+	m, dals, character, notifier, actionEvents := setupManager(t)
+	sword := &models.ItemInstance{TemplateID: "sword", LocationType: models.LocationCharacter, LocationID: "hero", Slot: "wielded"}
+	assert.NoError(t, dals.ItemInstanceDAL.CreateInstance(sword))
+
+	_, err := m.Engage(character, "troll")
+	assert.ErrorIs(t, err, ErrTargetNotFound)
+	npc, err := m.Engage(character, "gob")
+	assert.NoError(t, err)
+	assert.Equal(t, "goblin", npc.ID)
+	_, err = m.Engage(character, "goblin")
+	assert.ErrorIs(t, err, ErrAlreadyFighting)
+
+	// Initiative 19 vs 0, the hero hits with a 20 for 7+1+1 damage, then the goblin misses with a 1.
+	m.roll = rolls(19, 0, 19, 7, 0)
+	m.Tick()
+	assert.Equal(t, []string{"You hit a goblin for 9 damage.", "A goblin misses you."}, notifier.Take())
+	event := (<-actionEvents).(*events.ActionEvent)
+	assert.Equal(t, "combat_action", event.ActionType)
+	assert.Equal(t, "goblin", event.Targets[0].(*models.NPC).ID)
+
+	// The next hit kills the goblin before it can act.
+	m.roll = rolls(19, 0, 19, 7)
+	m.Tick()
+	assert.Equal(t, []string{"You hit a goblin for 9 damage.", "A goblin is dead!"}, notifier.Take())
+	assert.False(t, m.InCombat("hero"))
+	<-actionEvents
+	assert.Equal(t, "slay_npc", (<-actionEvents).(*events.ActionEvent).ActionType)
+
+	_, err = m.Engage(character, "goblin")
+	assert.ErrorIs(t, err, ErrTargetNotFound, "Dead NPCs cannot be attacked")
+
+	m.respawnDue(time.Now().Add(m.NPCRespawnDelay))
+	goblin, err := dals.NpcDAL.GetNPCByID("goblin")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, goblin.Health)
+}
+
+func TestManager_CharacterDeathAndRespawn(t *testing.T) {
+	m, dals, character, notifier, _ := setupManager(t)
+	_, err := dals.PlayerCharacterDAL.AdjustCharacterVitals("hero", -19, 0)
+	assert.NoError(t, err)
+
+	_, err = m.Engage(character, "goblin")
+	assert.NoError(t, err)
+	// The goblin wins initiative and lands the final blow.
+	m.roll = rolls(0, 19, 19, 1)
+	m.Tick()
+
+	assert.Contains(t, notifier.Messages(), "You have been slain by a goblin!")
+	assert.Equal(t, []string{"hero"}, notifier.RespawnedIDs())
+	assert.False(t, m.InCombat("hero"))
+
+	saved, err := dals.PlayerCharacterDAL.GetCharacterByID("hero")
+	assert.NoError(t, err)
+	assert.Equal(t, "camp", saved.CurrentRoomID)
+	assert.Equal(t, 20, saved.Health)
+}
+
+func TestManager_RoundsWriteOnlyHealth(t *testing.T) {
+	m, dals, character, _, _ := setupManager(t)
+
+	_, err := m.Engage(character, "goblin")
+	assert.NoError(t, err)
+	// The player's own character is theirs alone: rounds change the character
+	// as stored, and keep what the player changed meanwhile.
+	character.Health = 5
+	assert.NoError(t, dals.PlayerCharacterDAL.UpdateCharacter(&models.PlayerCharacter{ID: "hero", Name: "Hero", RaceID: "human", CurrentRoomID: "cave", Health: 20, MaxHealth: 20, Inventory: "[]", VisitedRoomIDs: `["cave"]`}))
+
+	// The goblin wins initiative and hits for 1+1-1 damage, then the hero misses.
+	m.roll = rolls(0, 19, 19, 1, 0)
+	m.Tick()
+	assert.Equal(t, 5, character.Health)
+	saved, err := dals.PlayerCharacterDAL.GetCharacterByID("hero")
+	assert.NoError(t, err)
+	assert.Equal(t, 19, saved.Health)
+	assert.Equal(t, `["cave"]`, saved.VisitedRoomIDs)
+}
+
+func TestManager_FleeAndConsider(t *testing.T) {
+	m, _, character, _, _ := setupManager(t)
+
+	_, err := m.Flee(character)
+	assert.ErrorIs(t, err, ErrNotFighting)
+
+	_, assessment, err := m.Consider(character, "goblin")
+	assert.NoError(t, err)
+	assert.Equal(t, "A goblin would be an easy fight.", assessment)
+
+	_, err = m.Engage(character, "goblin")
+	assert.NoError(t, err)
+	m.roll = rolls(99)
+	_, err = m.Flee(character)
+	assert.ErrorIs(t, err, ErrFleeFailed)
+	assert.True(t, m.InCombat("hero"))
+
+	// Only the open exit can be used to flee.
+	m.roll = rolls(0)
+	direction, err := m.Flee(character)
+	assert.NoError(t, err)
+	assert.Equal(t, "north", direction)
+	assert.False(t, m.InCombat("hero"))
+}
@@ -0,0 +1,71 @@
+package combat
+
+import (
+	"fmt"
+	"math"
+
+	"mud/internal/game/stats"
+	"mud/internal/presentation"
+)
+
+// Combatant holds the numbers a fighter brings to a round of combat.
+type Combatant struct {
+	Name         string
+	Strength     int
+	Dexterity    int
+	Constitution int
+	Damage       int // Size of the damage die
+	Armor        int // Added to the roll an attacker needs to hit
+}
+
+// modifier converts a stat into a bonus or penalty, with 10 as average.
+func modifier(stat int) int {
+	return stats.Modifier(stat)
+}
+
+// newCombatant builds a combatant from a fighter's derived stats.
+func newCombatant(name string, s *stats.Stats) *Combatant {
+	return &Combatant{
+		Name:         name,
+		Strength:     s.Get(stats.Strength),
+		Dexterity:    s.Get(stats.Dexterity),
+		Constitution: s.Get(stats.Constitution),
+		Damage:       max(1, s.Damage),
+		Armor:        s.Armor,
+	}
+}
+
+// hitChance is the probability that attacker hits defender with a d20 roll.
+func hitChance(attacker, defender *Combatant) float64 {
+	needed := 10 + modifier(defender.Dexterity) + defender.Armor - modifier(attacker.Dexterity)
+	chance := float64(21-needed) / 20
+	return math.Max(0.05, math.Min(0.95, chance))
+}
+
+// expectedDamage is the average damage attacker deals to defender per round.
+func expectedDamage(attacker, defender *Combatant) float64 {
+	average := float64(attacker.Damage+1)/2 + float64(modifier(attacker.Strength))
+	return hitChance(attacker, defender) * math.Max(1, average)
+}
+
+// assess compares how many rounds each side needs to win and describes the
+// fight from the player's point of view.
+func assess(player *Combatant, playerHealth int, npc *Combatant, npcHealth int) string {
+	roundsToWin := float64(npcHealth) / expectedDamage(player, npc)
+	roundsToLose := float64(playerHealth) / expectedDamage(npc, player)
+	ratio := roundsToWin / roundsToLose
+	name := presentation.Capitalize(npc.Name)
+
+	switch {
+	case ratio < 0.5:
+		return fmt.Sprintf("%s would be an easy fight.", name)
+	case ratio < 0.8:
+		return fmt.Sprintf("%s should not give you much trouble.", name)
+	case ratio < 1.25:
+		return fmt.Sprintf("%s looks like an even match.", name)
+	case ratio < 2:
+		return fmt.Sprintf("%s would be a tough fight.", name)
+	default:
+		return fmt.Sprintf("%s would destroy you.", name)
+	}
+}
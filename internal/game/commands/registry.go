@@ -0,0 +1,282 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// State identifies the connection state a command may be used in. Its values
+// are defined by the server that owns the registry.
+type State int
+
+// ErrUnknownCommand is returned when input matches no registered command.
+var ErrUnknownCommand = errors.New("unknown command")
+
+// Handler executes a command. The session is whatever the owning server uses to
+// represent a connected player.
+type Handler func(session interface{}, inv *Invocation)
+
+// Command describes a single player command.
+type Command struct {
+	Name          string   // Canonical name, e.g. "look"
+	Aliases       []string // Alternative names, e.g. "l"
+	Syntax        string   // Argument syntax, e.g. "<item> to <target>"
+	Help          string   // One-line description shown by help
+	RequiredState State    // Connection state the command is available in
+	ActionType    string   // ActionEvent type emitted when the command runs, empty for none
+	Handler       Handler
+}
+
+// Usage returns the command name followed by its argument syntax.
+func (c *Command) Usage() string {
+	if c.Syntax == "" {
+		return c.Name
+	}
+	return c.Name + " " + c.Syntax
+}
+
+// Invocation is a parsed line of player input bound to a command.
+type Invocation struct {
+	Command *Command
+	Verb    string   // The word the player actually typed
+	Args    []string // Whitespace-separated arguments
+	Input   string   // The full input line
+}
+
+// ArgString returns the arguments joined by single spaces.
+func (inv *Invocation) ArgString() string {
+	return strings.Join(inv.Args, " ")
+}
+
+// AmbiguousCommandError is returned when a prefix matches more than one command.
+type AmbiguousCommandError struct {
+	Input      string
+	Candidates []string
+}
+
+func (e *AmbiguousCommandError) Error() string {
+	return fmt.Sprintf("ambiguous command %q: could be %s", e.Input, strings.Join(e.Candidates, ", "))
+}
+
+// Registry holds the set of commands available to players.
+type Registry struct {
+	commands map[string]*Command // Canonical name -> command
+	names    map[string]*Command // Name or alias -> command
+	mu       sync.RWMutex
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		commands: make(map[string]*Command),
+		names:    make(map[string]*Command),
+	}
+}
+
+// Register adds a command. Names and aliases are case-insensitive and must be unique.
+func (r *Registry) Register(cmd *Command) error {
+	if cmd.Name == "" {
+		return fmt.Errorf("command name is required")
+	}
+	if cmd.Handler == nil {
+		return fmt.Errorf("command %s has no handler", cmd.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := append([]string{cmd.Name}, cmd.Aliases...)
+	for _, key := range keys {
+		if existing, found := r.names[strings.ToLower(key)]; found {
+			return fmt.Errorf("command name %q is already used by %s", key, existing.Name)
+		}
+	}
+	for _, key := range keys {
+		r.names[strings.ToLower(key)] = cmd
+	}
+	r.commands[strings.ToLower(cmd.Name)] = cmd
+	return nil
+}
+
+// MustRegister is like Register but panics on error. It is intended for
+// registering the built-in command set at startup.
+func (r *Registry) MustRegister(cmd *Command) {
+	if err := r.Register(cmd); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup resolves a typed verb to a command available in the given state. Exact
+// names and aliases win; otherwise the verb must be an unambiguous prefix.
+func (r *Registry) Lookup(verb string, state State) (*Command, error) {
+	verb = strings.ToLower(verb)
+	if verb == "" {
+		return nil, ErrUnknownCommand
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if cmd, found := r.names[verb]; found && cmd.RequiredState == state {
+		return cmd, nil
+	}
+
+	matches := make(map[*Command]bool)
+	for key, cmd := range r.names {
+		if cmd.RequiredState == state && strings.HasPrefix(key, verb) {
+			matches[cmd] = true
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, ErrUnknownCommand
+	case 1:
+		for cmd := range matches {
+			return cmd, nil
+		}
+	}
+
+	candidates := make([]string, 0, len(matches))
+	for cmd := range matches {
+		candidates = append(candidates, cmd.Name)
+	}
+	sort.Strings(candidates)
+	return nil, &AmbiguousCommandError{Input: verb, Candidates: candidates}
+}
+
+// Parse splits a line of input and resolves its first word to a command.
+func (r *Registry) Parse(input string, state State) (*Invocation, error) {
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return nil, ErrUnknownCommand
+	}
+	cmd, err := r.Lookup(fields[0], state)
+	if err != nil {
+		return nil, err
+	}
+	return &Invocation{Command: cmd, Verb: fields[0], Args: fields[1:], Input: input}, nil
+}
+
+// Get returns the command with the given name or alias, regardless of state.
+func (r *Registry) Get(name string) (*Command, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cmd, found := r.names[strings.ToLower(name)]
+	return cmd, found
+}
+
+// Commands returns the commands available in the given state, sorted by name.
+func (r *Registry) Commands(state State) []*Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var cmds []*Command
+	for _, cmd := range r.commands {
+		if cmd.RequiredState == state {
+			cmds = append(cmds, cmd)
+		}
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds
+}
+
+// Suggest returns the names of commands available in the given state that are
+// close to the typed verb, best match first.
+func (r *Registry) Suggest(verb string, state State) []string {
+	verb = strings.ToLower(verb)
+	maxDistance := 1
+	if len(verb) > 4 {
+		maxDistance = 2
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	best := make(map[string]int)
+	for key, cmd := range r.names {
+		if cmd.RequiredState != state {
+			continue
+		}
+		d := editDistance(verb, key)
+		if d > maxDistance {
+			continue
+		}
+		if prev, found := best[cmd.Name]; !found || d < prev {
+			best[cmd.Name] = d
+		}
+	}
+
+	suggestions := make([]string, 0, len(best))
+	for name := range best {
+		suggestions = append(suggestions, name)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if best[suggestions[i]] != best[suggestions[j]] {
+			return best[suggestions[i]] < best[suggestions[j]]
+		}
+		return suggestions[i] < suggestions[j]
+	})
+	return suggestions
+}
+
+// HelpText returns the full help for a single command.
+func (r *Registry) HelpText(cmd *Command) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Usage: %s\n", cmd.Usage()))
+	if len(cmd.Aliases) > 0 {
+		b.WriteString(fmt.Sprintf("Aliases: %s\n", strings.Join(cmd.Aliases, ", ")))
+	}
+	if cmd.Help != "" {
+		b.WriteString(cmd.Help)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Summary returns a one-line-per-command overview of the commands available in the given state.
+func (r *Registry) Summary(state State) string {
+	cmds := r.Commands(state)
+	width := 0
+	for _, cmd := range cmds {
+		if len(cmd.Usage()) > width {
+			width = len(cmd.Usage())
+		}
+	}
+
+	var b strings.Builder
+	for _, cmd := range cmds {
+		b.WriteString(fmt.Sprintf("  %-*s  %s\n", width, cmd.Usage(), cmd.Help))
+	}
+	return b.String()
+}
+
+// editDistance returns the optimal string alignment distance between two
+// strings: insertions, deletions, substitutions and adjacent transpositions
+// each cost one, so "gvie" is a single edit away from "give".
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
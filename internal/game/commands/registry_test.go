@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	stateLogin State = iota
+	stateInGame
+)
+
+func newTestRegistry(t *testing.T) *Registry {
+	r := NewRegistry()
+	noop := func(session interface{}, inv *Invocation) {}
+	for _, cmd := range []*Command{
+		{Name: "look", Aliases: []string{"l"}, Help: "Look around.", RequiredState: stateInGame, ActionType: "observe_area", Handler: noop},
+		{Name: "give", Syntax: "<item> to <target>", Help: "Give an item.", RequiredState: stateInGame, ActionType: "deliver_item", Handler: noop},
+		{Name: "gather", Syntax: "<item>", Help: "Gather an item.", RequiredState: stateInGame, ActionType: "gather_item", Handler: noop},
+		{Name: "north", Aliases: []string{"n"}, RequiredState: stateInGame, ActionType: "move", Handler: noop},
+		{Name: "logout", RequiredState: stateLogin, Handler: noop},
+	} {
+		if err := r.Register(cmd); err != nil {
+			t.Fatalf("Failed to register %s: %v", cmd.Name, err)
+		}
+	}
+	return r
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	r := newTestRegistry(t)
+
+	tests := []struct {
+		verb string
+		want string
+	}{
+		{"look", "look"},
+		{"LOOK", "look"},
+		{"l", "look"}, // Alias wins over the "lo" prefix of logout in another state
+		{"loo", "look"},
+		{"giv", "give"},
+		{"gat", "gather"},
+		{"n", "north"},
+		{"nor", "north"},
+	}
+	for _, tt := range tests {
+		cmd, err := r.Lookup(tt.verb, stateInGame)
+		if err != nil {
+			t.Fatalf("Lookup(%q) returned error: %v", tt.verb, err)
+		}
+		if cmd.Name != tt.want {
+			t.Errorf("Lookup(%q) = %s, want %s", tt.verb, cmd.Name, tt.want)
+		}
+	}
+}
+
+func TestRegistry_LookupAmbiguousAndUnknown(t *testing.T) {
+	r := newTestRegistry(t)
+
+	_, err := r.Lookup("g", stateInGame)
+	var ambiguous *AmbiguousCommandError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("Expected AmbiguousCommandError, got %v", err)
+	}
+	if strings.Join(ambiguous.Candidates, ",") != "gather,give" {
+		t.Errorf("Unexpected candidates: %v", ambiguous.Candidates)
+	}
+
+	if _, err := r.Lookup("dance", stateInGame); !errors.Is(err, ErrUnknownCommand) {
+		t.Errorf("Expected ErrUnknownCommand, got %v", err)
+	}
+
+	// Commands are only visible in their required state.
+	if _, err := r.Lookup("logout", stateInGame); !errors.Is(err, ErrUnknownCommand) {
+		t.Errorf("Expected logout to be unavailable in game, got %v", err)
+	}
+	if _, err := r.Lookup("look", stateLogin); !errors.Is(err, ErrUnknownCommand) {
+		t.Errorf("Expected look to be unavailable at login, got %v", err)
+	}
+}
+
+func TestRegistry_RegisterRejectsDuplicates(t *testing.T) {
+	r := newTestRegistry(t)
+	noop := func(session interface{}, inv *Invocation) {}
+
+	if err := r.Register(&Command{Name: "listen", Aliases: []string{"l"}, Handler: noop}); err == nil {
+		t.Error("Expected an error for a duplicate alias")
+	}
+	if err := r.Register(&Command{Name: "Look", Handler: noop}); err == nil {
+		t.Error("Expected an error for a duplicate name differing only in case")
+	}
+	if err := r.Register(&Command{Name: "wave"}); err == nil {
+		t.Error("Expected an error for a command without a handler")
+	}
+}
+
+func TestRegistry_Parse(t *testing.T) {
+	r := newTestRegistry(t)
+
+	inv, err := r.Parse("  giv mushrooms to  farmer_maggot ", stateInGame)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if inv.Command.Name != "give" || inv.Verb != "giv" {
+		t.Errorf("Unexpected invocation: %+v", inv)
+	}
+	if inv.ArgString() != "mushrooms to farmer_maggot" {
+		t.Errorf("Unexpected args: %q", inv.ArgString())
+	}
+	if inv.Command.ActionType != "deliver_item" {
+		t.Errorf("Unexpected action type: %s", inv.Command.ActionType)
+	}
+}
+
+func TestRegistry_Suggest(t *testing.T) {
+	r := newTestRegistry(t)
+
+	if got := r.Suggest("lokk", stateInGame); len(got) == 0 || got[0] != "look" {
+		t.Errorf("Suggest(lokk) = %v, want look first", got)
+	}
+	if got := r.Suggest("gvie", stateInGame); len(got) == 0 || got[0] != "give" {
+		t.Errorf("Suggest(gvie) = %v, want give first", got)
+	}
+	if got := r.Suggest("xyzzy", stateInGame); len(got) != 0 {
+		t.Errorf("Suggest(xyzzy) = %v, want none", got)
+	}
+}
+
+func TestRegistry_HelpAndSummary(t *testing.T) {
+	r := newTestRegistry(t)
+
+	cmd, _ := r.Get("l")
+	help := r.HelpText(cmd)
+	if !strings.Contains(help, "Usage: look") || !strings.Contains(help, "Aliases: l") || !strings.Contains(help, "Look around.") {
+		t.Errorf("Unexpected help text: %q", help)
+	}
+
+	summary := r.Summary(stateInGame)
+	if !strings.Contains(summary, "give <item> to <target>") {
+		t.Errorf("Summary missing give syntax: %q", summary)
+	}
+	if strings.Contains(summary, "logout") {
+		t.Errorf("Summary should only list in-game commands: %q", summary)
+	}
+}
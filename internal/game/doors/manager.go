@@ -0,0 +1,334 @@
+package doors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"mud/internal/dal"
+	"mud/internal/game/scheduler"
+	"mud/internal/models"
+)
+
+// LockpickingSkillID is the skill used to pick locks.
+const LockpickingSkillID = "lockpicking"
+
+var (
+	// ErrNoExit is returned when the room has no exit in the given direction.
+	ErrNoExit = errors.New("no exit in that direction")
+	// ErrNoDoor is returned when the exit has no door.
+	ErrNoDoor = errors.New("exit has no door")
+	// ErrAlreadyOpen is returned when opening a door that is open.
+	ErrAlreadyOpen = errors.New("door is already open")
+	// ErrAlreadyClosed is returned when closing a door that is closed.
+	ErrAlreadyClosed = errors.New("door is already closed")
+	// ErrLocked is returned when opening a locked door.
+	ErrLocked = errors.New("door is locked")
+	// ErrNotLocked is returned when unlocking or picking a door that is not locked.
+	ErrNotLocked = errors.New("door is not locked")
+	// ErrAlreadyLocked is returned when locking a door that is locked.
+	ErrAlreadyLocked = errors.New("door is already locked")
+	// ErrNotClosed is returned when locking a door that is open.
+	ErrNotClosed = errors.New("door must be closed first")
+	// ErrNoLock is returned when locking or unlocking a door that takes no key.
+	ErrNoLock = errors.New("door has no keyhole")
+	// ErrNoKey is returned when the character does not carry the door's key.
+	ErrNoKey = errors.New("character does not have the key")
+	// ErrNoSkill is returned when picking a lock without the lockpicking skill.
+	ErrNoSkill = errors.New("character cannot pick locks")
+	// ErrPickFailed is returned when a lockpicking attempt fails.
+	ErrPickFailed = errors.New("failed to pick the lock")
+)
+
+// Manager opens, closes, locks and unlocks the doors on room exits. A door's
+// state is stored on the exits of both rooms it connects and every change is
+// applied to both sides.
+type Manager struct {
+	roomDAL        dal.RoomDALInterface
+	instanceDAL    dal.ItemInstanceDALInterface
+	playerSkillDAL dal.PlayerSkillDALInterface
+	mu             sync.Mutex
+	scheduler      *scheduler.Scheduler
+	relocks        map[string]*scheduler.Job // Pending relocks, keyed by room and direction
+	relockUnit     time.Duration             // Unit of Exit.RelockAfter
+	roll           func(n int) int           // Returns a number in [0, n); replaced in tests
+
+	// OnRelock, when set, is called for each side of a door after it has closed
+	// and locked itself.
+	OnRelock func(roomID, direction string)
+}
+
+// NewManager creates a new Manager. Doors relock through the scheduler.
+func NewManager(roomDAL dal.RoomDALInterface, instanceDAL dal.ItemInstanceDALInterface, playerSkillDAL dal.PlayerSkillDALInterface, sched *scheduler.Scheduler) *Manager {
+	return &Manager{
+		roomDAL:        roomDAL,
+		instanceDAL:    instanceDAL,
+		playerSkillDAL: playerSkillDAL,
+		scheduler:      sched,
+		relocks:        make(map[string]*scheduler.Job),
+		relockUnit:     time.Second,
+		roll:           rand.Intn,
+	}
+}
+
+// IsDoor reports whether an exit has a door.
+func IsDoor(exit models.Exit) bool {
+	return exit.HasDoor || exit.IsLocked || exit.KeyID != ""
+}
+
+// Open opens the door in the given direction from the character's room.
+func (m *Manager) Open(character *models.PlayerCharacter, direction string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.update(character.CurrentRoomID, direction, func(exit *models.Exit) error {
+		switch {
+		case exit.IsLocked:
+			return ErrLocked
+		case !exit.IsClosed:
+			return ErrAlreadyOpen
+		}
+		exit.IsClosed = false
+		return nil
+	})
+}
+
+// Close closes the door in the given direction from the character's room.
+func (m *Manager) Close(character *models.PlayerCharacter, direction string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.update(character.CurrentRoomID, direction, func(exit *models.Exit) error {
+		if exit.IsClosed || exit.IsLocked {
+			return ErrAlreadyClosed
+		}
+		exit.IsClosed = true
+		return nil
+	})
+}
+
+// Lock locks a closed door with the key the character is carrying.
+func (m *Manager) Lock(character *models.PlayerCharacter, direction string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err := m.update(character.CurrentRoomID, direction, func(exit *models.Exit) error {
+		switch {
+		case exit.KeyID == "":
+			return ErrNoLock
+		case exit.IsLocked:
+			return ErrAlreadyLocked
+		case !exit.IsClosed:
+			return ErrNotClosed
+		}
+		if err := m.checkKey(character, exit.KeyID); err != nil {
+			return err
+		}
+		exit.IsLocked = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.cancelRelock(character.CurrentRoomID, direction)
+	return nil
+}
+
+// Unlock unlocks a door with the key the character is carrying. The door stays closed.
+func (m *Manager) Unlock(character *models.PlayerCharacter, direction string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var relockAfter int
+	err := m.update(character.CurrentRoomID, direction, func(exit *models.Exit) error {
+		switch {
+		case exit.KeyID == "":
+			return ErrNoLock
+		case !exit.IsLocked:
+			return ErrNotLocked
+		}
+		if err := m.checkKey(character, exit.KeyID); err != nil {
+			return err
+		}
+		exit.IsLocked = false
+		relockAfter = exit.RelockAfter
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.scheduleRelock(character.CurrentRoomID, direction, relockAfter)
+	return nil
+}
+
+// Pick tries to unlock a door using the character's lockpicking skill. The
+// chance of success is the character's skill percentage. ErrPickFailed means
+// the attempt was made and failed; other errors mean no attempt was possible.
+func (m *Manager) Pick(character *models.PlayerCharacter, direction string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var relockAfter int
+	err := m.update(character.CurrentRoomID, direction, func(exit *models.Exit) error {
+		if !exit.IsLocked {
+			return ErrNotLocked
+		}
+		skill, err := m.playerSkillDAL.GetPlayerSkillByID(character.ID, LockpickingSkillID)
+		if err != nil {
+			return fmt.Errorf("failed to get lockpicking skill: %w", err)
+		}
+		if skill == nil || skill.Percentage <= 0 {
+			return ErrNoSkill
+		}
+		if m.roll(100) >= skill.Percentage {
+			return ErrPickFailed
+		}
+		exit.IsLocked = false
+		relockAfter = exit.RelockAfter
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.scheduleRelock(character.CurrentRoomID, direction, relockAfter)
+	return nil
+}
+
+// update applies change to the door in the given direction and, if it
+// succeeds, copies the new state to the matching exit in the target room.
+func (m *Manager) update(roomID, direction string, change func(exit *models.Exit) error) error {
+	direction = strings.ToLower(direction)
+	room, exits, err := m.exits(roomID)
+	if err != nil {
+		return err
+	}
+	exit, found := exits[direction]
+	if !found {
+		return ErrNoExit
+	}
+	if !IsDoor(exit) {
+		return ErrNoDoor
+	}
+	if err := change(&exit); err != nil {
+		return err
+	}
+	exits[direction] = exit
+	if err := m.saveExits(room, exits); err != nil {
+		return err
+	}
+
+	target, targetExits, err := m.exits(exit.TargetRoomID)
+	if err != nil {
+		return err
+	}
+	for dir, back := range targetExits {
+		if back.TargetRoomID != roomID || !IsDoor(back) {
+			continue
+		}
+		back.IsClosed, back.IsLocked = exit.IsClosed, exit.IsLocked
+		targetExits[dir] = back
+	}
+	return m.saveExits(target, targetExits)
+}
+
+// exits loads a room and decodes its exits.
+func (m *Manager) exits(roomID string) (*models.Room, map[string]models.Exit, error) {
+	room, err := m.roomDAL.GetRoomByID(roomID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get room %s: %w", roomID, err)
+	}
+	if room == nil {
+		return nil, nil, fmt.Errorf("room with ID %s not found", roomID)
+	}
+	exits := make(map[string]models.Exit)
+	if room.Exits != "" {
+		if err := json.Unmarshal([]byte(room.Exits), &exits); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal exits for room %s: %w", roomID, err)
+		}
+	}
+	return room, exits, nil
+}
+
+func (m *Manager) saveExits(room *models.Room, exits map[string]models.Exit) error {
+	exitsJSON, err := json.Marshal(exits)
+	if err != nil {
+		return fmt.Errorf("failed to marshal exits for room %s: %w", room.ID, err)
+	}
+	room.Exits = string(exitsJSON)
+	if err := m.roomDAL.UpdateRoom(room); err != nil {
+		return fmt.Errorf("failed to update room %s: %w", room.ID, err)
+	}
+	return nil
+}
+
+// checkKey returns ErrNoKey unless the character holds an instance of the key template.
+func (m *Manager) checkKey(character *models.PlayerCharacter, keyID string) error {
+	held, err := m.instanceDAL.GetInstancesByLocation(models.LocationCharacter, character.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get inventory: %w", err)
+	}
+	for _, instance := range held {
+		if instance.TemplateID == keyID {
+			return nil
+		}
+	}
+	return ErrNoKey
+}
+
+// scheduleRelock schedules the auto-relock of a door that has just been
+// unlocked. A relockAfter of zero leaves the door unlocked.
+func (m *Manager) scheduleRelock(roomID, direction string, relockAfter int) {
+	m.cancelRelock(roomID, direction)
+	if relockAfter <= 0 {
+		return
+	}
+	key := relockKey(roomID, direction)
+	m.relocks[key] = m.scheduler.After("relock "+key, time.Duration(relockAfter)*m.relockUnit, func() {
+		m.relock(roomID, direction)
+	})
+}
+
+func (m *Manager) cancelRelock(roomID, direction string) {
+	key := relockKey(roomID, direction)
+	if job, found := m.relocks[key]; found {
+		m.scheduler.Cancel(job)
+		delete(m.relocks, key)
+	}
+}
+
+// relock closes and locks a door whose relock job has run, unless it was
+// locked again in the meantime.
+func (m *Manager) relock(roomID, direction string) {
+	m.mu.Lock()
+	var targetRoomID string
+	err := m.update(roomID, direction, func(exit *models.Exit) error {
+		if exit.IsLocked {
+			return ErrAlreadyLocked
+		}
+		exit.IsClosed, exit.IsLocked = true, true
+		targetRoomID = exit.TargetRoomID
+		return nil
+	})
+	delete(m.relocks, relockKey(roomID, direction))
+	m.mu.Unlock()
+
+	if err != nil || m.OnRelock == nil {
+		return
+	}
+	m.OnRelock(roomID, direction)
+	if _, targetExits, err := m.exits(targetRoomID); err == nil {
+		for dir, back := range targetExits {
+			if back.TargetRoomID == roomID && IsDoor(back) {
+				m.OnRelock(targetRoomID, dir)
+			}
+		}
+	}
+}
+
+func relockKey(roomID, direction string) string {
+	return roomID + "/" + strings.ToLower(direction)
+}
@@ -0,0 +1,147 @@
+package doors
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/dal"
+	"mud/internal/game/scheduler"
+	"mud/internal/models"
+	"mud/internal/testutils/testdal"
+)
+
+func setupManager(t *testing.T) (*Manager, *dal.DAL, *models.PlayerCharacter) {
+	t.Helper()
+
+	dals := testdal.New(t)
+	if err := dals.ItemDAL.CreateItem(&models.Item{ID: "cellar_key", Name: "a cellar key", Type: "key", Properties: "{}"}); err != nil {
+		t.Fatalf("Failed to create item: %v", err)
+	}
+	hallExits, _ := json.Marshal(map[string]models.Exit{
+		"down": {Direction: "down", TargetRoomID: "cellar", IsLocked: true, IsClosed: true, KeyID: "cellar_key"},
+		"east": {Direction: "east", TargetRoomID: "garden"},
+	})
+	cellarExits, _ := json.Marshal(map[string]models.Exit{
+		"up": {Direction: "up", TargetRoomID: "hall", IsLocked: true, IsClosed: true, KeyID: "cellar_key"},
+	})
+	gardenExits, _ := json.Marshal(map[string]models.Exit{
+		"west": {Direction: "west", TargetRoomID: "hall"},
+	})
+	for _, room := range []*models.Room{
+		{ID: "hall", Name: "Hall", Exits: string(hallExits), Properties: "{}"},
+		{ID: "cellar", Name: "Cellar", Exits: string(cellarExits), Properties: "{}"},
+		{ID: "garden", Name: "Garden", Exits: string(gardenExits), Properties: "{}"},
+	} {
+		if err := dals.RoomDAL.CreateRoom(room); err != nil {
+			t.Fatalf("Failed to create room: %v", err)
+		}
+	}
+	character := &models.PlayerCharacter{ID: "hero", Name: "Hero", CurrentRoomID: "hall", Inventory: "[]", VisitedRoomIDs: "[]"}
+	if err := dals.PlayerCharacterDAL.CreateCharacter(character); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	return NewManager(dals.RoomDAL, dals.ItemInstanceDAL, dals.PlayerSkillDAL, scheduler.NewScheduler(scheduler.RealClock{})), dals, character
+}
+
+func exitIn(t *testing.T, dals *dal.DAL, roomID, direction string) models.Exit {
+	t.Helper()
+	room, err := dals.RoomDAL.GetRoomByID(roomID)
+	assert.NoError(t, err)
+	var exits map[string]models.Exit
+	assert.NoError(t, json.Unmarshal([]byte(room.Exits), &exits))
+	return exits[direction]
+}
+
+func giveKey(t *testing.T, dals *dal.DAL, character *models.PlayerCharacter) {
+	t.Helper()
+	key := &models.ItemInstance{TemplateID: "cellar_key", LocationType: models.LocationCharacter, LocationID: character.ID}
+	if err := dals.ItemInstanceDAL.CreateInstance(key); err != nil {
+		t.Fatalf("Failed to create key: %v", err)
+	}
+}
+
+func TestManager_KeysAndBothSides(t *testing.T) {
+	m, dals, character := setupManager(t)
+
+	assert.ErrorIs(t, m.Open(character, "north"), ErrNoExit)
+	assert.ErrorIs(t, m.Open(character, "east"), ErrNoDoor)
+	assert.ErrorIs(t, m.Open(character, "down"), ErrLocked)
+	assert.ErrorIs(t, m.Unlock(character, "down"), ErrNoKey)
+
+	giveKey(t, dals, character)
+	assert.NoError(t, m.Unlock(character, "down"))
+	assert.ErrorIs(t, m.Unlock(character, "down"), ErrNotLocked)
+	assert.NoError(t, m.Open(character, "DOWN"))
+	assert.ErrorIs(t, m.Open(character, "down"), ErrAlreadyOpen)
+
+	// The cellar side of the door changed with the hall side.
+	back := exitIn(t, dals, "cellar", "up")
+	assert.False(t, back.IsLocked)
+	assert.False(t, back.IsClosed)
+
+	assert.ErrorIs(t, m.Lock(character, "down"), ErrNotClosed)
+	assert.NoError(t, m.Close(character, "down"))
+	assert.NoError(t, m.Lock(character, "down"))
+	back = exitIn(t, dals, "cellar", "up")
+	assert.True(t, back.IsLocked)
+	assert.True(t, back.IsClosed)
+}
+
+func TestManager_Pick(t *testing.T) {
+	m, dals, character := setupManager(t)
+
+	assert.ErrorIs(t, m.Pick(character, "down"), ErrNoSkill)
+
+	skill := &models.PlayerSkill{PlayerID: character.ID, SkillID: LockpickingSkillID, Percentage: 40}
+	assert.NoError(t, dals.PlayerSkillDAL.CreatePlayerSkill(skill))
+
+	m.roll = func(n int) int { return 40 }
+	assert.ErrorIs(t, m.Pick(character, "down"), ErrPickFailed)
+	assert.True(t, exitIn(t, dals, "hall", "down").IsLocked)
+
+	m.roll = func(n int) int { return 39 }
+	assert.NoError(t, m.Pick(character, "down"))
+	assert.False(t, exitIn(t, dals, "cellar", "up").IsLocked)
+	assert.ErrorIs(t, m.Pick(character, "down"), ErrNotLocked)
+}
+
+func TestManager_Relock(t *testing.T) {
+	m, dals, character := setupManager(t)
+	clock := scheduler.NewFakeClock(time.Now())
+	m.scheduler = scheduler.NewScheduler(clock)
+
+	room, err := dals.RoomDAL.GetRoomByID("hall")
+	assert.NoError(t, err)
+	var exits map[string]models.Exit
+	assert.NoError(t, json.Unmarshal([]byte(room.Exits), &exits))
+	door := exits["down"]
+	door.RelockAfter = 20
+	exits["down"] = door
+	exitsJSON, _ := json.Marshal(exits)
+	room.Exits = string(exitsJSON)
+	assert.NoError(t, dals.RoomDAL.UpdateRoom(room))
+
+	var relocked []string
+	m.OnRelock = func(roomID, direction string) { relocked = append(relocked, roomID+"/"+direction) }
+
+	giveKey(t, dals, character)
+	assert.NoError(t, m.Unlock(character, "down"))
+	assert.NoError(t, m.Open(character, "down"))
+
+	clock.Advance(19 * time.Second)
+	m.scheduler.Tick()
+	assert.Empty(t, relocked)
+	assert.False(t, exitIn(t, dals, "hall", "down").IsLocked)
+
+	clock.Advance(time.Second)
+	m.scheduler.Tick()
+	assert.Equal(t, []string{"hall/down", "cellar/up"}, relocked)
+	assert.Equal(t, 0, m.scheduler.Len())
+	for _, side := range []models.Exit{exitIn(t, dals, "hall", "down"), exitIn(t, dals, "cellar", "up")} {
+		assert.True(t, side.IsClosed)
+		assert.True(t, side.IsLocked)
+	}
+}
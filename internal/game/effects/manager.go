@@ -0,0 +1,245 @@
+package effects
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"mud/internal/dal"
+	"mud/internal/game/scheduler"
+	"mud/internal/models"
+)
+
+// DefaultSweepInterval is how often expired effects are removed.
+const DefaultSweepInterval = time.Second
+
+var (
+	// ErrNoDuration is returned when applying an effect that would expire immediately.
+	ErrNoDuration = errors.New("effect has no duration")
+	// ErrUnknownStacking is returned when applying an effect with an unknown stacking rule.
+	ErrUnknownStacking = errors.New("unknown stacking rule")
+)
+
+// Notifier receives the narration of effects wearing off player characters.
+type Notifier interface {
+	// Notify sends a line of narration to a character.
+	Notify(characterID, content string)
+	// EffectsChanged is called after an effect on a character is applied or removed.
+	EffectsChanged(characterID string)
+}
+
+// Manager keeps the timed buffs, debuffs and statuses on player characters
+// and NPCs. Effects are stored in the database, so they survive restarts and
+// are visible to every subsystem that reads them; a background sweep removes
+// them once they expire.
+type Manager struct {
+	statusEffectDAL dal.StatusEffectDALInterface
+	notifier        Notifier
+	mu              sync.Mutex
+	now             func() time.Time // Replaced in tests
+
+	SweepInterval time.Duration
+}
+
+// NewManager creates a new Manager.
+func NewManager(statusEffectDAL dal.StatusEffectDALInterface, notifier Notifier) *Manager {
+	return &Manager{
+		statusEffectDAL: statusEffectDAL,
+		notifier:        notifier,
+		now:             time.Now,
+		SweepInterval:   DefaultSweepInterval,
+	}
+}
+
+// Schedule has the scheduler sweep expired effects every SweepInterval.
+func (m *Manager) Schedule(s *scheduler.Scheduler) *scheduler.Job {
+	return s.Every("effect expiry", m.SweepInterval, m.Sweep)
+}
+
+// Apply places an effect on its target for the given duration. If the target
+// already has an effect of the same type and key from the same source, the
+// effect's Stacking rule decides what happens: "refresh" (the default)
+// replaces its magnitude and restarts its duration, "stack" adds a stack up
+// to MaxStacks (zero means no limit) and restarts its duration, and "ignore"
+// leaves it unchanged. It returns the effect as it now stands.
+func (m *Manager) Apply(effect *models.StatusEffect, duration time.Duration) (*models.StatusEffect, error) {
+	if duration <= 0 {
+		return nil, ErrNoDuration
+	}
+	effect.Stacking = strings.ToLower(effect.Stacking)
+	switch effect.Stacking {
+	case "":
+		effect.Stacking = models.StackRefresh
+	case models.StackRefresh, models.StackAdd, models.StackIgnore:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStacking, effect.Stacking)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	existing, err := m.findMatching(effect)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		effect.Stacks = 1
+		effect.AppliedAt = now
+		effect.ExpiresAt = now.Add(duration)
+		if err := m.statusEffectDAL.CreateStatusEffect(effect); err != nil {
+			return nil, fmt.Errorf("failed to create status effect: %w", err)
+		}
+		m.changed(effect)
+		return effect, nil
+	}
+
+	switch {
+	case existing.Expired(now):
+		// It ran out but has not been swept yet; start it afresh.
+		existing.Stacks = 1
+		existing.AppliedAt = now
+	case effect.Stacking == models.StackIgnore:
+		return existing, nil
+	case effect.Stacking == models.StackAdd:
+		if effect.MaxStacks <= 0 || existing.Stacks < effect.MaxStacks {
+			existing.Stacks++
+		}
+	}
+	existing.Magnitude = effect.Magnitude
+	existing.Stacking = effect.Stacking
+	existing.MaxStacks = effect.MaxStacks
+	existing.ExpiresAt = now.Add(duration)
+	if err := m.statusEffectDAL.UpdateStatusEffect(existing); err != nil {
+		return nil, fmt.Errorf("failed to update status effect: %w", err)
+	}
+	m.changed(existing)
+	return existing, nil
+}
+
+// Active returns the unexpired effects on a character or NPC, oldest first.
+func (m *Manager) Active(targetType, targetID string) ([]*models.StatusEffect, error) {
+	effects, err := m.statusEffectDAL.GetStatusEffectsByTarget(targetType, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status effects for %s %s: %w", targetType, targetID, err)
+	}
+	now := m.now()
+	active := effects[:0]
+	for _, effect := range effects {
+		if !effect.Expired(now) {
+			active = append(active, effect)
+		}
+	}
+	return active, nil
+}
+
+// Remove ends an effect early without narrating it.
+func (m *Manager) Remove(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	effect, err := m.statusEffectDAL.GetStatusEffectByID(id)
+	if err != nil {
+		return fmt.Errorf("failed to get status effect %s: %w", id, err)
+	}
+	if err := m.statusEffectDAL.DeleteStatusEffect(id); err != nil {
+		return err
+	}
+	if effect != nil {
+		m.changed(effect)
+	}
+	return nil
+}
+
+// Sweep removes every expired effect and tells characters what wore off.
+func (m *Manager) Sweep() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expired, err := m.statusEffectDAL.GetExpiredStatusEffects(m.now())
+	if err != nil {
+		logrus.Errorf("Effects: Failed to get expired status effects: %v", err)
+		return
+	}
+	for _, effect := range expired {
+		if err := m.statusEffectDAL.DeleteStatusEffect(effect.ID); err != nil {
+			logrus.Errorf("Effects: Failed to delete status effect %s: %v", effect.ID, err)
+			continue
+		}
+		if effect.TargetType != models.EffectTargetCharacter {
+			continue
+		}
+		if effect.Type == models.EffectTypeStatus {
+			m.notifier.Notify(effect.TargetID, wearOff(effect.Key))
+		} else {
+			m.notifier.Notify(effect.TargetID, fmt.Sprintf("Your %s returns to normal.", Describe(effect.Key)))
+		}
+		m.changed(effect)
+	}
+}
+
+// changed tells the notifier that the effects on a character have changed.
+func (m *Manager) changed(effect *models.StatusEffect) {
+	if effect.TargetType == models.EffectTargetCharacter {
+		m.notifier.EffectsChanged(effect.TargetID)
+	}
+}
+
+// findMatching returns the effect on the same target with the same type, key
+// and source, or nil if there is none.
+func (m *Manager) findMatching(effect *models.StatusEffect) (*models.StatusEffect, error) {
+	effects, err := m.statusEffectDAL.GetStatusEffectsByTarget(effect.TargetType, effect.TargetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status effects for %s %s: %w", effect.TargetType, effect.TargetID, err)
+	}
+	for _, existing := range effects {
+		if existing.Type == effect.Type && existing.Key == effect.Key &&
+			existing.SourceType == effect.SourceType && existing.SourceID == effect.SourceID {
+			return existing, nil
+		}
+	}
+	return nil, nil
+}
+
+// Describe turns a status or attribute key like "keen_senses" into "keen senses".
+func Describe(key string) string {
+	return strings.ReplaceAll(key, "_", " ")
+}
+
+// conditions are the statuses named for how their bearer is, which read
+// as they are in "You are now stunned".
+var conditions = map[string]bool{
+	"alert":      true,
+	"distracted": true,
+	"dazzled":    true,
+	"stunned":    true,
+	"blinded":    true,
+}
+
+// IsCondition reports whether a status is named for how its bearer is, so
+// that "You are now " can be put before its description.
+func IsCondition(key string) bool {
+	return conditions[key]
+}
+
+// wearOffMessages are what characters are told when statuses other than
+// conditions wear off.
+var wearOffMessages = map[string]string{
+	"keen_senses": "Your senses dull again.",
+	"blinded":     "You can see again.",
+}
+
+// wearOff returns what a character is told when a status wears off. Statuses
+// without a message of their own wear off in a form that reads for any name.
+func wearOff(key string) string {
+	if message, ok := wearOffMessages[key]; ok {
+		return message
+	}
+	if IsCondition(key) {
+		return fmt.Sprintf("You are no longer %s.", Describe(key))
+	}
+	return fmt.Sprintf("The effect of %s wears off.", Describe(key))
+}
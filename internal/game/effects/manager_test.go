@@ -0,0 +1,131 @@
+package effects
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/dal"
+	"mud/internal/models"
+	"mud/internal/testutils"
+	"mud/internal/testutils/testdal"
+)
+
+func setupManager(t *testing.T) (*Manager, *dal.DAL, *testutils.RecordingNotifier, *time.Time) {
+	t.Helper()
+
+	dals := testdal.New(t)
+	notifier := &testutils.RecordingNotifier{}
+	m := NewManager(dals.StatusEffectDAL, notifier)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	return m, dals, notifier, &now
+}
+
+func keenSenses(stacking string, magnitude float64) *models.StatusEffect {
+	return &models.StatusEffect{
+		TargetType: models.EffectTargetCharacter,
+		TargetID:   "hero",
+		SourceType: "item",
+		SourceID:   "owl_feather",
+		Type:       models.EffectTypeStatus,
+		Key:        "keen_senses",
+		Magnitude:  magnitude,
+		Stacking:   stacking,
+		MaxStacks:  3,
+	}
+}
+
+func TestManager_ApplyStacking(t *testing.T) {
+	m, dals, _, now := setupManager(t)
+
+	_, err := m.Apply(keenSenses("", 1), 0)
+	assert.ErrorIs(t, err, ErrNoDuration)
+	_, err = m.Apply(keenSenses("sometimes", 1), time.Minute)
+	assert.ErrorIs(t, err, ErrUnknownStacking)
+
+	effect, err := m.Apply(keenSenses("", 1), time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StackRefresh, effect.Stacking)
+	assert.Equal(t, 1, effect.Stacks)
+	assert.Equal(t, now.Add(time.Minute), effect.ExpiresAt)
+
+	// Refreshing replaces the magnitude and restarts the duration.
+	*now = now.Add(30 * time.Second)
+	effect, err = m.Apply(keenSenses(models.StackRefresh, 2), time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, effect.Stacks)
+	assert.Equal(t, 2.0, effect.Magnitude)
+	saved, err := dals.StatusEffectDAL.GetStatusEffectByID(effect.ID)
+	assert.NoError(t, err)
+	assert.True(t, now.Add(time.Minute).Equal(saved.ExpiresAt))
+
+	// Ignoring keeps the effect as it is.
+	effect, err = m.Apply(keenSenses(models.StackIgnore, 5), time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, effect.Magnitude)
+	assert.True(t, now.Add(time.Minute).Equal(effect.ExpiresAt))
+
+	// Stacking adds up to MaxStacks.
+	for i := 0; i < 4; i++ {
+		effect, err = m.Apply(keenSenses(models.StackAdd, 2), time.Minute)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 3, effect.Stacks)
+	assert.Equal(t, 6.0, effect.TotalMagnitude())
+
+	// The same status from another source is a separate effect.
+	other := keenSenses("", 1)
+	other.SourceID = "eagle_eye"
+	_, err = m.Apply(other, time.Minute)
+	assert.NoError(t, err)
+	active, err := m.Active(models.EffectTargetCharacter, "hero")
+	assert.NoError(t, err)
+	assert.Len(t, active, 2)
+}
+
+func TestManager_Sweep(t *testing.T) {
+	m, dals, notifier, now := setupManager(t)
+
+	_, err := m.Apply(keenSenses("", 1), 10*time.Second)
+	assert.NoError(t, err)
+	_, err = m.Apply(&models.StatusEffect{TargetType: models.EffectTargetCharacter, TargetID: "hero", Type: models.EffectTypeModifier, Key: "armor", Magnitude: 3}, 20*time.Second)
+	assert.NoError(t, err)
+	_, err = m.Apply(&models.StatusEffect{TargetType: models.EffectTargetNPC, TargetID: "goblin", Type: models.EffectTypeStatus, Key: "stunned"}, 10*time.Second)
+	assert.NoError(t, err)
+
+	m.Sweep()
+	assert.Empty(t, notifier.Messages())
+	assert.Equal(t, 2, notifier.EffectChanges("hero"))
+	assert.Equal(t, 0, notifier.EffectChanges("goblin"), "Only characters hear about their effects changing")
+
+	// Expired effects stop counting before they are swept.
+	*now = now.Add(10 * time.Second)
+	active, err := m.Active(models.EffectTargetCharacter, "hero")
+	assert.NoError(t, err)
+	if assert.Len(t, active, 1) {
+		assert.Equal(t, "armor", active[0].Key)
+	}
+
+	m.Sweep()
+	assert.Equal(t, []string{"Your senses dull again."}, notifier.MessagesFor("hero"))
+	assert.Empty(t, notifier.MessagesFor("goblin"), "Nothing is said when an NPC's effect wears off")
+	remaining, err := dals.StatusEffectDAL.GetStatusEffectsByTarget(models.EffectTargetNPC, "goblin")
+	assert.NoError(t, err)
+	assert.Empty(t, remaining)
+
+	*now = now.Add(10 * time.Second)
+	m.Sweep()
+	assert.Equal(t, []string{"Your senses dull again.", "Your armor returns to normal."}, notifier.MessagesFor("hero"))
+	assert.Equal(t, 4, notifier.EffectChanges("hero"))
+	remaining, err = dals.StatusEffectDAL.GetStatusEffectsByTarget(models.EffectTargetCharacter, "hero")
+	assert.NoError(t, err)
+	assert.Empty(t, remaining)
+}
+
+func TestWearOff(t *testing.T) {
+	assert.Equal(t, "You are no longer stunned.", wearOff("stunned"))
+	assert.True(t, IsCondition("stunned"))
+	assert.False(t, IsCondition("keen_senses"), "Keen senses is something you have, not something you are")
+	assert.Equal(t, "The effect of night vision wears off.", wearOff("night_vision"), "Statuses without a message of their own get one that reads for any name")
+}
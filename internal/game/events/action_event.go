@@ -12,5 +12,5 @@ type ActionEvent struct {
 	Room       *models.Room
 	Timestamp  time.Time
 	SkillUsed  *models.Skill
-	Targets    []interface{} // Can be *models.NPC, *models.Item, etc.
+	Targets    []interface{} // Can be *models.NPC, *models.ItemInstance, etc.
 }
@@ -15,37 +15,28 @@ type GlobalObserverManager struct {
 	ownerDAL         dal.OwnerDALInterface
 	raceDAL          dal.RaceDALInterface
 	professionDAL    dal.ProfessionDALInterface
+	ledger           game.InfluenceLedgerInterface
 }
 
-// NewGlobalObserverManager creates a new GlobalObserverManager.
+// NewGlobalObserverManager creates a new GlobalObserverManager. It does not
+// subscribe to the event bus itself; the caller feeds it action events
+// through HandleActionEvent.
 func NewGlobalObserverManager(
 	eventBus *events.EventBus,
 	perceptionFilter game.PerceptionFilterInterface,
 	ownerDAL dal.OwnerDALInterface,
 	raceDAL dal.RaceDALInterface,
 	professionDAL dal.ProfessionDALInterface,
+	ledger game.InfluenceLedgerInterface,
 ) *GlobalObserverManager {
-	m := &GlobalObserverManager{
+	return &GlobalObserverManager{
 		eventBus:         eventBus,
 		perceptionFilter: perceptionFilter,
 		ownerDAL:         ownerDAL,
 		raceDAL:          raceDAL,
 		professionDAL:    professionDAL,
+		ledger:           ledger,
 	}
-
-	// Subscribe to ActionEvents for asynchronous processing
-	actionEventChannel := make(chan interface{})
-	eventBus.Subscribe(events.ActionEventType, actionEventChannel)
-	go func() {
-		for event := range actionEventChannel {
-			if actionEvent, ok := event.(*events.ActionEvent); ok {
-				m.HandleActionEvent(actionEvent)
-			} else {
-				logrus.Errorf("GlobalObserverManager: received unexpected event type on ActionEventType channel: %T", event)
-			}
-		}
-	}()
-	return m
 }
 
 func (gom *GlobalObserverManager) HandleActionEvent(event interface{}) {
@@ -93,22 +84,16 @@ func (gom *GlobalObserverManager) processGlobalObservation(event *events.ActionE
 	// For now, no additive bonuses or multipliers are implemented, so it's just BaseSignificance * Clarity
 	significance := perceivedAction.BaseSignificance * perceivedAction.Clarity
 
-	// Update owner's influence budget based on significance
-	// This is a simplified model. More complex logic might involve decay, caps, etc.
-	owner.CurrentInfluenceBudget = owner.CurrentInfluenceBudget + significance
-	// Ensure budget doesn't exceed max
-	if owner.CurrentInfluenceBudget > owner.MaxInfluenceBudget {
-		owner.CurrentInfluenceBudget = owner.MaxInfluenceBudget
-	}
-
-	// Persist the updated owner (this should ideally be batched or handled by a separate persistence layer)
-	err = gom.ownerDAL.UpdateOwner(owner)
+	// Credit the owner's influence budget with the significance; the ledger
+	// caps it at the maximum and records the credit for the audit log.
+	budget, err := gom.ledger.Credit(models.InfluenceOwner, owner.ID, significance, "observed "+perceivedAction.PerceivedActionType)
 	if err != nil {
 		logrus.Errorf("GlobalObserverManager: failed to update owner %s budget: %v", owner.ID, err)
+		return
 	}
 
 	logrus.Infof("GlobalObserverManager: Owner %s (Monitors: %s %s) perceived action '%s' with significance %.2f. New budget: %.2f",
-		owner.Name, owner.MonitoredAspect, owner.AssociatedID, perceivedAction.PerceivedActionType, significance, owner.CurrentInfluenceBudget)
+		owner.Name, owner.MonitoredAspect, owner.AssociatedID, perceivedAction.PerceivedActionType, significance, budget)
 
 	// TODO: Potentially trigger other global reactions here, e.g., new quests, global messages.
 }
\ No newline at end of file
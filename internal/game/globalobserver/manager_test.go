@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"mud/internal/dal"
 	"mud/internal/game/events"
+	"mud/internal/game/influence"
 	"mud/internal/game/perception"
 	"mud/internal/models"
 )
@@ -40,6 +41,17 @@ func (m *MockOwnerDAL) GetOwnerByID(id string) (*models.Owner, error) {
 	if m.GetOwnerByIDFunc != nil {
 		return m.GetOwnerByIDFunc(id)
 	}
+	// Fall back to the owners GetAllOwners returns, as the ledger reloads
+	// owners by ID before crediting them.
+	owners, err := m.GetAllOwners()
+	if err != nil {
+		return nil, err
+	}
+	for _, owner := range owners {
+		if owner.ID == id {
+			return owner, nil
+		}
+	}
 	return nil, nil
 }
 
@@ -54,6 +66,19 @@ func (m *MockOwnerDAL) CreateOwner(owner *models.Owner) error { return nil }
 func (m *MockOwnerDAL) DeleteOwner(id string) error { return nil }
 func (m *MockOwnerDAL) Cache() dal.CacheInterface { return nil }
 
+type MockInfluenceDAL struct {
+	entries []*models.InfluenceEntry
+}
+
+func (m *MockInfluenceDAL) CreateInfluenceEntry(entry *models.InfluenceEntry) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+func (m *MockInfluenceDAL) GetInfluenceEntries(entityType, entityID string, limit int) ([]*models.InfluenceEntry, error) {
+	return m.entries, nil
+}
+func (m *MockInfluenceDAL) Cache() dal.CacheInterface { return nil }
+
 type MockRaceDAL struct{}
 
 func (m *MockRaceDAL) GetRaceByID(id string) (*models.Race, error) { return nil, nil }
@@ -85,6 +110,7 @@ func TestNewGlobalObserverManager(t *testing.T) {
 		mockOwnerDAL,
 		mockRaceDAL,
 		mockProfessionDAL,
+		influence.NewLedger(mockOwnerDAL, nil, nil, &MockInfluenceDAL{}),
 	)
 
 	assert.NotNil(t, manager)
@@ -118,6 +144,7 @@ func TestGlobalObserverManager_HandleActionEvent_NoGlobalObservers(t *testing.T)
 		mockOwnerDAL,
 		mockRaceDAL,
 		mockProfessionDAL,
+		influence.NewLedger(mockOwnerDAL, nil, nil, &MockInfluenceDAL{}),
 	)
 
 	// Ensure UpdateOwner is never called
@@ -182,6 +209,7 @@ func TestGlobalObserverManager_HandleActionEvent_RaceBasedObserver(t *testing.T)
 		mockOwnerDAL,
 		mockRaceDAL,
 		mockProfessionDAL,
+		influence.NewLedger(mockOwnerDAL, nil, nil, &MockInfluenceDAL{}),
 	)
 
 	actionEvent := &events.ActionEvent{
@@ -239,6 +267,7 @@ func TestGlobalObserverManager_HandleActionEvent_ProfessionBasedObserver(t *test
 		mockOwnerDAL,
 		mockRaceDAL,
 		mockProfessionDAL,
+		influence.NewLedger(mockOwnerDAL, nil, nil, &MockInfluenceDAL{}),
 	)
 
 	actionEvent := &events.ActionEvent{
@@ -287,6 +316,7 @@ func TestGlobalObserverManager_HandleActionEvent_OwnerNotObserving(t *testing.T)
 		mockOwnerDAL,
 		mockRaceDAL,
 		mockProfessionDAL,
+		influence.NewLedger(mockOwnerDAL, nil, nil, &MockInfluenceDAL{}),
 	)
 
 	actionEvent := &events.ActionEvent{
@@ -321,6 +351,7 @@ func TestGlobalObserverManager_HandleActionEvent_GetAllOwnersError(t *testing.T)
 		mockOwnerDAL,
 		mockRaceDAL,
 		mockProfessionDAL,
+		influence.NewLedger(mockOwnerDAL, nil, nil, &MockInfluenceDAL{}),
 	)
 
 	// Ensure UpdateOwner is never called
@@ -370,9 +401,12 @@ func TestGlobalObserverManager_ProcessGlobalObservation_Success(t *testing.T) {
 		return nil
 	}
 
+	mockOwnerDAL.GetOwnerByIDFunc = func(id string) (*models.Owner, error) { return owner, nil }
+
 	manager := &GlobalObserverManager{
 		perceptionFilter: mockPerceptionFilter,
 		ownerDAL:         mockOwnerDAL,
+		ledger:           influence.NewLedger(mockOwnerDAL, nil, nil, &MockInfluenceDAL{}),
 	}
 
 	actionEvent := &events.ActionEvent{
@@ -412,9 +446,12 @@ func TestGlobalObserverManager_ProcessGlobalObservation_BudgetCapping(t *testing
 		return nil
 	}
 
+	mockOwnerDAL.GetOwnerByIDFunc = func(id string) (*models.Owner, error) { return owner, nil }
+
 	manager := &GlobalObserverManager{
 		perceptionFilter: mockPerceptionFilter,
 		ownerDAL:         mockOwnerDAL,
+		ledger:           influence.NewLedger(mockOwnerDAL, nil, nil, &MockInfluenceDAL{}),
 	}
 
 	actionEvent := &events.ActionEvent{
@@ -449,9 +486,12 @@ func TestGlobalObserverManager_ProcessGlobalObservation_FilterError(t *testing.T
 		return nil
 	}
 
+	mockOwnerDAL.GetOwnerByIDFunc = func(id string) (*models.Owner, error) { return owner, nil }
+
 	manager := &GlobalObserverManager{
 		perceptionFilter: mockPerceptionFilter,
 		ownerDAL:         mockOwnerDAL,
+		ledger:           influence.NewLedger(mockOwnerDAL, nil, nil, &MockInfluenceDAL{}),
 	}
 
 	actionEvent := &events.ActionEvent{
@@ -487,9 +527,12 @@ func TestGlobalObserverManager_ProcessGlobalObservation_UpdateOwnerError(t *test
 		return errors.New("update owner error")
 	}
 
+	mockOwnerDAL.GetOwnerByIDFunc = func(id string) (*models.Owner, error) { return owner, nil }
+
 	manager := &GlobalObserverManager{
 		perceptionFilter: mockPerceptionFilter,
 		ownerDAL:         mockOwnerDAL,
+		ledger:           influence.NewLedger(mockOwnerDAL, nil, nil, &MockInfluenceDAL{}),
 	}
 
 	actionEvent := &events.ActionEvent{
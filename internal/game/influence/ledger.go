@@ -0,0 +1,227 @@
+package influence
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"mud/internal/dal"
+	"mud/internal/game/scheduler"
+	"mud/internal/models"
+)
+
+// DefaultRegenInterval is how often budgets regenerate by their BudgetRegenRate.
+const DefaultRegenInterval = time.Minute
+
+// ReasonRegeneration is the reason recorded for time-based regeneration.
+const ReasonRegeneration = "regeneration"
+
+var (
+	// ErrInsufficientBudget is returned when a charge costs more than is left in the budget.
+	ErrInsufficientBudget = errors.New("insufficient influence budget")
+	// ErrNoBudget is returned for entities that do not hold an influence budget.
+	ErrNoBudget = errors.New("entity has no influence budget")
+)
+
+// Ledger keeps the influence budgets of Owners, Questmakers and Quest Owners.
+// Every credit and debit, and every charge it refuses, is recorded in the
+// audit log. Budgets never rise above their maximum or fall below zero.
+type Ledger struct {
+	ownerDAL      dal.OwnerDALInterface
+	questmakerDAL dal.QuestmakerDALInterface
+	questOwnerDAL dal.QuestOwnerDALInterface
+	influenceDAL  dal.InfluenceDALInterface
+	mu            sync.Mutex
+
+	// RegenInterval is how often Regenerate runs once scheduled; each run
+	// adds an entity's BudgetRegenRate to its budget.
+	RegenInterval time.Duration
+}
+
+// NewLedger creates a new Ledger.
+func NewLedger(ownerDAL dal.OwnerDALInterface, questmakerDAL dal.QuestmakerDALInterface, questOwnerDAL dal.QuestOwnerDALInterface, influenceDAL dal.InfluenceDALInterface) *Ledger {
+	return &Ledger{
+		ownerDAL:      ownerDAL,
+		questmakerDAL: questmakerDAL,
+		questOwnerDAL: questOwnerDAL,
+		influenceDAL:  influenceDAL,
+		RegenInterval: DefaultRegenInterval,
+	}
+}
+
+// Entity returns the influence entity type and ID of an Owner, Questmaker or
+// QuestOwner. ok is false for anything else, such as NPCs.
+func Entity(entity interface{}) (entityType, entityID string, ok bool) {
+	switch e := entity.(type) {
+	case *models.Owner:
+		return models.InfluenceOwner, e.ID, true
+	case *models.Questmaker:
+		return models.InfluenceQuestmaker, e.ID, true
+	case *models.QuestOwner:
+		return models.InfluenceQuestOwner, e.ID, true
+	}
+	return "", "", false
+}
+
+// Within runs fn with a ledger that keeps budgets through d, such as the DAL
+// of a transaction, so that its charges and refunds commit or roll back with
+// the rest of the transaction's work. Callers of l wait until fn returns, so
+// none of them read or overwrite budgets the transaction has yet to commit.
+func (l *Ledger) Within(d *dal.DAL, fn func(*Ledger) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	within := NewLedger(d.OwnerDAL, d.QuestmakerDAL, d.QuestOwnerDAL, d.InfluenceDAL)
+	within.RegenInterval = l.RegenInterval
+	return fn(within)
+}
+
+// Schedule has the scheduler regenerate budgets every RegenInterval.
+func (l *Ledger) Schedule(s *scheduler.Scheduler) *scheduler.Job {
+	return s.Every("influence regeneration", l.RegenInterval, l.Regenerate)
+}
+
+// Balance returns an entity's current and maximum budget.
+func (l *Ledger) Balance(entityType, entityID string) (current, max float64, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, err := l.load(entityType, entityID)
+	if err != nil {
+		return 0, 0, err
+	}
+	return *b.current, *b.max, nil
+}
+
+// Credit adds amount to an entity's budget, up to its maximum, and returns
+// the new balance. Nothing is recorded when the budget is already full.
+func (l *Ledger) Credit(entityType, entityID string, amount float64, reason string) (float64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, err := l.load(entityType, entityID)
+	if err != nil {
+		return 0, err
+	}
+	credited := min(amount, *b.max-*b.current)
+	if credited <= 0 {
+		return *b.current, nil
+	}
+	*b.current += credited
+	if err := b.save(); err != nil {
+		return 0, fmt.Errorf("failed to save influence budget of %s %s: %w", entityType, entityID, err)
+	}
+	return *b.current, l.record(&models.InfluenceEntry{EntityType: entityType, EntityID: entityID, Amount: credited, Balance: *b.current, Reason: reason})
+}
+
+// Charge takes cost from an entity's budget and returns the new balance. A
+// charge the budget cannot cover is refused with ErrInsufficientBudget,
+// recorded as refused, and leaves the budget unchanged.
+func (l *Ledger) Charge(entityType, entityID string, cost float64, reason string) (float64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, err := l.load(entityType, entityID)
+	if err != nil {
+		return 0, err
+	}
+	entry := &models.InfluenceEntry{EntityType: entityType, EntityID: entityID, Amount: -cost, Reason: reason}
+	if cost > *b.current {
+		entry.Balance, entry.Refused = *b.current, true
+		if err := l.record(entry); err != nil {
+			return 0, err
+		}
+		return *b.current, fmt.Errorf("%w: it costs %.1f and only %.1f of %.1f is left", ErrInsufficientBudget, cost, *b.current, *b.max)
+	}
+	*b.current -= cost
+	if err := b.save(); err != nil {
+		return 0, fmt.Errorf("failed to save influence budget of %s %s: %w", entityType, entityID, err)
+	}
+	entry.Balance = *b.current
+	return *b.current, l.record(entry)
+}
+
+// Regenerate credits every Owner, Questmaker and Quest Owner with their
+// BudgetRegenRate. Failures are logged and do not stop the others.
+func (l *Ledger) Regenerate() {
+	type regen struct {
+		entityType, entityID string
+		rate                 float64
+	}
+	var due []regen
+	owners, err := l.ownerDAL.GetAllOwners()
+	if err != nil {
+		logrus.Errorf("Ledger: Failed to get owners: %v", err)
+	}
+	for _, owner := range owners {
+		due = append(due, regen{models.InfluenceOwner, owner.ID, owner.BudgetRegenRate})
+	}
+	questmakers, err := l.questmakerDAL.GetAllQuestmakers()
+	if err != nil {
+		logrus.Errorf("Ledger: Failed to get questmakers: %v", err)
+	}
+	for _, questmaker := range questmakers {
+		due = append(due, regen{models.InfluenceQuestmaker, questmaker.ID, questmaker.BudgetRegenRate})
+	}
+	questOwners, err := l.questOwnerDAL.GetAllQuestOwners()
+	if err != nil {
+		logrus.Errorf("Ledger: Failed to get quest owners: %v", err)
+	}
+	for _, questOwner := range questOwners {
+		due = append(due, regen{models.InfluenceQuestOwner, questOwner.ID, questOwner.BudgetRegenRate})
+	}
+
+	for _, r := range due {
+		if r.rate <= 0 {
+			continue
+		}
+		if _, err := l.Credit(r.entityType, r.entityID, r.rate, ReasonRegeneration); err != nil {
+			logrus.Errorf("Ledger: Failed to regenerate budget of %s %s: %v", r.entityType, r.entityID, err)
+		}
+	}
+}
+
+// budget points at an entity's budget fields and saves the entity.
+type budget struct {
+	current *float64
+	max     *float64
+	save    func() error
+}
+
+func (l *Ledger) load(entityType, entityID string) (*budget, error) {
+	switch entityType {
+	case models.InfluenceOwner:
+		owner, err := l.ownerDAL.GetOwnerByID(entityID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get owner %s: %w", entityID, err)
+		}
+		if owner != nil {
+			return &budget{&owner.CurrentInfluenceBudget, &owner.MaxInfluenceBudget, func() error { return l.ownerDAL.UpdateOwner(owner) }}, nil
+		}
+	case models.InfluenceQuestmaker:
+		questmaker, err := l.questmakerDAL.GetQuestmakerByID(entityID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get questmaker %s: %w", entityID, err)
+		}
+		if questmaker != nil {
+			return &budget{&questmaker.CurrentInfluenceBudget, &questmaker.MaxInfluenceBudget, func() error { return l.questmakerDAL.UpdateQuestmaker(questmaker) }}, nil
+		}
+	case models.InfluenceQuestOwner:
+		questOwner, err := l.questOwnerDAL.GetQuestOwnerByID(entityID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get quest owner %s: %w", entityID, err)
+		}
+		if questOwner != nil {
+			return &budget{&questOwner.CurrentInfluenceBudget, &questOwner.MaxInfluenceBudget, func() error { return l.questOwnerDAL.UpdateQuestOwner(questOwner) }}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoBudget, entityType, entityID)
+}
+
+func (l *Ledger) record(entry *models.InfluenceEntry) error {
+	if err := l.influenceDAL.CreateInfluenceEntry(entry); err != nil {
+		return fmt.Errorf("failed to record influence change for %s %s: %w", entry.EntityType, entry.EntityID, err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package influence
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/dal"
+	"mud/internal/models"
+	"mud/internal/testutils/testdal"
+)
+
+func setupLedger(t *testing.T) (*Ledger, *dal.DAL) {
+	t.Helper()
+
+	dals := testdal.New(t)
+	if err := dals.OwnerDAL.CreateOwner(&models.Owner{ID: "spirit", Name: "Spirit", CurrentInfluenceBudget: 30, MaxInfluenceBudget: 40, BudgetRegenRate: 4}); err != nil {
+		t.Fatalf("Failed to create owner: %v", err)
+	}
+	if err := dals.QuestmakerDAL.CreateQuestmaker(&models.Questmaker{ID: "hunt", Name: "Hunt", CurrentInfluenceBudget: 0, MaxInfluenceBudget: 100, BudgetRegenRate: 5}); err != nil {
+		t.Fatalf("Failed to create questmaker: %v", err)
+	}
+	return NewLedger(dals.OwnerDAL, dals.QuestmakerDAL, dals.QuestOwnerDAL, dals.InfluenceDAL), dals
+}
+
+func TestLedger_Charge(t *testing.T) {
+	l, dals := setupLedger(t)
+
+	balance, err := l.Charge(models.InfluenceOwner, "spirit", 25, "trigger_world_event")
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, balance)
+
+	// A charge the budget cannot cover is refused and changes nothing.
+	balance, err = l.Charge(models.InfluenceOwner, "spirit", 10, "spawn_entity")
+	assert.True(t, errors.Is(err, ErrInsufficientBudget))
+	assert.Equal(t, 5.0, balance)
+	owner, err := dals.OwnerDAL.GetOwnerByID("spirit")
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, owner.CurrentInfluenceBudget)
+
+	_, err = l.Charge(models.InfluenceOwner, "nobody", 1, "send_message")
+	assert.True(t, errors.Is(err, ErrNoBudget))
+
+	entries, err := dals.InfluenceDAL.GetInfluenceEntries(models.InfluenceOwner, "spirit", 0)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "spawn_entity", entries[0].Reason)
+		assert.True(t, entries[0].Refused)
+		assert.Equal(t, -10.0, entries[0].Amount)
+		assert.Equal(t, 5.0, entries[0].Balance)
+		assert.Equal(t, "trigger_world_event", entries[1].Reason)
+		assert.False(t, entries[1].Refused)
+		assert.Equal(t, -25.0, entries[1].Amount)
+	}
+}
+
+func TestLedger_Regenerate(t *testing.T) {
+	l, dals := setupLedger(t)
+
+	l.Regenerate()
+	l.Regenerate()
+	l.Regenerate()
+
+	current, max, err := l.Balance(models.InfluenceOwner, "spirit")
+	assert.NoError(t, err)
+	assert.Equal(t, 40.0, current, "Budgets stop at their maximum")
+	assert.Equal(t, 40.0, max)
+	current, _, err = l.Balance(models.InfluenceQuestmaker, "hunt")
+	assert.NoError(t, err)
+	assert.Equal(t, 15.0, current)
+
+	entries, err := dals.InfluenceDAL.GetInfluenceEntries(models.InfluenceOwner, "spirit", 0)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 3, "Nothing is recorded once the budget is full") {
+		assert.Equal(t, 2.0, entries[0].Amount)
+		assert.Equal(t, ReasonRegeneration, entries[0].Reason)
+	}
+	entries, err = dals.InfluenceDAL.GetInfluenceEntries("", "", 2)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
@@ -27,7 +27,12 @@ type LLMServiceInterface interface {
 
 // ToolDispatcherInterface defines the methods used by SentientEntityManager on ToolDispatcher.
 type ToolDispatcherInterface interface {
-	Dispatch(ctx context.Context, player *models.PlayerCharacter, entity interface{}, toolCalls []llm.ToolCall) error
+	Dispatch(ctx context.Context, player *models.PlayerCharacter, entity interface{}, toolCalls []llm.ToolCall) ([]llm.ToolResult, error)
+}
+
+// InfluenceLedgerInterface defines the methods used by GlobalObserverManager on the influence Ledger.
+type InfluenceLedgerInterface interface {
+	Credit(entityType, entityID string, amount float64, reason string) (float64, error)
 }
 
 // TelnetRendererInterface defines the methods used by SentientEntityManager on TelnetRenderer.
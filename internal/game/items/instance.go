@@ -0,0 +1,59 @@
+package items
+
+import (
+	"encoding/json"
+
+	"mud/internal/models"
+)
+
+// Resolve returns the template of an instance with the instance's name and
+// property overrides applied. The result keeps the template's ID.
+func Resolve(instance *models.ItemInstance) *models.Item {
+	item := &models.Item{ID: instance.TemplateID, Name: instance.TemplateID, Properties: "{}"}
+	if instance.Template != nil {
+		*item = *instance.Template
+	}
+	if instance.Name != "" {
+		item.Name = instance.Name
+	}
+
+	overrides := decodeProperties(instance.Properties)
+	if len(overrides) > 0 {
+		properties := decodeProperties(item.Properties)
+		for k, v := range overrides {
+			properties[k] = v
+		}
+		if propertiesJSON, err := json.Marshal(properties); err == nil {
+			item.Properties = string(propertiesJSON)
+		}
+	}
+	return item
+}
+
+// Name returns the name players see for an instance.
+func Name(instance *models.ItemInstance) string {
+	return Resolve(instance).Name
+}
+
+// Stackable reports whether an instance can be merged with other instances of
+// its template. Only templates marked "stackable" stack, and only while the
+// instance has no custom name or property overrides and is not equipped.
+func Stackable(instance *models.ItemInstance) bool {
+	if instance.Template == nil || instance.Name != "" || instance.Slot != "" || len(decodeProperties(instance.Properties)) > 0 {
+		return false
+	}
+	stackable, _ := decodeProperties(instance.Template.Properties)["stackable"].(bool)
+	return stackable
+}
+
+// decodeProperties parses a JSON properties object, treating invalid JSON as empty.
+func decodeProperties(properties string) map[string]interface{} {
+	decoded := make(map[string]interface{})
+	if properties != "" {
+		json.Unmarshal([]byte(properties), &decoded)
+	}
+	if decoded == nil {
+		decoded = make(map[string]interface{})
+	}
+	return decoded
+}
@@ -0,0 +1,511 @@
+package items
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"mud/internal/dal"
+	"mud/internal/models"
+)
+
+var (
+	// ErrItemNotFound is returned when no item matches the player's description.
+	ErrItemNotFound = errors.New("item not found")
+	// ErrTargetNotFound is returned when the recipient of an item is not present.
+	ErrTargetNotFound = errors.New("target not found")
+	// ErrNotWearable is returned when wearing an item that has no armor slot.
+	ErrNotWearable = errors.New("item cannot be worn")
+	// ErrNotWieldable is returned when wielding an item that is not a weapon.
+	ErrNotWieldable = errors.New("item cannot be wielded")
+	// ErrNotEquipped is returned when removing an item that is not equipped.
+	ErrNotEquipped = errors.New("item is not equipped")
+	// ErrUnknownTemplate is returned when spawning an item whose template does not exist.
+	ErrUnknownTemplate = errors.New("unknown item template")
+)
+
+// Manager moves item instances between rooms, characters and NPCs and keeps
+// track of what characters have equipped. All changes are persisted through the DAL.
+type Manager struct {
+	roomDAL     dal.RoomDALInterface
+	itemDAL     dal.ItemDALInterface
+	instanceDAL dal.ItemInstanceDALInterface
+	npcDAL      dal.NPCDALInterface
+	mu          sync.Mutex // Serialises this manager's moves; other managers' are caught by the DAL
+}
+
+// NewManager creates a new Manager.
+func NewManager(roomDAL dal.RoomDALInterface, itemDAL dal.ItemDALInterface, instanceDAL dal.ItemInstanceDALInterface, npcDAL dal.NPCDALInterface) *Manager {
+	return &Manager{
+		roomDAL:     roomDAL,
+		itemDAL:     itemDAL,
+		instanceDAL: instanceDAL,
+		npcDAL:      npcDAL,
+	}
+}
+
+// Inventory returns the items a character is carrying, excluding equipped items.
+func (m *Manager) Inventory(character *models.PlayerCharacter) ([]*models.ItemInstance, error) {
+	held, err := m.held(character)
+	if err != nil {
+		return nil, err
+	}
+	var carried []*models.ItemInstance
+	for _, instance := range held {
+		if instance.Slot == "" {
+			carried = append(carried, instance)
+		}
+	}
+	return carried, nil
+}
+
+// Equipment returns the items a character has equipped, keyed by slot.
+func (m *Manager) Equipment(character *models.PlayerCharacter) (map[string]*models.ItemInstance, error) {
+	held, err := m.held(character)
+	if err != nil {
+		return nil, err
+	}
+	equipped := make(map[string]*models.ItemInstance)
+	for _, instance := range held {
+		if instance.Slot != "" {
+			equipped[instance.Slot] = instance
+		}
+	}
+	return equipped, nil
+}
+
+// RoomItems returns the items lying in a room.
+func (m *Manager) RoomItems(roomID string) ([]*models.ItemInstance, error) {
+	return m.at(models.LocationRoom, roomID)
+}
+
+// PickUp moves an item, or a whole stack, from the character's room into their inventory.
+func (m *Manager) PickUp(character *models.PlayerCharacter, query string) (*models.ItemInstance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	roomItems, err := m.at(models.LocationRoom, character.CurrentRoomID)
+	if err != nil {
+		return nil, err
+	}
+	instance := matchInstance(roomItems, query)
+	if instance == nil {
+		return nil, ErrItemNotFound
+	}
+	return m.move(instance, instance.Quantity, models.LocationCharacter, character.ID)
+}
+
+// Gather adds one of the room's gatherable items to the character's inventory.
+// Gatherable sources are not used up.
+func (m *Manager) Gather(character *models.PlayerCharacter, query string) (*models.ItemInstance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sources, err := m.gatherables(character.CurrentRoomID)
+	if err != nil {
+		return nil, err
+	}
+	source := matchInstance(sources, query)
+	if source == nil {
+		return nil, ErrItemNotFound
+	}
+
+	source.LocationType, source.LocationID = models.LocationCharacter, character.ID
+	if err := m.add(source); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// Drop moves one of an item from the character's inventory to the floor of their room.
+func (m *Manager) Drop(character *models.PlayerCharacter, query string) (*models.ItemInstance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	instance, err := m.findCarried(character, query)
+	if err != nil {
+		return nil, err
+	}
+	return m.move(instance, 1, models.LocationRoom, character.CurrentRoomID)
+}
+
+// Spawn creates a new item from its template at the instance's location,
+// merging it into a stack already there when possible. A quantity below one
+// spawns a single item. It returns the instance, whose ID is the stack it
+// ended up in.
+func (m *Manager) Spawn(instance *models.ItemInstance) (*models.ItemInstance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	template, err := m.itemDAL.GetItemByID(instance.TemplateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item %s: %w", instance.TemplateID, err)
+	}
+	if template == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, instance.TemplateID)
+	}
+	spawned := *instance
+	spawned.Template = template
+	spawned.Quantity = max(1, spawned.Quantity)
+	if err := m.add(&spawned); err != nil {
+		return nil, err
+	}
+	return &spawned, nil
+}
+
+// Give transfers one of an item from the character's inventory to an NPC in the same room.
+func (m *Manager) Give(character *models.PlayerCharacter, itemQuery, npcQuery string) (*models.ItemInstance, *models.NPC, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	instance, err := m.findCarried(character, itemQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	npcs, err := m.npcDAL.GetNPCsByRoom(character.CurrentRoomID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get NPCs in room: %w", err)
+	}
+	npc := matchNPC(npcs, npcQuery)
+	if npc == nil {
+		return nil, nil, ErrTargetNotFound
+	}
+
+	given, err := m.move(instance, 1, models.LocationNPC, npc.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return given, npc, nil
+}
+
+// Equip moves one of an item from the character's inventory into its equipment
+// slot. Weapons must be wielded and everything else with a slot must be worn.
+// Any item already in the slot goes back to the inventory and is returned as replaced.
+func (m *Manager) Equip(character *models.PlayerCharacter, query string, wield bool) (item, replaced *models.ItemInstance, slot string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	instance, err := m.findCarried(character, query)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	slot, ok := Slot(Resolve(instance))
+	switch {
+	case wield && (!ok || slot != SlotWielded):
+		return nil, nil, "", ErrNotWieldable
+	case !wield && (!ok || slot == SlotWielded):
+		return nil, nil, "", ErrNotWearable
+	}
+
+	equipped, err := m.Equipment(character)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if previous := equipped[slot]; previous != nil {
+		if replaced, err = m.move(previous, previous.Quantity, models.LocationCharacter, character.ID); err != nil {
+			return nil, nil, "", err
+		}
+	}
+
+	if instance.Quantity > 1 {
+		if instance, err = m.split(instance, 1); err != nil {
+			return nil, nil, "", err
+		}
+	}
+	if err := m.setSlot(instance, slot); err != nil {
+		return nil, nil, "", err
+	}
+	return instance, replaced, slot, nil
+}
+
+// Unequip moves an equipped item back into the character's inventory. The query
+// may name the item or the slot it occupies.
+func (m *Manager) Unequip(character *models.PlayerCharacter, query string) (*models.ItemInstance, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	equipped, err := m.Equipment(character)
+	if err != nil {
+		return nil, "", err
+	}
+	instance := equipped[strings.ToLower(query)]
+	if instance == nil {
+		for _, slot := range SortedSlots(equipped) {
+			if matchInstance([]*models.ItemInstance{equipped[slot]}, query) != nil {
+				instance = equipped[slot]
+				break
+			}
+		}
+	}
+	if instance == nil {
+		return nil, "", ErrNotEquipped
+	}
+
+	slot := instance.Slot
+	removed, err := m.move(instance, instance.Quantity, models.LocationCharacter, character.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	return removed, slot, nil
+}
+
+// Examine finds an item the character can see: something equipped, carried,
+// lying in the room or growing there.
+func (m *Manager) Examine(character *models.PlayerCharacter, query string) (*models.ItemInstance, error) {
+	held, err := m.held(character)
+	if err != nil {
+		return nil, err
+	}
+	// Equipped items sort first so "examine sword" finds the one in hand.
+	sort.SliceStable(held, func(i, j int) bool { return held[i].Slot != "" && held[j].Slot == "" })
+	if instance := matchInstance(held, query); instance != nil {
+		return instance, nil
+	}
+
+	roomItems, err := m.RoomItems(character.CurrentRoomID)
+	if err != nil {
+		return nil, err
+	}
+	if instance := matchInstance(roomItems, query); instance != nil {
+		return instance, nil
+	}
+
+	sources, err := m.gatherables(character.CurrentRoomID)
+	if err != nil {
+		return nil, err
+	}
+	if source := matchInstance(sources, query); source != nil {
+		return source, nil
+	}
+	return nil, ErrItemNotFound
+}
+
+// gatherables returns an unsaved instance for each template that can be
+// gathered in a room. They are listed by template ID under "gatherables" in the
+// room's properties.
+func (m *Manager) gatherables(roomID string) ([]*models.ItemInstance, error) {
+	room, err := m.roomDAL.GetRoomByID(roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get room %s: %w", roomID, err)
+	}
+	if room == nil {
+		return nil, fmt.Errorf("room with ID %s not found", roomID)
+	}
+	var properties struct {
+		Gatherables []string `json:"gatherables"`
+	}
+	if room.Properties != "" {
+		if err := json.Unmarshal([]byte(room.Properties), &properties); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal room properties: %w", err)
+		}
+	}
+
+	var sources []*models.ItemInstance
+	for _, templateID := range properties.Gatherables {
+		template, err := m.itemDAL.GetItemByID(templateID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get item %s: %w", templateID, err)
+		}
+		if template != nil {
+			sources = append(sources, &models.ItemInstance{TemplateID: template.ID, Quantity: 1, Template: template})
+		}
+	}
+	return sources, nil
+}
+
+// held returns every item instance the character holds, equipped or not.
+func (m *Manager) held(character *models.PlayerCharacter) ([]*models.ItemInstance, error) {
+	return m.at(models.LocationCharacter, character.ID)
+}
+
+// at returns the item instances at a location whose templates still exist.
+func (m *Manager) at(locationType, locationID string) ([]*models.ItemInstance, error) {
+	instances, err := m.instanceDAL.GetInstancesByLocation(locationType, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items: %w", err)
+	}
+	var resolved []*models.ItemInstance
+	for _, instance := range instances {
+		if instance.Template != nil {
+			resolved = append(resolved, instance)
+		}
+	}
+	return resolved, nil
+}
+
+// findCarried resolves a query against the character's unequipped items.
+func (m *Manager) findCarried(character *models.PlayerCharacter, query string) (*models.ItemInstance, error) {
+	carried, err := m.Inventory(character)
+	if err != nil {
+		return nil, err
+	}
+	instance := matchInstance(carried, query)
+	if instance == nil {
+		return nil, ErrItemNotFound
+	}
+	return instance, nil
+}
+
+// move transfers quantity units of an instance to a new location, splitting the
+// stack when only part of it moves and merging into a matching stack at the
+// destination. It returns a copy of the instance describing what moved.
+//
+// The units are taken from the instance only while it is still as it was
+// read, so that of two managers moving the same item, one finds it gone. A
+// merge takes the units and adds them to the stack in one transaction, and
+// only while the stack is still there, so that no units are lost or doubled.
+func (m *Manager) move(instance *models.ItemInstance, quantity int, locationType, locationID string) (*models.ItemInstance, error) {
+	moved := *instance
+	moved.Quantity = quantity
+	moved.LocationType, moved.LocationID, moved.Slot = locationType, locationID, ""
+
+	if quantity < instance.Quantity {
+		remaining := *instance
+		remaining.Quantity -= quantity
+		if err := m.instanceDAL.UpdateInstanceIfUnchanged(instance, &remaining); err != nil {
+			return nil, takeFailed("split item stack", err)
+		}
+		moved.ID = ""
+		if err := m.add(&moved); err != nil {
+			return nil, err
+		}
+		return &moved, nil
+	}
+
+	if target, err := m.stackFor(&moved); err != nil {
+		return nil, err
+	} else if target != nil {
+		if err := m.instanceDAL.MergeInstance(instance, target); err != nil {
+			return nil, takeFailed("merge item stack", err)
+		}
+		moved.ID = target.ID
+		return &moved, nil
+	}
+
+	if err := m.instanceDAL.UpdateInstanceIfUnchanged(instance, &moved); err != nil {
+		return nil, takeFailed("move item", err)
+	}
+	return &moved, nil
+}
+
+// takeFailed reports a failure to take units of an item. An item that
+// changed since it was read was taken by someone else, and is no longer
+// there to be found.
+func takeFailed(action string, err error) error {
+	if errors.Is(err, dal.ErrInstanceChanged) {
+		return ErrItemNotFound
+	}
+	return fmt.Errorf("failed to %s: %w", action, err)
+}
+
+// add places a new instance at its location, merging it into an existing stack
+// when possible. On return the instance's ID is the stack it ended up in.
+func (m *Manager) add(instance *models.ItemInstance) error {
+	target, err := m.stackFor(instance)
+	if err != nil {
+		return err
+	}
+	if target != nil {
+		// The stack may have been taken away since it was found, in which
+		// case a new one is started.
+		err := m.instanceDAL.AddInstanceQuantity(target, instance.Quantity)
+		if err == nil {
+			instance.ID = target.ID
+			return nil
+		}
+		if !errors.Is(err, dal.ErrInstanceChanged) {
+			return fmt.Errorf("failed to merge item stack: %w", err)
+		}
+	}
+
+	instance.ID = ""
+	if err := m.instanceDAL.CreateInstance(instance); err != nil {
+		return fmt.Errorf("failed to create item: %w", err)
+	}
+	return nil
+}
+
+// split takes quantity units off a stack into a new instance at the same location.
+func (m *Manager) split(instance *models.ItemInstance, quantity int) (*models.ItemInstance, error) {
+	remaining := *instance
+	remaining.Quantity -= quantity
+	if err := m.instanceDAL.UpdateInstanceIfUnchanged(instance, &remaining); err != nil {
+		return nil, takeFailed("split item stack", err)
+	}
+	part := *instance
+	part.ID = ""
+	part.Quantity = quantity
+	if err := m.instanceDAL.CreateInstance(&part); err != nil {
+		return nil, fmt.Errorf("failed to split item stack: %w", err)
+	}
+	return &part, nil
+}
+
+// stackFor finds an instance at the destination of instance that it can merge into.
+func (m *Manager) stackFor(instance *models.ItemInstance) (*models.ItemInstance, error) {
+	if !Stackable(instance) {
+		return nil, nil
+	}
+	existing, err := m.at(instance.LocationType, instance.LocationID)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range existing {
+		if candidate.ID != instance.ID && candidate.TemplateID == instance.TemplateID && Stackable(candidate) {
+			return candidate, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *Manager) setSlot(instance *models.ItemInstance, slot string) error {
+	updated := *instance
+	updated.Slot = slot
+	if err := m.instanceDAL.UpdateInstance(&updated); err != nil {
+		return fmt.Errorf("failed to update equipment: %w", err)
+	}
+	instance.Slot = slot
+	return nil
+}
+
+// matchInstance finds the item a player means. An exact ID or name wins, then
+// the first item whose name contains the query.
+func matchInstance(instances []*models.ItemInstance, query string) *models.ItemInstance {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+	for _, instance := range instances {
+		if strings.ToLower(instance.ID) == query || strings.ToLower(instance.TemplateID) == query || strings.ToLower(Name(instance)) == query {
+			return instance
+		}
+	}
+	for _, instance := range instances {
+		if strings.Contains(strings.ToLower(Name(instance)), query) || strings.Contains(strings.ToLower(instance.TemplateID), query) {
+			return instance
+		}
+	}
+	return nil
+}
+
+// matchNPC finds the NPC a player means, by ID, name or part of the name.
+func matchNPC(npcs []*models.NPC, query string) *models.NPC {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+	for _, npc := range npcs {
+		if strings.ToLower(npc.ID) == query || strings.ToLower(npc.Name) == query {
+			return npc
+		}
+	}
+	for _, npc := range npcs {
+		if strings.Contains(strings.ToLower(npc.Name), query) {
+			return npc
+		}
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package items
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/dal"
+	"mud/internal/models"
+	"mud/internal/testutils/testdal"
+)
+
+func setupManager(t *testing.T) (*Manager, *dal.DAL, *models.PlayerCharacter) {
+	t.Helper()
+
+	dals := testdal.New(t)
+	for _, item := range []*models.Item{
+		{ID: "sword", Name: "a short sword", Type: "weapon", Properties: "{}"},
+		{ID: "helm", Name: "an iron helm", Type: "armor", Properties: `{"slot": "head"}`},
+		{ID: "cap", Name: "a felt cap", Type: "armor", Properties: `{"slot": "head"}`},
+		{ID: "apple", Name: "a red apple", Type: "consumable", Properties: `{"stackable": true}`},
+	} {
+		if err := dals.ItemDAL.CreateItem(item); err != nil {
+			t.Fatalf("Failed to create item: %v", err)
+		}
+	}
+	room := &models.Room{ID: "orchard", Name: "Orchard", Exits: "{}", Properties: `{"gatherables": ["apple"]}`}
+	if err := dals.RoomDAL.CreateRoom(room); err != nil {
+		t.Fatalf("Failed to create room: %v", err)
+	}
+	for _, templateID := range []string{"sword", "helm", "cap"} {
+		instance := &models.ItemInstance{TemplateID: templateID, LocationType: models.LocationRoom, LocationID: "orchard"}
+		if err := dals.ItemInstanceDAL.CreateInstance(instance); err != nil {
+			t.Fatalf("Failed to create item instance: %v", err)
+		}
+	}
+	npc := &models.NPC{ID: "farmer", Name: "Farmer Brown", CurrentRoomID: "orchard", Inventory: []string{}}
+	if err := dals.NpcDAL.CreateNPC(npc); err != nil {
+		t.Fatalf("Failed to create NPC: %v", err)
+	}
+	character := &models.PlayerCharacter{ID: "hero", Name: "Hero", CurrentRoomID: "orchard", Inventory: "[]", VisitedRoomIDs: "[]"}
+	if err := dals.PlayerCharacterDAL.CreateCharacter(character); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	return NewManager(dals.RoomDAL, dals.ItemDAL, dals.ItemInstanceDAL, dals.NpcDAL), dals, character
+}
+
+func TestManager_PickUpAndDrop(t *testing.T) {
+	m, dals, character := setupManager(t)
+
+	item, err := m.PickUp(character, "SWORD")
+	assert.NoError(t, err)
+	assert.Equal(t, "sword", item.TemplateID)
+
+	_, err = m.PickUp(character, "sword")
+	assert.ErrorIs(t, err, ErrItemNotFound)
+
+	inventory, err := dals.ItemInstanceDAL.GetInstancesByLocation(models.LocationCharacter, "hero")
+	assert.NoError(t, err)
+	assert.Len(t, inventory, 1)
+	assert.Equal(t, item.ID, inventory[0].ID, "Picking up moves the instance rather than copying it")
+
+	roomItems, err := m.RoomItems("orchard")
+	assert.NoError(t, err)
+	assert.Len(t, roomItems, 2)
+
+	_, err = m.Drop(character, "short")
+	assert.NoError(t, err)
+	roomItems, err = m.RoomItems("orchard")
+	assert.NoError(t, err)
+	assert.Len(t, roomItems, 3)
+	carried, err := m.Inventory(character)
+	assert.NoError(t, err)
+	assert.Empty(t, carried)
+}
+
+func TestManager_ItemsAreTakenOnce(t *testing.T) {
+	m, dals, character := setupManager(t)
+	other := NewManager(dals.RoomDAL, dals.ItemDAL, dals.ItemInstanceDAL, dals.NpcDAL)
+	scout := &models.PlayerCharacter{ID: "scout", Name: "Scout", CurrentRoomID: "orchard", Inventory: "[]", VisitedRoomIDs: "[]"}
+	assert.NoError(t, dals.PlayerCharacterDAL.CreateCharacter(scout))
+
+	// Both managers see the sword on the floor, and the first to move it
+	// gets it; the other finds it gone, though it holds its own lock.
+	roomItems, err := other.RoomItems("orchard")
+	assert.NoError(t, err)
+	seen := matchInstance(roomItems, "sword")
+	_, err = m.PickUp(character, "sword")
+	assert.NoError(t, err)
+	_, err = other.move(seen, seen.Quantity, models.LocationCharacter, scout.ID)
+	assert.ErrorIs(t, err, ErrItemNotFound)
+
+	carried, err := other.Inventory(scout)
+	assert.NoError(t, err)
+	assert.Empty(t, carried)
+	carried, err = m.Inventory(character)
+	assert.NoError(t, err)
+	assert.Len(t, carried, 1)
+}
+
+func TestManager_GatherStacks(t *testing.T) {
+	m, _, character := setupManager(t)
+
+	for i := 0; i < 3; i++ {
+		item, err := m.Gather(character, "apple")
+		assert.NoError(t, err)
+		assert.Equal(t, "apple", item.TemplateID)
+	}
+	carried, err := m.Inventory(character)
+	assert.NoError(t, err)
+	assert.Len(t, carried, 1)
+	assert.Equal(t, 3, carried[0].Quantity)
+
+	_, err = m.Gather(character, "sword")
+	assert.ErrorIs(t, err, ErrItemNotFound, "Items on the floor are picked up, not gathered")
+
+	// Dropping takes one off the stack.
+	dropped, err := m.Drop(character, "apple")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, dropped.Quantity)
+	carried, err = m.Inventory(character)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, carried[0].Quantity)
+
+	// Picking up merges the stack back into the one being carried.
+	_, err = m.PickUp(character, "apple")
+	assert.NoError(t, err)
+	carried, err = m.Inventory(character)
+	assert.NoError(t, err)
+	assert.Len(t, carried, 1)
+	assert.Equal(t, 3, carried[0].Quantity)
+}
+
+func TestManager_MergesStacksAtomically(t *testing.T) {
+	m, dals, character := setupManager(t)
+	for i := 0; i < 3; i++ {
+		_, err := m.Gather(character, "apple")
+		assert.NoError(t, err)
+	}
+	_, err := m.Drop(character, "apple")
+	assert.NoError(t, err)
+	carried, err := m.Inventory(character)
+	assert.NoError(t, err)
+	stack := carried[0]
+	roomItems, err := m.RoomItems("orchard")
+	assert.NoError(t, err)
+	fallen := matchInstance(roomItems, "apple")
+
+	// Apples added to the stack since it was read are kept by a merge.
+	assert.NoError(t, dals.ItemInstanceDAL.AddInstanceQuantity(stack, 5))
+	assert.NoError(t, dals.ItemInstanceDAL.MergeInstance(fallen, stack))
+	carried, err = m.Inventory(character)
+	assert.NoError(t, err)
+	assert.Len(t, carried, 1)
+	assert.Equal(t, 8, carried[0].Quantity)
+
+	// A merge into a stack that was taken away changes nothing.
+	_, err = m.Drop(character, "apple")
+	assert.NoError(t, err)
+	roomItems, err = m.RoomItems("orchard")
+	assert.NoError(t, err)
+	fallen = matchInstance(roomItems, "apple")
+	carried, err = m.Inventory(character)
+	assert.NoError(t, err)
+	stack = carried[0]
+	assert.NoError(t, dals.ItemInstanceDAL.DeleteInstance(stack.ID))
+	assert.ErrorIs(t, dals.ItemInstanceDAL.MergeInstance(fallen, stack), dal.ErrInstanceChanged)
+	roomItems, err = m.RoomItems("orchard")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, matchInstance(roomItems, "apple").Quantity, "The apple is not lost")
+
+	// With the stack gone, picking the apple up starts a new one.
+	_, err = m.PickUp(character, "apple")
+	assert.NoError(t, err)
+	carried, err = m.Inventory(character)
+	assert.NoError(t, err)
+	assert.Len(t, carried, 1)
+	assert.Equal(t, 1, carried[0].Quantity)
+}
+
+func TestManager_Spawn(t *testing.T) {
+	m, _, character := setupManager(t)
+
+	for i := 0; i < 2; i++ {
+		_, err := m.Spawn(&models.ItemInstance{TemplateID: "apple", Quantity: 2, LocationType: models.LocationCharacter, LocationID: character.ID})
+		assert.NoError(t, err)
+	}
+	carried, err := m.Inventory(character)
+	assert.NoError(t, err)
+	if assert.Len(t, carried, 1, "Spawned items join a stack already there") {
+		assert.Equal(t, 4, carried[0].Quantity)
+	}
+
+	spawned, err := m.Spawn(&models.ItemInstance{TemplateID: "sword", LocationType: models.LocationCharacter, LocationID: character.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, spawned.Quantity)
+	assert.NotEmpty(t, spawned.ID)
+
+	_, err = m.Spawn(&models.ItemInstance{TemplateID: "unicorn", LocationType: models.LocationRoom, LocationID: "orchard"})
+	assert.ErrorIs(t, err, ErrUnknownTemplate)
+}
+
+func TestManager_InstanceOverrides(t *testing.T) {
+	m, dals, character := setupManager(t)
+
+	named := &models.ItemInstance{TemplateID: "sword", Name: "Sting", Properties: `{"glows_near_orcs": true}`, LocationType: models.LocationCharacter, LocationID: "hero"}
+	assert.NoError(t, dals.ItemInstanceDAL.CreateInstance(named))
+	_, err := m.PickUp(character, "sword")
+	assert.NoError(t, err)
+
+	item, err := m.Examine(character, "sting")
+	assert.NoError(t, err)
+	assert.Equal(t, named.ID, item.ID)
+	resolved := Resolve(item)
+	assert.Equal(t, "Sting", resolved.Name)
+	assert.Equal(t, "weapon", resolved.Type)
+	assert.JSONEq(t, `{"glows_near_orcs": true}`, resolved.Properties)
+
+	item, err = m.Examine(character, "short sword")
+	assert.NoError(t, err)
+	assert.NotEqual(t, named.ID, item.ID, "Two swords from one template stay distinct")
+}
+
+func TestManager_EquipAndUnequip(t *testing.T) {
+	m, _, character := setupManager(t)
+	for _, id := range []string{"sword", "helm", "cap"} {
+		_, err := m.PickUp(character, id)
+		assert.NoError(t, err)
+	}
+
+	_, _, _, err := m.Equip(character, "sword", false)
+	assert.ErrorIs(t, err, ErrNotWearable)
+	_, _, _, err = m.Equip(character, "helm", true)
+	assert.ErrorIs(t, err, ErrNotWieldable)
+
+	_, _, slot, err := m.Equip(character, "sword", true)
+	assert.NoError(t, err)
+	assert.Equal(t, SlotWielded, slot)
+
+	_, replaced, slot, err := m.Equip(character, "helm", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "head", slot)
+	assert.Nil(t, replaced)
+
+	// Wearing a second item in the same slot swaps the first back into the inventory.
+	_, replaced, _, err = m.Equip(character, "cap", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "helm", replaced.TemplateID)
+	carried, err := m.Inventory(character)
+	assert.NoError(t, err)
+	assert.Len(t, carried, 1)
+	assert.Equal(t, "helm", carried[0].TemplateID)
+	equipped, err := m.Equipment(character)
+	assert.NoError(t, err)
+	assert.Equal(t, "sword", equipped[SlotWielded].TemplateID)
+	assert.Equal(t, "cap", equipped["head"].TemplateID)
+
+	item, slot, err := m.Unequip(character, "head")
+	assert.NoError(t, err)
+	assert.Equal(t, "cap", item.TemplateID)
+	assert.Equal(t, "head", slot)
+
+	_, _, err = m.Unequip(character, "cap")
+	assert.ErrorIs(t, err, ErrNotEquipped)
+
+	// Equipped items can be examined but not dropped.
+	examined, err := m.Examine(character, "sword")
+	assert.NoError(t, err)
+	assert.Equal(t, "sword", examined.TemplateID)
+	_, err = m.Drop(character, "sword")
+	assert.ErrorIs(t, err, ErrItemNotFound)
+}
+
+func TestManager_Give(t *testing.T) {
+	m, dals, character := setupManager(t)
+	_, err := m.Gather(character, "apple")
+	assert.NoError(t, err)
+
+	_, _, err = m.Give(character, "apple", "nobody")
+	assert.ErrorIs(t, err, ErrTargetNotFound)
+
+	item, npc, err := m.Give(character, "apple", "farmer")
+	assert.NoError(t, err)
+	assert.Equal(t, "apple", item.TemplateID)
+	assert.Equal(t, "farmer", npc.ID)
+
+	held, err := dals.ItemInstanceDAL.GetInstancesByLocation(models.LocationNPC, "farmer")
+	assert.NoError(t, err)
+	assert.Len(t, held, 1)
+	assert.Equal(t, "apple", held[0].TemplateID)
+	carried, err := m.Inventory(character)
+	assert.NoError(t, err)
+	assert.Empty(t, carried)
+}
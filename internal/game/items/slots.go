@@ -0,0 +1,52 @@
+package items
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"mud/internal/models"
+)
+
+// Equipment slots. Armor names its slot in Item.Properties ("slot": "head");
+// armor without one is worn on the body.
+const (
+	SlotWielded = "wielded"
+	SlotBody    = "body"
+)
+
+// Slot returns the equipment slot an item occupies, and false if the item cannot be equipped.
+func Slot(item *models.Item) (string, bool) {
+	var properties struct {
+		Slot string `json:"slot"`
+	}
+	if item.Properties != "" {
+		json.Unmarshal([]byte(item.Properties), &properties) // Items without valid properties fall back to their type
+	}
+
+	switch strings.ToLower(item.Type) {
+	case "weapon":
+		return SlotWielded, true
+	case "armor":
+		if properties.Slot != "" {
+			return strings.ToLower(properties.Slot), true
+		}
+		return SlotBody, true
+	default:
+		if properties.Slot != "" {
+			return strings.ToLower(properties.Slot), true
+		}
+		return "", false
+	}
+}
+
+// SortedSlots returns the slots of a character's equipment in order, so that
+// equipment is always listed the same way.
+func SortedSlots(equipped map[string]*models.ItemInstance) []string {
+	slots := make([]string, 0, len(equipped))
+	for slot := range equipped {
+		slots = append(slots, slot)
+	}
+	sort.Strings(slots)
+	return slots
+}
@@ -0,0 +1,257 @@
+package llmqueue
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"mud/internal/game/scheduler"
+)
+
+// ErrFull is returned when a job is submitted to a queue that has no room
+// for it.
+var ErrFull = errors.New("LLM queue is full")
+
+// Priority decides which waiting job runs first. Higher priorities run
+// before lower ones, and jobs of the same priority in the order submitted.
+type Priority int
+
+const (
+	// Deliberation is background work, such as Owners and Questmakers
+	// weighing what a player has done.
+	Deliberation Priority = iota
+	// Dialogue is an entity answering a player in front of it.
+	Dialogue
+)
+
+// DropReason tells why a job was dropped.
+type DropReason int
+
+const (
+	// Stale jobs waited longer than MaxWait allows for their priority.
+	Stale DropReason = iota
+	// Evicted jobs were pushed out of a full queue to make room for a job of
+	// a higher priority.
+	Evicted
+	// Superseded jobs were replaced by a newer job with the same key.
+	Superseded
+)
+
+// Defaults of a new Queue.
+const (
+	DefaultMaxConcurrent = 4
+	DefaultMaxPending    = 64
+)
+
+// Job is work for the LLM, such as an entity reacting to a player.
+type Job struct {
+	// Entity is the ID of the entity the job is for. An entity's jobs run one
+	// at a time, so it never has two replies in flight.
+	Entity string
+	// Key, if set, lets a job supersede an earlier one with the same key that
+	// has not started yet. The newer job takes the older one's place in line.
+	Key      string
+	Priority Priority
+	Run      func()
+	// Drop, if set, is called instead of Run when the job will not run: it
+	// waited too long to start, was pushed out of a full queue or was
+	// superseded.
+	Drop func(reason DropReason)
+}
+
+type droppedJob struct {
+	job    Job
+	reason DropReason
+}
+
+type queued struct {
+	job       Job
+	submitted time.Time
+	seq       uint64
+}
+
+// Queue runs LLM jobs on their own goroutines, at most MaxConcurrent at a
+// time and one at a time for each entity. Jobs that cannot start yet wait,
+// and are dropped if they wait longer than MaxWait allows for their
+// priority, as whoever they were for has moved on.
+type Queue struct {
+	// MaxConcurrent is how many jobs may run at once.
+	MaxConcurrent int
+	// MaxPending is how many jobs may wait. A full queue makes room for a
+	// job by dropping the oldest waiting job of a lower priority.
+	MaxPending int
+	// MaxWait is how long a job of each priority may wait to start. Jobs of
+	// priorities missing from it may wait as long as it takes.
+	MaxWait map[Priority]time.Duration
+
+	clock    scheduler.Clock
+	mu       sync.Mutex
+	idle     *sync.Cond
+	pending  []*queued
+	busy     map[string]bool // Entities with a job running
+	running  int
+	dropping int // Dropped jobs still being told
+	seq      uint64
+}
+
+// NewQueue creates an empty Queue that tells the time by clock.
+func NewQueue(clock scheduler.Clock) *Queue {
+	q := &Queue{
+		MaxConcurrent: DefaultMaxConcurrent,
+		MaxPending:    DefaultMaxPending,
+		MaxWait: map[Priority]time.Duration{
+			Dialogue:     30 * time.Second,
+			Deliberation: 2 * time.Minute,
+		},
+		clock: clock,
+		busy:  make(map[string]bool),
+	}
+	q.idle = sync.NewCond(&q.mu)
+	return q
+}
+
+// Submit adds a job to the queue, starting it at once if it can. It returns
+// ErrFull if the queue has no room for the job.
+func (q *Queue) Submit(job Job) error {
+	q.mu.Lock()
+	var dropped []droppedJob
+	defer func() {
+		q.mu.Unlock()
+		q.drop(dropped)
+	}()
+
+	now := q.clock.Now()
+	if job.Key != "" {
+		for _, waiting := range q.pending {
+			if waiting.job.Key == job.Key {
+				dropped = append(dropped, droppedJob{waiting.job, Superseded})
+				q.dropping++
+				waiting.job = job
+				waiting.submitted = now
+				dropped = append(dropped, q.dispatch()...)
+				return nil
+			}
+		}
+	}
+	if len(q.pending) >= q.MaxPending {
+		victim := -1
+		for i, waiting := range q.pending {
+			if waiting.job.Priority < job.Priority && (victim < 0 || waiting.job.Priority < q.pending[victim].job.Priority) {
+				victim = i
+			}
+		}
+		if victim < 0 {
+			return ErrFull
+		}
+		logrus.Warnf("LLM queue: Dropping job for entity %s to make room", q.pending[victim].job.Entity)
+		dropped = append(dropped, droppedJob{q.pending[victim].job, Evicted})
+		q.dropping++
+		q.pending = append(q.pending[:victim], q.pending[victim+1:]...)
+	}
+	q.seq++
+	q.pending = append(q.pending, &queued{job: job, submitted: now, seq: q.seq})
+	dropped = append(dropped, q.dispatch()...)
+	return nil
+}
+
+// dispatch drops the jobs that have waited too long and starts the ones that
+// can run, best first. It returns the jobs it dropped, to be told once the
+// lock is released. q.mu must be held.
+func (q *Queue) dispatch() []droppedJob {
+	now := q.clock.Now()
+	var dropped []droppedJob
+	waiting := q.pending[:0]
+	for _, candidate := range q.pending {
+		if maxWait, ok := q.MaxWait[candidate.job.Priority]; ok && now.Sub(candidate.submitted) > maxWait {
+			logrus.Warnf("LLM queue: Dropping stale job for entity %s after %s", candidate.job.Entity, now.Sub(candidate.submitted))
+			dropped = append(dropped, droppedJob{candidate.job, Stale})
+			q.dropping++
+			continue
+		}
+		waiting = append(waiting, candidate)
+	}
+	q.pending = waiting
+
+	for q.running < q.MaxConcurrent {
+		next := -1
+		for i, candidate := range q.pending {
+			if q.busy[candidate.job.Entity] {
+				continue
+			}
+			if next < 0 || candidate.job.Priority > q.pending[next].job.Priority ||
+				(candidate.job.Priority == q.pending[next].job.Priority && candidate.seq < q.pending[next].seq) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		job := q.pending[next].job
+		q.pending = append(q.pending[:next], q.pending[next+1:]...)
+		if job.Entity != "" {
+			q.busy[job.Entity] = true
+		}
+		q.running++
+		go q.run(job)
+	}
+
+	q.signalIdle()
+	return dropped
+}
+
+// signalIdle wakes those waiting for the queue to be idle, if it is. q.mu
+// must be held.
+func (q *Queue) signalIdle() {
+	if q.running == 0 && len(q.pending) == 0 && q.dropping == 0 {
+		q.idle.Broadcast()
+	}
+}
+
+func (q *Queue) run(job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.Errorf("LLM queue: Job for entity %s panicked: %v", job.Entity, r)
+		}
+		q.mu.Lock()
+		delete(q.busy, job.Entity)
+		q.running--
+		dropped := q.dispatch()
+		q.mu.Unlock()
+		q.drop(dropped)
+	}()
+	job.Run()
+}
+
+// drop tells jobs they were dropped, and why. q.mu must not be held.
+func (q *Queue) drop(jobs []droppedJob) {
+	if len(jobs) == 0 {
+		return
+	}
+	for _, dropped := range jobs {
+		if dropped.job.Drop != nil {
+			dropped.job.Drop(dropped.reason)
+		}
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.dropping -= len(jobs)
+	q.signalIdle()
+}
+
+// Len returns the number of jobs waiting to start.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Wait blocks until no job is running or waiting, and every dropped job has
+// been told.
+func (q *Queue) Wait() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.running > 0 || len(q.pending) > 0 || q.dropping > 0 {
+		q.idle.Wait()
+	}
+}
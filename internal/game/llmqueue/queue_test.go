@@ -0,0 +1,146 @@
+package llmqueue
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/game/scheduler"
+)
+
+// recorder records the jobs that ran and were dropped, and why, and can hold
+// jobs while they run.
+type recorder struct {
+	mu      sync.Mutex
+	ran     []string
+	dropped []string
+	reasons map[string]DropReason
+	release map[string]chan struct{}
+	started chan string
+}
+
+func newRecorder() *recorder {
+	return &recorder{reasons: make(map[string]DropReason), release: make(map[string]chan struct{}), started: make(chan string, 16)}
+}
+
+// job returns a job that records its name, and waits to be released if hold
+// is set.
+func (r *recorder) job(entity, name string, priority Priority, hold bool) Job {
+	var release chan struct{}
+	if hold {
+		release = make(chan struct{})
+		r.release[name] = release
+	}
+	return Job{
+		Entity:   entity,
+		Priority: priority,
+		Run: func() {
+			r.started <- name
+			if release != nil {
+				<-release
+			}
+			r.mu.Lock()
+			r.ran = append(r.ran, name)
+			r.mu.Unlock()
+		},
+		Drop: func(reason DropReason) {
+			r.mu.Lock()
+			r.dropped = append(r.dropped, name)
+			r.reasons[name] = reason
+			r.mu.Unlock()
+		},
+	}
+}
+
+func (r *recorder) results() ([]string, []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.ran...), append([]string(nil), r.dropped...)
+}
+
+func TestQueue_Limits(t *testing.T) {
+	q := NewQueue(scheduler.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	q.MaxConcurrent = 2
+	r := newRecorder()
+
+	assert.NoError(t, q.Submit(r.job("guard", "guard greets", Dialogue, true)))
+	assert.NoError(t, q.Submit(r.job("guard", "guard warns", Dialogue, false)))
+	assert.NoError(t, q.Submit(r.job("king", "king ponders", Deliberation, true)))
+	assert.NoError(t, q.Submit(r.job("smith", "smith haggles", Dialogue, false)))
+	assert.ElementsMatch(t, []string{"guard greets", "king ponders"}, []string{<-r.started, <-r.started})
+	assert.Equal(t, 2, q.Len(), "No more than two jobs run at once, and the guard's second waits for its first")
+
+	// The freed slot goes to the waiting dialogue rather than the guard,
+	// which is still busy.
+	close(r.release["king ponders"])
+	assert.Equal(t, "smith haggles", <-r.started)
+	close(r.release["guard greets"])
+	assert.Equal(t, "guard warns", <-r.started)
+	q.Wait()
+
+	ran, dropped := r.results()
+	assert.ElementsMatch(t, []string{"guard greets", "guard warns", "king ponders", "smith haggles"}, ran)
+	assert.Empty(t, dropped)
+}
+
+func TestQueue_Priorities(t *testing.T) {
+	q := NewQueue(scheduler.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	q.MaxConcurrent = 1
+	q.MaxPending = 3
+	r := newRecorder()
+
+	assert.NoError(t, q.Submit(r.job("guard", "guard greets", Dialogue, true)))
+	<-r.started
+	assert.NoError(t, q.Submit(r.job("king", "king ponders", Deliberation, false)))
+	assert.NoError(t, q.Submit(r.job("smith", "smith haggles", Dialogue, false)))
+	assert.NoError(t, q.Submit(r.job("queen", "queen schemes", Deliberation, false)))
+
+	// A full queue drops its oldest background work to make room for
+	// dialogue, but not dialogue for background work.
+	assert.NoError(t, q.Submit(r.job("bard", "bard sings", Dialogue, false)))
+	assert.Equal(t, ErrFull, q.Submit(r.job("king", "king ponders again", Deliberation, false)))
+	_, dropped := r.results()
+	assert.Equal(t, []string{"king ponders"}, dropped)
+	assert.Equal(t, Evicted, r.reasons["king ponders"])
+
+	close(r.release["guard greets"])
+	q.Wait()
+	ran, _ := r.results()
+	assert.Equal(t, []string{"guard greets", "smith haggles", "bard sings", "queen schemes"}, ran, "Dialogue runs before background work")
+}
+
+func TestQueue_DropsStaleAndSupersededJobs(t *testing.T) {
+	clock := scheduler.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	q := NewQueue(clock)
+	q.MaxConcurrent = 1
+	r := newRecorder()
+
+	assert.NoError(t, q.Submit(r.job("king", "king ponders", Deliberation, true)))
+	<-r.started
+
+	// A newer job for the same player replaces the one still waiting, which
+	// is told so.
+	first := r.job("guard", "guard greets", Dialogue, false)
+	first.Key = "guard:player1"
+	second := r.job("guard", "guard greets again", Dialogue, false)
+	second.Key = "guard:player1"
+	assert.NoError(t, q.Submit(first))
+	assert.NoError(t, q.Submit(r.job("smith", "smith haggles", Dialogue, false)))
+	clock.Advance(20 * time.Second)
+	assert.NoError(t, q.Submit(second))
+	assert.Equal(t, 2, q.Len())
+	_, dropped := r.results()
+	assert.Equal(t, []string{"guard greets"}, dropped)
+	assert.Equal(t, Superseded, r.reasons["guard greets"])
+
+	// Dialogue that waits longer than its limit is dropped; the replacement
+	// waited less.
+	clock.Advance(15 * time.Second)
+	close(r.release["king ponders"])
+	q.Wait()
+	ran, dropped := r.results()
+	assert.Equal(t, []string{"king ponders", "guard greets again"}, ran)
+	assert.Equal(t, []string{"guard greets", "smith haggles"}, dropped)
+	assert.Equal(t, Stale, r.reasons["smith haggles"])
+}
@@ -3,30 +3,53 @@ package perception
 import (
 	"fmt"
 	"math"
+	"strings"
+	"time"
 
 	"mud/internal/dal"
 	"mud/internal/game/events"
+	"mud/internal/game/stats"
 	"mud/internal/models"
 )
 
+// StatsSource derives the stats of characters and NPCs. The stats manager
+// implements it.
+type StatsSource interface {
+	ForCharacter(character *models.PlayerCharacter) (*stats.Stats, error)
+	ForNPC(npc *models.NPC) (*stats.Stats, error)
+}
+
 // PerceptionFilter service handles the subjective interpretation of ActionEvents.
 type PerceptionFilter struct {
 	roomDAL      dal.RoomDALInterface
 	raceDAL      dal.RaceDALInterface
 	professionDAL dal.ProfessionDALInterface
+	skillDAL        dal.SkillDALInterface
+	playerSkillDAL  dal.PlayerSkillDALInterface
+	statusEffectDAL dal.StatusEffectDALInterface
+	stats           StatsSource
 	baseActionSignificance map[string]map[string]float64 // ActionType -> ObserverType -> Score
 }
 
-// NewPerceptionFilter creates a new PerceptionFilter.
+// NewPerceptionFilter creates a new PerceptionFilter. Without a StatsSource
+// every observer has average wisdom.
 func NewPerceptionFilter(
 	roomDAL dal.RoomDALInterface,
 	raceDAL dal.RaceDALInterface,
 	professionDAL dal.ProfessionDALInterface,
+	skillDAL dal.SkillDALInterface,
+	playerSkillDAL dal.PlayerSkillDALInterface,
+	statusEffectDAL dal.StatusEffectDALInterface,
+	statsSource StatsSource,
 ) *PerceptionFilter {
 	return &PerceptionFilter{
 		roomDAL:      roomDAL,
 		raceDAL:      raceDAL,
 		professionDAL: professionDAL,
+		skillDAL:        skillDAL,
+		playerSkillDAL:  playerSkillDAL,
+		statusEffectDAL: statusEffectDAL,
+		stats:           statsSource,
 		baseActionSignificance: map[string]map[string]float64{
 			"attack": {"npc": 10.0, "owner": 10.0, "questmaker": 10.0, "player": 10.0},
 			"pray": {"npc": 2.0, "owner": 10.0, "questmaker": 2.0, "player": 5.0}, // Significant for owners
@@ -46,6 +69,17 @@ func NewPerceptionFilter(
 			"disable_trap": {"npc": 5.0, "owner": 5.0, "questmaker": 5.0, "player": 5.0},
 			"gather_item": {"npc": 3.0, "owner": 3.0, "questmaker": 3.0, "player": 3.0},
 			"deliver_item": {"npc": 2.0, "owner": 2.0, "questmaker": 2.0, "player": 2.0},
+			"get_item": {"npc": 2.0, "owner": 2.0, "questmaker": 2.0, "player": 2.0},
+			"drop_item": {"npc": 1.0, "owner": 1.0, "questmaker": 1.0, "player": 1.0},
+			"equip_item": {"npc": 2.0, "owner": 1.0, "questmaker": 1.0, "player": 2.0},
+			"unequip_item": {"npc": 1.0, "owner": 1.0, "questmaker": 1.0, "player": 1.0},
+			"open_door": {"npc": 1.0, "owner": 1.0, "questmaker": 1.0, "player": 1.0},
+			"close_door": {"npc": 1.0, "owner": 1.0, "questmaker": 1.0, "player": 1.0},
+			"lock_door": {"npc": 2.0, "owner": 2.0, "questmaker": 2.0, "player": 2.0},
+			"unlock_door": {"npc": 2.0, "owner": 2.0, "questmaker": 2.0, "player": 2.0},
+			"flee": {"npc": 2.0, "owner": 2.0, "questmaker": 2.0, "player": 2.0},
+			"slay_npc": {"npc": 12.0, "owner": 10.0, "questmaker": 8.0, "player": 8.0},
+			"slain_by_npc": {"npc": 4.0, "owner": 4.0, "questmaker": 4.0, "player": 4.0},
 			"find_item": {"npc": 3.0, "owner": 3.0, "questmaker": 3.0, "player": 3.0},
 			"return_item_to_npc": {"npc": 4.0, "owner": 4.0, "questmaker": 4.0, "player": 4.0},
 			"observe_area": {"npc": 1.0, "owner": 1.0, "questmaker": 1.0, "player": 1.0},
@@ -55,6 +89,25 @@ func NewPerceptionFilter(
 	}
 }
 
+// skillClarity is the clarity an observer gains from a skill at 100% in the
+// category of the skill being used.
+const skillClarity = 0.2
+
+// wisdomClarity is the clarity an observer gains per point of wisdom modifier.
+const wisdomClarity = 0.05
+
+// statusClarity is how much each stack of a status changes the clarity of
+// everything its bearer perceives. A MODIFY_ATTRIBUTE effect on "perception"
+// adds its magnitude in hundredths instead.
+var statusClarity = map[string]float64{
+	"keen_senses": 0.15,
+	"alert":       0.1,
+	"distracted":  -0.1,
+	"dazzled":     -0.2,
+	"stunned":     -0.3,
+	"blinded":     -0.5,
+}
+
 // Filter processes an ActionEvent through an observer's perception layers
 // to produce a PerceivedAction.
 func (pf *PerceptionFilter) Filter(event *events.ActionEvent, observer interface{}) (*PerceivedAction, error) {
@@ -69,10 +122,12 @@ func (pf *PerceptionFilter) Filter(event *events.ActionEvent, observer interface
 	var professionBiases map[string]float64
 	var roomBiases map[string]float64
 	var observerType string
+	var effectTargetType, effectTargetID string
 
 	switch obs := observer.(type) {
 	case *models.NPC:
 		observerType = "npc"
+		effectTargetType, effectTargetID = models.EffectTargetNPC, obs.ID
 		// Fetch racial biases
 		if obs.RaceID != "" {
 			race, err := pf.raceDAL.GetRaceByID(obs.RaceID)
@@ -142,6 +197,7 @@ func (pf *PerceptionFilter) Filter(event *events.ActionEvent, observer interface
 		roomBiases = map[string]float64{}
 	case *models.PlayerCharacter:
 		observerType = "player"
+		effectTargetType, effectTargetID = models.EffectTargetCharacter, obs.ID
 		// Fetch racial biases for player
 		if obs.RaceID != "" {
 			race, err := pf.raceDAL.GetRaceByID(obs.RaceID)
@@ -237,31 +293,30 @@ func (pf *PerceptionFilter) Filter(event *events.ActionEvent, observer interface
 		}
 	}
 
-	// Placeholder for Skill Proficiency:
-	// If observer has a relevant skill, increase clarity.
-	// This would involve checking observer's skills against event.SkillUsed.
-	// Layer 3: Explicit Modifiers (Passive Skills & Buffs)
-	// This would involve checking observer's active buffs/debuffs or passive skills.
-
-	// Retrieve conceptual skills and buffs for the observer
-	observerSkills, observerBuffs := getSkillsAndBuffs(observer)
-
-	// Apply Skill Proficiency (conceptual)
-	for _, skill := range observerSkills {
-		// Example: If observer has a skill related to the action's category, increase clarity
-		if event.SkillUsed != nil && event.SkillUsed.Category == skill.Category {
-			perceivedAction.Clarity += float64(skill.Level) * 0.02 // Small clarity boost per skill level
+	// Skill Proficiency: a player who knows skills of the category being used
+	// recognises it, in proportion to their best such skill.
+	if player, ok := observer.(*models.PlayerCharacter); ok && event.SkillUsed != nil && event.SkillUsed.Category != "" {
+		proficiency, err := pf.skillProficiency(player.ID, event.SkillUsed.Category)
+		if err != nil {
+			return nil, err
 		}
+		perceivedAction.Clarity += proficiency * skillClarity
+	}
+
+	// Wisdom: characters and NPCs notice more the wiser they are.
+	wisdom, err := pf.wisdomModifier(observer)
+	if err != nil {
+		return nil, err
 	}
+	perceivedAction.Clarity += float64(wisdom) * wisdomClarity
 
-	// Apply Explicit Modifiers (conceptual)
-	for _, buff := range observerBuffs {
-		switch buff.Type {
-		case "clarity_boost":
-			perceivedAction.Clarity += buff.Value
-		case "perception_debuff":
-			perceivedAction.Clarity += buff.Value
+	// Layer 3: Explicit Modifiers (Buffs, Debuffs and Statuses)
+	if effectTargetType != "" {
+		modifier, err := pf.effectClarity(effectTargetType, effectTargetID)
+		if err != nil {
+			return nil, err
 		}
+		perceivedAction.Clarity += modifier
 	}
 
 	// Cap clarity between 0.0 and 1.0
@@ -277,54 +332,71 @@ func (pf *PerceptionFilter) Filter(event *events.ActionEvent, observer interface
 	return perceivedAction, nil
 }
 
-// conceptualSkill represents a simplified skill for perception calculation.
-type conceptualSkill struct {
-	ID       string
-	Name     string
-	Category string
-	Level    int
-}
-
-// conceptualBuff represents a simplified buff/debuff for perception calculation.
-type conceptualBuff struct {
-	ID    string
-	Name  string
-	Type  string  // e.g., "clarity_boost", "perception_debuff"
-	Value float64 // e.g., 0.1 for a 10% clarity boost
+// skillProficiency returns the player's best percentage, as a fraction, in
+// skills of the given category.
+func (pf *PerceptionFilter) skillProficiency(playerID, category string) (float64, error) {
+	playerSkills, err := pf.playerSkillDAL.GetPlayerSkillsByPlayerID(playerID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get skills for Player %s: %w", playerID, err)
+	}
+	best := 0
+	for _, playerSkill := range playerSkills {
+		skill, err := pf.skillDAL.GetSkillByID(playerSkill.SkillID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get skill %s: %w", playerSkill.SkillID, err)
+		}
+		if skill != nil && strings.EqualFold(skill.Category, category) {
+			best = max(best, playerSkill.Percentage)
+		}
+	}
+	return float64(best) / 100, nil
 }
 
-// getSkillsAndBuffs is a conceptual helper function. In a real implementation,
-// this would retrieve the actual skills and active buffs/debuffs for the observer
-// from the respective models and DALs (e.g., Player.GetSkills(), NPC.GetActiveBuffs()).
-// For this placeholder, it returns hardcoded examples.
-func getSkillsAndBuffs(observer interface{}) ([]conceptualSkill, []conceptualBuff) {
-	// NOTE: To fully implement this, you would need to:
-	// 1. Add methods like `GetSkills()` and `GetActiveBuffs()` to `models.Player`, `models.NPC`, `models.Owner`, `models.Questmaker`.
-	// 2. Implement the logic in these methods to fetch data from relevant DALs (e.g., PlayerSkillDAL, BuffDAL).
-	// 3. Ensure the DALs and database schema support storing and retrieving this information.
-
+// wisdomModifier returns the wisdom modifier of a character or NPC observer,
+// from their derived stats. Other observers have none.
+func (pf *PerceptionFilter) wisdomModifier(observer interface{}) (int, error) {
+	if pf.stats == nil {
+		return 0, nil
+	}
+	var derived *stats.Stats
+	var err error
 	switch obs := observer.(type) {
 	case *models.PlayerCharacter:
-		// Example for a player
-		if obs.ID == "player1" {
-			return []conceptualSkill{
-				{ID: "skill_stealth", Name: "Stealth", Category: "subterfuge", Level: 5},
-				{ID: "skill_detect_magic", Name: "Detect Magic", Category: "magic", Level: 3},
-			}, []conceptualBuff{
-				{ID: "buff_keen_senses", Name: "Keen Senses", Type: "clarity_boost", Value: 0.15},
-			}
-		}
+		derived, err = pf.stats.ForCharacter(obs)
 	case *models.NPC:
-		// Example for an NPC
-		if obs.ID == "npc1" {
-			return []conceptualSkill{
-				{ID: "skill_guarding", Name: "Guarding", Category: "combat", Level: 7},
-			}, []conceptualBuff{
-				{ID: "debuff_dazzled", Name: "Dazzled", Type: "perception_debuff", Value: -0.2},
+		derived, err = pf.stats.ForNPC(obs)
+	default:
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stats of observer: %w", err)
+	}
+	return derived.Modifier(stats.Wisdom), nil
+}
+
+// effectClarity sums the clarity changes of the unexpired status effects on
+// a character or NPC.
+func (pf *PerceptionFilter) effectClarity(targetType, targetID string) (float64, error) {
+	effects, err := pf.statusEffectDAL.GetStatusEffectsByTarget(targetType, targetID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get status effects for %s %s: %w", targetType, targetID, err)
+	}
+	now := time.Now()
+	modifier := 0.0
+	for _, effect := range effects {
+		if effect.Expired(now) {
+			continue // Not swept yet
+		}
+		switch effect.Type {
+		case models.EffectTypeStatus:
+			modifier += statusClarity[effect.Key] * float64(max(1, effect.Stacks))
+		case models.EffectTypeModifier:
+			if effect.Key == "perception" {
+				modifier += effect.TotalMagnitude() / 100
 			}
 		}
 	}
-	return []conceptualSkill{}, []conceptualBuff{}
+	return modifier, nil
 }
 
 // determinePerceivedActionType maps clarity and action details to a perceived action type.
@@ -7,10 +7,32 @@ import (
 	"github.com/stretchr/testify/assert"
 	"mud/internal/dal"
 	"mud/internal/game/events"
+	"mud/internal/game/stats"
 	"mud/internal/models"
 	"mud/internal/testutils"
 )
 
+// MockStatsSource implements StatsSource with fixed wisdom by ID.
+type MockStatsSource struct {
+	wisdom map[string]int
+}
+
+func (m *MockStatsSource) ForCharacter(character *models.PlayerCharacter) (*stats.Stats, error) {
+	return m.forID(character.ID), nil
+}
+
+func (m *MockStatsSource) ForNPC(npc *models.NPC) (*stats.Stats, error) {
+	return m.forID(npc.ID), nil
+}
+
+func (m *MockStatsSource) forID(id string) *stats.Stats {
+	s := &stats.Stats{Attributes: map[string]int{}}
+	if wisdom, ok := m.wisdom[id]; ok {
+		s.Attributes[stats.Wisdom] = wisdom
+	}
+	return s
+}
+
 // MockRoomDAL implements dal.RoomDALInterface for testing.
 type MockRoomDAL struct {
 	cache dal.CacheInterface
@@ -71,6 +93,54 @@ func (m *MockProfessionDAL) UpdateProfession(profession *models.Profession) erro
 func (m *MockProfessionDAL) DeleteProfession(id string) error { return nil }
 func (m *MockProfessionDAL) Cache() dal.CacheInterface { return m.cache }
 
+// MockSkillDAL implements dal.SkillDALInterface for testing.
+type MockSkillDAL struct {
+	skills map[string]*models.Skill
+}
+
+func (m *MockSkillDAL) GetSkillByID(id string) (*models.Skill, error) { return m.skills[id], nil }
+func (m *MockSkillDAL) GetAllSkills() ([]*models.Skill, error) { return nil, nil }
+func (m *MockSkillDAL) CreateSkill(skill *models.Skill) error { return nil }
+func (m *MockSkillDAL) UpdateSkill(skill *models.Skill) error { return nil }
+func (m *MockSkillDAL) DeleteSkill(id string) error { return nil }
+func (m *MockSkillDAL) Cache() dal.CacheInterface { return nil }
+
+// MockPlayerSkillDAL implements dal.PlayerSkillDALInterface for testing.
+type MockPlayerSkillDAL struct {
+	playerSkills map[string][]*models.PlayerSkill // Keyed by player ID
+}
+
+func (m *MockPlayerSkillDAL) GetPlayerSkillByID(playerID, skillID string) (*models.PlayerSkill, error) { return nil, nil }
+func (m *MockPlayerSkillDAL) GetAllPlayerSkills() ([]*models.PlayerSkill, error) { return nil, nil }
+func (m *MockPlayerSkillDAL) GetPlayerSkillsByPlayerID(playerID string) ([]*models.PlayerSkill, error) {
+	return m.playerSkills[playerID], nil
+}
+func (m *MockPlayerSkillDAL) CreatePlayerSkill(playerSkill *models.PlayerSkill) error { return nil }
+func (m *MockPlayerSkillDAL) UpdatePlayerSkill(playerSkill *models.PlayerSkill) error { return nil }
+func (m *MockPlayerSkillDAL) DeletePlayerSkill(playerID, skillID string) error { return nil }
+func (m *MockPlayerSkillDAL) Cache() dal.CacheInterface { return nil }
+
+// MockStatusEffectDAL implements dal.StatusEffectDALInterface for testing.
+type MockStatusEffectDAL struct {
+	effects map[string][]*models.StatusEffect // Keyed by target ID
+}
+
+func (m *MockStatusEffectDAL) GetStatusEffectByID(id string) (*models.StatusEffect, error) { return nil, nil }
+func (m *MockStatusEffectDAL) GetStatusEffectsByTarget(targetType, targetID string) ([]*models.StatusEffect, error) {
+	var effects []*models.StatusEffect
+	for _, effect := range m.effects[targetID] {
+		if effect.TargetType == targetType {
+			effects = append(effects, effect)
+		}
+	}
+	return effects, nil
+}
+func (m *MockStatusEffectDAL) GetExpiredStatusEffects(now time.Time) ([]*models.StatusEffect, error) { return nil, nil }
+func (m *MockStatusEffectDAL) CreateStatusEffect(effect *models.StatusEffect) error { return nil }
+func (m *MockStatusEffectDAL) UpdateStatusEffect(effect *models.StatusEffect) error { return nil }
+func (m *MockStatusEffectDAL) DeleteStatusEffect(id string) error { return nil }
+func (m *MockStatusEffectDAL) Cache() dal.CacheInterface { return nil }
+
 func TestPerceptionFilter_Filter(t *testing.T) {
 	mockRooms := map[string]*models.Room{
 		"room_shire": {
@@ -147,8 +217,27 @@ func TestPerceptionFilter_Filter(t *testing.T) {
 	mockRoomDAL := &MockRoomDAL{cache: mockRoomCache}
 	mockRaceDAL := &MockRaceDAL{cache: mockRaceCache}
 	mockProfessionDAL := &MockProfessionDAL{cache: mockProfessionCache}
+	mockSkillDAL := &MockSkillDAL{skills: map[string]*models.Skill{
+		"stealth":      {ID: "stealth", Name: "Stealth", Category: "subterfuge"},
+		"detect_magic": {ID: "detect_magic", Name: "Detect Magic", Category: "magic"},
+	}}
+	mockPlayerSkillDAL := &MockPlayerSkillDAL{playerSkills: map[string][]*models.PlayerSkill{
+		"player2": {{PlayerID: "player2", SkillID: "stealth", Percentage: 50}, {PlayerID: "player2", SkillID: "detect_magic", Percentage: 90}},
+	}}
+	later := time.Now().Add(time.Hour)
+	mockStatusEffectDAL := &MockStatusEffectDAL{effects: map[string][]*models.StatusEffect{
+		"npc1": {{TargetType: "npc", TargetID: "npc1", Type: "STATUS_EFFECT", Key: "dazzled", Stacks: 1, ExpiresAt: later}},
+		"player1": {
+			{TargetType: "character", TargetID: "player1", Type: "STATUS_EFFECT", Key: "keen_senses", Stacks: 1, ExpiresAt: later},
+			{TargetType: "character", TargetID: "player1", Type: "MODIFY_ATTRIBUTE", Key: "perception", Magnitude: -5, Stacks: 1, ExpiresAt: later},
+		},
+		"player2": {
+			{TargetType: "character", TargetID: "player2", Type: "STATUS_EFFECT", Key: "dazzled", Stacks: 2, ExpiresAt: later},
+			{TargetType: "character", TargetID: "player2", Type: "STATUS_EFFECT", Key: "blinded", Stacks: 1, ExpiresAt: time.Now().Add(-time.Minute)},
+		},
+	}}
 
-	pf := NewPerceptionFilter(mockRoomDAL, mockRaceDAL, mockProfessionDAL)
+	pf := NewPerceptionFilter(mockRoomDAL, mockRaceDAL, mockProfessionDAL, mockSkillDAL, mockPlayerSkillDAL, mockStatusEffectDAL, &MockStatsSource{wisdom: map[string]int{"sage_npc": 16}})
 
 	player := &models.PlayerCharacter{
 		ID:           "player1",
@@ -180,7 +269,7 @@ func TestPerceptionFilter_Filter(t *testing.T) {
 				ProfessionID:  "commoner",
 			},
 			expectedBaseSig: 10.0, // Base for NPC observing 'say'
-			expectedClarity: 0.8,  // 1.0 (initial) - 0.2 (dazzled debuff from npc1 in getSkillsAndBuffs)
+			expectedClarity: 0.8,  // 1.0 (initial) - 0.2 (npc1 is dazzled)
 			expectedPerceivedActionType: "say_general",
 		},
 		{
@@ -201,6 +290,24 @@ func TestPerceptionFilter_Filter(t *testing.T) {
 			expectedClarity: 0.7, // Capped: 1.0 - 0.2 (hobbit magic_action) - 0.1 (shire magic_action) = 0.7
 			expectedPerceivedActionType: "magic_action_general", // Corrected expected type
 		},
+		{
+			name: "Wise NPC observes 'magic_action' despite racial and room bias",
+			actionEvent: &events.ActionEvent{
+				ActionType: "magic_action",
+				Player:     player,
+				Room:       &models.Room{ID: "room_shire"},
+				Timestamp:  time.Now(),
+			},
+			observer: &models.NPC{
+				ID:            "sage_npc",
+				CurrentRoomID: "room_shire",
+				RaceID:        "hobbit",
+				ProfessionID:  "commoner",
+			},
+			expectedBaseSig: 7.0,
+			expectedClarity: 0.85, // 1.0 - 0.2 (hobbit) - 0.1 (shire) + 0.15 (wisdom 16)
+			expectedPerceivedActionType: "magic_action_general",
+		},
 		{
 			name: "Owner (location) observes 'subterfuge_action' with room bias",
 			actionEvent: &events.ActionEvent{
@@ -331,6 +438,45 @@ func TestPerceptionFilter_Filter(t *testing.T) {
 			expectedClarity: 1.0, // Capped: 1.0 + 0.1 (shire say) + 0.2 (hobbit subterfuge) = 1.3 -> 1.0
 			expectedPerceivedActionType: "Stealth", // Corrected expected type
 		},
+		{
+			name: "Player with keen senses observes 'magic_action'",
+			actionEvent: &events.ActionEvent{
+				ActionType: "magic_action",
+				Player:     player,
+				Room:       &models.Room{ID: "room_shire"},
+				Timestamp:  time.Now(),
+			},
+			observer: &models.PlayerCharacter{
+				ID:            "player1",
+				CurrentRoomID: "room_shire",
+				RaceID:        "hobbit",
+			},
+			expectedBaseSig: 7.0,  // Base for player observing 'magic_action'
+			expectedClarity: 0.8,  // 1.0 - 0.2 (hobbit) - 0.1 (shire) + 0.15 (keen senses) - 0.05 (perception -5)
+			expectedPerceivedActionType: "magic_action_general",
+		},
+		{
+			name: "Dazzled player recognises a skill they know",
+			actionEvent: &events.ActionEvent{
+				ActionType: "use_skill",
+				SkillUsed: &models.Skill{
+					ID:       "stealth_skill",
+					Name:     "Stealth",
+					Category: "subterfuge",
+				},
+				Player:    player,
+				Room:      &models.Room{ID: "room_bree"},
+				Timestamp: time.Now(),
+			},
+			observer: &models.PlayerCharacter{
+				ID:            "player2",
+				CurrentRoomID: "room_bree",
+				RaceID:        "human",
+			},
+			expectedBaseSig: 5.0, // Base for player observing 'use_skill'
+			expectedClarity: 0.7, // 1.0 - 0.4 (dazzled, two stacks) + 0.1 (stealth 50%); the expired blindness is ignored
+			expectedPerceivedActionType: "subterfuge_action",
+		},
 	}
 
 	for _, tt := range tests {
@@ -351,7 +497,7 @@ func TestPerceptionFilter_determinePerceivedActionType(t *testing.T) {
 	mockRoomDAL := &MockRoomDAL{cache: testutils.NewMockCache()}
 	mockRaceDAL := &MockRaceDAL{cache: testutils.NewMockCache()}
 	mockProfessionDAL := &MockProfessionDAL{cache: testutils.NewMockCache()}
-	pf := NewPerceptionFilter(mockRoomDAL, mockRaceDAL, mockProfessionDAL)
+	pf := NewPerceptionFilter(mockRoomDAL, mockRaceDAL, mockProfessionDAL, &MockSkillDAL{}, &MockPlayerSkillDAL{}, &MockStatusEffectDAL{}, nil)
 
 	player := &models.PlayerCharacter{ID: "p1", Name: "Player1"}
 	skill := &models.Skill{ID: "s1", Name: "Sneak", Category: "subterfuge"}
@@ -0,0 +1,623 @@
+package progression
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"mud/internal/dal"
+	"mud/internal/game/events"
+	"mud/internal/models"
+)
+
+// GrantedByClass is the GrantedByEntityType of skills learned from class rewards.
+const GrantedByClass = "Class"
+
+const (
+	// DefaultPracticesPerLevel is how many practice points each class level grants.
+	DefaultPracticesPerLevel = 2
+	// DefaultSuccessLearnRate is the chance, as a percentage of the distance
+	// left to 100%, that a successful use improves a skill by a point.
+	DefaultSuccessLearnRate = 50
+	// DefaultFailureLearnRate is the same chance after a failed use; mistakes
+	// teach more than successes.
+	DefaultFailureLearnRate = 100
+	// DefaultPracticeRate is how much of the distance left to 100% a practice
+	// session with a trainer gains, as a percentage. Every session gains at
+	// least a point.
+	DefaultPracticeRate = 20
+)
+
+// DefaultThresholds is the experience needed to advance from level 1, 2, 3
+// and so on. Levels beyond the last entry need as much as the last entry.
+var DefaultThresholds = []int{100, 200, 300, 400}
+
+// DefaultExperience is the class experience each action type is worth to the
+// classes it is relevant to.
+var DefaultExperience = map[string]int{
+	"use_skill":          10,
+	"slay_npc":           25,
+	"defeat_dummy":       10,
+	"tamper_lock":        10,
+	"disable_trap":       10,
+	"observe_area":       2,
+	"find_item":          5,
+	"return_item_to_npc": 5,
+}
+
+// actionCategories maps action types to the skill category they exercise. A
+// class is relevant to an action if it has skills of that category; use_skill
+// exercises the category of the skill used.
+var actionCategories = map[string]string{
+	"slay_npc":           "combat",
+	"defeat_dummy":       "combat",
+	"tamper_lock":        "subterfuge",
+	"disable_trap":       "subterfuge",
+	"observe_area":       "knowledge",
+	"find_item":          "knowledge",
+	"return_item_to_npc": "knowledge",
+}
+
+var (
+	// ErrUnknownClass is returned when no class has the given ID.
+	ErrUnknownClass = errors.New("class not found")
+	// ErrAlreadyJoined is returned when joining a class the character already has.
+	ErrAlreadyJoined = errors.New("class already joined")
+	// ErrParentClassRequired is returned when joining a class without its parent class.
+	ErrParentClassRequired = errors.New("parent class required")
+	// ErrClassNotJoined is returned when awarding experience in a class the character does not have.
+	ErrClassNotJoined = errors.New("class not joined")
+	// ErrNoChoicePending is returned when choosing a skill without a choose_skill reward waiting.
+	ErrNoChoicePending = errors.New("no skill choice pending")
+	// ErrInvalidChoice is returned when the selection matches none of the offered skills.
+	ErrInvalidChoice = errors.New("invalid skill choice")
+	// ErrUnknownSkill is returned when raising a skill the character does not know.
+	ErrUnknownSkill = errors.New("skill not known")
+	// ErrSkillCapped is returned when a skill is already at the cap set by its class level.
+	ErrSkillCapped = errors.New("skill at class level cap")
+	// ErrNotTrainer is returned when practising with an NPC who trains no class.
+	ErrNotTrainer = errors.New("not a trainer")
+	// ErrNotTaught is returned when practising a skill the trainer does not teach.
+	ErrNotTaught = errors.New("skill not taught by trainer")
+	// ErrNoPracticePoints is returned when practising without practice points left in the skill's class.
+	ErrNoPracticePoints = errors.New("no practice points")
+)
+
+// Notifier receives the narration of level-ups and the skills they grant.
+type Notifier interface {
+	Notify(characterID, content string)
+}
+
+// Choice is a choose_skill reward the character has not picked from yet.
+type Choice struct {
+	ClassID   string
+	ClassName string
+	Level     int
+	Skills    []*models.Skill // The offered skills the character does not know yet
+}
+
+// Manager awards class experience and levels classes up. Each level's
+// LevelUpRewards are granted as it is reached: unlock_skill rewards are
+// learned at once, and choose_skill rewards wait until the player picks a
+// skill. Each level also grants practice points. Skills improve through use
+// and through practice with trainers, and their percentages are capped by
+// the level of the skill's class.
+type Manager struct {
+	classDAL       dal.ClassDALInterface
+	playerClassDAL dal.PlayerClassDALInterface
+	skillDAL       dal.SkillDALInterface
+	playerSkillDAL dal.PlayerSkillDALInterface
+	notifier       Notifier
+	mu             sync.Mutex
+	roll           func(n int) int // Returns a number in [0, n); replaced in tests
+
+	Thresholds        []int
+	Experience        map[string]int // Keyed by action type
+	PracticesPerLevel int
+	SuccessLearnRate  int
+	FailureLearnRate  int
+	PracticeRate      int
+}
+
+// NewManager creates a new Manager.
+func NewManager(classDAL dal.ClassDALInterface, playerClassDAL dal.PlayerClassDALInterface, skillDAL dal.SkillDALInterface, playerSkillDAL dal.PlayerSkillDALInterface, notifier Notifier) *Manager {
+	return &Manager{
+		classDAL:          classDAL,
+		playerClassDAL:    playerClassDAL,
+		skillDAL:          skillDAL,
+		playerSkillDAL:    playerSkillDAL,
+		notifier:          notifier,
+		roll:              rand.Intn,
+		Thresholds:        DefaultThresholds,
+		Experience:        DefaultExperience,
+		PracticesPerLevel: DefaultPracticesPerLevel,
+		SuccessLearnRate:  DefaultSuccessLearnRate,
+		FailureLearnRate:  DefaultFailureLearnRate,
+		PracticeRate:      DefaultPracticeRate,
+	}
+}
+
+// Threshold returns the experience needed to advance from the given level.
+func (m *Manager) Threshold(level int) int {
+	if len(m.Thresholds) == 0 {
+		return 0
+	}
+	return m.Thresholds[min(max(level, 1), len(m.Thresholds))-1]
+}
+
+// Join enrols a character in a class at level 1 and grants its level 1
+// rewards and practice points. A class with a parent class can only be
+// joined by characters who have the parent.
+func (m *Manager) Join(characterID, classID string) (*models.PlayerClass, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	class, err := m.classDAL.GetClassByID(classID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get class %s: %w", classID, err)
+	}
+	if class == nil {
+		return nil, ErrUnknownClass
+	}
+	existing, err := m.playerClassDAL.GetPlayerClassByID(characterID, classID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get class %s for character %s: %w", classID, characterID, err)
+	}
+	if existing != nil {
+		return nil, ErrAlreadyJoined
+	}
+	if class.ParentClassID != "" {
+		parent, err := m.playerClassDAL.GetPlayerClassByID(characterID, class.ParentClassID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get class %s for character %s: %w", class.ParentClassID, characterID, err)
+		}
+		if parent == nil {
+			return nil, ErrParentClassRequired
+		}
+	}
+
+	playerClass := &models.PlayerClass{PlayerID: characterID, ClassID: classID, Level: 1, PracticePoints: m.PracticesPerLevel}
+	if err := m.playerClassDAL.CreatePlayerClass(playerClass); err != nil {
+		return nil, fmt.Errorf("failed to create class %s for character %s: %w", classID, characterID, err)
+	}
+	if err := m.grantRewards(characterID, class, 1, 1); err != nil {
+		return nil, err
+	}
+	return playerClass, nil
+}
+
+// AwardExperience adds experience to one of the character's classes and
+// levels it up for every threshold it passes, granting each level's
+// rewards and practice points. A class at its TotalLevels gains no more
+// experience. It returns the number of levels gained.
+func (m *Manager) AwardExperience(characterID, classID string, amount int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.awardExperience(characterID, classID, amount)
+}
+
+func (m *Manager) awardExperience(characterID, classID string, amount int) (int, error) {
+	if amount <= 0 {
+		return 0, nil
+	}
+	playerClass, err := m.playerClassDAL.GetPlayerClassByID(characterID, classID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get class %s for character %s: %w", classID, characterID, err)
+	}
+	if playerClass == nil {
+		return 0, ErrClassNotJoined
+	}
+	class, err := m.classDAL.GetClassByID(classID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get class %s: %w", classID, err)
+	}
+	if class == nil {
+		return 0, ErrUnknownClass
+	}
+	if m.mastered(class, playerClass.Level) {
+		return 0, nil
+	}
+
+	oldLevel := playerClass.Level
+	playerClass.Experience += amount
+	for !m.mastered(class, playerClass.Level) {
+		threshold := m.Threshold(playerClass.Level)
+		if threshold <= 0 || playerClass.Experience < threshold {
+			break
+		}
+		playerClass.Experience -= threshold
+		playerClass.Level++
+	}
+	if m.mastered(class, playerClass.Level) {
+		playerClass.Experience = 0
+	}
+	playerClass.PracticePoints += (playerClass.Level - oldLevel) * m.PracticesPerLevel
+	if err := m.playerClassDAL.UpdatePlayerClass(playerClass); err != nil {
+		return 0, fmt.Errorf("failed to update class %s for character %s: %w", classID, characterID, err)
+	}
+
+	if playerClass.Level > oldLevel {
+		if err := m.grantRewards(characterID, class, oldLevel+1, playerClass.Level); err != nil {
+			return playerClass.Level - oldLevel, err
+		}
+	}
+	return playerClass.Level - oldLevel, nil
+}
+
+// mastered reports whether a class has reached its TotalLevels. Classes
+// without TotalLevels never stop levelling.
+func (m *Manager) mastered(class *models.Class, level int) bool {
+	return class.TotalLevels > 0 && level >= class.TotalLevels
+}
+
+// HandleActionEvent awards experience for an action to each of the acting
+// character's classes the action is relevant to: the class of the skill
+// used, and classes with skills in the category the action exercises.
+func (m *Manager) HandleActionEvent(event *events.ActionEvent) {
+	if event.Player == nil {
+		return
+	}
+	amount := m.Experience[event.ActionType]
+	if amount <= 0 {
+		return
+	}
+	category := actionCategories[event.ActionType]
+	if event.SkillUsed != nil {
+		category = event.SkillUsed.Category
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	playerClasses, err := m.playerClassDAL.GetPlayerClassesByPlayerID(event.Player.ID)
+	if err != nil {
+		logrus.Errorf("Progression: Failed to get classes for character %s: %v", event.Player.ID, err)
+		return
+	}
+	if len(playerClasses) == 0 {
+		return
+	}
+	categories, err := m.classCategories()
+	if err != nil {
+		logrus.Errorf("Progression: Failed to get class skill categories: %v", err)
+		return
+	}
+	for _, playerClass := range playerClasses {
+		relevant := categories[playerClass.ClassID][strings.ToLower(category)]
+		if event.SkillUsed != nil && event.SkillUsed.AssociatedClassID == playerClass.ClassID {
+			relevant = true
+		}
+		if !relevant {
+			continue
+		}
+		if _, err := m.awardExperience(event.Player.ID, playerClass.ClassID, amount); err != nil {
+			logrus.Errorf("Progression: Failed to award experience in class %s to character %s: %v", playerClass.ClassID, event.Player.ID, err)
+		}
+	}
+}
+
+// classCategories returns the categories of each class's skills, keyed by class ID.
+func (m *Manager) classCategories() (map[string]map[string]bool, error) {
+	skills, err := m.skillDAL.GetAllSkills()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get skills: %w", err)
+	}
+	categories := make(map[string]map[string]bool)
+	for _, skill := range skills {
+		if skill.AssociatedClassID == "" || skill.Category == "" {
+			continue
+		}
+		if categories[skill.AssociatedClassID] == nil {
+			categories[skill.AssociatedClassID] = make(map[string]bool)
+		}
+		categories[skill.AssociatedClassID][strings.ToLower(skill.Category)] = true
+	}
+	return categories, nil
+}
+
+// grantRewards announces each level from from to through of a class and
+// grants its rewards: stat gains are announced, unlock_skill skills are
+// learned and choose_skill choices are announced. Joining a class announces
+// no level.
+func (m *Manager) grantRewards(characterID string, class *models.Class, from, through int) error {
+	rewards, err := ParseRewards(class)
+	if err != nil {
+		return err
+	}
+	choose := false
+	for level := from; level <= through; level++ {
+		if level > 1 {
+			m.notifier.Notify(characterID, fmt.Sprintf("You have reached level %d as a %s!", level, class.Name))
+		}
+		reward, ok := rewards[level]
+		if !ok {
+			continue
+		}
+		for _, stat := range sortedKeys(reward.Stats) {
+			if amount := reward.Stats[stat]; amount > 0 {
+				m.notifier.Notify(characterID, fmt.Sprintf("Your %s increases by %d.", strings.ToLower(stat), amount))
+			} else if amount < 0 {
+				m.notifier.Notify(characterID, fmt.Sprintf("Your %s decreases by %d.", strings.ToLower(stat), -amount))
+			}
+		}
+		if reward.UnlockSkill != "" {
+			if _, err := m.learn(characterID, reward.UnlockSkill, class.ID); err != nil {
+				return err
+			}
+		}
+		if len(reward.ChooseSkill) > 0 {
+			choose = true
+		}
+	}
+	if choose {
+		return m.announceChoices(characterID)
+	}
+	return nil
+}
+
+// learn adds a skill the character does not know yet at 0%, as granted by
+// the class. It returns the skill, or nil if the character already knew it.
+func (m *Manager) learn(characterID, skillID, classID string) (*models.Skill, error) {
+	skill, err := m.skillDAL.GetSkillByID(skillID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get skill %s: %w", skillID, err)
+	}
+	if skill == nil {
+		return nil, fmt.Errorf("skill %s in rewards of class %s not found", skillID, classID)
+	}
+	known, err := m.playerSkillDAL.GetPlayerSkillByID(characterID, skillID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get skill %s for character %s: %w", skillID, characterID, err)
+	}
+	if known != nil {
+		return nil, nil
+	}
+	playerSkill := &models.PlayerSkill{
+		PlayerID:            characterID,
+		SkillID:             skillID,
+		Percentage:          0,
+		GrantedByEntityType: GrantedByClass,
+		GrantedByEntityID:   classID,
+	}
+	if err := m.playerSkillDAL.CreatePlayerSkill(playerSkill); err != nil {
+		return nil, fmt.Errorf("failed to grant skill %s to character %s: %w", skillID, characterID, err)
+	}
+	m.notifier.Notify(characterID, fmt.Sprintf("You have learned %s.", skill.Name))
+	return skill, nil
+}
+
+// PendingChoices returns the choose_skill rewards of levels the character has
+// reached without picking one of the offered skills, ordered by class and
+// level. A choice is settled once the character knows one of its skills
+// through that class.
+func (m *Manager) PendingChoices(characterID string) ([]Choice, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pendingChoices(characterID)
+}
+
+func (m *Manager) pendingChoices(characterID string) ([]Choice, error) {
+	playerClasses, err := m.playerClassDAL.GetPlayerClassesByPlayerID(characterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get classes for character %s: %w", characterID, err)
+	}
+	known, err := m.playerSkillDAL.GetPlayerSkillsByPlayerID(characterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get skills for character %s: %w", characterID, err)
+	}
+	knownSkills := make(map[string]*models.PlayerSkill, len(known))
+	for _, playerSkill := range known {
+		knownSkills[playerSkill.SkillID] = playerSkill
+	}
+	sort.Slice(playerClasses, func(i, j int) bool { return playerClasses[i].ClassID < playerClasses[j].ClassID })
+
+	var choices []Choice
+	for _, playerClass := range playerClasses {
+		class, err := m.classDAL.GetClassByID(playerClass.ClassID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get class %s: %w", playerClass.ClassID, err)
+		}
+		if class == nil {
+			continue
+		}
+		rewards, err := ParseRewards(class)
+		if err != nil {
+			return nil, err
+		}
+		levels := make([]int, 0, len(rewards))
+		for level := range rewards {
+			levels = append(levels, level)
+		}
+		sort.Ints(levels)
+
+		for _, level := range levels {
+			options := rewards[level].ChooseSkill
+			if level > playerClass.Level || len(options) == 0 {
+				continue
+			}
+			choice := Choice{ClassID: class.ID, ClassName: class.Name, Level: level}
+			settled := false
+			for _, skillID := range options {
+				if playerSkill, ok := knownSkills[skillID]; ok {
+					if playerSkill.GrantedByEntityType == GrantedByClass && playerSkill.GrantedByEntityID == class.ID {
+						settled = true
+					}
+					continue
+				}
+				skill, err := m.skillDAL.GetSkillByID(skillID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get skill %s: %w", skillID, err)
+				}
+				if skill != nil {
+					choice.Skills = append(choice.Skills, skill)
+				}
+			}
+			if !settled && len(choice.Skills) > 0 {
+				choices = append(choices, choice)
+			}
+		}
+	}
+	return choices, nil
+}
+
+// AnnounceChoices prompts the character to pick from their first pending
+// skill choice, if they have one. Call it when the character enters the game.
+func (m *Manager) AnnounceChoices(characterID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.announceChoices(characterID)
+}
+
+func (m *Manager) announceChoices(characterID string) error {
+	choices, err := m.pendingChoices(characterID)
+	if err != nil {
+		return err
+	}
+	if len(choices) == 0 {
+		return nil
+	}
+	choice := choices[0]
+	options := make([]string, len(choice.Skills))
+	for i, skill := range choice.Skills {
+		options[i] = fmt.Sprintf("%d) %s", i+1, skill.Name)
+	}
+	m.notifier.Notify(characterID, fmt.Sprintf("As a level %d %s you may learn one of: %s. Type 'choose <number>' to decide.",
+		choice.Level, choice.ClassName, strings.Join(options, ", ")))
+	return nil
+}
+
+// Choose settles the character's first pending skill choice with the skill
+// selected by its number in the list or by name, and learns it at 0%. Any
+// further pending choice is announced afterwards.
+func (m *Manager) Choose(characterID, selection string) (*models.Skill, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	choices, err := m.pendingChoices(characterID)
+	if err != nil {
+		return nil, err
+	}
+	if len(choices) == 0 {
+		return nil, ErrNoChoicePending
+	}
+	choice := choices[0]
+
+	selection = strings.ToLower(strings.TrimSpace(selection))
+	var chosen *models.Skill
+	if n, err := strconv.Atoi(selection); err == nil {
+		if n >= 1 && n <= len(choice.Skills) {
+			chosen = choice.Skills[n-1]
+		}
+	} else if selection != "" {
+		for _, skill := range choice.Skills {
+			id, name := strings.ToLower(skill.ID), strings.ToLower(skill.Name)
+			if id == selection || name == selection || strings.HasPrefix(name, selection) {
+				chosen = skill
+				break
+			}
+		}
+	}
+	if chosen == nil {
+		return nil, ErrInvalidChoice
+	}
+
+	if _, err := m.learn(characterID, chosen.ID, choice.ClassID); err != nil {
+		return nil, err
+	}
+	if err := m.announceChoices(characterID); err != nil {
+		return chosen, err
+	}
+	return chosen, nil
+}
+
+// SkillCap returns the highest percentage the character can reach in a
+// skill: (level / TotalLevels) * 100 of the skill's class. Skills without a
+// class are not capped, and skills of a class the character lacks are capped at 0.
+func (m *Manager) SkillCap(characterID string, skill *models.Skill) (int, error) {
+	if skill.AssociatedClassID == "" {
+		return 100, nil
+	}
+	class, err := m.classDAL.GetClassByID(skill.AssociatedClassID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get class %s: %w", skill.AssociatedClassID, err)
+	}
+	if class == nil || class.TotalLevels <= 0 {
+		return 100, nil
+	}
+	playerClass, err := m.playerClassDAL.GetPlayerClassByID(characterID, skill.AssociatedClassID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get class %s for character %s: %w", skill.AssociatedClassID, characterID, err)
+	}
+	if playerClass == nil {
+		return 0, nil
+	}
+	return min(100, playerClass.Level*100/class.TotalLevels), nil
+}
+
+// RaiseSkill raises the character's percentage in a skill by amount, up to
+// the skill's cap. It returns ErrSkillCapped if the skill is already at or
+// above the cap.
+func (m *Manager) RaiseSkill(characterID, skillID string, amount int) (*models.PlayerSkill, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	skill, playerSkill, err := m.knownSkill(characterID, skillID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.raise(skill, playerSkill, amount); err != nil {
+		return playerSkill, err
+	}
+	return playerSkill, nil
+}
+
+// knownSkill returns a skill and the character's PlayerSkill for it, or
+// ErrUnknownSkill if the character does not know it.
+func (m *Manager) knownSkill(characterID, skillID string) (*models.Skill, *models.PlayerSkill, error) {
+	playerSkill, err := m.playerSkillDAL.GetPlayerSkillByID(characterID, skillID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get skill %s for character %s: %w", skillID, characterID, err)
+	}
+	if playerSkill == nil {
+		return nil, nil, ErrUnknownSkill
+	}
+	skill, err := m.skillDAL.GetSkillByID(skillID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get skill %s: %w", skillID, err)
+	}
+	if skill == nil {
+		return nil, nil, ErrUnknownSkill
+	}
+	return skill, playerSkill, nil
+}
+
+// raise adds amount to playerSkill's percentage, up to the skill's cap, and saves it.
+func (m *Manager) raise(skill *models.Skill, playerSkill *models.PlayerSkill, amount int) error {
+	limit, err := m.SkillCap(playerSkill.PlayerID, skill)
+	if err != nil {
+		return err
+	}
+	if playerSkill.Percentage >= limit {
+		return ErrSkillCapped
+	}
+	playerSkill.Percentage = min(limit, playerSkill.Percentage+max(0, amount))
+	if err := m.playerSkillDAL.UpdatePlayerSkill(playerSkill); err != nil {
+		return fmt.Errorf("failed to update skill %s for character %s: %w", skill.ID, playerSkill.PlayerID, err)
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a stats reward in alphabetical order.
+func sortedKeys(stats map[string]int) []string {
+	keys := make([]string, 0, len(stats))
+	for key := range stats {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,163 @@
+package progression
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/dal"
+	"mud/internal/game/events"
+	"mud/internal/models"
+	"mud/internal/testutils"
+	"mud/internal/testutils/testdal"
+)
+
+func setupManager(t *testing.T) (*Manager, *dal.DAL, *testutils.RecordingNotifier) {
+	t.Helper()
+
+	dals := testdal.New(t)
+	for _, class := range []*models.Class{
+		{ID: "basic_fighter", Name: "Basic Fighter", Description: "A foundational combat class.", TotalLevels: 5,
+			LevelUpRewards: `{"1": {"unlock_skill": "basic_strike"}, "2": {"unlock_skill": "defensive_stance"}, "3": {"choose_skill": ["cleave", "shield_bash"]}}`},
+		{ID: "sword_master", Name: "Sword Master", Description: "A specialized fighter focused on swords.", TotalLevels: 3, ParentClassID: "basic_fighter",
+			LevelUpRewards: `{}`},
+	} {
+		if err := dals.ClassDAL.CreateClass(class); err != nil {
+			t.Fatalf("Failed to create class: %v", err)
+		}
+	}
+	for _, skill := range []*models.Skill{
+		{ID: "basic_strike", Name: "Basic Strike", Category: "combat", Type: "active", AssociatedClassID: "basic_fighter", Effects: "{}"},
+		{ID: "defensive_stance", Name: "Defensive Stance", Category: "combat", Type: "active", AssociatedClassID: "basic_fighter", Effects: "{}"},
+		{ID: "cleave", Name: "Cleave", Category: "combat", Type: "active", AssociatedClassID: "basic_fighter", Effects: "{}"},
+		{ID: "shield_bash", Name: "Shield Bash", Category: "combat", Type: "active", AssociatedClassID: "basic_fighter", Effects: "{}"},
+		{ID: "stealth", Name: "Stealth", Category: "subterfuge", Type: "passive", Effects: "{}"},
+	} {
+		if err := dals.SkillDAL.CreateSkill(skill); err != nil {
+			t.Fatalf("Failed to create skill: %v", err)
+		}
+	}
+
+	notifier := &testutils.RecordingNotifier{}
+	m := NewManager(dals.ClassDAL, dals.PlayerClassDAL, dals.SkillDAL, dals.PlayerSkillDAL, notifier)
+	return m, dals, notifier
+}
+
+func TestManager_LevelUpRewards(t *testing.T) {
+	m, dals, notifier := setupManager(t)
+
+	_, err := m.Join("hero", "sword_master")
+	assert.ErrorIs(t, err, ErrParentClassRequired)
+	_, err = m.Join("hero", "necromancer")
+	assert.ErrorIs(t, err, ErrUnknownClass)
+
+	playerClass, err := m.Join("hero", "basic_fighter")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, playerClass.Level)
+	assert.Equal(t, []string{"You have learned Basic Strike."}, notifier.Take())
+	strike, err := dals.PlayerSkillDAL.GetPlayerSkillByID("hero", "basic_strike")
+	assert.NoError(t, err)
+	if assert.NotNil(t, strike) {
+		assert.Equal(t, 0, strike.Percentage)
+		assert.Equal(t, GrantedByClass, strike.GrantedByEntityType)
+		assert.Equal(t, "basic_fighter", strike.GrantedByEntityID)
+	}
+	_, err = m.Join("hero", "basic_fighter")
+	assert.ErrorIs(t, err, ErrAlreadyJoined)
+
+	levels, err := m.AwardExperience("hero", "basic_fighter", 99)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, levels)
+	assert.Empty(t, notifier.Take())
+
+	// 99 + 201 = 300 experience passes both the 100 and 200 thresholds.
+	levels, err = m.AwardExperience("hero", "basic_fighter", 201)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, levels)
+	assert.Equal(t, []string{
+		"You have reached level 2 as a Basic Fighter!",
+		"You have learned Defensive Stance.",
+		"You have reached level 3 as a Basic Fighter!",
+		"As a level 3 Basic Fighter you may learn one of: 1) Cleave, 2) Shield Bash. Type 'choose <number>' to decide.",
+	}, notifier.Take())
+	playerClass, err = dals.PlayerClassDAL.GetPlayerClassByID("hero", "basic_fighter")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, playerClass.Level)
+	assert.Equal(t, 0, playerClass.Experience)
+
+	_, err = m.Choose("hero", "3")
+	assert.ErrorIs(t, err, ErrInvalidChoice)
+	choices, err := m.PendingChoices("hero")
+	assert.NoError(t, err)
+	assert.Len(t, choices, 1)
+
+	skill, err := m.Choose("hero", "shield")
+	assert.NoError(t, err)
+	assert.Equal(t, "shield_bash", skill.ID)
+	assert.Equal(t, []string{"You have learned Shield Bash."}, notifier.Take())
+	_, err = m.Choose("hero", "1")
+	assert.ErrorIs(t, err, ErrNoChoicePending)
+
+	// The last level stops experience from accumulating.
+	levels, err = m.AwardExperience("hero", "basic_fighter", 10000)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, levels)
+	levels, err = m.AwardExperience("hero", "basic_fighter", 10000)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, levels)
+	playerClass, err = dals.PlayerClassDAL.GetPlayerClassByID("hero", "basic_fighter")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, playerClass.Level)
+	assert.Equal(t, 0, playerClass.Experience)
+}
+
+func TestManager_HandleActionEvent(t *testing.T) {
+	m, dals, _ := setupManager(t)
+	_, err := m.Join("hero", "basic_fighter")
+	assert.NoError(t, err)
+
+	hero := &models.PlayerCharacter{ID: "hero"}
+	m.HandleActionEvent(&events.ActionEvent{Player: hero, ActionType: "slay_npc"})
+	m.HandleActionEvent(&events.ActionEvent{Player: hero, ActionType: "use_skill", SkillUsed: &models.Skill{ID: "basic_strike", Category: "combat", AssociatedClassID: "basic_fighter"}})
+	// Neither subterfuge nor saying things is relevant to a fighter.
+	m.HandleActionEvent(&events.ActionEvent{Player: hero, ActionType: "tamper_lock"})
+	m.HandleActionEvent(&events.ActionEvent{Player: hero, ActionType: "use_skill", SkillUsed: &models.Skill{ID: "stealth", Category: "subterfuge"}})
+	m.HandleActionEvent(&events.ActionEvent{Player: hero, ActionType: "say"})
+
+	playerClass, err := dals.PlayerClassDAL.GetPlayerClassByID("hero", "basic_fighter")
+	assert.NoError(t, err)
+	assert.Equal(t, 35, playerClass.Experience)
+}
+
+func TestManager_SkillCap(t *testing.T) {
+	m, dals, _ := setupManager(t)
+	assert.NoError(t, dals.PlayerSkillDAL.CreatePlayerSkill(&models.PlayerSkill{PlayerID: "hero", SkillID: "cleave", Percentage: 10}))
+	assert.NoError(t, dals.PlayerSkillDAL.CreatePlayerSkill(&models.PlayerSkill{PlayerID: "hero", SkillID: "stealth", Percentage: 90}))
+
+	// Without the class, its skills cannot improve at all.
+	_, err := m.RaiseSkill("hero", "cleave", 5)
+	assert.ErrorIs(t, err, ErrSkillCapped)
+
+	_, err = m.Join("hero", "basic_fighter")
+	assert.NoError(t, err)
+	playerSkill, err := m.RaiseSkill("hero", "cleave", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, playerSkill.Percentage, "Level 1 of 5 caps skills at 20%")
+	_, err = m.RaiseSkill("hero", "cleave", 1)
+	assert.ErrorIs(t, err, ErrSkillCapped)
+
+	_, err = m.AwardExperience("hero", "basic_fighter", 100)
+	assert.NoError(t, err)
+	playerSkill, err = m.RaiseSkill("hero", "cleave", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, 40, playerSkill.Percentage)
+	saved, err := dals.PlayerSkillDAL.GetPlayerSkillByID("hero", "cleave")
+	assert.NoError(t, err)
+	assert.Equal(t, 40, saved.Percentage)
+
+	// Skills without a class are only capped at 100%.
+	playerSkill, err = m.RaiseSkill("hero", "stealth", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, playerSkill.Percentage)
+	_, err = m.RaiseSkill("hero", "juggling", 5)
+	assert.ErrorIs(t, err, ErrUnknownSkill)
+}
@@ -0,0 +1,44 @@
+package progression
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"mud/internal/models"
+)
+
+// LevelReward is what reaching one level of a class grants, as one entry of
+// a class's LevelUpRewards JSON object, e.g.
+// {"1": {"unlock_skill": "basic_strike"}, "3": {"choose_skill": ["cleave", "shield_bash"], "stats": {"strength": 1}}}.
+type LevelReward struct {
+	// UnlockSkill is learned automatically at 0%.
+	UnlockSkill string `json:"unlock_skill,omitempty"`
+	// ChooseSkill lists skills the player picks one of with the choose command.
+	ChooseSkill []string `json:"choose_skill,omitempty"`
+	// Stats are added to the character's attributes for as long as they have the level.
+	Stats map[string]int `json:"stats,omitempty"`
+}
+
+// ParseRewards decodes a class's LevelUpRewards into rewards keyed by level.
+// Classes whose rewards are empty grant nothing.
+func ParseRewards(class *models.Class) (map[int]LevelReward, error) {
+	raw := strings.TrimSpace(class.LevelUpRewards)
+	if raw == "" || raw == "null" {
+		return nil, nil
+	}
+	var byKey map[string]LevelReward
+	if err := json.Unmarshal([]byte(raw), &byKey); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal level up rewards for class %s: %w", class.ID, err)
+	}
+	rewards := make(map[int]LevelReward, len(byKey))
+	for key, reward := range byKey {
+		level, err := strconv.Atoi(strings.TrimSpace(key))
+		if err != nil || level < 1 {
+			return nil, fmt.Errorf("invalid level %q in level up rewards for class %s", key, class.ID)
+		}
+		rewards[level] = reward
+	}
+	return rewards, nil
+}
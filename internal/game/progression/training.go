@@ -0,0 +1,166 @@
+package progression
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"mud/internal/models"
+)
+
+// KnownSkill is a skill a character knows, with the highest percentage the
+// character can currently reach in it.
+type KnownSkill struct {
+	Skill       *models.Skill
+	PlayerSkill *models.PlayerSkill
+	Cap         int
+}
+
+// TrainerClass returns the ID of the class an NPC trains, taken from the
+// "trains_class" key of its BehaviorState, or "" if it trains none.
+func TrainerClass(npc *models.NPC) string {
+	var state struct {
+		TrainsClass string `json:"trains_class"`
+	}
+	if npc == nil || json.Unmarshal([]byte(npc.BehaviorState), &state) != nil {
+		return ""
+	}
+	return state.TrainsClass
+}
+
+// Skills returns the skills the character knows with their caps, ordered by name.
+func (m *Manager) Skills(characterID string) ([]KnownSkill, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.skills(characterID)
+}
+
+func (m *Manager) skills(characterID string) ([]KnownSkill, error) {
+	playerSkills, err := m.playerSkillDAL.GetPlayerSkillsByPlayerID(characterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get skills for character %s: %w", characterID, err)
+	}
+	known := make([]KnownSkill, 0, len(playerSkills))
+	for _, playerSkill := range playerSkills {
+		skill, err := m.skillDAL.GetSkillByID(playerSkill.SkillID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get skill %s: %w", playerSkill.SkillID, err)
+		}
+		if skill == nil {
+			continue
+		}
+		limit, err := m.SkillCap(characterID, skill)
+		if err != nil {
+			return nil, err
+		}
+		known = append(known, KnownSkill{Skill: skill, PlayerSkill: playerSkill, Cap: limit})
+	}
+	sort.Slice(known, func(i, j int) bool { return known[i].Skill.Name < known[j].Skill.Name })
+	return known, nil
+}
+
+// Improve rolls to improve a skill the character has just used. The chance
+// of gaining a point is the distance left to 100% scaled by SuccessLearnRate
+// or FailureLearnRate, so skills improve quickly at first and slowly near
+// mastery. Skills at their cap do not improve. It reports whether the skill
+// improved, and narrates it if so.
+func (m *Manager) Improve(characterID, skillID string, succeeded bool) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	skill, playerSkill, err := m.knownSkill(characterID, skillID)
+	if err != nil {
+		return false, err
+	}
+	rate := m.SuccessLearnRate
+	if !succeeded {
+		rate = m.FailureLearnRate
+	}
+	if m.roll(100) >= (100-playerSkill.Percentage)*rate/100 {
+		return false, nil
+	}
+	if err := m.raise(skill, playerSkill, 1); err != nil {
+		if errors.Is(err, ErrSkillCapped) {
+			return false, nil
+		}
+		return false, err
+	}
+	m.notifier.Notify(characterID, fmt.Sprintf("You have become better at %s! (%d%%)", skill.Name, playerSkill.Percentage))
+	return true, nil
+}
+
+// Practice spends a practice point of a skill's class to improve the skill
+// with a trainer of that class. The skill is matched by ID or name, exactly
+// or by prefix. A session gains PracticeRate percent of the distance left to
+// 100%, at least a point, up to the skill's cap; nothing is spent on a
+// capped skill. It returns the skill as it now stands and the points gained.
+func (m *Manager) Practice(characterID string, trainer *models.NPC, query string) (*KnownSkill, int, error) {
+	classID := TrainerClass(trainer)
+	if classID == "" {
+		return nil, 0, ErrNotTrainer
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	skills, err := m.skills(characterID)
+	if err != nil {
+		return nil, 0, err
+	}
+	known := matchSkill(skills, query)
+	if known == nil {
+		return nil, 0, ErrUnknownSkill
+	}
+	if known.Skill.AssociatedClassID != classID {
+		return known, 0, ErrNotTaught
+	}
+	playerClass, err := m.playerClassDAL.GetPlayerClassByID(characterID, classID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get class %s for character %s: %w", classID, characterID, err)
+	}
+	if playerClass == nil || playerClass.PracticePoints <= 0 {
+		return known, 0, ErrNoPracticePoints
+	}
+
+	before := known.PlayerSkill.Percentage
+	limit, err := m.SkillCap(characterID, known.Skill)
+	if err != nil {
+		return known, 0, err
+	}
+	if before >= limit {
+		return known, 0, ErrSkillCapped
+	}
+
+	// The point is spent before the skill is raised, so that a failure in
+	// between costs the character a point rather than granting a free session.
+	playerClass.PracticePoints--
+	if err := m.playerClassDAL.UpdatePlayerClass(playerClass); err != nil {
+		return nil, 0, fmt.Errorf("failed to update class %s for character %s: %w", classID, characterID, err)
+	}
+	gain := max(1, (100-before)*m.PracticeRate/100)
+	if err := m.raise(known.Skill, known.PlayerSkill, gain); err != nil {
+		return known, 0, err
+	}
+	return known, known.PlayerSkill.Percentage - before, nil
+}
+
+// matchSkill finds a skill by ID or name, preferring an exact match to a prefix.
+func matchSkill(skills []KnownSkill, query string) *KnownSkill {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+	var partial *KnownSkill
+	for i := range skills {
+		id, name := strings.ToLower(skills[i].Skill.ID), strings.ToLower(skills[i].Skill.Name)
+		if id == query || name == query || id == strings.ReplaceAll(query, " ", "_") {
+			return &skills[i]
+		}
+		if partial == nil && (strings.HasPrefix(name, query) || strings.HasPrefix(id, query)) {
+			partial = &skills[i]
+		}
+	}
+	return partial
+}
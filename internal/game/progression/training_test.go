@@ -0,0 +1,100 @@
+package progression
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/models"
+)
+
+func TestManager_Improve(t *testing.T) {
+	m, dals, notifier := setupManager(t)
+	_, err := m.Join("hero", "basic_fighter")
+	assert.NoError(t, err)
+	notifier.Take()
+	var rolled int
+	m.roll = func(n int) int { return rolled }
+
+	// At 0% a success improves the skill half the time.
+	rolled = 49
+	improved, err := m.Improve("hero", "basic_strike", true)
+	assert.NoError(t, err)
+	assert.True(t, improved)
+	assert.Equal(t, []string{"You have become better at Basic Strike! (1%)"}, notifier.Take())
+	rolled = 50
+	improved, err = m.Improve("hero", "basic_strike", true)
+	assert.NoError(t, err)
+	assert.False(t, improved)
+
+	// Failures teach more, but less the better the skill already is.
+	strike, err := dals.PlayerSkillDAL.GetPlayerSkillByID("hero", "basic_strike")
+	assert.NoError(t, err)
+	strike.Percentage = 19
+	assert.NoError(t, dals.PlayerSkillDAL.UpdatePlayerSkill(strike))
+	rolled = 80
+	improved, err = m.Improve("hero", "basic_strike", false)
+	assert.NoError(t, err)
+	assert.True(t, improved)
+	rolled = 0
+	improved, err = m.Improve("hero", "basic_strike", false)
+	assert.NoError(t, err)
+	assert.False(t, improved, "Level 1 of 5 caps skills at 20%")
+	assert.Equal(t, []string{"You have become better at Basic Strike! (20%)"}, notifier.Take())
+
+	_, err = m.Improve("hero", "cleave", true)
+	assert.ErrorIs(t, err, ErrUnknownSkill)
+}
+
+func TestManager_Practice(t *testing.T) {
+	m, dals, _ := setupManager(t)
+	assert.NoError(t, dals.PlayerSkillDAL.CreatePlayerSkill(&models.PlayerSkill{PlayerID: "hero", SkillID: "stealth", Percentage: 10}))
+	trainer := &models.NPC{ID: "sergeant", Name: "Sergeant", BehaviorState: `{"trains_class": "basic_fighter"}`}
+	commoner := &models.NPC{ID: "farmer", Name: "Farmer", BehaviorState: "{}"}
+
+	_, _, err := m.Practice("hero", commoner, "basic strike")
+	assert.ErrorIs(t, err, ErrNotTrainer)
+	_, _, err = m.Practice("hero", trainer, "basic strike")
+	assert.ErrorIs(t, err, ErrUnknownSkill)
+
+	playerClass, err := m.Join("hero", "basic_fighter")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPracticesPerLevel, playerClass.PracticePoints)
+	_, _, err = m.Practice("hero", trainer, "stealth")
+	assert.ErrorIs(t, err, ErrNotTaught)
+
+	known, gained, err := m.Practice("hero", trainer, "basic")
+	assert.NoError(t, err)
+	assert.Equal(t, "basic_strike", known.Skill.ID)
+	assert.Equal(t, 20, gained, "A fifth of the way to 100%, up to the cap")
+	_, _, err = m.Practice("hero", trainer, "basic")
+	assert.ErrorIs(t, err, ErrSkillCapped)
+
+	// Levelling up raises the cap and grants more practice points.
+	_, err = m.AwardExperience("hero", "basic_fighter", 100)
+	assert.NoError(t, err)
+	known, gained, err = m.Practice("hero", trainer, "basic_strike")
+	assert.NoError(t, err)
+	assert.Equal(t, 16, gained)
+	assert.Equal(t, 36, known.PlayerSkill.Percentage)
+	assert.Equal(t, 40, known.Cap)
+	playerClass, err = dals.PlayerClassDAL.GetPlayerClassByID("hero", "basic_fighter")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, playerClass.PracticePoints)
+
+	for i := 0; i < 2; i++ {
+		_, _, err = m.Practice("hero", trainer, "defensive stance")
+		assert.NoError(t, err)
+	}
+	_, _, err = m.Practice("hero", trainer, "defensive stance")
+	assert.ErrorIs(t, err, ErrNoPracticePoints)
+
+	skills, err := m.Skills("hero")
+	assert.NoError(t, err)
+	if assert.Len(t, skills, 3) {
+		assert.Equal(t, "Basic Strike", skills[0].Skill.Name)
+		assert.Equal(t, "Defensive Stance", skills[1].Skill.Name)
+		assert.Equal(t, 36, skills[1].PlayerSkill.Percentage)
+		assert.Equal(t, "Stealth", skills[2].Skill.Name)
+		assert.Equal(t, 100, skills[2].Cap)
+	}
+}
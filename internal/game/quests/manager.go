@@ -0,0 +1,224 @@
+package quests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"mud/internal/dal"
+	"mud/internal/game/events"
+	"mud/internal/models"
+)
+
+var (
+	// ErrUnknownQuest is returned when offering a quest that does not exist.
+	ErrUnknownQuest = errors.New("unknown quest")
+	// ErrUnknownTrigger is returned when offering a quest with a trigger type that is not supported.
+	ErrUnknownTrigger = errors.New("unknown quest trigger")
+	// ErrQuestTaken is returned when the player has already been offered, or taken on, the quest.
+	ErrQuestTaken = errors.New("player already has the quest")
+)
+
+// Notifier receives the narration of quests beginning.
+type Notifier interface {
+	Notify(characterID, content string)
+}
+
+// progress is the CurrentProgress of a quest state while it waits for its trigger.
+type progress struct {
+	Trigger *models.QuestTrigger `json:"trigger,omitempty"`
+}
+
+// Manager offers quests to players and begins them when the player sets off
+// the trigger they wait on, such as talking to an NPC or entering a room.
+type Manager struct {
+	questDAL      dal.QuestDALInterface
+	questStateDAL dal.PlayerQuestStateDALInterface
+	npcDAL        dal.NPCDALInterface
+	notifier      Notifier
+	mu            sync.Mutex
+	now           func() time.Time // Replaced in tests
+}
+
+// NewManager creates a new Manager.
+func NewManager(questDAL dal.QuestDALInterface, questStateDAL dal.PlayerQuestStateDALInterface, npcDAL dal.NPCDALInterface, notifier Notifier) *Manager {
+	return &Manager{
+		questDAL:      questDAL,
+		questStateDAL: questStateDAL,
+		npcDAL:        npcDAL,
+		notifier:      notifier,
+		now:           time.Now,
+	}
+}
+
+// Offer makes a quest available to a player. With no trigger the quest
+// begins at once; otherwise it is offered and waits until the player sets
+// the trigger off. A quest the player failed or abandoned can be offered
+// again; one they have been offered, are on or have completed cannot.
+func (m *Manager) Offer(playerID, questID string, trigger *models.QuestTrigger) (*models.PlayerQuestState, error) {
+	if trigger != nil {
+		switch trigger.Type {
+		case models.QuestTriggerTalkToNPC, models.QuestTriggerEnterRoom, models.QuestTriggerInteractItem:
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrUnknownTrigger, trigger.Type)
+		}
+		if trigger.ID == "" {
+			return nil, fmt.Errorf("%w: %s needs an ID", ErrUnknownTrigger, trigger.Type)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	quest, err := m.questDAL.GetQuestByID(questID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quest %s: %w", questID, err)
+	}
+	if quest == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownQuest, questID)
+	}
+	existing, err := m.questStateDAL.GetPlayerQuestStateByID(playerID, questID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state of quest %s for character %s: %w", questID, playerID, err)
+	}
+	if existing != nil && existing.Status != models.QuestStatusFailed && existing.Status != models.QuestStatusAbandoned {
+		return nil, fmt.Errorf("%w: %s is %s", ErrQuestTaken, questID, existing.Status)
+	}
+
+	current, err := json.Marshal(progress{Trigger: trigger})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal quest progress: %w", err)
+	}
+	state := &models.PlayerQuestState{
+		PlayerID:            playerID,
+		QuestID:             questID,
+		CurrentProgress:     string(current),
+		LastActionTimestamp: m.now(),
+		Status:              models.QuestStatusOffered,
+	}
+	if trigger == nil {
+		state.Status = models.QuestStatusActive
+	}
+	if existing == nil {
+		err = m.questStateDAL.CreatePlayerQuestState(state)
+	} else {
+		err = m.questStateDAL.UpdatePlayerQuestState(state)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save state of quest %s for character %s: %w", questID, playerID, err)
+	}
+	if state.Status == models.QuestStatusActive {
+		m.announce(playerID, quest)
+	}
+	return state, nil
+}
+
+// Fire begins every quest offered to the player that waits on the trigger,
+// and returns their states. Trigger IDs match regardless of case.
+func (m *Manager) Fire(playerID string, trigger models.QuestTrigger) ([]*models.PlayerQuestState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	states, err := m.questStateDAL.GetPlayerQuestStatesByPlayerID(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quest states for character %s: %w", playerID, err)
+	}
+	var begun []*models.PlayerQuestState
+	for _, state := range states {
+		if state.Status != models.QuestStatusOffered {
+			continue
+		}
+		var current progress
+		if err := json.Unmarshal([]byte(state.CurrentProgress), &current); err != nil || current.Trigger == nil {
+			logrus.Errorf("Quests: Offered quest %s for character %s has no trigger: %v", state.QuestID, playerID, err)
+			continue
+		}
+		if current.Trigger.Type != trigger.Type || !strings.EqualFold(current.Trigger.ID, trigger.ID) {
+			continue
+		}
+
+		state.Status = models.QuestStatusActive
+		state.CurrentProgress = "{}"
+		state.LastActionTimestamp = m.now()
+		if err := m.questStateDAL.UpdatePlayerQuestState(state); err != nil {
+			return begun, fmt.Errorf("failed to begin quest %s for character %s: %w", state.QuestID, playerID, err)
+		}
+		begun = append(begun, state)
+		quest, err := m.questDAL.GetQuestByID(state.QuestID)
+		if err != nil || quest == nil {
+			logrus.Errorf("Quests: Failed to get quest %s to announce it: %v", state.QuestID, err)
+			continue
+		}
+		m.announce(playerID, quest)
+	}
+	return begun, nil
+}
+
+// HandleActionEvent fires the triggers a player's action sets off: entering
+// a room, talking to an NPC and handling items.
+func (m *Manager) HandleActionEvent(event *events.ActionEvent) {
+	if event.Player == nil {
+		return
+	}
+	var triggers []models.QuestTrigger
+	for _, target := range event.Targets {
+		switch t := target.(type) {
+		case string:
+			switch event.ActionType {
+			case "move":
+				triggers = append(triggers, models.QuestTrigger{Type: models.QuestTriggerEnterRoom, ID: t})
+			case "talk":
+				if npc := m.findNPC(event.Room, t); npc != nil {
+					triggers = append(triggers, models.QuestTrigger{Type: models.QuestTriggerTalkToNPC, ID: npc.ID})
+				}
+			}
+		case *models.NPC:
+			if event.ActionType == "talk" {
+				triggers = append(triggers, models.QuestTrigger{Type: models.QuestTriggerTalkToNPC, ID: t.ID})
+			}
+		case *models.ItemInstance:
+			triggers = append(triggers, models.QuestTrigger{Type: models.QuestTriggerInteractItem, ID: t.TemplateID})
+			triggers = append(triggers, models.QuestTrigger{Type: models.QuestTriggerInteractItem, ID: t.ID})
+		}
+	}
+	for _, trigger := range triggers {
+		if _, err := m.Fire(event.Player.ID, trigger); err != nil {
+			logrus.Errorf("Quests: Failed to fire %s %s for character %s: %v", trigger.Type, trigger.ID, event.Player.ID, err)
+		}
+	}
+}
+
+// findNPC matches a living NPC in a room by ID or name, as players name them
+// when they talk.
+func (m *Manager) findNPC(room *models.Room, query string) *models.NPC {
+	if room == nil {
+		return nil
+	}
+	npcs, err := m.npcDAL.GetNPCsByRoom(room.ID)
+	if err != nil {
+		logrus.Errorf("Quests: Failed to get NPCs in room %s: %v", room.ID, err)
+		return nil
+	}
+	query = strings.ToLower(strings.TrimSpace(query))
+	for _, npc := range npcs {
+		if npc.Health > 0 && (strings.ToLower(npc.ID) == query || strings.ToLower(npc.Name) == query) {
+			return npc
+		}
+	}
+	return nil
+}
+
+func (m *Manager) announce(playerID string, quest *models.Quest) {
+	if m.notifier == nil {
+		return
+	}
+	content := fmt.Sprintf("A new quest begins: %s.", quest.Name)
+	if quest.Description != "" {
+		content += " " + quest.Description
+	}
+	m.notifier.Notify(playerID, content)
+}
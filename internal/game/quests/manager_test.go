@@ -0,0 +1,95 @@
+package quests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/dal"
+	"mud/internal/game/events"
+	"mud/internal/models"
+	"mud/internal/testutils"
+	"mud/internal/testutils/testdal"
+)
+
+func setupManager(t *testing.T) (*Manager, *dal.DAL, *testutils.RecordingNotifier) {
+	t.Helper()
+
+	dals := testdal.New(t)
+	for _, quest := range []*models.Quest{
+		{ID: "pony", Name: "The Missing Pony", Description: "Bill the pony has wandered off.", Objectives: "[]", Rewards: "[]", InfluencePointsMap: "{}"},
+		{ID: "barrow", Name: "Barrow Wights", Objectives: "[]", Rewards: "[]", InfluencePointsMap: "{}"},
+		{ID: "ring", Name: "The Lost Ring", Objectives: "[]", Rewards: "[]", InfluencePointsMap: "{}"},
+	} {
+		assert.NoError(t, dals.QuestDAL.CreateQuest(quest))
+	}
+	assert.NoError(t, dals.NpcDAL.CreateNPC(&models.NPC{ID: "barliman", Name: "Barliman", CurrentRoomID: "inn", Health: 10, MaxHealth: 10, BehaviorState: "{}"}))
+
+	notifier := &testutils.RecordingNotifier{}
+	m := NewManager(dals.QuestDAL, dals.PlayerQuestState, dals.NpcDAL, notifier)
+	m.now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+	return m, dals, notifier
+}
+
+func TestManager_Offer(t *testing.T) {
+	m, dals, notifier := setupManager(t)
+
+	_, err := m.Offer("hero", "dragon", nil)
+	assert.ErrorIs(t, err, ErrUnknownQuest)
+	_, err = m.Offer("hero", "pony", &models.QuestTrigger{Type: "on_sneeze", ID: "barliman"})
+	assert.ErrorIs(t, err, ErrUnknownTrigger)
+	_, err = m.Offer("hero", "pony", &models.QuestTrigger{Type: models.QuestTriggerTalkToNPC})
+	assert.ErrorIs(t, err, ErrUnknownTrigger)
+
+	// Without a trigger the quest begins at once.
+	state, err := m.Offer("hero", "barrow", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, models.QuestStatusActive, state.Status)
+	assert.Equal(t, []string{"A new quest begins: Barrow Wights."}, notifier.MessagesFor("hero"))
+	_, err = m.Offer("hero", "barrow", nil)
+	assert.ErrorIs(t, err, ErrQuestTaken)
+
+	// A failed quest can be offered again.
+	state.Status = models.QuestStatusFailed
+	assert.NoError(t, dals.PlayerQuestState.UpdatePlayerQuestState(state))
+	state, err = m.Offer("hero", "barrow", &models.QuestTrigger{Type: models.QuestTriggerEnterRoom, ID: "barrow_downs"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.QuestStatusOffered, state.Status)
+	stored, err := dals.PlayerQuestState.GetPlayerQuestStateByID("hero", "barrow")
+	assert.NoError(t, err)
+	assert.Equal(t, models.QuestStatusOffered, stored.Status)
+	assert.JSONEq(t, `{"trigger": {"type": "on_enter_room", "id": "barrow_downs"}}`, stored.CurrentProgress)
+}
+
+func TestManager_Triggers(t *testing.T) {
+	m, dals, notifier := setupManager(t)
+	_, err := m.Offer("hero", "pony", &models.QuestTrigger{Type: models.QuestTriggerTalkToNPC, ID: "barliman"})
+	assert.NoError(t, err)
+	_, err = m.Offer("hero", "barrow", &models.QuestTrigger{Type: models.QuestTriggerEnterRoom, ID: "barrow_downs"})
+	assert.NoError(t, err)
+	_, err = m.Offer("hero", "ring", &models.QuestTrigger{Type: models.QuestTriggerInteractItem, ID: "gold_ring"})
+	assert.NoError(t, err)
+	assert.Empty(t, notifier.MessagesFor("hero"), "Offered quests wait for their trigger")
+
+	hero := &models.PlayerCharacter{ID: "hero", CurrentRoomID: "inn"}
+	inn := &models.Room{ID: "inn"}
+	m.HandleActionEvent(&events.ActionEvent{Player: hero, ActionType: "talk", Room: inn, Targets: []interface{}{"the cat"}})
+	m.HandleActionEvent(&events.ActionEvent{Player: hero, ActionType: "move", Room: inn, Targets: []interface{}{"market"}})
+	assert.Empty(t, notifier.MessagesFor("hero"))
+
+	m.HandleActionEvent(&events.ActionEvent{Player: hero, ActionType: "talk", Room: inn, Targets: []interface{}{"barliman"}})
+	assert.Equal(t, []string{"A new quest begins: The Missing Pony. Bill the pony has wandered off."}, notifier.MessagesFor("hero"))
+	m.HandleActionEvent(&events.ActionEvent{Player: hero, ActionType: "move", Room: inn, Targets: []interface{}{"barrow_downs"}})
+	m.HandleActionEvent(&events.ActionEvent{Player: hero, ActionType: "get_item", Room: inn, Targets: []interface{}{&models.ItemInstance{ID: "instance-1", TemplateID: "gold_ring"}}})
+
+	for _, questID := range []string{"pony", "barrow", "ring"} {
+		state, err := dals.PlayerQuestState.GetPlayerQuestStateByID("hero", questID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.QuestStatusActive, state.Status, questID)
+	}
+	assert.Len(t, notifier.MessagesFor("hero"), 3)
+
+	begun, err := m.Fire("hero", models.QuestTrigger{Type: models.QuestTriggerTalkToNPC, ID: "barliman"})
+	assert.NoError(t, err)
+	assert.Empty(t, begun, "A quest only begins once")
+}
@@ -0,0 +1,204 @@
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultTickRate is how often Run checks for jobs that are due.
+const DefaultTickRate = 100 * time.Millisecond
+
+// Clock tells the scheduler the time.
+type Clock interface {
+	Now() time.Time
+}
+
+// RealClock is the wall clock.
+type RealClock struct{}
+
+// Now returns the current time.
+func (RealClock) Now() time.Time { return time.Now() }
+
+// FakeClock is a Clock that only moves when it is advanced, so tests can
+// decide exactly when jobs become due and then call Tick.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock creates a FakeClock stopped at start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now returns the time the clock is stopped at.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Job is a function the scheduler runs once after a delay, or periodically.
+type Job struct {
+	name      string
+	interval  time.Duration // Zero for jobs that run once
+	due       time.Time
+	seq       uint64 // Registration order, to run jobs due at the same time in order
+	fn        func()
+	cancelled bool
+}
+
+// Name returns the name the job was registered with.
+func (j *Job) Name() string { return j.name }
+
+// Scheduler is the world's game loop. Subsystems register periodic jobs,
+// such as combat rounds and regeneration, and delayed ones, such as doors
+// relocking, and Run runs them as they fall due on a single goroutine.
+type Scheduler struct {
+	clock Clock
+	mu    sync.Mutex
+	jobs  []*Job
+	seq   uint64
+
+	// TickRate is how often Run checks for due jobs, and so how late a job
+	// can run. It must be set before Run is called.
+	TickRate time.Duration
+}
+
+// NewScheduler creates a new Scheduler that tells the time by clock.
+func NewScheduler(clock Clock) *Scheduler {
+	return &Scheduler{clock: clock, TickRate: DefaultTickRate}
+}
+
+// Now returns the scheduler's current time.
+func (s *Scheduler) Now() time.Time {
+	return s.clock.Now()
+}
+
+// Every runs fn every interval, starting one interval from now, until the job
+// is cancelled. A job that falls behind runs once and then resumes a full
+// interval later rather than catching up.
+func (s *Scheduler) Every(name string, interval time.Duration, fn func()) *Job {
+	if interval <= 0 {
+		interval = s.TickRate
+	}
+	return s.add(&Job{name: name, interval: interval, due: s.clock.Now().Add(interval), fn: fn})
+}
+
+// After runs fn once, delay from now, unless the job is cancelled first.
+func (s *Scheduler) After(name string, delay time.Duration, fn func()) *Job {
+	return s.add(&Job{name: name, due: s.clock.Now().Add(delay), fn: fn})
+}
+
+func (s *Scheduler) add(job *Job) *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	job.seq = s.seq
+	s.jobs = append(s.jobs, job)
+	return job
+}
+
+// Cancel stops a job from running again. Cancelling a job that has already
+// run, or a nil job, does nothing.
+func (s *Scheduler) Cancel(job *Job) {
+	if job == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.cancelled = true
+	s.remove(job)
+}
+
+func (s *Scheduler) remove(job *Job) {
+	for i, j := range s.jobs {
+		if j == job {
+			s.jobs = append(s.jobs[:i], s.jobs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Len returns the number of jobs waiting to run.
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.jobs)
+}
+
+// Run calls Tick every TickRate until ctx is cancelled. Jobs run on Run's
+// goroutine, so none is running once it has returned.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.TickRate)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Tick()
+		}
+	}
+}
+
+// Tick runs every job that is due, in the order they fell due. Jobs may
+// register and cancel jobs, including ones due in the same tick. A job that
+// panics is logged and does not stop the others.
+func (s *Scheduler) Tick() {
+	now := s.clock.Now()
+
+	s.mu.Lock()
+	var due []*Job
+	for _, job := range s.jobs {
+		if !job.due.After(now) {
+			due = append(due, job)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].due.Equal(due[j].due) {
+			return due[i].due.Before(due[j].due)
+		}
+		return due[i].seq < due[j].seq
+	})
+	for _, job := range due {
+		if job.interval == 0 {
+			s.remove(job)
+			continue
+		}
+		job.due = job.due.Add(job.interval)
+		if !job.due.After(now) {
+			job.due = now.Add(job.interval)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, job := range due {
+		s.mu.Lock()
+		cancelled := job.cancelled
+		s.mu.Unlock()
+		if !cancelled {
+			s.run(job)
+		}
+	}
+}
+
+func (s *Scheduler) run(job *Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.Errorf("Scheduler: Job %s panicked: %v", job.name, r)
+		}
+	}()
+	job.fn()
+}
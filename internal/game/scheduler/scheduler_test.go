@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler_Tick(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := NewScheduler(clock)
+	var ran []string
+	record := func(name string) func() { return func() { ran = append(ran, name) } }
+
+	s.Every("regen", 10*time.Second, record("regen"))
+	relock := s.After("relock", 15*time.Second, record("relock"))
+	s.After("weather", 5*time.Second, record("weather"))
+
+	s.Tick()
+	assert.Empty(t, ran, "Nothing is due yet")
+
+	clock.Advance(10 * time.Second)
+	s.Tick()
+	assert.Equal(t, []string{"weather", "regen"}, ran, "Jobs run in the order they fell due")
+
+	// A job that is cancelled never runs.
+	ran = nil
+	s.Cancel(relock)
+	s.Cancel(relock)
+	s.Cancel(nil)
+	clock.Advance(10 * time.Second)
+	s.Tick()
+	assert.Equal(t, []string{"regen"}, ran)
+	assert.Equal(t, 1, s.Len(), "Only the periodic job is left")
+
+	// A periodic job that falls behind runs once rather than catching up.
+	ran = nil
+	clock.Advance(35 * time.Second)
+	s.Tick()
+	s.Tick()
+	assert.Equal(t, []string{"regen"}, ran)
+	clock.Advance(9 * time.Second)
+	s.Tick()
+	assert.Equal(t, []string{"regen"}, ran)
+	clock.Advance(time.Second)
+	s.Tick()
+	assert.Equal(t, []string{"regen", "regen"}, ran)
+}
+
+func TestScheduler_JobsManageJobs(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := NewScheduler(clock)
+	var ran []string
+
+	var second *Job
+	s.After("first", time.Second, func() {
+		ran = append(ran, "first")
+		s.Cancel(second)
+		s.After("follow-up", 0, func() { ran = append(ran, "follow-up") })
+	})
+	second = s.After("second", time.Second, func() { ran = append(ran, "second") })
+	s.After("panics", time.Second, func() { panic("boom") })
+	s.After("third", time.Second, func() { ran = append(ran, "third") })
+
+	clock.Advance(time.Second)
+	s.Tick()
+	assert.Equal(t, []string{"first", "third"}, ran, "A cancelled job does not run and a panic does not stop the tick")
+	s.Tick()
+	assert.Equal(t, []string{"first", "third", "follow-up"}, ran, "Jobs added during a tick wait for the next one")
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestScheduler_Run(t *testing.T) {
+	s := NewScheduler(RealClock{})
+	s.TickRate = time.Millisecond
+	ran := make(chan struct{}, 100)
+	s.Every("ping", time.Millisecond, func() { ran <- struct{}{} })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	select {
+	case <-ran:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the job to run")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected Run to return once the context is cancelled")
+	}
+}
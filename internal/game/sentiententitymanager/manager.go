@@ -2,18 +2,26 @@ package sentiententitymanager
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"mud/internal/dal"
 	"mud/internal/game"
 	"mud/internal/game/events"
 	"mud/internal/game/perception"
+	"mud/internal/llm"
 	"mud/internal/models"
 )
 
 // SentientEntityManager orchestrates AI responses based on significant player actions.
 type SentientEntityManager struct {
+	// ReactionTimeout is how long an entity may take to react to a player,
+	// including its follow-up.
+	ReactionTimeout time.Duration
+
 	llmService    game.LLMServiceInterface
 	npcDAL        dal.NPCDALInterface
 	ownerDAL      dal.OwnerDALInterface
@@ -34,6 +42,7 @@ func NewSentientEntityManager(
 	eventBus *events.EventBus,
 ) *SentientEntityManager {
 	return &SentientEntityManager{
+		ReactionTimeout: 90 * time.Second,
 		llmService:    llmService,
 		npcDAL:        npcDAL,
 		ownerDAL:      ownerDAL,
@@ -122,36 +131,96 @@ func (m *SentientEntityManager) TriggerReaction(observer interface{}, perceivedA
 	// For now, just a simple prompt based on the first RELEVANT action
 	prompt := fmt.Sprintf("Player %s performed action %s (clarity %.2f). Respond to this.", player.Name, relevantPerceivedActions[0].PerceivedAction.PerceivedActionType, relevantPerceivedActions[0].PerceivedAction.Clarity)
 
-	// 5. Send to LLM
-	llmResponse, err := m.llmService.ProcessAction(context.Background(), entity, player, prompt)
+	// 5. Send to LLM. While the entity's provider is unavailable it falls
+	// back on a canned reaction rather than ignoring the player.
+	ctx, cancel := context.WithTimeout(context.Background(), m.ReactionTimeout)
+	defer cancel()
+	llmResponse, err := m.llmService.ProcessAction(ctx, entity, player, prompt)
+	if errors.Is(err, llm.ErrUnavailable) {
+		logrus.Warnf("Entity %s falls back on a canned reaction: %v", entityID, err)
+		m.fallBack(observer, entity, player)
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("LLM Service ProcessAction failed for entity %s: %w", entityID, err)
 	}
 
 	// 6. Handle LLM Response
-	if llmResponse != nil {
-		// Publish narrative to player
-		if llmResponse.Narrative != "" {
-			playerMessage := &events.PlayerMessageEvent{
-				PlayerID: player.ID,
-				Content:  fmt.Sprintf("%s says: %s", getObserverName(observer), llmResponse.Narrative),
-			}
-			m.eventBus.Publish(events.PlayerMessageEventType, playerMessage)
-			logrus.Printf("LLM Narrative for %s: %s", entityID, llmResponse.Narrative)
+	results := m.respond(observer, entity, player, llmResponse)
+
+	// 7. Tell the entity which calls were not carried out, whether refused
+	// for lack of influence, forbidden or invalid, so it can answer in
+	// character. Only one follow-up is made.
+	var refusals []string
+	for _, result := range results {
+		if result.Status != llm.ToolStatusOK {
+			refusals = append(refusals, fmt.Sprintf("%s was %s: %s.", result.ToolName, result.Status, result.Message))
 		}
-
-		// Dispatch tool calls
-		if len(llmResponse.ToolCalls) > 0 {
-			logrus.Printf("LLM Tool Calls for %s: %+v", entityID, llmResponse.ToolCalls)
-			err = m.toolDispatcher.Dispatch(context.Background(), player, entity, llmResponse.ToolCalls)
-			if err != nil {
-				logrus.Errorf("Failed to dispatch tool calls for entity %s: %v", entityID, err)
-			}
+	}
+	if len(refusals) > 0 {
+		followUp := fmt.Sprintf("Not everything you attempted was carried out. %s Respond to %s without those actions.", strings.Join(refusals, " "), player.Name)
+		llmResponse, err = m.llmService.ProcessAction(ctx, entity, player, followUp)
+		if errors.Is(err, llm.ErrUnavailable) {
+			// The entity has already answered the player.
+			logrus.Warnf("Entity %s could not follow up on rejected tool calls: %v", entityID, err)
+			return nil
 		}
+		if err != nil {
+			return fmt.Errorf("LLM Service ProcessAction failed for entity %s after rejected tool calls: %w", entityID, err)
+		}
+		m.respond(observer, entity, player, llmResponse)
 	}
 	return nil
 }
 
+// respond publishes an LLM response's narrative to the player and dispatches
+// its tool calls, returning what became of them.
+func (m *SentientEntityManager) respond(observer, entity interface{}, player *models.PlayerCharacter, llmResponse *llm.InnerLLMResponse) []llm.ToolResult {
+	if llmResponse == nil {
+		return nil
+	}
+	entityID := getObserverID(observer)
+
+	// Publish narrative to player
+	if llmResponse.Narrative != "" {
+		playerMessage := &events.PlayerMessageEvent{
+			PlayerID: player.ID,
+			Content:  fmt.Sprintf("%s says: %s", getObserverName(observer), llmResponse.Narrative),
+		}
+		m.eventBus.Publish(events.PlayerMessageEventType, playerMessage)
+		logrus.Printf("LLM Narrative for %s: %s", entityID, llmResponse.Narrative)
+	}
+
+	// Dispatch tool calls
+	if len(llmResponse.ToolCalls) == 0 {
+		return nil
+	}
+	logrus.Printf("LLM Tool Calls for %s: %+v", entityID, llmResponse.ToolCalls)
+	results, err := m.toolDispatcher.Dispatch(context.Background(), player, entity, llmResponse.ToolCalls)
+	if err != nil {
+		logrus.Errorf("Failed to dispatch tool calls for entity %s: %v", entityID, err)
+	}
+	return results
+}
+
+// fallBack has an entity react to a player without its LLM, in a way that
+// suits what it is.
+func (m *SentientEntityManager) fallBack(observer, entity interface{}, player *models.PlayerCharacter) {
+	var reaction string
+	switch entity.(type) {
+	case *models.NPC:
+		reaction = "%s regards you for a long moment, lost in thought, and says nothing."
+	case *models.Owner:
+		reaction = "You feel the attention of %s settle upon you, silent and watchful."
+	default:
+		reaction = "%s seems to weigh your deeds, but keeps its counsel for now."
+	}
+	m.eventBus.Publish(events.PlayerMessageEventType, &events.PlayerMessageEvent{
+		PlayerID: player.ID,
+		Content:  fmt.Sprintf(reaction, getObserverName(observer)),
+	})
+}
+
 // Helper to get observer name
 func getObserverName(observer interface{}) string {
 	switch obs := observer.(type) {
@@ -3,6 +3,7 @@ package sentiententitymanager
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -25,7 +26,6 @@ func (m *MockPlayerCharacterDAL) GetAllCharacters() ([]*models.PlayerCharacter,
 func (m *MockPlayerCharacterDAL) CreateCharacter(character *models.PlayerCharacter) error { return nil }
 func (m *MockPlayerCharacterDAL) UpdateCharacter(character *models.PlayerCharacter) error { return nil }
 func (m *MockPlayerCharacterDAL) DeleteCharacter(id string) error { return nil }
-func (m *MockPlayerCharacterDAL) GetCharacterInventory(characterID string) ([]*models.Item, error) { return nil, nil }
 func (m *MockPlayerCharacterDAL) GetCharacterSkills(characterID string) ([]*models.PlayerSkill, error) { return nil, nil }
 func (m *MockPlayerCharacterDAL) GetCharacterClass(characterID string) (*models.PlayerClass, error) { if m.GetCharacterClassFunc != nil { return m.GetCharacterClassFunc(characterID) } ; return nil, nil }
 func (m *MockPlayerCharacterDAL) GetCharacterQuestState(characterID, questID string) (*models.PlayerQuestState, error) { if m.GetCharacterQuestStateFunc != nil { return m.GetCharacterQuestStateFunc(characterID, questID) } ; return nil, nil }
@@ -205,14 +205,14 @@ func (m *MockLLMService) AnalyzeResponse(ctx context.Context, narrative string,
 }
 
 type MockToolDispatcher struct {
-	DispatchFunc func(ctx context.Context, player *models.PlayerCharacter, entity interface{}, toolCalls []llm.ToolCall) error
+	DispatchFunc func(ctx context.Context, player *models.PlayerCharacter, entity interface{}, toolCalls []llm.ToolCall) ([]llm.ToolResult, error)
 }
 
-func (m *MockToolDispatcher) Dispatch(ctx context.Context, player *models.PlayerCharacter, entity interface{}, toolCalls []llm.ToolCall) error {
+func (m *MockToolDispatcher) Dispatch(ctx context.Context, player *models.PlayerCharacter, entity interface{}, toolCalls []llm.ToolCall) ([]llm.ToolResult, error) {
 	if m.DispatchFunc != nil {
 		return m.DispatchFunc(ctx, player, entity, toolCalls)
 	}
-	return nil
+	return nil, nil
 }
 
 type MockTelnetRenderer struct{
@@ -507,6 +507,48 @@ func TestSentientEntityManager_TriggerReaction_SuccessfulOwnerReaction(t *testin
 	assert.NoError(t, err)
 }
 
+func TestSentientEntityManager_TriggerReaction_RefusedToolCalls(t *testing.T) {
+	mockLLMService := &MockLLMService{}
+	mockNPCDAL := &MockNPCDAL{}
+	mockOwnerDAL := &MockOwnerDAL{}
+	mockQuestmakerDAL := &MockQuestmakerDAL{}
+	mockToolDispatcher := &MockToolDispatcher{}
+	mockTelnetRenderer := &MockTelnetRenderer{}
+
+	owner := &models.Owner{ID: "owner1", Name: "Test Owner"}
+	player := &models.PlayerCharacter{ID: "player1", Name: "Test Player"}
+	record := perception.PerceivedActionRecord{
+		PerceivedAction: &perception.PerceivedAction{PerceivedActionType: "pray", SourcePlayer: player, Clarity: 1.0},
+		Significance:    10.0,
+	}
+	mockOwnerDAL.GetOwnerByIDFunc = func(id string) (*models.Owner, error) { return owner, nil }
+
+	var prompts []string
+	mockLLMService.ProcessActionFunc = func(ctx context.Context, entity interface{}, p *models.PlayerCharacter, prompt string) (*llm.InnerLLMResponse, error) {
+		prompts = append(prompts, prompt)
+		return &llm.InnerLLMResponse{
+			Narrative: "The earth trembles.",
+			ToolCalls: []llm.ToolCall{{ToolName: "OWNER_memorize", Cost: 5}},
+		}, nil
+	}
+	dispatches := 0
+	mockToolDispatcher.DispatchFunc = func(ctx context.Context, p *models.PlayerCharacter, entity interface{}, toolCalls []llm.ToolCall) ([]llm.ToolResult, error) {
+		dispatches++
+		return []llm.ToolResult{{ToolName: "OWNER_memorize", Status: llm.ToolStatusRefused, Message: "insufficient influence budget: it costs 5.0 and only 2.0 of 100.0 is left"}}, nil
+	}
+
+	manager := NewSentientEntityManager(
+		mockLLMService, mockNPCDAL, mockOwnerDAL, mockQuestmakerDAL, mockToolDispatcher, mockTelnetRenderer, events.NewEventBus(),
+	)
+
+	err := manager.TriggerReaction(owner, []perception.PerceivedActionRecord{record})
+	assert.NoError(t, err)
+	if assert.Len(t, prompts, 2, "The entity is told about the refusal once") {
+		assert.Contains(t, prompts[1], "OWNER_memorize was refused: insufficient influence budget: it costs 5.0 and only 2.0 of 100.0 is left.")
+	}
+	assert.Equal(t, 2, dispatches)
+}
+
 func TestSentientEntityManager_TriggerReaction_SuccessfulQuestmakerReaction(t *testing.T) {
 	mockLLMService := &MockLLMService{}
 	mockNPCDAL := &MockNPCDAL{}
@@ -668,6 +710,58 @@ func TestSentientEntityManager_TriggerReaction_LLMServiceError(t *testing.T) {
 	assert.Contains(t, err.Error(), "LLM Service ProcessAction failed")
 }
 
+func TestSentientEntityManager_TriggerReaction_FallsBackWhenUnavailable(t *testing.T) {
+	mockLLMService := &MockLLMService{}
+	mockNPCDAL := &MockNPCDAL{}
+	mockOwnerDAL := &MockOwnerDAL{}
+	mockQuestmakerDAL := &MockQuestmakerDAL{}
+	mockToolDispatcher := &MockToolDispatcher{}
+	mockTelnetRenderer := &MockTelnetRenderer{}
+
+	npc := &models.NPC{ID: "npc1", Name: "Test NPC"}
+	player := &models.PlayerCharacter{ID: "player1", Name: "Test Player"}
+	perceivedAction := perception.PerceivedAction{
+		PerceivedActionType: "test",
+		SourcePlayer:        player,
+	}
+	record := perception.PerceivedActionRecord{
+		PerceivedAction: &perceivedAction,
+	}
+
+	mockNPCDAL.GetNPCByIDFunc = func(id string) (*models.NPC, error) {
+		return npc, nil
+	}
+
+	mockLLMService.ProcessActionFunc = func(ctx context.Context, entity interface{}, p *models.PlayerCharacter, prompt string) (*llm.InnerLLMResponse, error) {
+		return nil, fmt.Errorf("%w: default: %w", llm.ErrUnavailable, llm.ErrCircuitOpen)
+	}
+
+	eventBus := events.NewEventBus()
+	messages := make(chan interface{}, 1)
+	eventBus.Subscribe(events.PlayerMessageEventType, messages)
+
+	manager := NewSentientEntityManager(
+		mockLLMService,
+		mockNPCDAL,
+		mockOwnerDAL,
+		mockQuestmakerDAL,
+		mockToolDispatcher,
+		mockTelnetRenderer,
+		eventBus,
+	)
+
+	err := manager.TriggerReaction(npc, []perception.PerceivedActionRecord{record})
+	assert.NoError(t, err)
+	select {
+	case event := <-messages:
+		message := event.(*events.PlayerMessageEvent)
+		assert.Equal(t, "player1", message.PlayerID)
+		assert.Contains(t, message.Content, "Test NPC")
+	default:
+		t.Fatal("Expected the NPC to react without its LLM")
+	}
+}
+
 func TestSentientEntityManager_TriggerReaction_LLMResponseWithToolCalls(t *testing.T) {
 	mockLLMService := &MockLLMService{}
 	mockNPCDAL := &MockNPCDAL{}
@@ -701,14 +795,14 @@ func TestSentientEntityManager_TriggerReaction_LLMResponseWithToolCalls(t *testi
 	}
 
 	dispatchCalled := false
-	mockToolDispatcher.DispatchFunc = func(ctx context.Context, player *models.PlayerCharacter, entity interface{}, toolCalls []llm.ToolCall) error {
+	mockToolDispatcher.DispatchFunc = func(ctx context.Context, player *models.PlayerCharacter, entity interface{}, toolCalls []llm.ToolCall) ([]llm.ToolResult, error) {
 		dispatchCalled = true
 		assert.NotNil(t, ctx)
 		assert.Equal(t, player, player)
 		assert.Equal(t, npc, entity)
 		assert.Len(t, toolCalls, 1)
 		assert.Equal(t, "test_tool", toolCalls[0].ToolName)
-		return nil
+		return nil, nil
 	}
 
 	manager := NewSentientEntityManager(
@@ -758,8 +852,8 @@ func TestSentientEntityManager_TriggerReaction_ToolDispatcherError(t *testing.T)
 		}, nil
 	}
 
-	mockToolDispatcher.DispatchFunc = func(ctx context.Context, player *models.PlayerCharacter, entity interface{}, toolCalls []llm.ToolCall) error {
-		return errors.New("tool dispatcher error")
+	mockToolDispatcher.DispatchFunc = func(ctx context.Context, player *models.PlayerCharacter, entity interface{}, toolCalls []llm.ToolCall) ([]llm.ToolResult, error) {
+		return nil, errors.New("tool dispatcher error")
 	}
 
 	manager := NewSentientEntityManager(
@@ -0,0 +1,134 @@
+package skills
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"mud/internal/models"
+)
+
+// Effect types a skill can have.
+const (
+	EffectDamage          = "DAMAGE"
+	EffectHeal            = "HEAL"
+	EffectModifyAttribute = "MODIFY_ATTRIBUTE"
+	EffectStatus          = "STATUS_EFFECT"
+	EffectUnlockAction    = "UNLOCK_ACTION"
+)
+
+// Effect targets.
+const (
+	TargetSelf  = "SELF"
+	TargetEnemy = "ENEMY"
+	TargetAlly  = "ALLY"
+	TargetArea  = "AREA"
+)
+
+// Effect is one entry of a skill's Effects JSON array.
+type Effect struct {
+	Type         string `json:"type"`
+	Target       string `json:"target"`
+	ValueFormula string `json:"value_formula"`
+	DamageType   string `json:"damage_type,omitempty"`
+	Attribute    string `json:"attribute,omitempty"`
+	StatusID     string `json:"status_id,omitempty"`
+	ActionID     string `json:"action_id,omitempty"`
+	// Stacking and MaxStacks decide what reapplying a MODIFY_ATTRIBUTE or
+	// STATUS_EFFECT effect does; see effects.Manager.Apply.
+	Stacking  string `json:"stacking,omitempty"`
+	MaxStacks int    `json:"max_stacks,omitempty"`
+	// DurationSeconds is either a number or a formula string.
+	DurationSeconds json.RawMessage `json:"duration_seconds,omitempty"`
+}
+
+// ParseEffects decodes and validates a skill's effects. Skills whose effects
+// are empty or an empty object have no effects.
+func ParseEffects(skill *models.Skill) ([]Effect, error) {
+	raw := strings.TrimSpace(skill.Effects)
+	if raw == "" || raw == "{}" || raw == "null" {
+		return nil, nil
+	}
+	var effects []Effect
+	if err := json.Unmarshal([]byte(raw), &effects); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal effects for skill %s: %w", skill.ID, err)
+	}
+	for i := range effects {
+		effects[i].Type = strings.ToUpper(effects[i].Type)
+		effects[i].Target = strings.ToUpper(effects[i].Target)
+		effects[i].Stacking = strings.ToLower(effects[i].Stacking)
+		if effects[i].Target == "" {
+			effects[i].Target = TargetSelf
+		}
+		if err := effects[i].validate(); err != nil {
+			return nil, fmt.Errorf("invalid effect for skill %s: %w", skill.ID, err)
+		}
+	}
+	return effects, nil
+}
+
+// Duration evaluates the effect's duration in seconds. Effects without a
+// duration last zero seconds.
+func (e Effect) Duration(variables map[string]float64) (float64, error) {
+	if len(e.DurationSeconds) == 0 {
+		return 0, nil
+	}
+	var seconds float64
+	if err := json.Unmarshal(e.DurationSeconds, &seconds); err == nil {
+		return seconds, nil
+	}
+	var formula string
+	if err := json.Unmarshal(e.DurationSeconds, &formula); err != nil {
+		return 0, fmt.Errorf("%w: duration_seconds must be a number or a formula", ErrInvalidFormula)
+	}
+	return EvaluateFormula(formula, variables)
+}
+
+// validate checks that the effect's type, target and parameters fit together.
+func (e Effect) validate() error {
+	targets := map[string][]string{
+		EffectDamage:          {TargetEnemy, TargetArea},
+		EffectHeal:            {TargetSelf, TargetAlly},
+		EffectModifyAttribute: {TargetSelf, TargetAlly, TargetEnemy},
+		EffectStatus:          {TargetSelf, TargetAlly, TargetEnemy},
+	}
+	switch e.Type {
+	case EffectUnlockAction:
+		if e.ActionID == "" {
+			return fmt.Errorf("%s effect needs an action_id", e.Type)
+		}
+		return nil
+	case EffectDamage, EffectHeal:
+		if e.ValueFormula == "" {
+			return fmt.Errorf("%s effect needs a value_formula", e.Type)
+		}
+	case EffectModifyAttribute:
+		if e.Attribute == "" || e.ValueFormula == "" {
+			return fmt.Errorf("%s effect needs an attribute and a value_formula", e.Type)
+		}
+	case EffectStatus:
+		if e.StatusID == "" {
+			return fmt.Errorf("%s effect needs a status_id", e.Type)
+		}
+	}
+	switch e.Stacking {
+	case "", models.StackRefresh, models.StackAdd, models.StackIgnore:
+	default:
+		return fmt.Errorf("unknown stacking rule %q", e.Stacking)
+	}
+	allowed, ok := targets[e.Type]
+	if !ok {
+		return fmt.Errorf("unknown effect type %q", e.Type)
+	}
+	for _, target := range allowed {
+		if e.Target == target {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s effect cannot target %s", e.Type, e.Target)
+}
+
+// needsEnemy reports whether applying the effect requires an enemy target.
+func (e Effect) needsEnemy() bool {
+	return e.Type != EffectUnlockAction && e.Target == TargetEnemy
+}
@@ -0,0 +1,257 @@
+package skills
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// maxFormulaLength bounds the size of a formula so that skill data cannot
+	// make the evaluator do unbounded work.
+	maxFormulaLength = 256
+	// maxFormulaDepth bounds the nesting of parentheses and function calls.
+	maxFormulaDepth = 16
+)
+
+// ErrInvalidFormula is returned when a value formula cannot be evaluated.
+var ErrInvalidFormula = errors.New("invalid formula")
+
+// formulaFunctions are the only functions a formula may call.
+var formulaFunctions = map[string]func(args []float64) (float64, error){
+	"min": func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, errors.New("min needs at least one argument")
+		}
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Min(result, arg)
+		}
+		return result, nil
+	},
+	"max": func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, errors.New("max needs at least one argument")
+		}
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Max(result, arg)
+		}
+		return result, nil
+	},
+	"floor": unary(math.Floor),
+	"ceil":  unary(math.Ceil),
+	"round": unary(math.Round),
+	"abs":   unary(math.Abs),
+}
+
+func unary(f func(float64) float64) func(args []float64) (float64, error) {
+	return func(args []float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, errors.New("expected one argument")
+		}
+		return f(args[0]), nil
+	}
+}
+
+// EvaluateFormula computes a skill value formula such as
+// "base_damage + (skill_percentage * 0.5)". Formulas are arithmetic only:
+// numbers, the given variables, + - * / %, parentheses and the functions min,
+// max, floor, ceil, round and abs. Nothing else is accepted, so formulas stored
+// in skill data cannot reach the rest of the game.
+func EvaluateFormula(formula string, variables map[string]float64) (float64, error) {
+	if len(formula) > maxFormulaLength {
+		return 0, fmt.Errorf("%w: longer than %d characters", ErrInvalidFormula, maxFormulaLength)
+	}
+	p := &formulaParser{input: formula, variables: variables}
+	value, err := p.expression(0)
+	if err == nil {
+		p.skipSpace()
+		if p.pos < len(p.input) {
+			err = fmt.Errorf("unexpected %q at position %d", p.input[p.pos], p.pos)
+		}
+	}
+	if err == nil && (math.IsNaN(value) || math.IsInf(value, 0)) {
+		err = errors.New("result is not a number")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w %q: %v", ErrInvalidFormula, formula, err)
+	}
+	return value, nil
+}
+
+// formulaParser is a recursive descent parser that evaluates as it parses.
+type formulaParser struct {
+	input     string
+	pos       int
+	variables map[string]float64
+}
+
+// expression parses terms joined by + and -.
+func (p *formulaParser) expression(depth int) (float64, error) {
+	if depth > maxFormulaDepth {
+		return 0, errors.New("nested too deeply")
+	}
+	value, err := p.term(depth)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch p.peek() {
+		case '+':
+			p.pos++
+			right, err := p.term(depth)
+			if err != nil {
+				return 0, err
+			}
+			value += right
+		case '-':
+			p.pos++
+			right, err := p.term(depth)
+			if err != nil {
+				return 0, err
+			}
+			value -= right
+		default:
+			return value, nil
+		}
+	}
+}
+
+// term parses factors joined by *, / and %.
+func (p *formulaParser) term(depth int) (float64, error) {
+	value, err := p.factor(depth)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			return value, nil
+		}
+		p.pos++
+		right, err := p.factor(depth)
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case '*':
+			value *= right
+		case '/', '%':
+			if right == 0 {
+				return 0, errors.New("division by zero")
+			}
+			if op == '/' {
+				value /= right
+			} else {
+				value = math.Mod(value, right)
+			}
+		}
+	}
+}
+
+// factor parses a number, variable, function call, parenthesised expression
+// or a negated factor.
+func (p *formulaParser) factor(depth int) (float64, error) {
+	switch c := p.peek(); {
+	case c == '-':
+		p.pos++
+		value, err := p.factor(depth + 1)
+		return -value, err
+	case c == '(':
+		p.pos++
+		value, err := p.expression(depth + 1)
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ')' {
+			return 0, errors.New("missing )")
+		}
+		p.pos++
+		return value, nil
+	case c == '.' || (c >= '0' && c <= '9'):
+		return p.number()
+	case c == '_' || unicode.IsLetter(rune(c)):
+		return p.identifier(depth)
+	case c == 0:
+		return 0, errors.New("unexpected end of formula")
+	default:
+		return 0, fmt.Errorf("unexpected %q at position %d", c, p.pos)
+	}
+}
+
+func (p *formulaParser) number() (float64, error) {
+	start := p.pos
+	for p.pos < len(p.input) && (p.input[p.pos] == '.' || (p.input[p.pos] >= '0' && p.input[p.pos] <= '9')) {
+		p.pos++
+	}
+	value, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad number %q", p.input[start:p.pos])
+	}
+	return value, nil
+}
+
+// identifier parses a variable or, when followed by "(", a function call.
+func (p *formulaParser) identifier(depth int) (float64, error) {
+	start := p.pos
+	for p.pos < len(p.input) && (p.input[p.pos] == '_' || unicode.IsLetter(rune(p.input[p.pos])) || unicode.IsDigit(rune(p.input[p.pos]))) {
+		p.pos++
+	}
+	name := strings.ToLower(p.input[start:p.pos])
+
+	if p.peek() != '(' {
+		value, ok := p.variables[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown variable %q", name)
+		}
+		return value, nil
+	}
+
+	function, ok := formulaFunctions[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown function %q", name)
+	}
+	p.pos++
+	var args []float64
+	if p.peek() != ')' {
+		for {
+			arg, err := p.expression(depth + 1)
+			if err != nil {
+				return 0, err
+			}
+			args = append(args, arg)
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+	}
+	if p.peek() != ')' {
+		return 0, errors.New("missing )")
+	}
+	p.pos++
+	value, err := function(args)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return value, nil
+}
+
+// peek skips whitespace and returns the next character, or 0 at the end.
+func (p *formulaParser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *formulaParser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
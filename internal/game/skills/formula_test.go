@@ -0,0 +1,55 @@
+package skills
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateFormula(t *testing.T) {
+	variables := map[string]float64{"base_damage": 5, "skill_percentage": 40}
+
+	tests := []struct {
+		formula string
+		want    float64
+	}{
+		{"base_damage + (skill_percentage * 0.5)", 25},
+		{"10 + skill_percentage * 0.5", 30},
+		{"(10 + skill_percentage) * 0.5", 25},
+		{"-base_damage + 2 * -3", -11},
+		{"skill_percentage / 8 % 3", 2},
+		{"max(base_damage, 7, 3) + min(1, 2)", 8},
+		{"floor(2.7) + ceil(2.1) + round(2.5) + abs(-1)", 9},
+		{"  Skill_Percentage*.25 ", 10},
+	}
+	for _, tt := range tests {
+		got, err := EvaluateFormula(tt.formula, variables)
+		assert.NoError(t, err, tt.formula)
+		assert.Equal(t, tt.want, got, tt.formula)
+	}
+}
+
+func TestEvaluateFormula_Rejects(t *testing.T) {
+	variables := map[string]float64{"skill_percentage": 40}
+
+	for _, formula := range []string{
+		"",
+		"strength * 2",
+		"os.Exit(1)",
+		"exec(\"rm\")",
+		"skill_percentage / 0",
+		"(1 + 2",
+		"1 + 2)",
+		"1 +",
+		"min()",
+		"floor(1, 2)",
+		"1.2.3",
+		"skill_percentage; 1",
+		strings.Repeat("(", 20) + "1" + strings.Repeat(")", 20),
+		strings.Repeat("1+", 200) + "1",
+	} {
+		_, err := EvaluateFormula(formula, variables)
+		assert.ErrorIs(t, err, ErrInvalidFormula, formula)
+	}
+}
@@ -0,0 +1,502 @@
+package skills
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"mud/internal/dal"
+	"mud/internal/game/effects"
+	"mud/internal/game/events"
+	"mud/internal/game/scheduler"
+	"mud/internal/game/stats"
+	"mud/internal/models"
+	"mud/internal/presentation"
+)
+
+const (
+	// DefaultRegenInterval is how often characters regain energy.
+	DefaultRegenInterval = 10 * time.Second
+	// DefaultEnergyRegen is how much energy is regained each interval.
+	DefaultEnergyRegen = 5
+	// DefaultEffectDuration is how long attribute modifiers and statuses
+	// without a duration_seconds last.
+	DefaultEffectDuration = 30 * time.Second
+
+	// baseDamage and baseHeal are the base_damage and base_heal formula variables.
+	baseDamage = 5
+	baseHeal   = 5
+)
+
+var (
+	// ErrUnknownSkill is returned when the character knows no skill by that name.
+	ErrUnknownSkill = errors.New("skill not known")
+	// ErrPassiveSkill is returned when using a passive skill.
+	ErrPassiveSkill = errors.New("skill is passive")
+	// ErrClassLevelTooLow is returned when the character's level in the skill's class is below its MinClassLevel.
+	ErrClassLevelTooLow = errors.New("class level too low")
+	// ErrOnCooldown is returned when the skill was used too recently.
+	ErrOnCooldown = errors.New("skill is on cooldown")
+	// ErrNotEnoughEnergy is returned when the character cannot pay the skill's cost.
+	ErrNotEnoughEnergy = errors.New("not enough energy")
+	// ErrNoTarget is returned when a skill that affects an enemy is used without naming one.
+	ErrNoTarget = errors.New("skill needs a target")
+	// ErrTargetNotFound is returned when no living NPC in the room matches the target.
+	ErrTargetNotFound = errors.New("target not found")
+)
+
+// Notifier receives the narration of skills being used, and the vitals
+// changes they and energy regeneration cause.
+type Notifier interface {
+	// Notify sends a line of narration to a character.
+	Notify(characterID, content string)
+	// VitalsChanged is called after a character's health or energy has changed.
+	VitalsChanged(character *models.PlayerCharacter)
+}
+
+// Striker deals skill damage to NPCs. The combat manager implements it, so
+// that skills start fights and NPCs slain by skills die like any other.
+type Striker interface {
+	Strike(character *models.PlayerCharacter, npcID string, damage int) (*models.NPC, error)
+}
+
+// Manager executes active skills. Using a skill checks that the character
+// knows it, meets its MinClassLevel, is off cooldown and can pay its Cost in
+// energy; then every effect's value_formula is evaluated with the character's
+// skill percentage and the effects are applied. Attribute modifiers and
+// statuses become timed status effects. Energy regenerates over time.
+//
+// Energy regenerates on the scheduler's goroutine, so the manager holds
+// character IDs rather than the players' own characters, and writes only
+// the vitals of the character as stored. The Notifier is handed the stored
+// character whenever its vitals change.
+type Manager struct {
+	characterDAL   dal.PlayerCharacterDALInterface
+	skillDAL       dal.SkillDALInterface
+	playerSkillDAL dal.PlayerSkillDALInterface
+	playerClassDAL dal.PlayerClassDALInterface
+	npcDAL         dal.NPCDALInterface
+	stats          *stats.Manager
+	roomDAL        dal.RoomDALInterface
+	eventBus       *events.EventBus
+	striker        Striker
+	notifier       Notifier
+	effects        *effects.Manager
+	mu             sync.Mutex
+	cooldowns      map[string]time.Time // Keyed by character ID and skill ID
+	recovering     map[string]bool      // IDs of online characters below maximum energy
+	now            func() time.Time     // Replaced in tests
+
+	RegenInterval time.Duration
+	EnergyRegen   int
+}
+
+// NewManager creates a new Manager.
+func NewManager(characterDAL dal.PlayerCharacterDALInterface, skillDAL dal.SkillDALInterface, playerSkillDAL dal.PlayerSkillDALInterface, playerClassDAL dal.PlayerClassDALInterface, npcDAL dal.NPCDALInterface, statsManager *stats.Manager, roomDAL dal.RoomDALInterface, eventBus *events.EventBus, striker Striker, notifier Notifier, effectManager *effects.Manager) *Manager {
+	return &Manager{
+		characterDAL:   characterDAL,
+		skillDAL:       skillDAL,
+		playerSkillDAL: playerSkillDAL,
+		playerClassDAL: playerClassDAL,
+		npcDAL:         npcDAL,
+		stats:          statsManager,
+		roomDAL:        roomDAL,
+		eventBus:       eventBus,
+		striker:        striker,
+		notifier:       notifier,
+		effects:        effectManager,
+		cooldowns:      make(map[string]time.Time),
+		recovering:     make(map[string]bool),
+		now:            time.Now,
+		RegenInterval:  DefaultRegenInterval,
+		EnergyRegen:    DefaultEnergyRegen,
+	}
+}
+
+// Schedule has the scheduler regenerate energy every RegenInterval.
+func (m *Manager) Schedule(s *scheduler.Scheduler) *scheduler.Job {
+	return s.Every("energy regeneration", m.RegenInterval, m.Tick)
+}
+
+// Use uses one of the character's skills. The longest leading run of args
+// that names a skill the character knows is the skill; the rest names the
+// target. Nothing is spent unless every effect can be evaluated. What the
+// effects do is narrated through the Notifier as they are applied. The
+// character is only used for its ID: the skill is paid for from, and heals,
+// the character as stored.
+func (m *Manager) Use(character *models.PlayerCharacter, args []string) (*models.Skill, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	character, err := m.characterDAL.GetCharacterByID(character.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get character: %w", err)
+	}
+	if character == nil {
+		return nil, fmt.Errorf("character not found")
+	}
+	skill, known, rest, err := m.findSkill(character.ID, args)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(skill.Type, "passive") {
+		return nil, ErrPassiveSkill
+	}
+	classLevel, err := m.classLevel(character.ID, skill)
+	if err != nil {
+		return nil, err
+	}
+	if skill.AssociatedClassID != "" && classLevel < skill.MinClassLevel {
+		return nil, ErrClassLevelTooLow
+	}
+	now := m.now()
+	cooldownKey := character.ID + "/" + skill.ID
+	if until, found := m.cooldowns[cooldownKey]; found && now.Before(until) {
+		return nil, fmt.Errorf("%w: %s remaining", ErrOnCooldown, until.Sub(now).Round(time.Second))
+	}
+	if character.Energy < skill.Cost {
+		return nil, ErrNotEnoughEnergy
+	}
+
+	effects, err := ParseEffects(skill)
+	if err != nil {
+		return nil, err
+	}
+	var target *models.NPC
+	for _, effect := range effects {
+		if effect.needsEnemy() {
+			if len(rest) == 0 {
+				return nil, ErrNoTarget
+			}
+			if target, err = m.findNPC(character.CurrentRoomID, strings.Join(rest, " ")); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	// Evaluate everything before changing anything, so a broken formula
+	// costs the player nothing.
+	variables, err := m.variables(character, known, classLevel, target)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]float64, len(effects))
+	durations := make([]time.Duration, len(effects))
+	for i, effect := range effects {
+		if effect.Type == EffectUnlockAction {
+			continue
+		}
+		if effect.ValueFormula != "" {
+			if values[i], err = EvaluateFormula(effect.ValueFormula, variables); err != nil {
+				return nil, fmt.Errorf("failed to evaluate %s effect of skill %s: %w", effect.Type, skill.ID, err)
+			}
+		}
+		seconds, err := effect.Duration(variables)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate duration of skill %s: %w", skill.ID, err)
+		}
+		durations[i] = time.Duration(seconds * float64(time.Second))
+	}
+
+	if character, err = m.characterDAL.AdjustCharacterVitals(character.ID, 0, -skill.Cost); err != nil {
+		return nil, fmt.Errorf("failed to charge character %s: %w", character.ID, err)
+	}
+	if skill.Cooldown > 0 {
+		m.cooldowns[cooldownKey] = now.Add(time.Duration(skill.Cooldown) * time.Second)
+	}
+
+	m.notifier.Notify(character.ID, fmt.Sprintf("You use %s.", skill.Name))
+	var targets []interface{}
+	if target != nil {
+		targets = append(targets, target)
+	}
+	for i, effect := range effects {
+		struck, err := m.apply(character, skill, effect, values[i], durations[i], target)
+		if err != nil {
+			logrus.Errorf("Skills: Failed to apply %s effect of skill %s for character %s: %v", effect.Type, skill.ID, character.ID, err)
+			continue
+		}
+		for _, npc := range struck {
+			if target == nil || npc.ID != target.ID {
+				targets = append(targets, npc)
+			}
+		}
+	}
+
+	if character.Energy < character.MaxEnergy {
+		m.recovering[character.ID] = true
+	}
+	m.notifier.VitalsChanged(character)
+	m.publish(character, skill, targets)
+	return skill, nil
+}
+
+// apply carries out and narrates one evaluated effect. It returns the NPCs
+// that were damaged. A heal updates character, which must be the caller's own
+// copy, to the character as stored afterwards.
+func (m *Manager) apply(character *models.PlayerCharacter, skill *models.Skill, effect Effect, value float64, duration time.Duration, target *models.NPC) ([]*models.NPC, error) {
+	amount := int(math.Round(value))
+	switch effect.Type {
+	case EffectDamage:
+		victims := []*models.NPC{target}
+		if effect.Target == TargetArea {
+			npcs, err := m.npcDAL.GetNPCsByRoom(character.CurrentRoomID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get NPCs in room: %w", err)
+			}
+			victims = nil
+			for _, npc := range npcs {
+				if npc.Health > 0 {
+					victims = append(victims, npc)
+				}
+			}
+		}
+		amount = max(0, amount)
+		var struck []*models.NPC
+		for _, victim := range victims {
+			// Narrate the hit first, so that it comes before the news of a kill.
+			m.notifier.Notify(character.ID, fmt.Sprintf("Your %s hits %s for %d damage.", skill.Name, victim.Name, amount))
+			npc, err := m.striker.Strike(character, victim.ID, amount)
+			if err != nil {
+				return struck, err
+			}
+			struck = append(struck, npc)
+		}
+		return struck, nil
+
+	case EffectHeal:
+		healed, err := m.characterDAL.AdjustCharacterVitals(character.ID, max(0, amount), 0)
+		if err != nil {
+			return nil, err
+		}
+		m.notifier.Notify(character.ID, fmt.Sprintf("Your %s heals you for %d.", skill.Name, max(0, healed.Health-character.Health)))
+		*character = *healed
+
+	case EffectModifyAttribute, EffectStatus:
+		if duration <= 0 {
+			duration = DefaultEffectDuration
+		}
+		status := &models.StatusEffect{
+			TargetType: models.EffectTargetCharacter,
+			TargetID:   character.ID,
+			SourceType: models.EffectSourceSkill,
+			SourceID:   skill.ID,
+			Type:       effect.Type,
+			Key:        strings.ToLower(effect.StatusID),
+			Magnitude:  float64(amount),
+			Stacking:   effect.Stacking,
+			MaxStacks:  effect.MaxStacks,
+		}
+		if effect.Type == EffectModifyAttribute {
+			status.Key = strings.ToLower(effect.Attribute)
+		}
+		subject := "You are"
+		possessive := "Your"
+		if effect.Target == TargetEnemy {
+			status.TargetType = models.EffectTargetNPC
+			status.TargetID = target.ID
+			subject = presentation.Capitalize(target.Name) + " is"
+			possessive = presentation.Capitalize(target.Name) + "'s"
+		}
+		if _, err := m.effects.Apply(status, duration); err != nil {
+			return nil, err
+		}
+
+		if effect.Type == EffectStatus && effects.IsCondition(status.Key) {
+			m.notifier.Notify(character.ID, fmt.Sprintf("%s now %s.", subject, effects.Describe(status.Key)))
+		} else if effect.Type == EffectStatus {
+			m.notifier.Notify(character.ID, fmt.Sprintf("%s now under the effect of %s.", subject, effects.Describe(status.Key)))
+		} else if amount >= 0 {
+			m.notifier.Notify(character.ID, fmt.Sprintf("%s %s increases by %d.", possessive, effects.Describe(status.Key), amount))
+		} else {
+			m.notifier.Notify(character.ID, fmt.Sprintf("%s %s decreases by %d.", possessive, effects.Describe(status.Key), -amount))
+		}
+
+	case EffectUnlockAction:
+		// Actions are unlocked by knowing the skill, not by using it.
+	}
+	return nil, nil
+}
+
+// Recover starts regenerating an online character's energy if it is below
+// maximum. Call it when the character enters the game.
+func (m *Manager) Recover(character *models.PlayerCharacter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if character.Energy < character.MaxEnergy {
+		m.recovering[character.ID] = true
+	}
+}
+
+// Forget stops regenerating a character's energy, for example when the
+// player disconnects. Cooldowns are kept so that reconnecting does not reset them.
+func (m *Manager) Forget(characterID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.recovering, characterID)
+}
+
+// Cooldown returns how long the character must wait before using a skill
+// again, or zero if it is ready.
+func (m *Manager) Cooldown(characterID, skillID string) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if until, found := m.cooldowns[characterID+"/"+skillID]; found {
+		return max(0, until.Sub(m.now()))
+	}
+	return 0
+}
+
+// Tick regenerates energy for recovering characters and forgets elapsed cooldowns.
+func (m *Manager) Tick() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id := range m.recovering {
+		character, err := m.characterDAL.AdjustCharacterVitals(id, 0, m.EnergyRegen)
+		if err != nil {
+			logrus.Errorf("Skills: Failed to update energy for character %s: %v", id, err)
+			continue
+		}
+		if character.Energy >= character.MaxEnergy {
+			delete(m.recovering, id)
+		}
+		m.notifier.VitalsChanged(character)
+	}
+
+	now := m.now()
+	for key, until := range m.cooldowns {
+		if !now.Before(until) {
+			delete(m.cooldowns, key)
+		}
+	}
+}
+
+// findSkill matches the longest leading run of args against the skills the
+// character knows, by ID or name, exactly or by prefix. It returns the skill,
+// the character's PlayerSkill and the remaining args.
+func (m *Manager) findSkill(characterID string, args []string) (*models.Skill, *models.PlayerSkill, []string, error) {
+	known, err := m.playerSkillDAL.GetPlayerSkillsByPlayerID(characterID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get skills for character %s: %w", characterID, err)
+	}
+	skills := make([]*models.Skill, len(known))
+	for i, playerSkill := range known {
+		if skills[i], err = m.skillDAL.GetSkillByID(playerSkill.SkillID); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get skill %s: %w", playerSkill.SkillID, err)
+		}
+	}
+
+	for n := len(args); n > 0; n-- {
+		query := strings.ToLower(strings.Join(args[:n], " "))
+		partial := -1
+		for i, skill := range skills {
+			if skill == nil {
+				continue
+			}
+			id, name := strings.ToLower(skill.ID), strings.ToLower(skill.Name)
+			if id == query || name == query || id == strings.ReplaceAll(query, " ", "_") {
+				return skill, known[i], args[n:], nil
+			}
+			if partial < 0 && (strings.HasPrefix(name, query) || strings.HasPrefix(id, query)) {
+				partial = i
+			}
+		}
+		if partial >= 0 {
+			return skills[partial], known[partial], args[n:], nil
+		}
+	}
+	return nil, nil, nil, ErrUnknownSkill
+}
+
+// classLevel returns the character's level in the skill's class, or zero if
+// the skill belongs to no class or the character has not joined it.
+func (m *Manager) classLevel(characterID string, skill *models.Skill) (int, error) {
+	if skill.AssociatedClassID == "" {
+		return 0, nil
+	}
+	playerClass, err := m.playerClassDAL.GetPlayerClassByID(characterID, skill.AssociatedClassID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get class %s for character %s: %w", skill.AssociatedClassID, characterID, err)
+	}
+	if playerClass == nil {
+		return 0, nil
+	}
+	return playerClass.Level, nil
+}
+
+// variables builds the values a formula may refer to: skill_percentage,
+// class_level, base_damage, base_heal, the character's health and energy,
+// their derived attributes, armor and damage by name and, when there is a
+// target, target_health and target_max_health.
+func (m *Manager) variables(character *models.PlayerCharacter, known *models.PlayerSkill, classLevel int, target *models.NPC) (map[string]float64, error) {
+	variables := map[string]float64{
+		"skill_percentage": float64(known.Percentage),
+		"class_level":      float64(classLevel),
+		"base_damage":      baseDamage,
+		"base_heal":        baseHeal,
+		"health":           float64(character.Health),
+		"max_health":       float64(character.MaxHealth),
+		"energy":           float64(character.Energy),
+		"max_energy":       float64(character.MaxEnergy),
+	}
+	derived, err := m.stats.ForCharacter(character)
+	if err != nil {
+		return nil, err
+	}
+	for stat, value := range derived.Map() {
+		variables[stat] = float64(value)
+	}
+	if target != nil {
+		variables["target_health"] = float64(target.Health)
+		variables["target_max_health"] = float64(target.MaxHealth)
+	}
+	return variables, nil
+}
+
+// findNPC matches a living NPC in a room by ID or name.
+func (m *Manager) findNPC(roomID, query string) (*models.NPC, error) {
+	npcs, err := m.npcDAL.GetNPCsByRoom(roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NPCs in room: %w", err)
+	}
+	query = strings.ToLower(strings.TrimSpace(query))
+	var partial *models.NPC
+	for _, npc := range npcs {
+		if npc.Health <= 0 {
+			continue
+		}
+		if strings.ToLower(npc.ID) == query || strings.ToLower(npc.Name) == query {
+			return npc, nil
+		}
+		if partial == nil && strings.Contains(strings.ToLower(npc.Name), query) {
+			partial = npc
+		}
+	}
+	if partial == nil {
+		return nil, ErrTargetNotFound
+	}
+	return partial, nil
+}
+
+// publish sends a use_skill ActionEvent so perception can react to the skill's category.
+func (m *Manager) publish(character *models.PlayerCharacter, skill *models.Skill, targets []interface{}) {
+	room, err := m.roomDAL.GetRoomByID(character.CurrentRoomID)
+	if err != nil || room == nil {
+		logrus.Infof("Skills: Room %s not found for character %s or error: %v", character.CurrentRoomID, character.ID, err)
+		return
+	}
+	m.eventBus.Publish(events.ActionEventType, &events.ActionEvent{
+		Player:     character,
+		ActionType: "use_skill",
+		Room:       room,
+		Timestamp:  m.now(),
+		SkillUsed:  skill,
+		Targets:    targets,
+	})
+}
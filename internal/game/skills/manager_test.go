@@ -0,0 +1,195 @@
+package skills
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/dal"
+	"mud/internal/game/combat"
+	"mud/internal/game/effects"
+	"mud/internal/game/events"
+	"mud/internal/game/stats"
+	"mud/internal/models"
+	"mud/internal/testutils"
+	"mud/internal/testutils/testdal"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func setupManager(t *testing.T) (*Manager, *combat.Manager, *dal.DAL, *models.PlayerCharacter, *testutils.RecordingNotifier, *testClock, chan interface{}) {
+	t.Helper()
+
+	dals := testdal.New(t)
+	if err := dals.RaceDAL.CreateRace(&models.Race{ID: "human", Name: "Human", BaseStats: map[string]int{"strength": 12}}); err != nil {
+		t.Fatalf("Failed to create race: %v", err)
+	}
+	if err := dals.RoomDAL.CreateRoom(&models.Room{ID: "cave", Name: "Cave", Exits: "{}", Properties: "{}"}); err != nil {
+		t.Fatalf("Failed to create room: %v", err)
+	}
+	npc := &models.NPC{ID: "goblin", Name: "a goblin", CurrentRoomID: "cave", Health: 30, MaxHealth: 30, RaceID: "human", Inventory: []string{}}
+	if err := dals.NpcDAL.CreateNPC(npc); err != nil {
+		t.Fatalf("Failed to create NPC: %v", err)
+	}
+	character := &models.PlayerCharacter{ID: "hero", Name: "Hero", RaceID: "human", CurrentRoomID: "cave", Health: 20, MaxHealth: 20, Energy: 20, MaxEnergy: 20, Inventory: "[]", VisitedRoomIDs: "[]"}
+	if err := dals.PlayerCharacterDAL.CreateCharacter(character); err != nil {
+		t.Fatalf("Failed to create character: %v", err)
+	}
+
+	for _, skill := range []struct {
+		skill      *models.Skill
+		percentage int
+	}{
+		{&models.Skill{ID: "fireball", Name: "Fireball", Category: "magic", Type: "active", AssociatedClassID: "mage", MinClassLevel: 2, Cost: 10, Cooldown: 5,
+			Effects: `[{"type": "DAMAGE", "target": "ENEMY", "value_formula": "base_damage + (skill_percentage * 0.5)", "damage_type": "fire"}]`}, 40},
+		{&models.Skill{ID: "heal", Name: "Heal", Category: "magic", Type: "active",
+			Effects: `[{"type": "HEAL", "target": "SELF", "value_formula": "base_heal + skill_percentage * 0.2"}]`}, 50},
+		{&models.Skill{ID: "stun", Name: "Stun", Category: "combat", Type: "active",
+			Effects: `[{"type": "STATUS_EFFECT", "target": "ENEMY", "status_id": "stunned", "duration_seconds": "skill_percentage * 0.05"}]`}, 100},
+		{&models.Skill{ID: "shield_wall", Name: "Shield Wall", Category: "combat", Type: "active",
+			Effects: `[{"type": "MODIFY_ATTRIBUTE", "target": "SELF", "attribute": "armor", "value_formula": "strength / 4", "duration_seconds": 10}]`}, 10},
+		{&models.Skill{ID: "broken", Name: "Broken", Category: "magic", Type: "active", Cost: 5,
+			Effects: `[{"type": "DAMAGE", "target": "ENEMY", "value_formula": "skill_percentage * bogus"}]`}, 10},
+		{&models.Skill{ID: "stealth", Name: "Stealth", Category: "subterfuge", Type: "passive", Effects: "{}"}, 10},
+	} {
+		if err := dals.SkillDAL.CreateSkill(skill.skill); err != nil {
+			t.Fatalf("Failed to create skill: %v", err)
+		}
+		if err := dals.PlayerSkillDAL.CreatePlayerSkill(&models.PlayerSkill{PlayerID: "hero", SkillID: skill.skill.ID, Percentage: skill.percentage}); err != nil {
+			t.Fatalf("Failed to create player skill: %v", err)
+		}
+	}
+	if err := dals.PlayerClassDAL.CreatePlayerClass(&models.PlayerClass{PlayerID: "hero", ClassID: "mage", Level: 1}); err != nil {
+		t.Fatalf("Failed to create player class: %v", err)
+	}
+
+	eventBus := events.NewEventBus()
+	actionEvents := make(chan interface{}, 100)
+	eventBus.Subscribe(events.ActionEventType, actionEvents)
+	notifier := &testutils.RecordingNotifier{}
+	effectManager := effects.NewManager(dals.StatusEffectDAL, notifier)
+	statsManager := stats.NewManager(dals.RaceDAL, dals.ClassDAL, dals.PlayerClassDAL, dals.ItemInstanceDAL, effectManager)
+	fights := combat.NewManager(dals.PlayerCharacterDAL, dals.NpcDAL, dals.RoomDAL, statsManager, eventBus, notifier, "cave")
+	m := NewManager(dals.PlayerCharacterDAL, dals.SkillDAL, dals.PlayerSkillDAL, dals.PlayerClassDAL, dals.NpcDAL, statsManager, dals.RoomDAL, eventBus, fights, notifier, effectManager)
+	clock := &testClock{now: time.Now()}
+	m.now = clock.Now
+	return m, fights, dals, character, notifier, clock, actionEvents
+}
+
+// stored returns the hero as stored, whose vitals skills change.
+func stored(t *testing.T, dals *dal.DAL) *models.PlayerCharacter {
+	t.Helper()
+	character, err := dals.PlayerCharacterDAL.GetCharacterByID("hero")
+	if err != nil || character == nil {
+		t.Fatalf("Failed to get character: %v", err)
+	}
+	return character
+}
+
+func TestManager_UseDamageSkill(t *testing.T) {
+	m, fights, dals, character, notifier, clock, actionEvents := setupManager(t)
+
+	_, err := m.Use(character, []string{"fireball", "goblin"})
+	assert.ErrorIs(t, err, ErrClassLevelTooLow)
+	assert.NoError(t, dals.PlayerClassDAL.UpdatePlayerClass(&models.PlayerClass{PlayerID: "hero", ClassID: "mage", Level: 2}))
+
+	_, err = m.Use(character, []string{"fire"})
+	assert.ErrorIs(t, err, ErrNoTarget)
+	_, err = m.Use(character, []string{"fire", "troll"})
+	assert.ErrorIs(t, err, ErrTargetNotFound)
+
+	// 5 + 40 * 0.5 = 25 damage.
+	skill, err := m.Use(character, []string{"fire", "gob"})
+	assert.NoError(t, err)
+	assert.Equal(t, "fireball", skill.ID)
+	assert.Equal(t, []string{"You use Fireball.", "Your Fireball hits a goblin for 25 damage."}, notifier.Take())
+	assert.Equal(t, 10, stored(t, dals).Energy)
+	assert.True(t, fights.InCombat("hero"), "Attacking with a skill starts a fight")
+	goblin, err := dals.NpcDAL.GetNPCByID("goblin")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, goblin.Health)
+
+	event := (<-actionEvents).(*events.ActionEvent)
+	assert.Equal(t, "use_skill", event.ActionType)
+	assert.Equal(t, "magic", event.SkillUsed.Category)
+	assert.Equal(t, "goblin", event.Targets[0].(*models.NPC).ID)
+
+	_, err = m.Use(character, []string{"fireball", "goblin"})
+	assert.ErrorIs(t, err, ErrOnCooldown)
+	clock.now = clock.now.Add(time.Second)
+	assert.Equal(t, 4*time.Second, m.Cooldown("hero", "fireball"))
+
+	clock.now = clock.now.Add(5 * time.Second)
+	assert.Zero(t, m.Cooldown("hero", "fireball"))
+	_, err = m.Use(character, []string{"fireball", "goblin"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"You use Fireball.", "Your Fireball hits a goblin for 25 damage.", "A goblin is dead!"}, notifier.Take())
+	assert.False(t, fights.InCombat("hero"))
+	assert.Equal(t, "slay_npc", (<-actionEvents).(*events.ActionEvent).ActionType)
+	assert.Equal(t, "use_skill", (<-actionEvents).(*events.ActionEvent).ActionType)
+
+	clock.now = clock.now.Add(6 * time.Second)
+	_, err = m.Use(character, []string{"fireball", "goblin"})
+	assert.ErrorIs(t, err, ErrNotEnoughEnergy)
+	_, err = m.Use(character, []string{"stun", "goblin"})
+	assert.ErrorIs(t, err, ErrTargetNotFound, "Dead NPCs cannot be targeted")
+
+	// Energy regenerates on each tick until it is full.
+	m.EnergyRegen = 15
+	m.Tick()
+	assert.Equal(t, 15, stored(t, dals).Energy)
+	m.Tick()
+	assert.Equal(t, 20, stored(t, dals).Energy)
+	assert.Equal(t, 20, character.Energy, "The player's own character is left to them")
+}
+
+func TestManager_UseEffects(t *testing.T) {
+	m, _, dals, character, notifier, _, _ := setupManager(t)
+
+	_, err := m.Use(character, []string{"stealth"})
+	assert.ErrorIs(t, err, ErrPassiveSkill)
+	_, err = m.Use(character, []string{"juggling"})
+	assert.ErrorIs(t, err, ErrUnknownSkill)
+
+	// A formula that cannot be evaluated costs nothing.
+	_, err = m.Use(character, []string{"broken", "goblin"})
+	assert.ErrorIs(t, err, ErrInvalidFormula)
+	assert.Equal(t, 20, stored(t, dals).Energy)
+
+	// 5 + 50 * 0.2 = 15, capped at the missing health.
+	_, err = dals.PlayerCharacterDAL.AdjustCharacterVitals("hero", -10, 0)
+	assert.NoError(t, err)
+	_, err = m.Use(character, []string{"heal"})
+	assert.NoError(t, err)
+	assert.Equal(t, 20, stored(t, dals).Health)
+	assert.Equal(t, []string{"You use Heal.", "Your Heal heals you for 10."}, notifier.Take())
+
+	// Multi-word skill names take precedence over the target.
+	_, err = m.Use(character, []string{"shield", "wall"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"You use Shield Wall.", "Your armor increases by 3."}, notifier.Take())
+
+	_, err = m.Use(character, []string{"stun", "goblin"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"You use Stun.", "A goblin is now stunned."}, notifier.Take())
+
+	stunned, err := m.effects.Active(models.EffectTargetNPC, "goblin")
+	assert.NoError(t, err)
+	if assert.Len(t, stunned, 1) {
+		assert.Equal(t, models.EffectTypeStatus, stunned[0].Type)
+		assert.Equal(t, "stunned", stunned[0].Key)
+		assert.Equal(t, "stun", stunned[0].SourceID)
+		assert.WithinDuration(t, time.Now().Add(5*time.Second), stunned[0].ExpiresAt, time.Second)
+	}
+	armor, err := m.effects.Active(models.EffectTargetCharacter, "hero")
+	assert.NoError(t, err)
+	if assert.Len(t, armor, 1) {
+		assert.Equal(t, models.EffectTypeModifier, armor[0].Type)
+		assert.Equal(t, "armor", armor[0].Key)
+		assert.Equal(t, 3.0, armor[0].Magnitude)
+	}
+}
@@ -0,0 +1,179 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"mud/internal/dal"
+	"mud/internal/game/effects"
+	"mud/internal/game/items"
+	"mud/internal/game/progression"
+	"mud/internal/models"
+)
+
+// Manager derives the stats of characters and NPCs. Attributes start at the
+// race's BaseStats, or Average where the race sets none. Characters then add
+// the "stats" rewards of every class level they have reached. Items add the
+// properties named after attributes and "armor"; the best weapon's "damage"
+// sets the damage die. Characters count the items they have equipped, NPCs
+// everything they hold. Finally MODIFY_ATTRIBUTE effects on an attribute,
+// armor or damage add their magnitude.
+type Manager struct {
+	raceDAL        dal.RaceDALInterface
+	classDAL       dal.ClassDALInterface
+	playerClassDAL dal.PlayerClassDALInterface
+	instanceDAL    dal.ItemInstanceDALInterface
+	effects        *effects.Manager
+}
+
+// NewManager creates a new Manager.
+func NewManager(raceDAL dal.RaceDALInterface, classDAL dal.ClassDALInterface, playerClassDAL dal.PlayerClassDALInterface, instanceDAL dal.ItemInstanceDALInterface, effectManager *effects.Manager) *Manager {
+	return &Manager{
+		raceDAL:        raceDAL,
+		classDAL:       classDAL,
+		playerClassDAL: playerClassDAL,
+		instanceDAL:    instanceDAL,
+		effects:        effectManager,
+	}
+}
+
+// ForCharacter derives a player character's stats.
+func (m *Manager) ForCharacter(character *models.PlayerCharacter) (*Stats, error) {
+	s, err := m.base(character.RaceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.addClassLevels(s, character.ID); err != nil {
+		return nil, err
+	}
+	held, err := m.instanceDAL.GetInstancesByLocation(models.LocationCharacter, character.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get equipment of character %s: %w", character.ID, err)
+	}
+	var equipped []*models.ItemInstance
+	for _, instance := range held {
+		if instance.Slot != "" {
+			equipped = append(equipped, instance)
+		}
+	}
+	addEquipment(s, equipped)
+	if err := m.addEffects(s, models.EffectTargetCharacter, character.ID); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ForNPC derives an NPC's stats. NPCs have no equipment slots or classes, so
+// everything they hold counts: their best weapon and all their armor.
+func (m *Manager) ForNPC(npc *models.NPC) (*Stats, error) {
+	s, err := m.base(npc.RaceID)
+	if err != nil {
+		return nil, err
+	}
+	held, err := m.instanceDAL.GetInstancesByLocation(models.LocationNPC, npc.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items of NPC %s: %w", npc.ID, err)
+	}
+	addEquipment(s, held)
+	if err := m.addEffects(s, models.EffectTargetNPC, npc.ID); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// base returns the race's base stats over average attributes.
+func (m *Manager) base(raceID string) (*Stats, error) {
+	s := newStats()
+	if raceID == "" {
+		return s, nil
+	}
+	race, err := m.raceDAL.GetRaceByID(raceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get race %s: %w", raceID, err)
+	}
+	if race != nil {
+		for attribute, value := range race.BaseStats {
+			s.Attributes[strings.ToLower(attribute)] = value
+		}
+	}
+	return s, nil
+}
+
+// addClassLevels adds the stats rewards of every level of every class the character has reached.
+func (m *Manager) addClassLevels(s *Stats, characterID string) error {
+	playerClasses, err := m.playerClassDAL.GetPlayerClassesByPlayerID(characterID)
+	if err != nil {
+		return fmt.Errorf("failed to get classes for character %s: %w", characterID, err)
+	}
+	for _, playerClass := range playerClasses {
+		class, err := m.classDAL.GetClassByID(playerClass.ClassID)
+		if err != nil {
+			return fmt.Errorf("failed to get class %s: %w", playerClass.ClassID, err)
+		}
+		if class == nil {
+			continue
+		}
+		rewards, err := progression.ParseRewards(class)
+		if err != nil {
+			return err
+		}
+		for level, reward := range rewards {
+			if level > playerClass.Level {
+				continue
+			}
+			for stat, amount := range reward.Stats {
+				s.add(strings.ToLower(stat), amount)
+			}
+		}
+	}
+	return nil
+}
+
+// addEquipment adds the stat properties of items.
+func addEquipment(s *Stats, instances []*models.ItemInstance) {
+	for _, instance := range instances {
+		item := items.Resolve(instance)
+		properties := make(map[string]interface{})
+		if item.Properties != "" {
+			json.Unmarshal([]byte(item.Properties), &properties)
+		}
+		for key, value := range properties {
+			amount, ok := value.(float64)
+			if !ok {
+				continue
+			}
+			key = strings.ToLower(key)
+			switch {
+			case key == Damage:
+				if item.Type == "weapon" && int(amount) > s.Damage {
+					s.Damage = int(amount)
+				}
+			case key == Armor || standard(key):
+				s.add(key, int(amount))
+			}
+		}
+	}
+}
+
+// addEffects adds the attribute modifiers active on a character or NPC.
+func (m *Manager) addEffects(s *Stats, targetType, targetID string) error {
+	if m.effects == nil {
+		return nil
+	}
+	active, err := m.effects.Active(targetType, targetID)
+	if err != nil {
+		return err
+	}
+	for _, effect := range active {
+		if effect.Type != models.EffectTypeModifier {
+			continue
+		}
+		key := strings.ToLower(effect.Key)
+		if _, attribute := s.Attributes[key]; attribute || key == Armor || key == Damage {
+			s.add(key, int(math.Round(effect.TotalMagnitude())))
+		}
+	}
+	return nil
+}
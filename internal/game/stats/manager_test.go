@@ -0,0 +1,102 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/dal"
+	"mud/internal/game/effects"
+	"mud/internal/models"
+	"mud/internal/testutils"
+	"mud/internal/testutils/testdal"
+)
+
+func setupManager(t *testing.T) (*Manager, *dal.DAL, *effects.Manager) {
+	t.Helper()
+
+	dals := testdal.New(t)
+	if err := dals.RaceDAL.CreateRace(&models.Race{ID: "dwarf", Name: "Dwarf", BaseStats: map[string]int{"Strength": 13, "constitution": 14, "dexterity": 8}}); err != nil {
+		t.Fatalf("Failed to create race: %v", err)
+	}
+	if err := dals.ClassDAL.CreateClass(&models.Class{ID: "fighter", Name: "Fighter", TotalLevels: 5,
+		LevelUpRewards: `{"2": {"stats": {"strength": 1}}, "3": {"stats": {"constitution": 2, "armor": 1}}}`}); err != nil {
+		t.Fatalf("Failed to create class: %v", err)
+	}
+	for _, item := range []*models.Item{
+		{ID: "axe", Name: "an axe", Type: "weapon", Properties: `{"damage": 8}`},
+		{ID: "dagger", Name: "a dagger", Type: "weapon", Properties: `{"damage": 4}`},
+		{ID: "mail", Name: "a mail shirt", Type: "armor", Properties: `{"armor": 3, "dexterity": -1}`},
+	} {
+		if err := dals.ItemDAL.CreateItem(item); err != nil {
+			t.Fatalf("Failed to create item: %v", err)
+		}
+	}
+
+	effectManager := effects.NewManager(dals.StatusEffectDAL, &testutils.RecordingNotifier{})
+	return NewManager(dals.RaceDAL, dals.ClassDAL, dals.PlayerClassDAL, dals.ItemInstanceDAL, effectManager), dals, effectManager
+}
+
+func TestManager_ForCharacter(t *testing.T) {
+	m, dals, effectManager := setupManager(t)
+	hero := &models.PlayerCharacter{ID: "hero", RaceID: "dwarf"}
+
+	s, err := m.ForCharacter(hero)
+	assert.NoError(t, err)
+	assert.Equal(t, 13, s.Get(Strength))
+	assert.Equal(t, 14, s.Get(Constitution))
+	assert.Equal(t, Average, s.Get(Wisdom), "Attributes the race leaves out are average")
+	assert.Equal(t, 2, s.Modifier(Constitution))
+	assert.Equal(t, -1, s.Modifier(Dexterity))
+	assert.Equal(t, UnarmedDamage, s.Damage)
+
+	// Class levels reached so far add their stats.
+	assert.NoError(t, dals.PlayerClassDAL.CreatePlayerClass(&models.PlayerClass{PlayerID: "hero", ClassID: "fighter", Level: 3}))
+	// Only equipped items count, and only the best weapon's damage.
+	for _, instance := range []*models.ItemInstance{
+		{TemplateID: "axe", LocationType: models.LocationCharacter, LocationID: "hero", Slot: "wielded"},
+		{TemplateID: "dagger", LocationType: models.LocationCharacter, LocationID: "hero", Slot: "offhand"},
+		{TemplateID: "mail", LocationType: models.LocationCharacter, LocationID: "hero", Slot: "body"},
+		{TemplateID: "mail", LocationType: models.LocationCharacter, LocationID: "hero"},
+	} {
+		assert.NoError(t, dals.ItemInstanceDAL.CreateInstance(instance))
+	}
+	_, err = effectManager.Apply(&models.StatusEffect{TargetType: models.EffectTargetCharacter, TargetID: "hero", SourceType: "skill", SourceID: "bless", Type: models.EffectTypeModifier, Key: "Wisdom", Magnitude: 2.4}, time.Minute)
+	assert.NoError(t, err)
+	_, err = effectManager.Apply(&models.StatusEffect{TargetType: models.EffectTargetCharacter, TargetID: "hero", SourceType: "skill", SourceID: "focus", Type: models.EffectTypeModifier, Key: "perception", Magnitude: 5}, time.Minute)
+	assert.NoError(t, err)
+
+	s, err = m.ForCharacter(hero)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		Strength: 14, Dexterity: 7, Constitution: 16, Intelligence: 10, Wisdom: 12, Charisma: 10,
+		Armor: 4, Damage: 8,
+	}, s.Map(), "Perception is not a stat, so its modifier is left to perception")
+}
+
+func TestManager_ForNPC(t *testing.T) {
+	m, dals, _ := setupManager(t)
+	guard := &models.NPC{ID: "guard", RaceID: "dwarf"}
+	for _, instance := range []*models.ItemInstance{
+		{TemplateID: "dagger", LocationType: models.LocationNPC, LocationID: "guard"},
+		{TemplateID: "mail", LocationType: models.LocationNPC, LocationID: "guard"},
+	} {
+		assert.NoError(t, dals.ItemInstanceDAL.CreateInstance(instance))
+	}
+
+	s, err := m.ForNPC(guard)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, s.Damage)
+	assert.Equal(t, 3, s.Armor)
+	assert.Equal(t, 7, s.Get(Dexterity))
+
+	s, err = m.ForNPC(&models.NPC{ID: "wisp"})
+	assert.NoError(t, err)
+	assert.Equal(t, Average, s.Get(Strength))
+}
+
+func TestModifier(t *testing.T) {
+	for value, want := range map[int]int{3: -4, 9: -1, 10: 0, 11: 0, 12: 1, 18: 4} {
+		assert.Equal(t, want, Modifier(value), "Modifier(%d)", value)
+	}
+}
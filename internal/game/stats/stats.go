@@ -0,0 +1,114 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// The attributes every character and NPC has.
+const (
+	Strength     = "strength"
+	Dexterity    = "dexterity"
+	Constitution = "constitution"
+	Intelligence = "intelligence"
+	Wisdom       = "wisdom"
+	Charisma     = "charisma"
+)
+
+// Armor and Damage are the keys of the combat stats in item properties,
+// attribute modifiers and Map.
+const (
+	Armor  = "armor"
+	Damage = "damage"
+)
+
+// Average is the value of an attribute that nothing sets.
+const Average = 10
+
+// UnarmedDamage is the damage die of a fighter without a weapon.
+const UnarmedDamage = 2
+
+// Attributes lists the attributes in the order they are displayed.
+var Attributes = []string{Strength, Dexterity, Constitution, Intelligence, Wisdom, Charisma}
+
+// Stats are the attributes and combat stats of a character or NPC, derived
+// from their race, class levels, equipment and active effects.
+type Stats struct {
+	Attributes map[string]int // Keyed by lower-case attribute name
+	Armor      int            // Added to the roll an attacker needs to hit
+	Damage     int            // Size of the damage die
+}
+
+// newStats returns average attributes and no equipment.
+func newStats() *Stats {
+	s := &Stats{Attributes: make(map[string]int), Damage: UnarmedDamage}
+	for _, attribute := range Attributes {
+		s.Attributes[attribute] = Average
+	}
+	return s
+}
+
+// Get returns an attribute, or Average if it is not set.
+func (s *Stats) Get(attribute string) int {
+	if value, ok := s.Attributes[attribute]; ok {
+		return value
+	}
+	return Average
+}
+
+// Modifier returns the bonus or penalty an attribute gives.
+func (s *Stats) Modifier(attribute string) int {
+	return Modifier(s.Get(attribute))
+}
+
+// Map returns the attributes together with armor and damage, as formula
+// variables and structured client updates use them.
+func (s *Stats) Map() map[string]int {
+	m := make(map[string]int, len(s.Attributes)+2)
+	for attribute, value := range s.Attributes {
+		m[attribute] = value
+	}
+	m[Armor] = s.Armor
+	m[Damage] = s.Damage
+	return m
+}
+
+// Names returns the attribute names, the standard ones first in display
+// order followed by any others alphabetically.
+func (s *Stats) Names() []string {
+	names := append([]string(nil), Attributes...)
+	var extra []string
+	for attribute := range s.Attributes {
+		if !standard(attribute) {
+			extra = append(extra, attribute)
+		}
+	}
+	sort.Strings(extra)
+	return append(names, extra...)
+}
+
+// add changes a stat by amount. Armor and damage are stats like the attributes.
+func (s *Stats) add(stat string, amount int) {
+	switch stat {
+	case Armor:
+		s.Armor += amount
+	case Damage:
+		s.Damage += amount
+	default:
+		s.Attributes[stat] = s.Get(stat) + amount
+	}
+}
+
+// Modifier converts an attribute into a bonus or penalty, with Average as 0.
+func Modifier(value int) int {
+	return int(math.Floor(float64(value-Average) / 2))
+}
+
+func standard(attribute string) bool {
+	for _, a := range Attributes {
+		if a == attribute {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+
+	"mud/internal/models"
+)
+
+// Kinds of entity that call tools.
+const (
+	EntityNPC        = "npc"
+	EntityOwner      = "owner"
+	EntityQuestmaker = "questmaker"
+	EntityQuestOwner = "quest_owner"
+)
+
+var (
+	// ErrUnknownTool is returned for tools that are not registered.
+	ErrUnknownTool = errors.New("unknown tool")
+	// ErrForbidden is returned when an entity calls a tool it may not use or
+	// does not list among its AvailableTools.
+	ErrForbidden = errors.New("tool not available")
+	// ErrInvalidParameters is returned when a call's parameters do not match
+	// the tool's schema.
+	ErrInvalidParameters = errors.New("invalid parameters")
+)
+
+// Cost is the range of influence a tool call may cost. Calls are charged the
+// cost the entity proposes, kept within the range.
+type Cost struct {
+	Min, Max float64
+}
+
+// Charge returns the cost of a call for which the entity proposed a cost.
+func (c Cost) Charge(proposed float64) float64 {
+	return max(c.Min, min(proposed, c.Max))
+}
+
+// Handler carries out a tool call and returns what it changed. player is the
+// player the entity is reacting to, if any; params have been validated
+// against the tool's schema.
+type Handler func(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error)
+
+// Definition describes a tool: what it does, the parameters it takes as a
+// JSON-Schema object, the kinds of entity that may call it, what it costs
+// and how it is carried out.
+type Definition struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}
+	Entities    []string
+	Cost        Cost
+	Handler     Handler
+}
+
+// Allows reports whether a kind of entity may call the tool.
+func (d *Definition) Allows(kind string) bool {
+	for _, allowed := range d.Entities {
+		if allowed == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks a call's parameters against the tool's schema.
+func (d *Definition) Validate(params map[string]interface{}) error {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	if err := Validate(d.Parameters, params); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidParameters, err)
+	}
+	return nil
+}
+
+// Registry holds the tools LLM-driven entities can call. It is the one
+// source of what a tool takes and who may use it, for both the prompts that
+// describe tools and the dispatcher that runs them. Tools are registered
+// when it is set up; after that it is safe for concurrent use.
+type Registry struct {
+	tools map[string]*Definition
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{tools: make(map[string]*Definition)}
+}
+
+// Register adds a tool. It fails if the name is taken, the tool has no
+// handler or its parameter schema is malformed.
+func (r *Registry) Register(def Definition) error {
+	if def.Name == "" {
+		return errors.New("tool has no name")
+	}
+	if _, taken := r.tools[def.Name]; taken {
+		return fmt.Errorf("tool %s is already registered", def.Name)
+	}
+	if def.Handler == nil {
+		return fmt.Errorf("tool %s has no handler", def.Name)
+	}
+	if def.Parameters == nil {
+		def.Parameters = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+	if t, ok := def.Parameters["type"]; !ok || t != "object" {
+		return fmt.Errorf("parameters of tool %s must be an object schema", def.Name)
+	}
+	if err := checkSchema(def.Parameters, ""); err != nil {
+		return fmt.Errorf("invalid parameters of tool %s: %w", def.Name, err)
+	}
+	r.tools[def.Name] = &def
+	return nil
+}
+
+// MustRegister is like Register but panics on error. It is intended for
+// registering the built-in tools at startup.
+func (r *Registry) MustRegister(def Definition) {
+	if err := r.Register(def); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns a registered tool.
+func (r *Registry) Lookup(name string) (*Definition, bool) {
+	def, ok := r.tools[name]
+	return def, ok
+}
+
+// Authorize returns the tool an entity calls if it may use it: its kind of
+// entity must be allowed to, and it must list the tool among its
+// AvailableTools.
+func (r *Registry) Authorize(entity interface{}, name string) (*Definition, error) {
+	def, ok := r.tools[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTool, name)
+	}
+	kind := Kind(entity)
+	if !def.Allows(kind) {
+		return nil, fmt.Errorf("%w: %s cannot be used by a %s", ErrForbidden, name, kind)
+	}
+	if !lists(entity, name) {
+		return nil, fmt.Errorf("%w: %s is not among the %s's tools", ErrForbidden, name, kind)
+	}
+	return def, nil
+}
+
+// Available returns the tools an entity can call, in the order it lists
+// them, described as the registry defines them. Listed tools that are not
+// registered, or that its kind of entity may not use, are left out.
+func (r *Registry) Available(entity interface{}) []models.Tool {
+	kind := Kind(entity)
+	var available []models.Tool
+	for _, listed := range AvailableTools(entity) {
+		def, ok := r.tools[listed.Name]
+		if !ok || !def.Allows(kind) {
+			continue
+		}
+		available = append(available, models.Tool{Name: def.Name, Description: def.Description, Parameters: def.Parameters})
+	}
+	return available
+}
+
+// Kind returns the kind of entity making a tool call, or "" if it cannot call tools.
+func Kind(entity interface{}) string {
+	switch entity.(type) {
+	case *models.NPC:
+		return EntityNPC
+	case *models.Owner:
+		return EntityOwner
+	case *models.Questmaker:
+		return EntityQuestmaker
+	case *models.QuestOwner:
+		return EntityQuestOwner
+	}
+	return ""
+}
+
+// AvailableTools returns the tools an entity lists.
+func AvailableTools(entity interface{}) []models.Tool {
+	switch e := entity.(type) {
+	case *models.NPC:
+		return e.AvailableTools
+	case *models.Owner:
+		return e.AvailableTools
+	case *models.Questmaker:
+		return e.AvailableTools
+	case *models.QuestOwner:
+		return e.AvailableTools
+	}
+	return nil
+}
+
+func lists(entity interface{}, name string) bool {
+	for _, tool := range AvailableTools(entity) {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/models"
+)
+
+func noop(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+func TestRegistry_Authorize(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(Definition{Name: "NPC_memorize", Description: "Remember a player.", Entities: []string{EntityNPC}, Handler: noop})
+	r.MustRegister(Definition{Name: "spawn_entity", Description: "Spawn something.", Entities: []string{EntityOwner, EntityQuestOwner}, Handler: noop})
+
+	assert.Error(t, r.Register(Definition{Name: "spawn_entity", Handler: noop}), "Names are unique")
+	assert.Error(t, r.Register(Definition{Name: "no_handler"}))
+	assert.Error(t, r.Register(Definition{Name: "bad_schema", Handler: noop, Parameters: map[string]interface{}{"type": "object", "required": []string{"missing"}}}))
+	assert.Error(t, r.Register(Definition{Name: "not_object", Handler: noop, Parameters: map[string]interface{}{"type": "string"}}))
+
+	owner := &models.Owner{ID: "spirit", AvailableTools: []models.Tool{{Name: "spawn_entity"}, {Name: "NPC_memorize"}, {Name: "retired"}}}
+	def, err := r.Authorize(owner, "spawn_entity")
+	assert.NoError(t, err)
+	assert.Equal(t, "spawn_entity", def.Name)
+
+	_, err = r.Authorize(owner, "NPC_memorize")
+	assert.True(t, errors.Is(err, ErrForbidden), "Owners cannot use NPC tools even if they list them")
+	_, err = r.Authorize(&models.QuestOwner{ID: "shadow"}, "spawn_entity")
+	assert.True(t, errors.Is(err, ErrForbidden), "Entities must list the tools they call")
+	_, err = r.Authorize(owner, "retired")
+	assert.True(t, errors.Is(err, ErrUnknownTool))
+
+	assert.Equal(t, []models.Tool{{Name: "spawn_entity", Description: "Spawn something.", Parameters: map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}}}, r.Available(owner),
+		"Only the listed tools an entity may use are available, as the registry describes them")
+}
+
+func TestValidate(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"npc_id":      map[string]interface{}{"type": "string", "minLength": 1},
+			"reward_type": map[string]interface{}{"type": "string", "enum": []string{"item", "skill"}},
+			"quantity":    map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 10},
+			"changes": map[string]interface{}{
+				"type":                 "object",
+				"minProperties":        1,
+				"additionalProperties": map[string]interface{}{"type": "number"},
+			},
+			"tags": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+		"required":             []string{"npc_id"},
+		"additionalProperties": false,
+	}
+
+	for _, tc := range []struct {
+		params map[string]interface{}
+		err    string
+	}{
+		{map[string]interface{}{"npc_id": "guard", "reward_type": "item", "quantity": 3.0, "changes": map[string]interface{}{"strength": 2.0}, "tags": []interface{}{"loyal"}}, ""},
+		{map[string]interface{}{"npc_id": "guard", "quantity": 2, "reward_type": nil}, ""},
+		{map[string]interface{}{}, "npc_id is required"},
+		{map[string]interface{}{"npc_id": ""}, "npc_id cannot be empty"},
+		{map[string]interface{}{"npc_id": 5.0}, "npc_id must be a string"},
+		{map[string]interface{}{"npc_id": "guard", "reward_type": "gold"}, "reward_type must be one of item, skill"},
+		{map[string]interface{}{"npc_id": "guard", "quantity": 1.5}, "quantity must be an integer"},
+		{map[string]interface{}{"npc_id": "guard", "quantity": 0.0}, "quantity must be at least 1"},
+		{map[string]interface{}{"npc_id": "guard", "quantity": 11.0}, "quantity must be at most 10"},
+		{map[string]interface{}{"npc_id": "guard", "changes": map[string]interface{}{}}, "changes cannot be empty"},
+		{map[string]interface{}{"npc_id": "guard", "changes": map[string]interface{}{"strength": "lots"}}, "changes.strength must be a number"},
+		{map[string]interface{}{"npc_id": "guard", "tags": []interface{}{"loyal", 3.0}}, "tags[1] must be a string"},
+		{map[string]interface{}{"npc_id": "guard", "mood": "grim"}, "mood is not a known parameter"},
+	} {
+		err := Validate(schema, tc.params)
+		if tc.err == "" {
+			assert.NoError(t, err, "%v", tc.params)
+		} else if assert.Error(t, err, "%v", tc.params) {
+			assert.Equal(t, tc.err, err.Error())
+		}
+	}
+}
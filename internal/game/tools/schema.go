@@ -0,0 +1,291 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// schemaTypes are the JSON-Schema types a parameter definition may use.
+var schemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true, "integer": true, "boolean": true, "null": true,
+}
+
+// Validate checks a value against a JSON-Schema definition. It supports the
+// keywords tool parameters need: type, properties, required,
+// additionalProperties, minProperties, items, enum, minimum, maximum and
+// minLength. Other keywords, such as description, are ignored. The error
+// names the offending value by its path, such as "stat_changes.strength".
+func Validate(schema map[string]interface{}, value interface{}) error {
+	return validate(schema, value, "")
+}
+
+func validate(schema map[string]interface{}, value interface{}, path string) error {
+	name := path
+	if name == "" {
+		name = "parameters"
+	}
+
+	if types := schemaTypeList(schema["type"]); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			matched = matched || hasType(value, t)
+		}
+		if !matched {
+			return fmt.Errorf("%s must be %s", name, describeTypes(types))
+		}
+	}
+
+	if enum := enumList(schema["enum"]); enum != nil {
+		found := false
+		for _, allowed := range enum {
+			found = found || equal(allowed, value)
+		}
+		if !found {
+			options := make([]string, len(enum))
+			for i, allowed := range enum {
+				options[i] = fmt.Sprint(allowed)
+			}
+			return fmt.Errorf("%s must be one of %s", name, strings.Join(options, ", "))
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		if minLength, ok := Number(schema["minLength"]); ok && float64(len([]rune(v))) < minLength {
+			if minLength == 1 {
+				return fmt.Errorf("%s cannot be empty", name)
+			}
+			return fmt.Errorf("%s must be at least %g characters", name, minLength)
+		}
+	case map[string]interface{}:
+		return validateObject(schema, v, path, name)
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validate(items, item, fmt.Sprintf("%s[%d]", name, i)); err != nil {
+					return err
+				}
+			}
+		}
+	default:
+		if number, ok := Number(value); ok {
+			if minimum, ok := Number(schema["minimum"]); ok && number < minimum {
+				return fmt.Errorf("%s must be at least %g", name, minimum)
+			}
+			if maximum, ok := Number(schema["maximum"]); ok && number > maximum {
+				return fmt.Errorf("%s must be at most %g", name, maximum)
+			}
+		}
+	}
+	return nil
+}
+
+func validateObject(schema map[string]interface{}, object map[string]interface{}, path, name string) error {
+	properties, _ := schema["properties"].(map[string]interface{})
+	for _, required := range stringList(schema["required"]) {
+		if value, ok := object[required]; !ok || value == nil {
+			return fmt.Errorf("%s is required", join(path, required))
+		}
+	}
+	if minProperties, ok := Number(schema["minProperties"]); ok && float64(len(object)) < minProperties {
+		return fmt.Errorf("%s cannot be empty", name)
+	}
+
+	// Check keys in order so the same call always reports the same error.
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := object[key]
+		if property, ok := properties[key].(map[string]interface{}); ok {
+			// A null optional parameter is treated as left out.
+			if value == nil {
+				continue
+			}
+			if err := validate(property, value, join(path, key)); err != nil {
+				return err
+			}
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				return fmt.Errorf("%s is not a known parameter", join(path, key))
+			}
+		case map[string]interface{}:
+			if err := validate(additional, value, join(path, key)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkSchema reports mistakes in a parameter definition itself, such as
+// unknown types or required parameters that are not defined.
+func checkSchema(schema map[string]interface{}, path string) error {
+	name := path
+	if name == "" {
+		name = "parameters"
+	}
+	types := schemaTypeList(schema["type"])
+	if raw, ok := schema["type"]; ok && len(types) == 0 {
+		return fmt.Errorf("%s has an invalid type %v", name, raw)
+	}
+	for _, t := range types {
+		if !schemaTypes[t] {
+			return fmt.Errorf("%s has an unknown type %q", name, t)
+		}
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+	for key, property := range properties {
+		definition, ok := property.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be a schema", join(path, key))
+		}
+		if err := checkSchema(definition, join(path, key)); err != nil {
+			return err
+		}
+	}
+	for _, required := range stringList(schema["required"]) {
+		if _, ok := properties[required]; !ok {
+			return fmt.Errorf("%s is required but not defined", join(path, required))
+		}
+	}
+	for _, keyword := range []string{"items", "additionalProperties"} {
+		if nested, ok := schema[keyword].(map[string]interface{}); ok {
+			if err := checkSchema(nested, name+"."+keyword); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Number returns a numeric parameter as a float64. Parameters decoded from
+// JSON are float64s; ints and json.Numbers are accepted as well.
+func Number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func hasType(value interface{}, t string) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := Number(value)
+		return ok
+	case "integer":
+		number, ok := Number(value)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+func describeTypes(types []string) string {
+	described := make([]string, len(types))
+	for i, t := range types {
+		switch t {
+		case "object", "array", "integer":
+			described[i] = "an " + t
+		case "null":
+			described[i] = "null"
+		default:
+			described[i] = "a " + t
+		}
+	}
+	return strings.Join(described, " or ")
+}
+
+// schemaTypeList returns the types a schema allows: "type" is a single type
+// or a list of them.
+func schemaTypeList(raw interface{}) []string {
+	if t, ok := raw.(string); ok {
+		return []string{t}
+	}
+	return stringList(raw)
+}
+
+// stringList returns a list of strings written as []string or as a decoded
+// JSON array.
+func stringList(raw interface{}) []string {
+	switch v := raw.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// enumList returns the values an enum allows, written as []string or as a
+// decoded JSON array.
+func enumList(raw interface{}) []interface{} {
+	switch v := raw.(type) {
+	case []interface{}:
+		return v
+	case []string:
+		list := make([]interface{}, len(v))
+		for i, s := range v {
+			list[i] = s
+		}
+		return list
+	}
+	return nil
+}
+
+// equal compares an enum value with a parameter, treating all numbers alike.
+func equal(a, b interface{}) bool {
+	if x, ok := Number(a); ok {
+		y, ok := Number(b)
+		return ok && x == y
+	}
+	switch a.(type) {
+	case string, bool, nil:
+		return a == b
+	}
+	return false
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,419 @@
+package world
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"mud/internal/dal"
+	"mud/internal/game/items"
+	"mud/internal/game/scheduler"
+	"mud/internal/models"
+	"mud/internal/presentation"
+)
+
+// DefaultSweepInterval is how often expired world events and room overlays are ended.
+const DefaultSweepInterval = 5 * time.Second
+
+var (
+	// ErrNoDuration is returned when triggering a world event that would end immediately.
+	ErrNoDuration = errors.New("world event has no duration")
+	// ErrUnknownRoom is returned when a change or spawn names a room that does not exist.
+	ErrUnknownRoom = errors.New("unknown room")
+	// ErrUnknownNPC is returned when spawning an NPC from one that does not exist.
+	ErrUnknownNPC = errors.New("unknown NPC")
+	// ErrUnknownOverlay is returned when reverting an overlay that does not exist or was already reverted.
+	ErrUnknownOverlay = errors.New("unknown room overlay")
+	// ErrNoChanges is returned when a room overlay changes nothing.
+	ErrNoChanges = errors.New("room overlay changes nothing")
+)
+
+// Notifier receives the announcements of changes to the world.
+type Notifier interface {
+	Notify(characterID, content string)
+}
+
+// Manager carries out the world-shaping changes Owners and Quest Owners make:
+// world events that last for a while, revertible changes to rooms, and NPCs
+// and items spawned into rooms. Everything is stored in the database, and
+// the players each change affects are told about it. A background sweep ends
+// events and reverts room changes once they expire.
+type Manager struct {
+	roomDAL      dal.RoomDALInterface
+	npcDAL       dal.NPCDALInterface
+	characterDAL dal.PlayerCharacterDALInterface
+	worldDAL     dal.WorldDALInterface
+	items        *items.Manager
+	notifier     Notifier
+	mu           sync.Mutex
+	now          func() time.Time // Replaced in tests
+
+	SweepInterval time.Duration
+}
+
+// NewManager creates a new Manager.
+func NewManager(roomDAL dal.RoomDALInterface, npcDAL dal.NPCDALInterface, characterDAL dal.PlayerCharacterDALInterface, worldDAL dal.WorldDALInterface, items *items.Manager, notifier Notifier) *Manager {
+	return &Manager{
+		roomDAL:       roomDAL,
+		npcDAL:        npcDAL,
+		characterDAL:  characterDAL,
+		worldDAL:      worldDAL,
+		items:         items,
+		notifier:      notifier,
+		now:           time.Now,
+		SweepInterval: DefaultSweepInterval,
+	}
+}
+
+// Schedule has the scheduler end expired events and overlays every SweepInterval.
+func (m *Manager) Schedule(s *scheduler.Scheduler) *scheduler.Job {
+	return s.Every("world expiry", m.SweepInterval, m.Sweep)
+}
+
+// TriggerEvent starts a world event that lasts for duration, and announces
+// its details to the players in the rooms it affects.
+func (m *Manager) TriggerEvent(event *models.WorldEvent, duration time.Duration) (*models.WorldEvent, error) {
+	if duration <= 0 {
+		return nil, ErrNoDuration
+	}
+	if event.RoomID != "" {
+		if _, err := m.room(event.RoomID); err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	event.StartedAt = m.now()
+	event.EndsAt = event.StartedAt.Add(duration)
+	event.Ended = false
+	if err := m.worldDAL.CreateWorldEvent(event); err != nil {
+		return nil, err
+	}
+	details := event.Details
+	if details == "" {
+		details = fmt.Sprintf("Something stirs in the world: %s.", eventName(event))
+	}
+	m.announce(event.Affects, details)
+	return event, nil
+}
+
+// ActiveEvents returns the world events under way that affect a room, oldest
+// first. A nil room returns every event under way.
+func (m *Manager) ActiveEvents(room *models.Room) ([]*models.WorldEvent, error) {
+	worldEvents, err := m.worldDAL.GetActiveWorldEvents()
+	if err != nil {
+		return nil, err
+	}
+	now := m.now()
+	active := worldEvents[:0]
+	for _, event := range worldEvents {
+		if !event.Expired(now) && (room == nil || event.Affects(room)) {
+			active = append(active, event)
+		}
+	}
+	return active, nil
+}
+
+// ChangeRoom applies an overlay to its room and tells the players there. A
+// duration of zero keeps the change until it is reverted; otherwise it is
+// reverted once the duration has passed. The overlay's Properties must be a
+// JSON object, or empty.
+func (m *Manager) ChangeRoom(overlay *models.RoomOverlay, duration time.Duration) (*models.RoomOverlay, error) {
+	if overlay.Description == "" && overlay.DescriptionAdd == "" && overlay.Properties == "" {
+		return nil, ErrNoChanges
+	}
+	if _, err := decodeProperties(overlay.Properties); err != nil {
+		return nil, fmt.Errorf("invalid overlay properties: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	room, err := m.room(overlay.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	overlay.PreviousDescription = room.Description
+	overlay.PreviousProperties = room.Properties
+	overlay.CreatedAt = m.now()
+	overlay.ExpiresAt = time.Time{}
+	if duration > 0 {
+		overlay.ExpiresAt = overlay.CreatedAt.Add(duration)
+	}
+	overlay.Reverted = false
+	if err := apply(room, overlay); err != nil {
+		return nil, err
+	}
+	if err := m.worldDAL.CreateRoomOverlay(overlay); err != nil {
+		return nil, err
+	}
+	if err := m.roomDAL.UpdateRoom(room); err != nil {
+		return nil, fmt.Errorf("failed to update room %s: %w", room.ID, err)
+	}
+
+	content := overlay.DescriptionAdd
+	if content == "" {
+		content = overlay.Description
+	}
+	if content == "" {
+		content = "Something about this place has changed."
+	}
+	m.announce(inRoom(room.ID), content)
+	return overlay, nil
+}
+
+// Revert undoes a room overlay. The room is rebuilt from how it was before
+// its oldest active overlay, with the remaining overlays applied again in
+// order, so overlays can be reverted in any order. Changes made to the room
+// some other way while it had overlays are lost.
+func (m *Manager) Revert(overlayID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	overlay, err := m.worldDAL.GetRoomOverlayByID(overlayID)
+	if err != nil {
+		return err
+	}
+	if overlay == nil || overlay.Reverted {
+		return fmt.Errorf("%w: %s", ErrUnknownOverlay, overlayID)
+	}
+	return m.revert(overlay)
+}
+
+func (m *Manager) revert(overlay *models.RoomOverlay) error {
+	room, err := m.room(overlay.RoomID)
+	if err != nil {
+		return err
+	}
+	overlays, err := m.worldDAL.GetActiveRoomOverlays(room.ID)
+	if err != nil {
+		return err
+	}
+	if len(overlays) > 0 {
+		room.Description = overlays[0].PreviousDescription
+		room.Properties = overlays[0].PreviousProperties
+	}
+	for _, other := range overlays {
+		if other.ID == overlay.ID {
+			continue
+		}
+		// Each overlay remembers the room as it now was before it.
+		other.PreviousDescription, other.PreviousProperties = room.Description, room.Properties
+		if err := apply(room, other); err != nil {
+			return err
+		}
+		if err := m.worldDAL.UpdateRoomOverlay(other); err != nil {
+			return err
+		}
+	}
+
+	overlay.Reverted = true
+	if err := m.worldDAL.UpdateRoomOverlay(overlay); err != nil {
+		return err
+	}
+	if err := m.roomDAL.UpdateRoom(room); err != nil {
+		return fmt.Errorf("failed to update room %s: %w", room.ID, err)
+	}
+	m.announce(inRoom(room.ID), fmt.Sprintf("%s returns to the way it was.", room.Name))
+	return nil
+}
+
+// SpawnNPC creates a new NPC in a room, copied from an existing one that
+// serves as its template. The copy starts at full health with no memories,
+// and the players in the room see it appear.
+func (m *Manager) SpawnNPC(templateID, roomID string) (*models.NPC, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	template, err := m.npcDAL.GetNPCByID(templateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NPC %s: %w", templateID, err)
+	}
+	if template == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownNPC, templateID)
+	}
+	if _, err := m.room(roomID); err != nil {
+		return nil, err
+	}
+
+	npc := *template
+	npc.ID = template.ID + "-" + uuid.New().String()[:8]
+	npc.CurrentRoomID = roomID
+	npc.Health = npc.MaxHealth
+	npc.Inventory = append([]string{}, template.Inventory...)
+	npc.OwnerIDs = append([]string{}, template.OwnerIDs...)
+	npc.AvailableTools = append([]models.Tool{}, template.AvailableTools...)
+	npc.MemoriesAboutPlayers = make(map[string][]string)
+	if err := m.npcDAL.CreateNPC(&npc); err != nil {
+		return nil, err
+	}
+	m.announce(inRoom(roomID), fmt.Sprintf("%s appears.", npc.Name))
+	return &npc, nil
+}
+
+// SpawnItem creates quantity of an item template lying in a room, and the
+// players in the room see it appear.
+func (m *Manager) SpawnItem(templateID, roomID string, quantity int) (*models.ItemInstance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.room(roomID); err != nil {
+		return nil, err
+	}
+	instance, err := m.items.Spawn(&models.ItemInstance{
+		TemplateID:   templateID,
+		LocationType: models.LocationRoom,
+		LocationID:   roomID,
+		Quantity:     quantity,
+	})
+	if err != nil {
+		return nil, err
+	}
+	name := items.Resolve(instance).Name
+	if instance.Quantity > 1 {
+		name = fmt.Sprintf("%s (x%d)", name, instance.Quantity)
+	}
+	m.announce(inRoom(roomID), fmt.Sprintf("%s appears.", presentation.Capitalize(name)))
+	return instance, nil
+}
+
+// Sweep ends the world events and reverts the room overlays that have
+// expired, and tells the players they affected. Failures are logged and do
+// not stop the others.
+func (m *Manager) Sweep() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	worldEvents, err := m.worldDAL.GetActiveWorldEvents()
+	if err != nil {
+		logrus.Errorf("World: Failed to get world events: %v", err)
+	}
+	for _, event := range worldEvents {
+		if !event.Expired(now) {
+			continue
+		}
+		event.Ended = true
+		if err := m.worldDAL.UpdateWorldEvent(event); err != nil {
+			logrus.Errorf("World: Failed to end world event %s: %v", event.ID, err)
+			continue
+		}
+		m.announce(event.Affects, fmt.Sprintf("The %s has come to an end.", eventName(event)))
+	}
+
+	overlays, err := m.worldDAL.GetActiveRoomOverlays("")
+	if err != nil {
+		logrus.Errorf("World: Failed to get room overlays: %v", err)
+	}
+	for _, overlay := range overlays {
+		if !overlay.Expired(now) {
+			continue
+		}
+		if err := m.revert(overlay); err != nil {
+			logrus.Errorf("World: Failed to revert room overlay %s: %v", overlay.ID, err)
+		}
+	}
+}
+
+// room loads a room, or returns ErrUnknownRoom.
+func (m *Manager) room(roomID string) (*models.Room, error) {
+	room, err := m.roomDAL.GetRoomByID(roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get room %s: %w", roomID, err)
+	}
+	if room == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRoom, roomID)
+	}
+	return room, nil
+}
+
+// announce tells every character in a room that affected reports true for.
+// Characters who are not playing are not told.
+func (m *Manager) announce(affected func(room *models.Room) bool, content string) {
+	if m.notifier == nil {
+		return
+	}
+	characters, err := m.characterDAL.GetAllCharacters()
+	if err != nil {
+		logrus.Errorf("World: Failed to get characters to announce to: %v", err)
+		return
+	}
+	rooms := make(map[string]bool)
+	for _, character := range characters {
+		reached, seen := rooms[character.CurrentRoomID]
+		if !seen {
+			room, err := m.roomDAL.GetRoomByID(character.CurrentRoomID)
+			reached = err == nil && room != nil && affected(room)
+			rooms[character.CurrentRoomID] = reached
+		}
+		if reached {
+			m.notifier.Notify(character.ID, content)
+		}
+	}
+}
+
+// inRoom matches a single room.
+func inRoom(roomID string) func(room *models.Room) bool {
+	return func(room *models.Room) bool { return room.ID == roomID }
+}
+
+// apply changes a room as an overlay describes.
+func apply(room *models.Room, overlay *models.RoomOverlay) error {
+	if overlay.Description != "" {
+		room.Description = overlay.Description
+	}
+	if overlay.DescriptionAdd != "" {
+		room.Description = strings.TrimSpace(room.Description + " " + overlay.DescriptionAdd)
+	}
+	if overlay.Properties == "" {
+		return nil
+	}
+	properties, err := decodeProperties(room.Properties)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal properties of room %s: %w", room.ID, err)
+	}
+	changes, err := decodeProperties(overlay.Properties)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal properties of room overlay %s: %w", overlay.ID, err)
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(properties, key)
+		} else {
+			properties[key] = value
+		}
+	}
+	encoded, err := json.Marshal(properties)
+	if err != nil {
+		return fmt.Errorf("failed to marshal properties of room %s: %w", room.ID, err)
+	}
+	room.Properties = string(encoded)
+	return nil
+}
+
+// decodeProperties parses a JSON object of properties; empty means none.
+func decodeProperties(encoded string) (map[string]interface{}, error) {
+	properties := make(map[string]interface{})
+	if encoded == "" {
+		return properties, nil
+	}
+	if err := json.Unmarshal([]byte(encoded), &properties); err != nil {
+		return nil, err
+	}
+	if properties == nil {
+		properties = make(map[string]interface{})
+	}
+	return properties, nil
+}
+
+// eventName turns an event type such as "nazgul_patrol_increase" into words.
+func eventName(event *models.WorldEvent) string {
+	return strings.ReplaceAll(event.EventType, "_", " ")
+}
@@ -0,0 +1,155 @@
+package world
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/dal"
+	"mud/internal/game/items"
+	"mud/internal/models"
+	"mud/internal/testutils"
+	"mud/internal/testutils/testdal"
+)
+
+func setupManager(t *testing.T) (*Manager, *dal.DAL, *testutils.RecordingNotifier, *time.Time) {
+	t.Helper()
+
+	dals := testdal.New(t)
+	for _, room := range []*models.Room{
+		{ID: "market", Name: "Market", Description: "Stalls line the square.", Exits: "{}", TerritoryID: "bree", Properties: `{"safe": true}`},
+		{ID: "gate", Name: "Gate", Description: "The west gate.", Exits: "{}", TerritoryID: "bree", Properties: "{}"},
+		{ID: "barrow", Name: "Barrow", Description: "A cold mound.", Exits: "{}", TerritoryID: "downs", Properties: "{}"},
+	} {
+		assert.NoError(t, dals.RoomDAL.CreateRoom(room))
+	}
+	for _, character := range []*models.PlayerCharacter{
+		{ID: "hero", Name: "Hero", CurrentRoomID: "market", Inventory: "[]", VisitedRoomIDs: "[]"},
+		{ID: "scout", Name: "Scout", CurrentRoomID: "gate", Inventory: "[]", VisitedRoomIDs: "[]"},
+		{ID: "wight", Name: "Wight", CurrentRoomID: "barrow", Inventory: "[]", VisitedRoomIDs: "[]"},
+	} {
+		assert.NoError(t, dals.PlayerCharacterDAL.CreateCharacter(character))
+	}
+
+	notifier := &testutils.RecordingNotifier{}
+	itemManager := items.NewManager(dals.RoomDAL, dals.ItemDAL, dals.ItemInstanceDAL, dals.NpcDAL)
+	m := NewManager(dals.RoomDAL, dals.NpcDAL, dals.PlayerCharacterDAL, dals.WorldDAL, itemManager, notifier)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	return m, dals, notifier, &now
+}
+
+func TestManager_WorldEvents(t *testing.T) {
+	m, _, notifier, now := setupManager(t)
+
+	_, err := m.TriggerEvent(&models.WorldEvent{EventType: "fair"}, 0)
+	assert.ErrorIs(t, err, ErrNoDuration)
+
+	fair, err := m.TriggerEvent(&models.WorldEvent{EventType: "harvest_fair", Details: "Music drifts through Bree.", TerritoryID: "bree"}, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), fair.EndsAt)
+	assert.Equal(t, []string{"Music drifts through Bree."}, notifier.TakeFor("hero"))
+	assert.Equal(t, []string{"Music drifts through Bree."}, notifier.TakeFor("scout"))
+	assert.Empty(t, notifier.TakeFor("wight"), "Only players in the territory are told")
+
+	_, err = m.TriggerEvent(&models.WorldEvent{EventType: "storm"}, 2*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Something stirs in the world: storm."}, notifier.TakeFor("wight"))
+	notifier.TakeFor("hero")
+	notifier.TakeFor("scout")
+
+	barrow, err := m.room("barrow")
+	assert.NoError(t, err)
+	active, err := m.ActiveEvents(barrow)
+	assert.NoError(t, err)
+	if assert.Len(t, active, 1) {
+		assert.Equal(t, "storm", active[0].EventType)
+	}
+
+	// Events end once their time is up, and the players they affected hear of it.
+	*now = now.Add(time.Hour)
+	m.Sweep()
+	assert.Equal(t, []string{"The harvest fair has come to an end."}, notifier.TakeFor("hero"))
+	assert.Empty(t, notifier.TakeFor("wight"))
+	active, err = m.ActiveEvents(nil)
+	assert.NoError(t, err)
+	assert.Len(t, active, 1)
+	m.Sweep()
+	assert.Empty(t, notifier.TakeFor("hero"), "An event only ends once")
+}
+
+func TestManager_RoomOverlays(t *testing.T) {
+	m, dals, notifier, now := setupManager(t)
+
+	_, err := m.ChangeRoom(&models.RoomOverlay{RoomID: "market"}, 0)
+	assert.ErrorIs(t, err, ErrNoChanges)
+	_, err = m.ChangeRoom(&models.RoomOverlay{RoomID: "nowhere", DescriptionAdd: "Mist."}, 0)
+	assert.ErrorIs(t, err, ErrUnknownRoom)
+
+	smoke, err := m.ChangeRoom(&models.RoomOverlay{RoomID: "market", DescriptionAdd: "Smoke hangs in the air.", Properties: `{"safe": null, "burning": true}`}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Smoke hangs in the air."}, notifier.TakeFor("hero"))
+	assert.Empty(t, notifier.TakeFor("scout"))
+	ruin, err := m.ChangeRoom(&models.RoomOverlay{RoomID: "market", Description: "Only ashes remain.", Properties: `{"ruined": true}`}, 10*time.Minute)
+	assert.NoError(t, err)
+	notifier.TakeFor("hero")
+
+	market, err := dals.RoomDAL.GetRoomByID("market")
+	assert.NoError(t, err)
+	assert.Equal(t, "Only ashes remain.", market.Description)
+	assert.JSONEq(t, `{"burning": true, "ruined": true}`, market.Properties)
+
+	// Reverting the older change keeps the newer one.
+	assert.NoError(t, m.Revert(smoke.ID))
+	assert.Equal(t, []string{"Market returns to the way it was."}, notifier.TakeFor("hero"))
+	market, err = dals.RoomDAL.GetRoomByID("market")
+	assert.NoError(t, err)
+	assert.Equal(t, "Only ashes remain.", market.Description)
+	assert.JSONEq(t, `{"safe": true, "ruined": true}`, market.Properties)
+	assert.True(t, errors.Is(m.Revert(smoke.ID), ErrUnknownOverlay), "An overlay is only reverted once")
+
+	// The newer change runs out and the room is as it began.
+	*now = now.Add(10 * time.Minute)
+	m.Sweep()
+	market, err = dals.RoomDAL.GetRoomByID("market")
+	assert.NoError(t, err)
+	assert.Equal(t, "Stalls line the square.", market.Description)
+	assert.JSONEq(t, `{"safe": true}`, market.Properties)
+	stored, err := dals.WorldDAL.GetRoomOverlayByID(ruin.ID)
+	assert.NoError(t, err)
+	assert.True(t, stored.Reverted)
+}
+
+func TestManager_Spawn(t *testing.T) {
+	m, dals, notifier, _ := setupManager(t)
+	assert.NoError(t, dals.NpcDAL.CreateNPC(&models.NPC{ID: "ruffian", Name: "A ruffian", CurrentRoomID: "barrow", Health: 0, MaxHealth: 30,
+		Inventory: []string{"club"}, MemoriesAboutPlayers: map[string][]string{"hero": {"Was beaten by them"}}, BehaviorState: "{}"}))
+	assert.NoError(t, dals.ItemDAL.CreateItem(&models.Item{ID: "coin", Name: "a silver coin", Type: "treasure", Properties: `{"stackable": true}`}))
+
+	npc, err := m.SpawnNPC("ruffian", "gate")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "ruffian", npc.ID)
+	stored, err := dals.NpcDAL.GetNPCByID(npc.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "gate", stored.CurrentRoomID)
+	assert.Equal(t, 30, stored.Health, "Spawned NPCs start at full health")
+	assert.Equal(t, []string{"club"}, stored.Inventory)
+	assert.Empty(t, stored.MemoriesAboutPlayers)
+	assert.Equal(t, []string{"A ruffian appears."}, notifier.TakeFor("scout"))
+
+	_, err = m.SpawnNPC("dragon", "gate")
+	assert.ErrorIs(t, err, ErrUnknownNPC)
+	_, err = m.SpawnNPC("ruffian", "nowhere")
+	assert.ErrorIs(t, err, ErrUnknownRoom)
+
+	instance, err := m.SpawnItem("coin", "market", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, instance.Quantity)
+	assert.Equal(t, []string{"A silver coin (x5) appears."}, notifier.TakeFor("hero"))
+	lying, err := dals.ItemInstanceDAL.GetInstancesByLocation(models.LocationRoom, "market")
+	assert.NoError(t, err)
+	assert.Len(t, lying, 1)
+	_, err = m.SpawnItem("gem", "market", 1)
+	assert.ErrorIs(t, err, items.ErrUnknownTemplate)
+}
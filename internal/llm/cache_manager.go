@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"strings"
 	"sync"
 	"time"
 )
@@ -54,4 +55,15 @@ func (c *CacheManager) Delete(key string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	delete(c.items, key)
-}
\ No newline at end of file
+}
+
+// DeletePrefix removes every item whose key starts with prefix.
+func (c *CacheManager) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.items, key)
+		}
+	}
+}
@@ -1,169 +1,351 @@
 package llm
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"mud/internal/game/tools"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
+// DefaultPromptBudget is the prompt budget clients are created with, in
+// tokens.
+const DefaultPromptBudget = 4000
+
+// SystemPrompt is the system message of the requests entities answer
+// players by.
+const SystemPrompt = "You are a character in a multi-user dungeon game. Reply in character with the text to be shown to the player. Use the tools you are given for any actions you take; you will be told what became of them before you reply."
+
+// Client makes the game's requests of an LLM provider and decodes its
+// replies. It retries requests as its Retry policy allows, and its Breaker
+// stops it from asking a provider that keeps failing.
 type Client struct {
-	apiKey     string
-	apiURL     string
-	httpClient *http.Client
+	Retry   RetryPolicy
+	Breaker *CircuitBreaker
+	// PromptBudget is how many tokens the requests made of the provider's
+	// model for entities to answer players may take, system message and
+	// prompt together. Zero means no limit.
+	PromptBudget int
+
+	provider Provider
 }
 
+// NewClient creates a Client for the OpenAI-compatible API configured by the
+// LLM_API_ENDPOINT, LLM_API_KEY and LLM_MODEL_NAME environment variables.
+// Its provider is named "default".
 func NewClient() *Client {
-	apiKey := os.Getenv("LLM_API_KEY")
-	if apiKey == "" {
-		apiKey = "unused"
-	}
-	apiURL := os.Getenv("LLM_API_ENDPOINT")
-	if apiURL == "" {
-		apiURL = "https://api.llm7.io/v1"
-	}
+	return NewClientFor(NewOpenAIProvider(
+		DefaultProvider,
+		withDefault(os.Getenv("LLM_API_ENDPOINT"), "https://api.llm7.io/v1"),
+		withDefault(os.Getenv("LLM_API_KEY"), "unused"),
+		withDefault(os.Getenv("LLM_MODEL_NAME"), "gpt-4.1-2025-04-14"),
+	))
+}
 
+// NewClientFor creates a Client for a provider, with the default retry
+// policy and prompt budget, and a breaker that opens after 5 failed requests
+// in a row for 30 seconds.
+func NewClientFor(provider Provider) *Client {
 	return &Client{
-		apiKey: apiKey,
-		apiURL: apiURL,
-		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		Retry:        DefaultRetryPolicy(),
+		Breaker:      NewCircuitBreaker(5, 30*time.Second),
+		PromptBudget: DefaultPromptBudget,
+		provider:     provider,
 	}
 }
 
-type LLMRequest struct {
-	Model         string        `json:"model"`
-	Messages      []Message     `json:"messages"`
-	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+// Provider returns the provider the client makes its requests of.
+func (c *Client) Provider() Provider {
+	return c.provider
 }
 
-type ResponseFormat struct {
-	Type string `json:"type"`
+type InnerLLMResponse struct {
+	Narrative string     `json:"narrative"`
+	ToolCalls []ToolCall `json:"tool_calls"` // Calls left for the caller to carry out
+	// ToolResults are what became of the calls carried out while the model
+	// was answering.
+	ToolResults []ToolResult `json:"-"`
 }
 
-type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+type ToolCall struct {
+	ID         string                 `json:"id,omitempty"` // The model's ID for a native call
+	ToolName   string                 `json:"tool_name"`
+	Parameters map[string]interface{} `json:"parameters"`
+	Cost       float64                `json:"cost,omitempty"`   // Influence the entity proposes to spend
+	Reason     string                 `json:"reason,omitempty"` // Why the entity is making the call
 }
 
-type LLMResponse struct {
-	ID      string   `json:"id"`
-	Object  string   `json:"object"`
-	Created int64    `json:"created"`
-	Model   string   `json:"model"`
-	Choices []Choice `json:"choices"`
-	Usage   Usage    `json:"usage"`
-}
+// Tool result statuses.
+const (
+	ToolStatusOK        = "ok"
+	ToolStatusRefused   = "refused"   // The influence budget could not pay for the call
+	ToolStatusForbidden = "forbidden" // The entity may not use the tool
+	ToolStatusInvalid   = "invalid"   // The tool does not exist, or the parameters were missing, malformed or named nothing that exists
+	ToolStatusFailed    = "failed"    // The batch failed, and none of its calls were carried out
+)
 
-type Choice struct {
-	Index        int     `json:"index"`
-	Message      Message `json:"message"`
-	FinishReason string  `json:"finish_reason"`
+// ToolResult reports what became of a tool call, so it can be fed back to
+// the LLM.
+type ToolResult struct {
+	ToolName string                 `json:"tool_name"`
+	Status   string                 `json:"status"`
+	Cost     float64                `json:"cost"`              // Influence actually charged
+	Message  string                 `json:"message,omitempty"` // Why a call was not carried out
+	Data     map[string]interface{} `json:"data,omitempty"`    // What the call changed
 }
 
-type Usage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
-}
+// ToolRunner carries out a model's tool calls and reports what became of
+// each, in order.
+type ToolRunner func(ctx context.Context, calls []ToolCall) ([]ToolResult, error)
 
-type InnerLLMResponse struct {
-	Narrative string      `json:"narrative"`
-	ToolCalls []ToolCall  `json:"tool_calls"`
+// SendPrompt asks the model to answer a prompt without tools.
+func (c *Client) SendPrompt(ctx context.Context, prompt string) (*InnerLLMResponse, error) {
+	return c.Converse(ctx, prompt, nil, nil, 1)
 }
 
-type ToolCall struct {
-	ToolName   string                 `json:"tool_name"`
-	Parameters map[string]interface{} `json:"parameters"`
+// Converse asks the model to answer a prompt, offering it functions to call
+// through the provider's native tool calling. run carries out its calls, and
+// their results go back to it so it can react to them, step by step, until
+// it answers without calling tools. After maxSteps requests tools are no
+// longer offered, and a model that calls them anyway is asked once more to
+// answer without them. Without run, the calls of its first reply are left
+// for the caller to carry out.
+//
+// Models that still answer with a JSON object holding a narrative and
+// tool_calls are understood, and their calls left for the caller as well.
+func (c *Client) Converse(ctx context.Context, prompt string, functions []FunctionSpec, run ToolRunner, maxSteps int) (*InnerLLMResponse, error) {
+	messages := []Message{
+		{
+			Role:    "system",
+			Content: SystemPrompt,
+		},
+		{
+			Role:    "user",
+			Content: prompt,
+		},
+	}
+	response := &InnerLLMResponse{}
+	repaired := false   // Whether the model has been asked to repair a reply
+	finalAsked := false // Whether the model has been asked to answer without tools
+	for step := 1; ; step++ {
+		req := &CompletionRequest{Messages: messages}
+		if step < maxSteps || run == nil {
+			req.Tools = functions
+		}
+		completion, err := c.complete(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(completion.ToolCalls) == 0 {
+			legacy, err := decodeLegacyResponse(completion.Content)
+			if err != nil && !repaired {
+				repaired = true
+				step--
+				messages = append(messages,
+					Message{Role: "assistant", Content: completion.Content},
+					Message{Role: "user", Content: fmt.Sprintf("Your reply could not be read: %v. Reply again, in character, with only the text to be shown to the player.", err)})
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if legacy != nil {
+				legacy.ToolResults = response.ToolResults
+				return legacy, nil
+			}
+			response.Narrative = strings.TrimSpace(completion.Content)
+			return response, nil
+		}
+
+		if run != nil && step >= maxSteps {
+			// The model called tools it was no longer offered. Unless it
+			// answered as well, it is asked once more for an answer alone.
+			logrus.Warnf("LLM provider %s made tool calls after %d steps; they were ignored", c.provider.Name(), maxSteps)
+			narrative := strings.TrimSpace(completion.Content)
+			if narrative != "" || finalAsked {
+				response.Narrative = narrative
+				return response, nil
+			}
+			finalAsked = true
+			turn, err := toolTurn(messages, completion, nil, ToolResult{Status: ToolStatusFailed, Message: "no more tools may be used"})
+			if err != nil {
+				return nil, err
+			}
+			messages = append(turn, Message{Role: "user", Content: "You may not use any more tools. Reply now, in character, with only the text to be shown to the player."})
+			continue
+		}
+		calls, err := decodeToolCalls(completion.ToolCalls)
+		if err != nil && !repaired {
+			repaired = true
+			step--
+			turn, turnErr := toolTurn(messages, completion, nil, ToolResult{Status: ToolStatusInvalid, Message: "the arguments could not be read"})
+			if turnErr != nil {
+				return nil, turnErr
+			}
+			messages = append(turn, Message{Role: "user", Content: fmt.Sprintf("Your tool calls could not be read: %v. Make them again with JSON objects as their arguments.", err)})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if run == nil {
+			response.Narrative = strings.TrimSpace(completion.Content)
+			response.ToolCalls = calls
+			return response, nil
+		}
+
+		results, err := run(ctx, calls)
+		if err != nil {
+			logrus.Errorf("Failed to carry out tool calls from LLM provider %s: %v", c.provider.Name(), err)
+			results = make([]ToolResult, len(calls))
+			for i, call := range calls {
+				results[i] = ToolResult{ToolName: call.ToolName, Status: ToolStatusFailed, Message: err.Error()}
+			}
+		}
+		response.ToolResults = append(response.ToolResults, results...)
+
+		turn, err := toolTurn(messages, completion, results, ToolResult{Status: ToolStatusFailed, Message: "no result was reported"})
+		if err != nil {
+			return nil, err
+		}
+		messages = turn
+	}
 }
 
-func (c *Client) SendPrompt(ctx context.Context, prompt string) (*InnerLLMResponse, error) {
-	modelName := os.Getenv("LLM_MODEL_NAME")
-	if modelName == "" {
-		modelName = "gpt-4.1-2025-04-14"
+// toolTurn adds a reply that called tools to the conversation so far,
+// followed by the result of each call. Calls without a result in results
+// are given fallback.
+func toolTurn(messages []Message, completion *Completion, results []ToolResult, fallback ToolResult) ([]Message, error) {
+	messages = append(messages, Message{Role: "assistant", Content: completion.Content, ToolCalls: completion.ToolCalls})
+	for i, call := range completion.ToolCalls {
+		result := fallback
+		result.ToolName = call.Function.Name
+		if i < len(results) {
+			result = results[i]
+		}
+		content, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode result of tool %s: %w", call.Function.Name, err)
+		}
+		messages = append(messages, Message{Role: "tool", Content: string(content), ToolCallID: call.ID})
 	}
+	return messages, nil
+}
 
-	reqBody := LLMRequest{
-		Model: modelName,
-		Messages: []Message{
-			{
-				Role:    "system",
-				Content: "You are a helpful assistant for a multi-user dungeon game. Your responses should be in JSON format, with a 'narrative' field for text to be shown to the player, and a 'tool_calls' field for any actions the AI should take.",
-			},
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
-		ResponseFormat: &ResponseFormat{Type: "json_object"},
+// decodeToolCalls turns a model's function calls into tool calls. The
+// influence a call proposes to spend and why it is made are passed as the
+// "cost" and "reason" arguments.
+func decodeToolCalls(functionCalls []FunctionCall) ([]ToolCall, error) {
+	calls := make([]ToolCall, len(functionCalls))
+	for i, functionCall := range functionCalls {
+		var params map[string]interface{}
+		if err := json.Unmarshal([]byte(withDefault(functionCall.Function.Arguments, "{}")), &params); err != nil {
+			return nil, fmt.Errorf("failed to decode arguments of tool call %s: %w", functionCall.Function.Name, err)
+		}
+		if params == nil {
+			params = map[string]interface{}{}
+		}
+		call := ToolCall{ID: functionCall.ID, ToolName: functionCall.Function.Name, Parameters: params}
+		if cost, ok := tools.Number(params["cost"]); ok {
+			call.Cost = cost
+		}
+		call.Reason, _ = params["reason"].(string)
+		delete(params, "cost")
+		delete(params, "reason")
+		calls[i] = call
 	}
+	return calls, nil
+}
 
-	reqBytes, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, err
+// decodeLegacyResponse reads an answer given as a JSON object with a
+// narrative and tool_calls. It returns nil for answers that are not JSON
+// objects, and an error for ones that seem meant to be but cannot be read.
+func decodeLegacyResponse(content string) (*InnerLLMResponse, error) {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "{") {
+		return nil, nil
 	}
-
-	requestURL := c.apiURL + "/completions"
-	req, err := http.NewRequestWithContext(ctx, "POST", requestURL, bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return nil, err
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal inner LLM response: %w", err)
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
+	if _, ok := fields["narrative"]; !ok {
+		return nil, nil
 	}
-	defer resp.Body.Close()
-
-	// Read the response body into a byte slice
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	var response InnerLLMResponse
+	if err := json.Unmarshal([]byte(content), &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal inner LLM response: %w", err)
 	}
+	return &response, nil
+}
 
-	// Log the raw response body for debugging
-	fmt.Printf("Raw LLM Response: %s\n", string(bodyBytes))
-
-	var llmResponse LLMResponse
-	if err := json.Unmarshal(bodyBytes, &llmResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode LLM response: %w", err)
+// complete makes a request of the provider, as the retry policy and the
+// circuit breaker allow. When the provider cannot answer for now the error
+// wraps ErrUnavailable.
+func (c *Client) complete(ctx context.Context, req *CompletionRequest) (*Completion, error) {
+	name := c.provider.Name()
+	if err := c.Breaker.Allow(); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrUnavailable, name, err)
 	}
-
-	if len(llmResponse.Choices) == 0 {
-		return nil, errors.New("no choices in LLM response")
+	var err error
+	for attempt := 1; attempt <= max(1, c.Retry.MaxAttempts); attempt++ {
+		if attempt > 1 {
+			delay := c.Retry.backoff(attempt, err)
+			logrus.Warnf("LLM provider %s failed: %v. Retrying in %s", name, err, delay)
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				c.Breaker.Done()
+				return nil, fmt.Errorf("gave up on LLM provider %s: %w", name, ctx.Err())
+			case <-timer.C:
+			}
+		}
+		var completion *Completion
+		completion, err = c.attempt(ctx, req)
+		if err == nil {
+			c.Breaker.Success()
+			return completion, nil
+		}
+		if ctx.Err() != nil || !retryable(err) {
+			break
+		}
 	}
 
-	content := llmResponse.Choices[0].Message.Content
-
-	var innerLLMResponse InnerLLMResponse
-	if err := json.Unmarshal([]byte(content), &innerLLMResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal inner LLM response: %w", err)
+	switch {
+	case ctx.Err() != nil:
+		c.Breaker.Done()
+		return nil, fmt.Errorf("gave up on LLM provider %s: %w", name, err)
+	case retryable(err):
+		c.Breaker.Failure()
+		return nil, fmt.Errorf("%w: %s: %w", ErrUnavailable, name, err)
 	}
-
-	return &innerLLMResponse, nil
+	// The provider answered, if not usefully.
+	c.Breaker.Done()
+	return nil, err
 }
 
-func (c *Client) AnalyzeResponse(ctx context.Context, narrative string, query string) (float64, error) {
-	modelName := os.Getenv("LLM_MODEL_NAME")
-	if modelName == "" {
-		modelName = "gpt-4.1-2025-04-14"
+// attempt makes a request of the provider once, within the policy's deadline.
+func (c *Client) attempt(ctx context.Context, req *CompletionRequest) (*Completion, error) {
+	if c.Retry.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Retry.Timeout)
+		defer cancel()
 	}
+	return c.provider.Complete(ctx, req)
+}
 
+func (c *Client) AnalyzeResponse(ctx context.Context, narrative string, query string) (float64, error) {
 	fullPrompt := fmt.Sprintf("Given the following narrative: \"%s\"\n\n%s\n\nRespond ONLY with a single numerical value.", narrative, query)
 
-	reqBody := LLMRequest{
-		Model: modelName,
+	// Do not request a JSON object for analysis, as we expect a raw number
+	completion, err := c.complete(ctx, &CompletionRequest{
 		Messages: []Message{
 			{
 				Role:    "system",
@@ -174,51 +356,17 @@ func (c *Client) AnalyzeResponse(ctx context.Context, narrative string, query st
 				Content: fullPrompt,
 			},
 		},
-		// Do not request JSON object for analysis, as we expect a raw number
-	}
-
-	reqBytes, err := json.Marshal(reqBody)
+	})
 	if err != nil {
 		return 0, err
 	}
 
-	requestURL := c.apiURL + "/completions"
-	req, err := http.NewRequestWithContext(ctx, "POST", requestURL, bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return 0, err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return 0, err
-	}
-	defer resp.Body.Close()
-
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, fmt.Errorf("failed to read analysis response body: %w", err)
-	}
-
-	fmt.Printf("Raw LLM Analysis Response: %s\n", string(bodyBytes))
-
-	var llmResponse LLMResponse
-	if err := json.Unmarshal(bodyBytes, &llmResponse); err != nil {
-		return 0, fmt.Errorf("failed to decode LLM analysis response: %w", err)
-	}
-
-	if len(llmResponse.Choices) == 0 {
-		return 0, errors.New("no choices in LLM analysis response")
-	}
-
 	// Attempt to parse the content as a float64
-	scoreStr := strings.TrimSpace(llmResponse.Choices[0].Message.Content)
+	scoreStr := strings.TrimSpace(completion.Content)
 	score, err := strconv.ParseFloat(scoreStr, 64)
 	if err != nil {
 		return 0, fmt.Errorf("failed to parse LLM analysis score '%s': %w", scoreStr, err)
 	}
 
 	return score, nil
-}
\ No newline at end of file
+}
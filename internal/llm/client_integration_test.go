@@ -65,11 +65,12 @@ func TestSendPrompt_RealNetwork_DefaultValues(t *testing.T) {
 	client := NewClient()
 
 	// Verify that the client has loaded the default values
-	if client.apiKey != "unused" {
-		t.Fatalf("Expected default apiKey 'unused', but got '%s'", client.apiKey)
+	provider := client.Provider().(*OpenAIProvider)
+	if provider.apiKey != "unused" {
+		t.Fatalf("Expected default apiKey 'unused', but got '%s'", provider.apiKey)
 	}
-	if client.apiURL != "https://api.llm7.io/v1" {
-		t.Fatalf("Expected default apiURL 'https://api.llm7.io/v1', but got '%s'", client.apiURL)
+	if provider.apiURL != "https://api.llm7.io/v1" {
+		t.Fatalf("Expected default apiURL 'https://api.llm7.io/v1', but got '%s'", provider.apiURL)
 	}
 
 	prompt := "This is a real network test from a Go test suite. Please respond with a short, simple confirmation message. No tool calls are needed."
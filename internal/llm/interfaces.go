@@ -10,3 +10,8 @@ type LLMServiceInterface interface {
 	ProcessAction(ctx context.Context, entity interface{}, player *models.PlayerCharacter, playerAction string) (*InnerLLMResponse, error)
 	AnalyzeResponse(ctx context.Context, narrative string, query string) (float64, error)
 }
+
+// ToolDispatcher carries out the tool calls entities make.
+type ToolDispatcher interface {
+	Dispatch(ctx context.Context, player *models.PlayerCharacter, entity interface{}, toolCalls []ToolCall) ([]ToolResult, error)
+}
@@ -0,0 +1,139 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OllamaProvider completes chats with a model served by Ollama, through its
+// native chat API.
+type OllamaProvider struct {
+	name       string
+	apiURL     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOllamaProvider creates an OllamaProvider for the server at apiURL, such
+// as "http://localhost:11434".
+func NewOllamaProvider(name, apiURL, model string) *OllamaProvider {
+	return &OllamaProvider{
+		name:       name,
+		apiURL:     apiURL,
+		model:      model,
+		httpClient: newHTTPClient(),
+	}
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []OfferedTool   `json:"tools,omitempty"`
+	Stream   bool            `json:"stream"`
+	Format   string          `json:"format,omitempty"`
+}
+
+// ollamaMessage is a Message as Ollama takes it: the arguments of calls are
+// objects rather than strings, calls have no IDs, and results name the tool
+// they came from instead.
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaResponse struct {
+	Model           string        `json:"model"`
+	Message         ollamaMessage `json:"message"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+func (p *OllamaProvider) Name() string {
+	return p.name
+}
+
+func (p *OllamaProvider) Complete(ctx context.Context, req *CompletionRequest) (*Completion, error) {
+	reqBody := ollamaRequest{
+		Model: withDefault(req.Model, p.model),
+		Tools: offer(req.Tools),
+	}
+	called := make(map[string]string) // Tool names by call ID
+	for _, msg := range req.Messages {
+		converted := ollamaMessage{Role: msg.Role, Content: msg.Content, ToolName: called[msg.ToolCallID]}
+		for _, call := range msg.ToolCalls {
+			called[call.ID] = call.Function.Name
+			var toolCall ollamaToolCall
+			toolCall.Function.Name = call.Function.Name
+			toolCall.Function.Arguments = json.RawMessage(withDefault(call.Function.Arguments, "{}"))
+			converted.ToolCalls = append(converted.ToolCalls, toolCall)
+		}
+		reqBody.Messages = append(reqBody.Messages, converted)
+	}
+	if req.JSON {
+		reqBody.Format = "json"
+	}
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.apiURL+"/api/chat", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	logrus.Debugf("Raw LLM response from %s: %s", p.name, string(bodyBytes))
+
+	var ollamaResp ollamaResponse
+	if err := json.Unmarshal(bodyBytes, &ollamaResp); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("failed to decode Ollama response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(p.name, resp, withDefault(ollamaResp.Error, string(bodyBytes)))
+	}
+	var calls []FunctionCall
+	for i, toolCall := range ollamaResp.Message.ToolCalls {
+		calls = append(calls, FunctionCall{
+			ID:       fmt.Sprintf("call_%d", i),
+			Type:     "function",
+			Function: FunctionInvocation{Name: toolCall.Function.Name, Arguments: withDefault(string(toolCall.Function.Arguments), "{}")},
+		})
+	}
+	return &Completion{
+		Content:   ollamaResp.Message.Content,
+		ToolCalls: calls,
+		Model:     ollamaResp.Model,
+		Usage: Usage{
+			PromptTokens:     ollamaResp.PromptEvalCount,
+			CompletionTokens: ollamaResp.EvalCount,
+			TotalTokens:      ollamaResp.PromptEvalCount + ollamaResp.EvalCount,
+		},
+	}, nil
+}
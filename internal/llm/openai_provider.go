@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OpenAIProvider completes chats through an OpenAI-compatible chat
+// completions API, such as OpenAI's own, a hosted gateway or llama.cpp's
+// server.
+type OpenAIProvider struct {
+	name       string
+	apiURL     string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOpenAIProvider creates an OpenAIProvider for the API at apiURL, such as
+// "https://api.openai.com/v1".
+func NewOpenAIProvider(name, apiURL, apiKey, model string) *OpenAIProvider {
+	return &OpenAIProvider{
+		name:       name,
+		apiURL:     apiURL,
+		apiKey:     apiKey,
+		model:      model,
+		httpClient: newHTTPClient(),
+	}
+}
+
+type LLMRequest struct {
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	Tools          []OfferedTool   `json:"tools,omitempty"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// OfferedTool offers a function to the model, in the form OpenAI's API and
+// Ollama's share.
+type OfferedTool struct {
+	Type     string       `json:"type"`
+	Function FunctionSpec `json:"function"`
+}
+
+type ResponseFormat struct {
+	Type string `json:"type"`
+}
+
+type LLMResponse struct {
+	ID      string   `json:"id"`
+	Object  string   `json:"object"`
+	Created int64    `json:"created"`
+	Model   string   `json:"model"`
+	Choices []Choice `json:"choices"`
+	Usage   Usage    `json:"usage"`
+}
+
+type Choice struct {
+	Index        int     `json:"index"`
+	Message      Message `json:"message"`
+	FinishReason string  `json:"finish_reason"`
+}
+
+func (p *OpenAIProvider) Name() string {
+	return p.name
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, req *CompletionRequest) (*Completion, error) {
+	reqBody := LLMRequest{
+		Model:    withDefault(req.Model, p.model),
+		Messages: req.Messages,
+		Tools:    offer(req.Tools),
+	}
+	if req.JSON {
+		reqBody.ResponseFormat = &ResponseFormat{Type: "json_object"}
+	}
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.apiURL+"/chat/completions", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	logrus.Debugf("Raw LLM response from %s: %s", p.name, string(bodyBytes))
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(p.name, resp, string(bodyBytes))
+	}
+
+	var llmResponse LLMResponse
+	if err := json.Unmarshal(bodyBytes, &llmResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode LLM response: %w", err)
+	}
+	if len(llmResponse.Choices) == 0 {
+		return nil, errors.New("no choices in LLM response")
+	}
+	return &Completion{
+		Content:   llmResponse.Choices[0].Message.Content,
+		ToolCalls: llmResponse.Choices[0].Message.ToolCalls,
+		Model:     llmResponse.Model,
+		Usage:     llmResponse.Usage,
+	}, nil
+}
+
+// offer returns the tools that offer functions to a model.
+func offer(functions []FunctionSpec) []OfferedTool {
+	var tools []OfferedTool
+	for _, function := range functions {
+		tools = append(tools, OfferedTool{Type: "function", Function: function})
+	}
+	return tools
+}
@@ -1,81 +1,232 @@
 package llm
 
 import (
+	"encoding/json"
 	"fmt"
 	"mud/internal/dal"
 	"mud/internal/game/perception"
+	"mud/internal/game/tools"
 	"mud/internal/models"
 	"strings"
+	"unicode/utf8"
 )
 
 type PromptData struct {
 	Entity        interface{}
 	Player        *models.PlayerCharacter
 	Room          *models.Room
-	RecentActions []*perception.PerceivedAction
-	LoreEntries   []*models.Lore
+	RecentActions []*perception.PerceivedAction // Oldest first
+	LoreEntries   []*models.Lore                // Most relevant first
 	DAL           *dal.DAL
 	PlayerAction  string
+	// Tools describes the tools the entity lists, as the dispatcher that
+	// runs them defines them. Without it the entity's own list is used.
+	Tools *tools.Registry
+	// NativeTools is set when the tools are offered to the model as
+	// functions, which carry their parameter schemas, so the prompt only
+	// names and describes them.
+	NativeTools bool
+	// Budget is how many tokens, as EstimateTokens counts them, the whole
+	// request may take. Zero means no limit.
+	Budget int
+	// Reserved is how many tokens of the budget the rest of the request
+	// takes, such as the system message and anything sent after the prompt.
+	Reserved int
 }
 
+// Prompt sections, as PromptReport names them.
+const (
+	SectionPersonality   = "personality"
+	SectionTools         = "tools"
+	SectionMemories      = "memories"
+	SectionBehavior      = "behavior"
+	SectionLore          = "lore"
+	SectionRecentActions = "recent_actions"
+	SectionPlayerAction  = "player_action"
+)
+
+// EstimateTokens returns roughly how many tokens a model takes text to be:
+// one for every four characters, as is usual for English.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// PromptReport tells how a prompt was fitted to its budget.
+type PromptReport struct {
+	Budget   int
+	Reserved int // Estimated tokens of the rest of the request
+	Tokens   int // Estimated tokens of the prompt
+	Sections []SectionReport
+}
+
+// SectionReport tells how many tokens a section of a prompt took, and how
+// many of its entries were dropped to keep to the budget.
+type SectionReport struct {
+	Name    string
+	Tokens  int
+	Dropped int
+}
+
+// Trimmed returns the names of the sections entries were dropped from.
+func (r *PromptReport) Trimmed() []string {
+	var trimmed []string
+	for _, section := range r.Sections {
+		if section.Dropped > 0 {
+			trimmed = append(trimmed, section.Name)
+		}
+	}
+	return trimmed
+}
+
+func (r *PromptReport) String() string {
+	parts := make([]string, 0, len(r.Sections))
+	for _, section := range r.Sections {
+		part := fmt.Sprintf("%s %d", section.Name, section.Tokens)
+		if section.Dropped > 0 {
+			part += fmt.Sprintf(" (%d dropped)", section.Dropped)
+		}
+		parts = append(parts, part)
+	}
+	return fmt.Sprintf("%d+%d of %d tokens: %s", r.Tokens, r.Reserved, r.Budget, strings.Join(parts, ", "))
+}
+
+// Which end of a section entries are dropped from to keep to the budget.
+const (
+	keepAll = iota
+	dropFirst
+	dropLast
+)
+
+// promptSection is a part of the prompt: a heading and its entries, each
+// ending in a newline. A section without entries is left out.
+type promptSection struct {
+	name    string
+	heading string
+	entries []string
+	drop    int
+	dropped int
+}
+
+func (s *promptSection) String() string {
+	if len(s.entries) == 0 {
+		return ""
+	}
+	return s.heading + strings.Join(s.entries, "")
+}
+
+// AssemblePrompt assembles the prompt an entity answers a player by.
 func AssemblePrompt(data *PromptData) (string, error) {
-	var sb strings.Builder
+	prompt, _, err := AssemblePromptWithReport(data)
+	return prompt, err
+}
 
+// AssemblePromptWithReport assembles the prompt an entity answers a player
+// by, keeping to what its budget leaves once the reserved tokens are
+// counted, and reports how. Over budget, lore is dropped least relevant
+// first, then memories and recent actions oldest first. The entity's
+// personality, tools and behavior, and the player's action, are always
+// kept, even if they alone are over budget.
+func AssemblePromptWithReport(data *PromptData) (string, *PromptReport, error) {
 	// 1. Add entity's personality
 	personality, err := getEntityPersonality(data.Entity)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	sb.WriteString(fmt.Sprintf("Your personality: %s\n\n", personality))
+	sections := []*promptSection{{name: SectionPersonality, entries: []string{fmt.Sprintf("Your personality: %s\n", personality)}}}
 
 	// 2. Add available tools
-	tools, err := getEntityTools(data.Entity)
+	available, err := getEntityTools(data.Entity, data.Tools)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	if len(tools) > 0 {
-		sb.WriteString("You have the following tools available:\n")
-		for _, tool := range tools {
-			sb.WriteString(fmt.Sprintf("- %s: %s\n", tool.Name, tool.Description))
+	toolSection := &promptSection{name: SectionTools, heading: "You have the following tools available:\n"}
+	for _, tool := range available {
+		entry := fmt.Sprintf("- %s: %s\n", tool.Name, tool.Description)
+		if len(tool.Parameters) > 0 && !data.NativeTools {
+			parameters, err := json.Marshal(tool.Parameters)
+			if err != nil {
+				return "", nil, fmt.Errorf("failed to encode parameters of tool %s: %w", tool.Name, err)
+			}
+			entry += fmt.Sprintf("  Parameters: %s\n", parameters)
 		}
-		sb.WriteString("\n")
+		toolSection.entries = append(toolSection.entries, entry)
 	}
+	sections = append(sections, toolSection)
 
 	// 3. Add memories about the player
 	memories, err := getEntityMemories(data.Entity, data.Player.ID)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+	memorySection := &promptSection{name: SectionMemories, heading: "Your memories about this player:\n", drop: dropFirst}
+	for _, memory := range memories {
+		memorySection.entries = append(memorySection.entries, fmt.Sprintf("- %s\n", memory))
 	}
-	if len(memories) > 0 {
-		sb.WriteString("Your memories about this player:\n")
-		for _, memory := range memories {
-			sb.WriteString(fmt.Sprintf("- %s\n", memory))
+	sections = append(sections, memorySection)
+
+	// Add how an NPC has been told to behave toward the player
+	behaviorSection := &promptSection{name: SectionBehavior}
+	if npc, ok := data.Entity.(*models.NPC); ok {
+		if behavior := behaviorToward(npc, data.Player.ID); behavior != "" {
+			behaviorSection.entries = []string{fmt.Sprintf("Your behavior toward this player: %s\n", behavior)}
 		}
-		sb.WriteString("\n")
 	}
+	sections = append(sections, behaviorSection)
 
 	// 4. Add relevant lore
-	if len(data.LoreEntries) > 0 {
-		sb.WriteString("Relevant lore:\n")
-		for _, l := range data.LoreEntries {
-			sb.WriteString(fmt.Sprintf("- %s: %s\n", l.Title, l.Content))
-		}
-		sb.WriteString("\n")
+	loreSection := &promptSection{name: SectionLore, heading: "Relevant lore:\n", drop: dropLast}
+	for _, l := range data.LoreEntries {
+		loreSection.entries = append(loreSection.entries, fmt.Sprintf("- %s: %s\n", l.Title, l.Content))
 	}
+	sections = append(sections, loreSection)
 
 	// 5. Add recent actions
-	if len(data.RecentActions) > 0 {
-		sb.WriteString("Recent perceived actions by the player:\n")
-		for _, action := range data.RecentActions {
-			sb.WriteString(fmt.Sprintf("- %s (Significance: %.2f)\n", action.PerceivedActionType, action.BaseSignificance))
-		}
-		sb.WriteString("\n")
+	actionSection := &promptSection{name: SectionRecentActions, heading: "Recent perceived actions by the player:\n", drop: dropFirst}
+	for _, action := range data.RecentActions {
+		actionSection.entries = append(actionSection.entries, fmt.Sprintf("- %s (Significance: %.2f)\n", action.PerceivedActionType, action.BaseSignificance))
 	}
+	sections = append(sections, actionSection)
 
 	// 6. Add the player's action
-	sb.WriteString(fmt.Sprintf("The player's action: %s\n", data.PlayerAction))
+	sections = append(sections, &promptSection{name: SectionPlayerAction, entries: []string{fmt.Sprintf("The player's action: %s\n", data.PlayerAction)}})
+
+	// 7. Keep to the budget, trimming lore, then memories, then actions
+	report := &PromptReport{Budget: data.Budget, Reserved: data.Reserved}
+	if data.Budget > 0 {
+		tokens := data.Reserved
+		for _, section := range sections {
+			if text := section.String(); text != "" {
+				tokens += EstimateTokens(text) + 1 // The blank line after it
+			}
+		}
+		for _, section := range []*promptSection{loreSection, memorySection, actionSection} {
+			for tokens > data.Budget && len(section.entries) > 0 {
+				before := EstimateTokens(section.String())
+				if section.drop == dropFirst {
+					section.entries = section.entries[1:]
+				} else {
+					section.entries = section.entries[:len(section.entries)-1]
+				}
+				section.dropped++
+				tokens -= before - EstimateTokens(section.String())
+			}
+		}
+	}
 
-	return sb.String(), nil
+	var parts []string
+	for _, section := range sections {
+		text := section.String()
+		if text == "" && section.dropped == 0 {
+			continue
+		}
+		report.Sections = append(report.Sections, SectionReport{Name: section.name, Tokens: EstimateTokens(text), Dropped: section.dropped})
+		if text != "" {
+			parts = append(parts, text)
+		}
+	}
+	prompt := strings.Join(parts, "\n")
+	report.Tokens = EstimateTokens(prompt)
+	return prompt, report, nil
 }
 
 func getEntityPersonality(entity interface{}) (string, error) {
@@ -86,22 +237,23 @@ func getEntityPersonality(entity interface{}) (string, error) {
 		return v.LLMPromptContext, nil
 	case *models.Questmaker:
 		return v.LLMPromptContext, nil
+	case *models.QuestOwner:
+		return v.LLMPromptContext, nil
 	default:
 		return "", fmt.Errorf("unknown entity type for personality")
 	}
 }
 
-func getEntityTools(entity interface{}) ([]models.Tool, error) {
-	switch v := entity.(type) {
-	case *models.NPC:
-		return v.AvailableTools, nil
-	case *models.Owner:
-		return v.AvailableTools, nil
-	case *models.Questmaker:
-		return v.AvailableTools, nil
-	default:
+// getEntityTools returns the tools an entity can call, described by the
+// registry when there is one.
+func getEntityTools(entity interface{}, registry *tools.Registry) ([]models.Tool, error) {
+	if tools.Kind(entity) == "" {
 		return nil, fmt.Errorf("unknown entity type for tools")
 	}
+	if registry != nil {
+		return registry.Available(entity), nil
+	}
+	return tools.AvailableTools(entity), nil
 }
 
 func getEntityMemories(entity interface{}, playerID string) ([]string, error) {
@@ -121,7 +273,21 @@ func getEntityMemories(entity interface{}, playerID string) ([]string, error) {
 			return memories, nil
 		}
 		return nil, nil
+	case *models.QuestOwner:
+		return nil, nil // Quest Owners keep no memories of players
 	default:
 		return nil, fmt.Errorf("unknown entity type for memories")
 	}
 }
+
+// behaviorToward returns the behavior an NPC has been given toward a player
+// under the "toward_players" key of its BehaviorState, or "" if none.
+func behaviorToward(npc *models.NPC, playerID string) string {
+	var state struct {
+		TowardPlayers map[string]string `json:"toward_players"`
+	}
+	if json.Unmarshal([]byte(npc.BehaviorState), &state) != nil {
+		return ""
+	}
+	return state.TowardPlayers[playerID]
+}
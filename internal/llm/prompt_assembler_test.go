@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/game/perception"
+	"mud/internal/game/tools"
+	"mud/internal/models"
+)
+
+func TestAssemblePrompt_KeepsToBudget(t *testing.T) {
+	npc := &models.NPC{
+		ID:                "guard",
+		PersonalityPrompt: "A gruff city guard.",
+		MemoriesAboutPlayers: map[string][]string{
+			"hero": {"The hero arrived at the gate.", "The hero paid the toll.", "The hero insulted the captain."},
+		},
+	}
+	data := &PromptData{
+		Entity: npc,
+		Player: &models.PlayerCharacter{ID: "hero"},
+		LoreEntries: []*models.Lore{
+			{Title: "The Watch", Content: "The city watch guards the gates day and night."},
+			{Title: "The Old War", Content: "Long ago the city was besieged for seven years."},
+		},
+		RecentActions: []*perception.PerceivedAction{
+			{PerceivedActionType: "wave", BaseSignificance: 1},
+		},
+		PlayerAction: "asks for directions",
+	}
+
+	full, report, err := AssemblePromptWithReport(data)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Trimmed(), "Prompts without a budget are not trimmed")
+	assert.Contains(t, full, "Your personality: A gruff city guard.\n\n")
+	assert.Contains(t, full, "- The Old War: ")
+	assert.Equal(t, EstimateTokens(full), report.Tokens)
+
+	// Least relevant lore goes first.
+	data.Budget = report.Tokens - 5
+	prompt, report, err := AssemblePromptWithReport(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{SectionLore}, report.Trimmed())
+	assert.Contains(t, prompt, "- The Watch: ")
+	assert.NotContains(t, prompt, "The Old War")
+	assert.LessOrEqual(t, report.Tokens, data.Budget)
+
+	// Then the rest of the lore and the oldest memories.
+	data.Budget = report.Tokens - 25
+	prompt, report, err = AssemblePromptWithReport(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{SectionMemories, SectionLore}, report.Trimmed())
+	assert.NotContains(t, prompt, "Relevant lore")
+	assert.NotContains(t, prompt, "arrived at the gate")
+	assert.Contains(t, prompt, "The hero insulted the captain.")
+	assert.LessOrEqual(t, report.Tokens, data.Budget)
+
+	// The personality and the player's action are kept whatever the budget.
+	data.Budget = 1
+	prompt, report, err = AssemblePromptWithReport(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{SectionMemories, SectionLore, SectionRecentActions}, report.Trimmed())
+	assert.Equal(t, "Your personality: A gruff city guard.\n\n", prompt[:len("Your personality: A gruff city guard.\n\n")])
+	assert.Contains(t, prompt, "The player's action: asks for directions\n")
+	assert.Contains(t, report.String(), "memories 0 (3 dropped)")
+
+	// The rest of the request counts against the budget too.
+	data.Budget = 1000
+	_, report, err = AssemblePromptWithReport(data)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Trimmed())
+	data.Reserved = 1000
+	_, report, err = AssemblePromptWithReport(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{SectionMemories, SectionLore, SectionRecentActions}, report.Trimmed())
+	assert.Contains(t, report.String(), "+1000 of 1000 tokens")
+}
+
+func TestAssemblePrompt_DescribesTools(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(tools.Definition{
+		Name:        "recall",
+		Description: "Recall a memory.",
+		Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{"topic": map[string]interface{}{"type": "string"}}},
+		Entities:    []string{tools.EntityNPC},
+		Handler:     noop,
+	})
+	data := &PromptData{
+		Entity: &models.NPC{ID: "guard", AvailableTools: []models.Tool{{Name: "recall"}}},
+		Player: &models.PlayerCharacter{ID: "hero"},
+		Tools:  registry,
+	}
+
+	prompt, err := AssemblePrompt(data)
+	assert.NoError(t, err)
+	assert.Contains(t, prompt, "- recall: Recall a memory.\n  Parameters: {")
+
+	// Functions carry their own schemas, so the prompt only names them.
+	data.NativeTools = true
+	prompt, err = AssemblePrompt(data)
+	assert.NoError(t, err)
+	assert.Contains(t, prompt, "- recall: Recall a memory.\n")
+	assert.NotContains(t, prompt, "Parameters:")
+}
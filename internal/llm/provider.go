@@ -0,0 +1,128 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Kinds of provider.
+const (
+	ProviderOpenAI   = "openai"   // An OpenAI-compatible chat completions API
+	ProviderOllama   = "ollama"   // A local Ollama server
+	ProviderLlamaCpp = "llamacpp" // A local llama.cpp server, through its OpenAI-compatible API
+	ProviderScripted = "scripted" // Replies from a script, for offline development and tests
+)
+
+// ErrUnknownProvider is returned when a provider that is not configured is asked for.
+var ErrUnknownProvider = errors.New("unknown LLM provider")
+
+// Provider is a backend that completes chats: a hosted API, a local model
+// server or a script.
+type Provider interface {
+	// Name is the name entities and tasks select the provider by.
+	Name() string
+	// Complete returns the model's reply to a chat.
+	Complete(ctx context.Context, req *CompletionRequest) (*Completion, error)
+}
+
+// CompletionRequest is a chat to complete.
+type CompletionRequest struct {
+	Model    string // The model to use; empty for the provider's own
+	Messages []Message
+	Tools    []FunctionSpec // Functions the model may call instead of answering
+	JSON     bool           // Whether the reply must be a JSON object
+}
+
+// Completion is a model's reply: an answer, or calls of the functions it
+// was offered.
+type Completion struct {
+	Content   string
+	ToolCalls []FunctionCall
+	Model     string
+	Usage     Usage
+}
+
+// Message is a message of a chat. An assistant message carries the calls the
+// model made, and each call's result goes back in a "tool" message with the
+// call's ID.
+type Message struct {
+	Role       string         `json:"role"`
+	Content    string         `json:"content"`
+	ToolCalls  []FunctionCall `json:"tool_calls,omitempty"`
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+}
+
+// FunctionSpec describes a function the model may call, with its parameters
+// as a JSON-Schema object.
+type FunctionSpec struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// FunctionCall is a model's call of a function. Arguments is a JSON object.
+type FunctionCall struct {
+	ID       string             `json:"id,omitempty"`
+	Type     string             `json:"type"`
+	Function FunctionInvocation `json:"function"`
+}
+
+type FunctionInvocation struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ProviderConfig describes a provider. Endpoint and Model default to what
+// suits the kind; Script is the file a scripted provider replies from.
+type ProviderConfig struct {
+	Name     string
+	Kind     string
+	Endpoint string
+	APIKey   string
+	Model    string
+	Script   string
+}
+
+// NewProvider creates the provider a config describes.
+func NewProvider(cfg ProviderConfig) (Provider, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("LLM provider has no name")
+	}
+	switch cfg.Kind {
+	case ProviderOpenAI, "":
+		return NewOpenAIProvider(cfg.Name, withDefault(cfg.Endpoint, "https://api.llm7.io/v1"), withDefault(cfg.APIKey, "unused"), withDefault(cfg.Model, "gpt-4.1-2025-04-14")), nil
+	case ProviderLlamaCpp:
+		// llama.cpp serves whichever model it was started with, whatever the
+		// request names.
+		return NewOpenAIProvider(cfg.Name, withDefault(cfg.Endpoint, "http://localhost:8080/v1"), withDefault(cfg.APIKey, "unused"), withDefault(cfg.Model, "local")), nil
+	case ProviderOllama:
+		return NewOllamaProvider(cfg.Name, withDefault(cfg.Endpoint, "http://localhost:11434"), withDefault(cfg.Model, "llama3.1")), nil
+	case ProviderScripted:
+		if cfg.Script == "" {
+			return NewScriptedProvider(cfg.Name), nil
+		}
+		return LoadScriptedProvider(cfg.Name, cfg.Script)
+	}
+	return nil, fmt.Errorf("LLM provider %s has an unknown kind %q", cfg.Name, cfg.Kind)
+}
+
+// newHTTPClient returns the HTTP client of a provider. Requests have no
+// timeout of their own: the Client's retry policy gives each a deadline.
+func newHTTPClient() *http.Client {
+	return &http.Client{}
+}
+
+func withDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,121 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAIProvider(t *testing.T) {
+	var received LLMRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		if received.Model == "broken" {
+			http.Error(w, "model is overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(LLMResponse{
+			Model:   received.Model,
+			Choices: []Choice{{Message: Message{Role: "assistant", Content: `{"narrative": "Hello."}`}}},
+			Usage:   Usage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15},
+		})
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("hosted", server.URL+"/v1", "secret", "gpt-test")
+	completion, err := provider.Complete(context.Background(), &CompletionRequest{Messages: []Message{{Role: "user", Content: "Hi"}}, JSON: true})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"narrative": "Hello."}`, completion.Content)
+	assert.Equal(t, 15, completion.Usage.TotalTokens)
+	assert.Equal(t, "gpt-test", received.Model)
+	assert.Equal(t, &ResponseFormat{Type: "json_object"}, received.ResponseFormat)
+
+	received = LLMRequest{}
+	_, err = provider.Complete(context.Background(), &CompletionRequest{Model: "broken", Messages: []Message{{Role: "user", Content: "Hi"}}})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "model is overloaded")
+	}
+	assert.Nil(t, received.ResponseFormat, "Only JSON requests ask for a JSON object")
+}
+
+func TestOllamaProvider(t *testing.T) {
+	var received ollamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		if received.Model == "tools" {
+			w.Write([]byte(`{"model": "tools", "message": {"role": "assistant", "content": "", "tool_calls": [{"function": {"name": "recall", "arguments": {"topic": "hero"}}}]}}`))
+			return
+		}
+		if received.Model == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "model 'missing' not found"}`))
+			return
+		}
+		w.Write([]byte(`{"model": "llama3.1", "message": {"role": "assistant", "content": "42"}, "done": true, "prompt_eval_count": 20, "eval_count": 2}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(ProviderConfig{Name: "local", Kind: ProviderOllama, Endpoint: server.URL})
+	assert.NoError(t, err)
+	completion, err := provider.Complete(context.Background(), &CompletionRequest{Messages: []Message{{Role: "user", Content: "Score this."}}, JSON: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "42", completion.Content)
+	assert.Equal(t, Usage{PromptTokens: 20, CompletionTokens: 2, TotalTokens: 22}, completion.Usage)
+	assert.Equal(t, ollamaRequest{Model: "llama3.1", Messages: []ollamaMessage{{Role: "user", Content: "Score this."}}, Format: "json"}, received,
+		"Replies are not streamed, and the default model is used")
+
+	// Calls and their results are passed as Ollama takes them.
+	completion, err = provider.Complete(context.Background(), &CompletionRequest{
+		Model: "tools",
+		Messages: []Message{
+			{Role: "user", Content: "Who is this?"},
+			{Role: "assistant", ToolCalls: []FunctionCall{{ID: "call_0", Type: "function", Function: FunctionInvocation{Name: "recall", Arguments: `{"topic":"stranger"}`}}}},
+			{Role: "tool", Content: `{"status":"ok"}`, ToolCallID: "call_0"},
+		},
+		Tools: []FunctionSpec{{Name: "recall", Parameters: map[string]interface{}{"type": "object"}}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []FunctionCall{{ID: "call_0", Type: "function", Function: FunctionInvocation{Name: "recall", Arguments: `{"topic": "hero"}`}}}, completion.ToolCalls)
+	if assert.Len(t, received.Messages, 3) {
+		assert.JSONEq(t, `{"topic":"stranger"}`, string(received.Messages[1].ToolCalls[0].Function.Arguments))
+		assert.Equal(t, "recall", received.Messages[2].ToolName)
+	}
+	assert.Equal(t, []OfferedTool{{Type: "function", Function: FunctionSpec{Name: "recall", Parameters: map[string]interface{}{"type": "object"}}}}, received.Tools)
+
+	_, err = provider.Complete(context.Background(), &CompletionRequest{Model: "missing"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "model 'missing' not found")
+	}
+}
+
+func TestScriptedProvider(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "script.txt")
+	assert.NoError(t, os.WriteFile(script, []byte("# The guard greets, then grows bored.\n{\"narrative\": \"Halt!\"}\n\n{\"narrative\": \"Move along.\"}\n"), 0o644))
+	provider, err := NewProvider(ProviderConfig{Name: "offline", Kind: ProviderScripted, Script: script})
+	assert.NoError(t, err)
+
+	client := NewClientFor(provider)
+	for _, expected := range []string{"Halt!", "Move along.", "Move along."} {
+		resp, err := client.SendPrompt(context.Background(), "The player waves.")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, resp.Narrative)
+	}
+	requests := provider.(*ScriptedProvider).Requests()
+	assert.Len(t, requests, 3)
+	assert.Equal(t, "The player waves.", requests[0].Messages[1].Content)
+
+	_, err = NewScriptedProvider("empty").Complete(context.Background(), &CompletionRequest{})
+	assert.True(t, errors.Is(err, ErrEmptyScript))
+	_, err = NewProvider(ProviderConfig{Name: "mystery", Kind: "oracle"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,183 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnavailable is returned when a provider cannot answer for now: it
+	// kept failing however often it was asked, or its circuit is open.
+	ErrUnavailable = errors.New("LLM provider unavailable")
+	// ErrCircuitOpen is returned, along with ErrUnavailable, while a
+	// provider's circuit breaker is open.
+	ErrCircuitOpen = errors.New("circuit open")
+)
+
+// StatusError is returned when a provider's API answers with an error status.
+type StatusError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // How long the API asked to be left alone, if it did
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("LLM provider %s returned %d %s: %s", e.Provider, e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// Temporary reports whether the request may succeed if it is made again:
+// the API was rate limited or failed on its side.
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// newStatusError returns the error for a response with an error status.
+func newStatusError(provider string, resp *http.Response, body string) *StatusError {
+	err := &StatusError{Provider: provider, StatusCode: resp.StatusCode, Body: body}
+	if seconds, parseErr := strconv.Atoi(resp.Header.Get("Retry-After")); parseErr == nil && seconds > 0 {
+		err.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return err
+}
+
+// RetryPolicy is how a Client asks its provider: each attempt has a
+// deadline, and attempts that fail for reasons that may pass, such as rate
+// limits, server errors and timeouts, are made again after an exponential
+// backoff with jitter.
+type RetryPolicy struct {
+	Timeout     time.Duration // Deadline of each attempt
+	MaxAttempts int
+	BaseDelay   time.Duration // Backoff before the second attempt, doubling after that
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy returns the policy clients are created with.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Timeout:     30 * time.Second,
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    8 * time.Second,
+	}
+}
+
+// backoff returns how long to wait before an attempt after the first: half
+// the exponential delay, plus a random part of the other half so that
+// callers do not retry in step.
+func (p RetryPolicy) backoff(attempt int, err error) time.Duration {
+	delay := p.BaseDelay << (attempt - 2)
+	if delay > p.MaxDelay || delay <= 0 {
+		delay = p.MaxDelay
+	}
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > delay {
+		delay = min(statusErr.RetryAfter, p.MaxDelay)
+	}
+	return delay
+}
+
+// retryable reports whether an attempt that failed may succeed if it is
+// made again: it was rate limited, failed on the provider's side, timed out
+// or could not reach the provider.
+func retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr)
+}
+
+// Circuit breaker states.
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// CircuitBreaker stops a Client from asking a provider that keeps failing.
+// After Threshold calls fail in a row it opens, and calls fail at once
+// until Cooldown has passed. Then one call is let through: if it succeeds
+// the breaker closes, and if it fails it opens again.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool // Whether the call let through after the cooldown is under way
+	now      func() time.Time
+}
+
+// NewCircuitBreaker creates a closed CircuitBreaker.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{Threshold: threshold, Cooldown: cooldown, now: time.Now}
+}
+
+// State returns whether the breaker is closed, open or half-open.
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state()
+}
+
+func (b *CircuitBreaker) state() string {
+	switch {
+	case b.failures < b.Threshold:
+		return CircuitClosed
+	case b.trial || b.now().Sub(b.openedAt) < b.Cooldown:
+		return CircuitOpen
+	}
+	return CircuitHalfOpen
+}
+
+// Allow reports whether a call may be made, returning ErrCircuitOpen if not.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state() {
+	case CircuitOpen:
+		return ErrCircuitOpen
+	case CircuitHalfOpen:
+		b.trial = true
+	}
+	return nil
+}
+
+// Success records that a call succeeded, closing the breaker.
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.trial = false
+}
+
+// Failure records that a call failed, opening the breaker once too many
+// have failed in a row.
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.trial = false
+	if b.failures >= b.Threshold {
+		b.openedAt = b.now()
+	}
+}
+
+// Done records that a call ended without telling whether the provider
+// works, such as when its caller gave up on it.
+func (b *CircuitBreaker) Done() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
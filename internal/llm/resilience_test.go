@@ -0,0 +1,172 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestClient returns a Client for an httptest stand-in of an
+// OpenAI-compatible API, retrying quickly.
+func newTestClient(url string) *Client {
+	client := NewClientFor(NewOpenAIProvider("standin", url, "key", "model"))
+	client.Retry = RetryPolicy{Timeout: time.Second, MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	return client
+}
+
+// reply writes a chat completion with a message.
+func reply(w http.ResponseWriter, message Message) {
+	json.NewEncoder(w).Encode(LLMResponse{Choices: []Choice{{Message: message}}})
+}
+
+func TestClient_RetriesTemporaryFailures(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&hits, 1) {
+		case 1:
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+		case 2:
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		default:
+			reply(w, Message{Role: "assistant", Content: "Halt!"})
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	resp, err := client.SendPrompt(context.Background(), "The player waves.")
+	assert.NoError(t, err)
+	assert.Equal(t, "Halt!", resp.Narrative)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+
+	// Requests the provider rejects are not made again.
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		http.Error(w, "unknown model", http.StatusBadRequest)
+	})
+	atomic.StoreInt32(&hits, 0)
+	_, err = client.SendPrompt(context.Background(), "The player waves.")
+	var statusErr *StatusError
+	if assert.True(t, errors.As(err, &statusErr)) {
+		assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+	}
+	assert.False(t, errors.Is(err, ErrUnavailable))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+	assert.Equal(t, CircuitClosed, client.Breaker.State())
+}
+
+func TestClient_GivesEachAttemptADeadline(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.Retry.Timeout = 20 * time.Millisecond
+	start := time.Now()
+	_, err := client.SendPrompt(context.Background(), "The player waves.")
+	assert.True(t, errors.Is(err, ErrUnavailable), "%v", err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "%v", err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits), "Attempts that time out are made again")
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	// A caller that gives up is not kept waiting, and does not count against
+	// the provider.
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	client.Retry.Timeout = time.Second
+	_, err = client.SendPrompt(ctx, "The player waves.")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.False(t, errors.Is(err, ErrUnavailable))
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	var hits int32
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if !healthy.Load() {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		reply(w, Message{Role: "assistant", Content: "Halt!"})
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	now := time.Now()
+	client.Breaker = NewCircuitBreaker(2, time.Minute)
+	client.Breaker.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		_, err := client.SendPrompt(context.Background(), "The player waves.")
+		assert.True(t, errors.Is(err, ErrUnavailable))
+	}
+	assert.Equal(t, CircuitOpen, client.Breaker.State())
+	assert.Equal(t, int32(6), atomic.LoadInt32(&hits))
+
+	_, err := client.SendPrompt(context.Background(), "The player waves.")
+	assert.True(t, errors.Is(err, ErrUnavailable))
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, int32(6), atomic.LoadInt32(&hits), "An open circuit makes no requests")
+
+	// After the cooldown one request is let through, and closes the circuit
+	// if it succeeds.
+	now = now.Add(time.Minute)
+	assert.Equal(t, CircuitHalfOpen, client.Breaker.State())
+	healthy.Store(true)
+	resp, err := client.SendPrompt(context.Background(), "The player waves.")
+	assert.NoError(t, err)
+	assert.Equal(t, "Halt!", resp.Narrative)
+	assert.Equal(t, CircuitClosed, client.Breaker.State())
+}
+
+func TestClient_RepairsUnreadableReplies(t *testing.T) {
+	var requests []LLMRequest
+	replies := []Message{
+		{Role: "assistant", Content: `{"narrative": "Halt`},
+		{Role: "assistant", Content: "Halt! Who goes there?"},
+		{Role: "assistant", ToolCalls: []FunctionCall{{ID: "call_1", Type: "function", Function: FunctionInvocation{Name: "recall", Arguments: `{"topic":`}}}},
+		{Role: "assistant", Content: `{"narrative": `},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req LLMRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		reply(w, replies[len(requests)-1])
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	resp, err := client.SendPrompt(context.Background(), "The player waves.")
+	assert.NoError(t, err)
+	assert.Equal(t, "Halt! Who goes there?", resp.Narrative)
+	if assert.Len(t, requests, 2) {
+		repair := requests[1].Messages[len(requests[1].Messages)-1]
+		assert.Equal(t, "user", repair.Role)
+		assert.Contains(t, repair.Content, "Your reply could not be read")
+	}
+
+	// Only one repair is asked for.
+	_, err = client.SendPrompt(context.Background(), "The player waves.")
+	assert.Error(t, err)
+	if assert.Len(t, requests, 4) {
+		messages := requests[3].Messages
+		repair := messages[len(messages)-1]
+		assert.Contains(t, repair.Content, "Your tool calls could not be read")
+		assert.Equal(t, replies[2].ToolCalls, messages[len(messages)-3].ToolCalls, "The calls being repaired stay in the history")
+		assert.Equal(t, "call_1", messages[len(messages)-2].ToolCallID)
+	}
+}
@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrEmptyScript is returned by a ScriptedProvider that has nothing to reply.
+var ErrEmptyScript = errors.New("scripted LLM provider has no replies")
+
+// ScriptedProvider replies from a script instead of a model, so the game can
+// run offline and tests can play out a conversation. It gives its replies in
+// order and then keeps repeating the last one.
+type ScriptedProvider struct {
+	name string
+
+	// Respond, if set, replies instead of the script. It can call tools.
+	Respond func(req *CompletionRequest) (*Completion, error)
+
+	mu       sync.Mutex
+	replies  []string
+	next     int
+	requests []CompletionRequest
+}
+
+// NewScriptedProvider creates a ScriptedProvider with its replies.
+func NewScriptedProvider(name string, replies ...string) *ScriptedProvider {
+	return &ScriptedProvider{name: name, replies: replies}
+}
+
+// LoadScriptedProvider creates a ScriptedProvider that replies with the
+// lines of a file. Blank lines and lines starting with # are skipped.
+func LoadScriptedProvider(name, path string) (*ScriptedProvider, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open script of LLM provider %s: %w", name, err)
+	}
+	defer file.Close()
+
+	var replies []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		replies = append(replies, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read script of LLM provider %s: %w", name, err)
+	}
+	return NewScriptedProvider(name, replies...), nil
+}
+
+func (p *ScriptedProvider) Name() string {
+	return p.name
+}
+
+func (p *ScriptedProvider) Complete(ctx context.Context, req *CompletionRequest) (*Completion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.requests = append(p.requests, *req)
+	respond := p.Respond
+	var reply string
+	if respond == nil {
+		if len(p.replies) == 0 {
+			p.mu.Unlock()
+			return nil, fmt.Errorf("%w: %s", ErrEmptyScript, p.name)
+		}
+		reply = p.replies[min(p.next, len(p.replies)-1)]
+		p.next++
+	}
+	p.mu.Unlock()
+
+	if respond != nil {
+		return respond(req)
+	}
+	return &Completion{Content: reply, Model: p.name}, nil
+}
+
+// Requests returns the requests the provider has been sent.
+func (p *ScriptedProvider) Requests() []CompletionRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]CompletionRequest(nil), p.requests...)
+}
@@ -4,32 +4,198 @@ import (
 	"context"
 	"fmt"
 	"mud/internal/dal"
+	"mud/internal/game/tools"
 	"mud/internal/models"
+	"os"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
+// DefaultProvider is the name of the provider NewClient configures.
+const DefaultProvider = "default"
+
+// Tasks the service routes to providers.
+const (
+	TaskReaction = "reaction" // Entities responding to players
+	TaskAnalysis = "analysis" // Scoring narratives
+)
+
+// LLMService makes requests of LLM providers. Each request goes to the
+// provider its entity names, else the one routed for its task, else the
+// default. Providers and routes are configured before the service is used.
 type LLMService struct {
-	client      *Client
-	cache       *CacheManager
-	dal         *dal.DAL
+	// MaxToolSteps is how many requests answering an action may take while
+	// the entity calls tools and reacts to what became of them.
+	MaxToolSteps int
+
+	clients         map[string]*Client
+	defaultProvider string
+	taskProviders   map[string]string
+	cache           *CacheManager
+	dal             *dal.DAL
+	tools           *tools.Registry
+	dispatcher      ToolDispatcher
 }
 
+// NewLLMService creates an LLMService whose default provider is the client's.
 func NewLLMService(client *Client, dal *dal.DAL) *LLMService {
+	name := client.Provider().Name()
 	return &LLMService{
-		client:      client,
-		cache:       NewCacheManager(),
-		dal:         dal,
+		MaxToolSteps:    4,
+		clients:         map[string]*Client{name: client},
+		defaultProvider: name,
+		taskProviders:   make(map[string]string),
+		cache:           NewCacheManager(),
+		dal:             dal,
 	}
 }
 
+// UseDispatcher has the tool calls entities make while answering carried
+// out by a dispatcher, and their results returned to the entity.
+func (s *LLMService) UseDispatcher(dispatcher ToolDispatcher) {
+	s.dispatcher = dispatcher
+}
+
+// AddProvider makes a provider available to entities and tasks by its name.
+func (s *LLMService) AddProvider(provider Provider) error {
+	if _, taken := s.clients[provider.Name()]; taken {
+		return fmt.Errorf("LLM provider %s is already configured", provider.Name())
+	}
+	s.clients[provider.Name()] = NewClientFor(provider)
+	return nil
+}
+
+// SetDefaultProvider has requests that no entity or task routes elsewhere go
+// to a provider.
+func (s *LLMService) SetDefaultProvider(name string) error {
+	if _, ok := s.clients[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	s.defaultProvider = name
+	return nil
+}
+
+// RouteTask has a task's requests go to a provider, unless their entity
+// names its own.
+func (s *LLMService) RouteTask(task, name string) error {
+	if _, ok := s.clients[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	s.taskProviders[task] = name
+	return nil
+}
+
+// ConfigureFromEnv adds the providers named in LLM_PROVIDERS, a comma
+// separated list, and routes tasks and the default to them. A provider
+// called "local" is configured by LLM_LOCAL_KIND (openai, ollama, llamacpp
+// or scripted), LLM_LOCAL_API_ENDPOINT, LLM_LOCAL_API_KEY,
+// LLM_LOCAL_MODEL_NAME and LLM_LOCAL_SCRIPT. LLM_DEFAULT_PROVIDER names the
+// default provider, and LLM_TASK_REACTION and LLM_TASK_ANALYSIS the
+// providers for those tasks. LLM_MAX_TOOL_STEPS sets MaxToolSteps, and
+// LLM_LOCAL_PROMPT_BUDGET the prompt budget of the model "local" serves, in
+// tokens; the default provider's is LLM_DEFAULT_PROMPT_BUDGET.
+func (s *LLMService) ConfigureFromEnv() error {
+	if steps := os.Getenv("LLM_MAX_TOOL_STEPS"); steps != "" {
+		maxSteps, err := strconv.Atoi(steps)
+		if err != nil || maxSteps < 1 {
+			return fmt.Errorf("LLM_MAX_TOOL_STEPS must be a positive number, not %q", steps)
+		}
+		s.MaxToolSteps = maxSteps
+	}
+	for _, name := range strings.Split(os.Getenv("LLM_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		prefix := "LLM_" + strings.ToUpper(name) + "_"
+		provider, err := NewProvider(ProviderConfig{
+			Name:     name,
+			Kind:     os.Getenv(prefix + "KIND"),
+			Endpoint: os.Getenv(prefix + "API_ENDPOINT"),
+			APIKey:   os.Getenv(prefix + "API_KEY"),
+			Model:    os.Getenv(prefix + "MODEL_NAME"),
+			Script:   os.Getenv(prefix + "SCRIPT"),
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.AddProvider(provider); err != nil {
+			return err
+		}
+	}
+	for name, client := range s.clients {
+		variable := "LLM_" + strings.ToUpper(name) + "_PROMPT_BUDGET"
+		if budget := os.Getenv(variable); budget != "" {
+			tokens, err := strconv.Atoi(budget)
+			if err != nil || tokens < 0 {
+				return fmt.Errorf("%s must be a number of tokens, not %q", variable, budget)
+			}
+			client.PromptBudget = tokens
+		}
+	}
+	if name := os.Getenv("LLM_DEFAULT_PROVIDER"); name != "" {
+		if err := s.SetDefaultProvider(name); err != nil {
+			return err
+		}
+	}
+	for _, task := range []string{TaskReaction, TaskAnalysis} {
+		if name := os.Getenv("LLM_TASK_" + strings.ToUpper(task)); name != "" {
+			if err := s.RouteTask(task, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// client returns the client for the provider an entity's request for a
+// task goes to. entity may be nil.
+func (s *LLMService) client(entity interface{}, task string) (*Client, error) {
+	name := getEntityProvider(entity)
+	if name == "" {
+		name = s.taskProviders[task]
+	}
+	if name == "" {
+		name = s.defaultProvider
+	}
+	client, ok := s.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return client, nil
+}
+
+// UseTools has prompts describe tools as the registry defines them, so they
+// match what the tool dispatcher accepts.
+func (s *LLMService) UseTools(registry *tools.Registry) {
+	s.tools = registry
+}
+
 func (s *LLMService) ProcessAction(ctx context.Context, entity interface{}, player *models.PlayerCharacter, playerAction string) (*InnerLLMResponse, error) {
 	entityID, err := getEntityID(entity)
 	if err != nil {
 		return nil, err
 	}
+	client, err := s.client(entity, TaskReaction)
+	if err != nil {
+		return nil, err
+	}
+
+	// The prompt keeps to what the model's budget leaves once the system
+	// message and the player's action are counted. It tells what the entity
+	// remembers of the player and how it behaves toward them, so it is cached
+	// for the player as well as by model and by what that leaves.
+	action := fmt.Sprintf("Player action: %s", playerAction)
+	reserved := EstimateTokens(SystemPrompt) + EstimateTokens(action) + 1
+	available := 0
+	if client.PromptBudget > 0 {
+		available = client.PromptBudget - reserved
+	}
+	cacheKey := fmt.Sprintf("%s%s:%s:%d", promptCachePrefix(entityID), player.ID, client.Provider().Name(), available)
 
-	cacheKey := fmt.Sprintf("base_prompt:%s", entityID)
-	
 	// 1. Check cache for base prompt
 	cachedPrompt, found := s.cache.Get(cacheKey)
 	var basePrompt string
@@ -41,24 +207,82 @@ func (s *LLMService) ProcessAction(ctx context.Context, entity interface{}, play
 			Entity:      entity,
 			Player:      player,
 			DAL:         s.dal,
+			Tools:       s.tools,
+			NativeTools: s.tools != nil,
+			Budget:      client.PromptBudget,
+			Reserved:    reserved,
 		}
-		assembledPrompt, err := AssemblePrompt(promptData)
+		assembledPrompt, report, err := AssemblePromptWithReport(promptData)
 		if err != nil {
 			return nil, fmt.Errorf("failed to assemble prompt: %w", err)
 		}
+		if trimmed := report.Trimmed(); len(trimmed) > 0 {
+			logrus.Debugf("Trimmed %s from prompt for entity %s: %s", strings.Join(trimmed, ", "), entityID, report)
+		}
 		basePrompt = assembledPrompt
 		s.cache.Set(cacheKey, basePrompt, 5*time.Minute) // Cache for 5 minutes
 	}
 
 	// 3. Append dynamic player action
-	finalPrompt := fmt.Sprintf("%s\nPlayer action: %s", basePrompt, playerAction);
+	finalPrompt := basePrompt + "\n" + action
+
+	// 4. Send to LLM, carrying out the tools the entity calls
+	var run ToolRunner
+	if s.dispatcher != nil {
+		run = func(ctx context.Context, calls []ToolCall) ([]ToolResult, error) {
+			return s.dispatcher.Dispatch(ctx, player, entity, calls)
+		}
+	}
+	return client.Converse(ctx, finalPrompt, s.functions(entity), run, s.MaxToolSteps)
+}
+
+// ForgetEntity drops the prompts cached for an entity, so that its next
+// answers reflect how it has changed.
+func (s *LLMService) ForgetEntity(entityID string) {
+	s.cache.DeletePrefix(promptCachePrefix(entityID))
+}
+
+// promptCachePrefix starts the cache keys of an entity's prompts.
+func promptCachePrefix(entityID string) string {
+	return "base_prompt:" + entityID + ":"
+}
 
-	// 4. Send to LLM
-	return s.client.SendPrompt(ctx, finalPrompt)
+// functions returns the functions an entity may call: the tools it lists,
+// as the registry defines them. Tools that cost influence also take the
+// cost the entity proposes and its reason.
+func (s *LLMService) functions(entity interface{}) []FunctionSpec {
+	if s.tools == nil {
+		return nil
+	}
+	var functions []FunctionSpec
+	for _, tool := range s.tools.Available(entity) {
+		parameters := tool.Parameters
+		if def, ok := s.tools.Lookup(tool.Name); ok && def.Cost.Max > 0 {
+			properties := map[string]interface{}{
+				"cost":   map[string]interface{}{"type": "number", "description": fmt.Sprintf("Influence to spend, from %g to %g.", def.Cost.Min, def.Cost.Max)},
+				"reason": map[string]interface{}{"type": "string", "description": "Why you are doing this."},
+			}
+			defined, _ := parameters["properties"].(map[string]interface{})
+			for name, property := range defined {
+				properties[name] = property
+			}
+			parameters = make(map[string]interface{}, len(tool.Parameters))
+			for keyword, value := range tool.Parameters {
+				parameters[keyword] = value
+			}
+			parameters["properties"] = properties
+		}
+		functions = append(functions, FunctionSpec{Name: tool.Name, Description: tool.Description, Parameters: parameters})
+	}
+	return functions
 }
 
 func (s *LLMService) AnalyzeResponse(ctx context.Context, narrative string, query string) (float64, error) {
-	return s.client.AnalyzeResponse(ctx, narrative, query)
+	client, err := s.client(nil, TaskAnalysis)
+	if err != nil {
+		return 0, err
+	}
+	return client.AnalyzeResponse(ctx, narrative, query)
 }
 
 func getEntityID(entity interface{}) (string, error) {
@@ -69,7 +293,23 @@ func getEntityID(entity interface{}) (string, error) {
 		return v.ID, nil
 	case *models.Questmaker:
 		return v.ID, nil	
+	case *models.QuestOwner:
+		return v.ID, nil
 	default:
 		return "", fmt.Errorf("unknown entity type for getting ID")
 	}
 }
+
+// getEntityProvider returns the name of the provider an entity uses, or ""
+// for the default.
+func getEntityProvider(entity interface{}) string {
+	switch v := entity.(type) {
+	case *models.NPC:
+		return v.LLMProvider
+	case *models.Owner:
+		return v.LLMProvider
+	case *models.Questmaker:
+		return v.LLMProvider
+	}
+	return ""
+}
@@ -0,0 +1,225 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/game/tools"
+	"mud/internal/models"
+)
+
+func TestLLMService_RoutesRequests(t *testing.T) {
+	hosted := NewScriptedProvider(DefaultProvider, `{"narrative": "From the hosted model."}`, "10")
+	service := NewLLMService(NewClientFor(hosted), nil)
+
+	t.Setenv("LLM_PROVIDERS", "local, analyst")
+	t.Setenv("LLM_LOCAL_KIND", ProviderScripted)
+	t.Setenv("LLM_ANALYST_KIND", ProviderScripted)
+	t.Setenv("LLM_TASK_ANALYSIS", "analyst")
+	t.Setenv("LLM_LOCAL_PROMPT_BUDGET", "2000")
+	assert.NoError(t, service.ConfigureFromEnv())
+	assert.Equal(t, 2000, service.clients["local"].PromptBudget)
+	assert.Equal(t, DefaultPromptBudget, service.clients["analyst"].PromptBudget)
+	local := service.clients["local"].Provider().(*ScriptedProvider)
+	local.Respond = func(req *CompletionRequest) (*Completion, error) {
+		return &Completion{Content: "From the local model."}, nil
+	}
+	analyst := service.clients["analyst"].Provider().(*ScriptedProvider)
+	analyst.Respond = func(req *CompletionRequest) (*Completion, error) {
+		return &Completion{Content: "75"}, nil
+	}
+
+	player := &models.PlayerCharacter{ID: "hero", Name: "Hero"}
+	resp, err := service.ProcessAction(context.Background(), &models.NPC{ID: "guard", LLMProvider: "local"}, player, "waves")
+	assert.NoError(t, err)
+	assert.Equal(t, "From the local model.", resp.Narrative, "Entities can name their provider")
+	resp, err = service.ProcessAction(context.Background(), &models.Owner{ID: "spirit"}, player, "waves")
+	assert.NoError(t, err)
+	assert.Equal(t, "From the hosted model.", resp.Narrative, "Other entities use the default")
+
+	score, err := service.AnalyzeResponse(context.Background(), "Halt!", "How hostile is this?")
+	assert.NoError(t, err)
+	assert.Equal(t, 75.0, score, "Tasks can be routed to a provider")
+	assert.Len(t, hosted.Requests(), 1)
+
+	_, err = service.ProcessAction(context.Background(), &models.NPC{ID: "ghost", LLMProvider: "retired"}, player, "waves")
+	assert.True(t, errors.Is(err, ErrUnknownProvider))
+	assert.True(t, errors.Is(service.RouteTask(TaskReaction, "retired"), ErrUnknownProvider))
+	assert.Error(t, service.AddProvider(NewScriptedProvider("local")), "Provider names are unique")
+}
+
+func TestLLMService_FitsPromptsToTheBudget(t *testing.T) {
+	provider := NewScriptedProvider(DefaultProvider, "Halt!")
+	client := NewClientFor(provider)
+	service := NewLLMService(client, nil)
+	guard := &models.NPC{ID: "guard", PersonalityPrompt: "A gruff city guard.", MemoriesAboutPlayers: map[string][]string{"hero": {"The hero paid the toll."}}}
+	player := &models.PlayerCharacter{ID: "hero", Name: "Hero"}
+
+	_, err := service.ProcessAction(context.Background(), guard, player, "waves")
+	assert.NoError(t, err)
+	assert.Contains(t, provider.Requests()[0].Messages[1].Content, "The hero paid the toll.")
+
+	// The system message alone takes up this budget, so the memories go,
+	// rather than the prompt cached for the larger budget being reused.
+	client.PromptBudget = EstimateTokens(SystemPrompt)
+	_, err = service.ProcessAction(context.Background(), guard, player, "waves")
+	assert.NoError(t, err)
+	prompt := provider.Requests()[1].Messages[1].Content
+	assert.NotContains(t, prompt, "The hero paid the toll.")
+	assert.True(t, strings.HasSuffix(prompt, "\nPlayer action: waves"))
+}
+
+func TestLLMService_CachesPromptsForEachPlayer(t *testing.T) {
+	provider := NewScriptedProvider(DefaultProvider, "Halt!")
+	service := NewLLMService(NewClientFor(provider), nil)
+	guard := &models.NPC{ID: "guard", PersonalityPrompt: "A gruff city guard.", BehaviorState: `{"toward_players": {"hero": "hostile"}}`}
+	hero := &models.PlayerCharacter{ID: "hero", Name: "Hero"}
+	scout := &models.PlayerCharacter{ID: "scout", Name: "Scout"}
+
+	for _, player := range []*models.PlayerCharacter{hero, scout, hero} {
+		_, err := service.ProcessAction(context.Background(), guard, player, "waves")
+		assert.NoError(t, err)
+	}
+	requests := provider.Requests()
+	assert.Contains(t, requests[0].Messages[1].Content, "Your behavior toward this player: hostile")
+	assert.NotContains(t, requests[1].Messages[1].Content, "hostile", "The guard is only hostile to the hero")
+	assert.Contains(t, requests[2].Messages[1].Content, "hostile")
+
+	// Once the guard changes, its cached prompts are dropped.
+	guard.BehaviorState = `{"toward_players": {"hero": "friendly"}}`
+	service.ForgetEntity("guard")
+	_, err := service.ProcessAction(context.Background(), guard, hero, "waves")
+	assert.NoError(t, err)
+	assert.Contains(t, provider.Requests()[3].Messages[1].Content, "Your behavior toward this player: friendly")
+}
+
+func noop(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+// recordingDispatcher carries out tool calls by recording them.
+type recordingDispatcher struct {
+	calls   []ToolCall
+	results map[string]ToolResult
+}
+
+func (d *recordingDispatcher) Dispatch(ctx context.Context, player *models.PlayerCharacter, entity interface{}, toolCalls []ToolCall) ([]ToolResult, error) {
+	d.calls = append(d.calls, toolCalls...)
+	var results []ToolResult
+	for _, call := range toolCalls {
+		results = append(results, d.results[call.ToolName])
+	}
+	return results, nil
+}
+
+func TestLLMService_CallsTools(t *testing.T) {
+	registry := tools.NewRegistry()
+	recall := map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"topic": map[string]interface{}{"type": "string"}},
+		"required":   []string{"topic"},
+	}
+	registry.MustRegister(tools.Definition{Name: "recall", Description: "Recall a memory.", Parameters: recall, Entities: []string{tools.EntityNPC}, Handler: noop})
+	registry.MustRegister(tools.Definition{Name: "bless", Description: "Bless a player.", Entities: []string{tools.EntityNPC}, Cost: tools.Cost{Min: 1, Max: 5}, Handler: noop})
+	guard := &models.NPC{ID: "guard", AvailableTools: []models.Tool{{Name: "recall"}, {Name: "bless"}}}
+	player := &models.PlayerCharacter{ID: "hero", Name: "Hero"}
+
+	// The guard recalls the player and tries to bless them, then answers once
+	// it knows what became of that.
+	calls := []FunctionCall{
+		{ID: "call_1", Type: "function", Function: FunctionInvocation{Name: "recall", Arguments: `{"topic": "hero"}`}},
+		{ID: "call_2", Type: "function", Function: FunctionInvocation{Name: "bless", Arguments: `{"cost": 3, "reason": "Pity"}`}},
+	}
+	provider := NewScriptedProvider(DefaultProvider)
+	provider.Respond = func(req *CompletionRequest) (*Completion, error) {
+		if len(req.Tools) > 0 && req.Messages[len(req.Messages)-1].Role != "tool" {
+			return &Completion{ToolCalls: calls}, nil
+		}
+		return &Completion{Content: "Thief! I remember you."}, nil
+	}
+	dispatcher := &recordingDispatcher{results: map[string]ToolResult{
+		"recall": {ToolName: "recall", Status: ToolStatusOK, Data: map[string]interface{}{"memory": "stole bread"}},
+		"bless":  {ToolName: "bless", Status: ToolStatusRefused, Message: "not enough influence"},
+	}}
+	service := NewLLMService(NewClientFor(provider), nil)
+	service.UseTools(registry)
+	service.UseDispatcher(dispatcher)
+
+	resp, err := service.ProcessAction(context.Background(), guard, player, "waves")
+	assert.NoError(t, err)
+	assert.Equal(t, "Thief! I remember you.", resp.Narrative)
+	assert.Empty(t, resp.ToolCalls, "The calls were carried out")
+	assert.Equal(t, []ToolResult{dispatcher.results["recall"], dispatcher.results["bless"]}, resp.ToolResults)
+	assert.Equal(t, []ToolCall{
+		{ID: "call_1", ToolName: "recall", Parameters: map[string]interface{}{"topic": "hero"}},
+		{ID: "call_2", ToolName: "bless", Parameters: map[string]interface{}{}, Cost: 3, Reason: "Pity"},
+	}, dispatcher.calls, "The proposed cost and reason are taken from the arguments")
+
+	requests := provider.Requests()
+	if assert.Len(t, requests, 2) {
+		offered := requests[0].Tools
+		if assert.Len(t, offered, 2) {
+			assert.Equal(t, recall, offered[0].Parameters)
+			assert.Contains(t, offered[1].Parameters["properties"], "cost", "Tools that cost influence take a cost")
+		}
+		def, _ := registry.Lookup("bless")
+		assert.NotContains(t, def.Parameters["properties"], "cost", "The registry's schema is left alone")
+
+		prompt := requests[0].Messages[1].Content
+		assert.Contains(t, prompt, "- recall: Recall a memory.\n")
+		assert.NotContains(t, prompt, `"properties"`, "Schemas go with the functions, not in the prompt")
+
+		messages := requests[1].Messages
+		assert.Equal(t, calls, messages[2].ToolCalls)
+		assert.Equal(t, "call_1", messages[3].ToolCallID)
+		assert.Contains(t, messages[3].Content, "stole bread")
+		assert.Equal(t, "call_2", messages[4].ToolCallID)
+		assert.Contains(t, messages[4].Content, "not enough influence")
+	}
+
+	// An entity that keeps calling tools is made to answer on the last step.
+	provider.Respond = func(req *CompletionRequest) (*Completion, error) {
+		if len(req.Tools) > 0 {
+			return &Completion{ToolCalls: calls}, nil
+		}
+		return &Completion{Content: "Enough."}, nil
+	}
+	service.MaxToolSteps = 3
+	resp, err = service.ProcessAction(context.Background(), guard, player, "waves")
+	assert.NoError(t, err)
+	assert.Equal(t, "Enough.", resp.Narrative)
+	assert.Len(t, resp.ToolResults, 4)
+	assert.Len(t, provider.Requests(), 5)
+
+	// One that calls tools it is no longer offered is asked for an answer
+	// alone.
+	provider.Respond = func(req *CompletionRequest) (*Completion, error) {
+		last := req.Messages[len(req.Messages)-1]
+		if last.Role == "user" && strings.Contains(last.Content, "may not use any more tools") {
+			return &Completion{Content: "Be gone."}, nil
+		}
+		return &Completion{ToolCalls: calls}, nil
+	}
+	resp, err = service.ProcessAction(context.Background(), guard, player, "waves")
+	assert.NoError(t, err)
+	assert.Equal(t, "Be gone.", resp.Narrative)
+	assert.Len(t, resp.ToolResults, 4, "The calls of the last step are not carried out")
+	requests = provider.Requests()[5:]
+	if assert.Len(t, requests, 4) {
+		assert.Empty(t, requests[3].Tools)
+		messages := requests[3].Messages
+		assert.Equal(t, calls, messages[len(messages)-4].ToolCalls)
+		assert.Contains(t, messages[len(messages)-2].Content, "no more tools may be used")
+	}
+
+	// Without a dispatcher the calls are left for the caller.
+	service = NewLLMService(NewClientFor(provider), nil)
+	service.UseTools(registry)
+	resp, err = service.ProcessAction(context.Background(), guard, player, "waves")
+	assert.NoError(t, err)
+	assert.Len(t, resp.ToolCalls, 2)
+	assert.Empty(t, resp.ToolResults)
+}
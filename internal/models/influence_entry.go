@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Entity types that hold an influence budget.
+const (
+	InfluenceOwner      = "owner"
+	InfluenceQuestmaker = "questmaker"
+	InfluenceQuestOwner = "quest_owner"
+)
+
+// InfluenceEntry records one change to an entity's influence budget, or a
+// charge that was refused because the budget could not cover it.
+type InfluenceEntry struct {
+	ID         string    `json:"id"`
+	EntityType string    `json:"entity_type"` // "owner", "questmaker" or "quest_owner"
+	EntityID   string    `json:"entity_id"`
+	Amount     float64   `json:"amount"`  // Positive for credits, negative for debits
+	Balance    float64   `json:"balance"` // Budget after the change
+	Reason     string    `json:"reason"`  // e.g., "regeneration", "tool:send_message"
+	Refused    bool      `json:"refused"` // The debit was refused and the budget left unchanged
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// Location types for an ItemInstance.
+const (
+	LocationRoom      = "room"
+	LocationCharacter = "character"
+	LocationNPC       = "npc"
+	LocationContainer = "container" // LocationID is the ID of the containing ItemInstance
+)
+
+// ItemInstance is a single item in the world, created from an Item template.
+type ItemInstance struct {
+	ID           string    `json:"id"`
+	TemplateID   string    `json:"template_id"`
+	Name         string    `json:"name,omitempty"` // Custom name; empty uses the template's name
+	Properties   string    `json:"properties"`     // JSON object overriding the template's properties
+	Quantity     int       `json:"quantity"`       // Stack size
+	LocationType string    `json:"location_type"`  // "room", "character", "npc" or "container"
+	LocationID   string    `json:"location_id"`    // ID of the room, character, NPC or container
+	Slot         string    `json:"slot,omitempty"` // Equipment slot when equipped by a character
+	CreatedAt    time.Time `json:"created_at"`
+	Template     *Item     `json:"template,omitempty"` // Resolved template, not stored
+}
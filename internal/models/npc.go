@@ -17,4 +17,5 @@ type NPC struct {
 	RaceID            string `json:"race_id"`
 	ProfessionID      string `json:"profession_id"`
 	ReactionThreshold int    `json:"reaction_threshold"`
+	LLMProvider       string `json:"llm_provider"` // Name of the LLM provider to use; empty for the default
 }
@@ -15,4 +15,5 @@ type Owner struct {
 	AvailableTools       []Tool  `json:"available_tools"`   // Array of conceptual tools LLM can call
 	InitiatedQuests      []string `json:"initiated_quests"` // Array of quest IDs this owner can initiate/offer
 	ReactionThreshold    int      `json:"reaction_threshold"`
+	LLMProvider          string   `json:"llm_provider"` // Name of the LLM provider to use; empty for the default
 }
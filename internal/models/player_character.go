@@ -14,7 +14,9 @@ type PlayerCharacter struct {
 	CurrentRoomID   string    `json:"current_room_id"`
 	Health          int       `json:"health"`
 	MaxHealth       int       `json:"max_health"`
-	Inventory       string    `json:"inventory"` // JSON string
+	Energy          int       `json:"energy"` // Spent to use skills
+	MaxEnergy       int       `json:"max_energy"`
+	Inventory       string    `json:"inventory"` // JSON string; legacy item IDs, converted to ItemInstances when the inventory is loaded
 	VisitedRoomIDs  string    `json:"visited_room_ids"` // JSON string
 	CreatedAt       time.Time `json:"created_at"`
 	LastPlayedAt    time.Time `json:"last_played_at,omitempty"`
@@ -2,8 +2,9 @@ package models
 
 // PlayerClass tracks a player's progression in each class they have acquired.
 type PlayerClass struct {
-	PlayerID   string `json:"player_id"`
-	ClassID    string `json:"class_id"`
-	Level      int    `json:"level"`
-	Experience int    `json:"experience"`
+	PlayerID       string `json:"player_id"`
+	ClassID        string `json:"class_id"`
+	Level          int    `json:"level"`
+	Experience     int    `json:"experience"`
+	PracticePoints int    `json:"practice_points"` // Earned by levelling the class, spent with trainers on its skills
 }
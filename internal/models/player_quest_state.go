@@ -9,5 +9,28 @@ type PlayerQuestState struct {
 	CurrentProgress               string    `json:"current_progress"` // JSON object tracking objective progress
 	LastActionTimestamp           time.Time `json:"last_action_timestamp"`
 	QuestmakerInfluenceAccumulated float64   `json:"questmaker_influence_accumulated"`
-	Status                        string    `json:"status"` // e.g., "active", "completed", "failed", "abandoned"
-}
\ No newline at end of file
+	Status                        string    `json:"status"` // e.g., "offered", "active", "completed", "failed", "abandoned"
+}
+
+// Statuses of a PlayerQuestState.
+const (
+	QuestStatusOffered   = "offered" // Waiting for its trigger before it begins
+	QuestStatusActive    = "active"
+	QuestStatusCompleted = "completed"
+	QuestStatusFailed    = "failed"
+	QuestStatusAbandoned = "abandoned"
+)
+
+// Triggers that begin an offered quest.
+const (
+	QuestTriggerTalkToNPC    = "on_talk_to_npc"   // The player talks to the NPC
+	QuestTriggerEnterRoom    = "on_enter_room"    // The player enters the room
+	QuestTriggerInteractItem = "on_interact_item" // The player handles an item of the template, or the instance
+)
+
+// QuestTrigger is what an offered quest waits for. It is kept in the
+// CurrentProgress of the player's quest state.
+type QuestTrigger struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
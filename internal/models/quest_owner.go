@@ -9,4 +9,5 @@ type QuestOwner struct {
 	MaxInfluenceBudget   float64 `json:"max_influence_budget"`
 	BudgetRegenRate      float64 `json:"budget_regen_rate"`
 	AssociatedQuestmakerIDs string `json:"associated_questmaker_ids"` // JSON array of questmaker IDs
+	AvailableTools       []Tool `json:"available_tools"`
 }
\ No newline at end of file
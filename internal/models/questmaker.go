@@ -10,4 +10,5 @@ type Questmaker struct {
 	MemoriesAboutPlayers map[string][]string `json:"memories_about_players"`
 	AvailableTools       []Tool            `json:"available_tools"`
 	ReactionThreshold    int               `json:"reaction_threshold"`
+	LLMProvider          string            `json:"llm_provider"` // Name of the LLM provider to use; empty for the default
 }
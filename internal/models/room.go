@@ -3,9 +3,12 @@ package models
 // Exit represents a single exit from a room.
 type Exit struct {
 	Direction    string `json:"direction"`
-	TargetRoomID string `json:"TargetRoomID"`
+	TargetRoomID string `json:"target_room_id"`
 	IsLocked     bool   `json:"is_locked"`
 	KeyID        string `json:"key_id,omitempty"`
+	HasDoor      bool   `json:"has_door,omitempty"`     // Exits that are locked or have a KeyID always have a door
+	IsClosed     bool   `json:"is_closed,omitempty"`    // A locked door is always closed
+	RelockAfter  int    `json:"relock_after,omitempty"` // Seconds until an unlocked door closes and locks itself; 0 never relocks
 }
 
 // Room represents a game room or location.
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// RoomOverlay is a revertible change to a room's description and properties.
+// The room is stored with its active overlays applied, oldest first, and each
+// overlay remembers the room as it was beforehand so the change can be undone.
+type RoomOverlay struct {
+	ID                  string    `json:"id"`
+	RoomID              string    `json:"room_id"`
+	SourceType          string    `json:"source_type"` // Influence entity type of whoever made the change
+	SourceID            string    `json:"source_id"`
+	Description         string    `json:"description"`     // Replaces the description when set
+	DescriptionAdd      string    `json:"description_add"` // Appended to the description when set
+	Properties          string    `json:"properties"`      // JSON object merged into the room's properties; null removes a property
+	PreviousDescription string    `json:"previous_description"`
+	PreviousProperties  string    `json:"previous_properties"`
+	CreatedAt           time.Time `json:"created_at"`
+	ExpiresAt           time.Time `json:"expires_at"` // Zero if it lasts until reverted
+	Reverted            bool      `json:"reverted"`
+}
+
+// Expired reports whether the overlay has run out at the given time.
+func (o *RoomOverlay) Expired(now time.Time) bool {
+	return !o.ExpiresAt.IsZero() && !now.Before(o.ExpiresAt)
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// Target types for a StatusEffect.
+const (
+	EffectTargetCharacter = "character"
+	EffectTargetNPC       = "npc"
+)
+
+// Source types for a StatusEffect.
+const (
+	EffectSourceSkill = "skill"
+)
+
+// Kinds of StatusEffect.
+const (
+	EffectTypeModifier = "MODIFY_ATTRIBUTE" // Key is an attribute and Magnitude is added to it
+	EffectTypeStatus   = "STATUS_EFFECT"    // Key is a status such as "stunned" or "keen_senses"
+)
+
+// Stacking rules for a StatusEffect, deciding what happens when an effect with
+// the same target, type, key and source is applied again.
+const (
+	StackRefresh = "refresh" // Replace the magnitude and restart the duration
+	StackAdd     = "stack"   // Add a stack, up to MaxStacks, and restart the duration
+	StackIgnore  = "ignore"  // Keep the existing effect unchanged
+)
+
+// StatusEffect is a timed buff, debuff or status applied to a player character or NPC.
+type StatusEffect struct {
+	ID         string    `json:"id"`
+	TargetType string    `json:"target_type"` // "character" or "npc"
+	TargetID   string    `json:"target_id"`
+	SourceType string    `json:"source_type"` // e.g., "skill", "item", "Questmaker"
+	SourceID   string    `json:"source_id"`
+	Type       string    `json:"type"`      // e.g., "MODIFY_ATTRIBUTE", "STATUS_EFFECT"
+	Key        string    `json:"key"`       // Attribute or status ID, e.g., "strength", "keen_senses"
+	Magnitude  float64   `json:"magnitude"` // Per stack
+	Stacking   string    `json:"stacking"`  // "refresh", "stack" or "ignore"
+	Stacks     int       `json:"stacks"`
+	MaxStacks  int       `json:"max_stacks"`
+	AppliedAt  time.Time `json:"applied_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// TotalMagnitude is the magnitude of all stacks together.
+func (e *StatusEffect) TotalMagnitude() float64 {
+	return e.Magnitude * float64(max(1, e.Stacks))
+}
+
+// Expired reports whether the effect has run out at the given time.
+func (e *StatusEffect) Expired(now time.Time) bool {
+	return !now.Before(e.ExpiresAt)
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// WorldEvent is a happening set off across the world, or one room or
+// territory of it, by an Owner or Quest Owner. It lasts until EndsAt and is
+// announced to the players it affects when it begins and ends.
+type WorldEvent struct {
+	ID          string    `json:"id"`
+	EventType   string    `json:"event_type"`  // e.g., "nazgul_patrol_increase"
+	Details     string    `json:"details"`     // Narration announced when the event begins
+	SourceType  string    `json:"source_type"` // Influence entity type of whoever set it off
+	SourceID    string    `json:"source_id"`
+	RoomID      string    `json:"room_id"`      // Optional: the event only affects this room
+	TerritoryID string    `json:"territory_id"` // Optional: the event only affects this territory
+	StartedAt   time.Time `json:"started_at"`
+	EndsAt      time.Time `json:"ends_at"`
+	Ended       bool      `json:"ended"`
+}
+
+// Expired reports whether the event has run its course at the given time.
+func (e *WorldEvent) Expired(now time.Time) bool {
+	return !now.Before(e.EndsAt)
+}
+
+// Affects reports whether the event reaches a room.
+func (e *WorldEvent) Affects(room *Room) bool {
+	if e.RoomID != "" && e.RoomID != room.ID {
+		return false
+	}
+	return e.TerritoryID == "" || e.TerritoryID == room.TerritoryID
+}
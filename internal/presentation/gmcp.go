@@ -0,0 +1,120 @@
+package presentation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// GMCP package names sent to clients.
+const (
+	GMCPRoomInfo        = "Room.Info"
+	GMCPCharVitals      = "Char.Vitals"
+	GMCPCharStats       = "Char.Stats"
+	GMCPCharItemsList   = "Char.Items.List"
+	GMCPCommChannelText = "Comm.Channel.Text"
+)
+
+// GMCPPackage is a single Generic MUD Communication Protocol message.
+type GMCPPackage struct {
+	Name string
+	Data interface{}
+}
+
+// Encode returns the wire form of the package: the package name followed by its JSON data.
+func (p GMCPPackage) Encode() ([]byte, error) {
+	if p.Data == nil {
+		return []byte(p.Name), nil
+	}
+	data, err := json.Marshal(p.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal GMCP package %s: %w", p.Name, err)
+	}
+	return []byte(p.Name + " " + string(data)), nil
+}
+
+// Module returns the top-level module of the package, e.g. "Char" for "Char.Vitals".
+func (p GMCPPackage) Module() string {
+	if idx := strings.Index(p.Name, "."); idx >= 0 {
+		return p.Name[:idx]
+	}
+	return p.Name
+}
+
+// BuildGMCPPackages translates a SemanticMessage into the GMCP packages that
+// describe it. Game state updates are only translated when they carry a Payload.
+func BuildGMCPPackages(msg SemanticMessage) []GMCPPackage {
+	switch msg.Type {
+	case RoomUpdate:
+		if msg.Payload == nil {
+			return nil
+		}
+		return []GMCPPackage{{Name: GMCPRoomInfo, Data: msg.Payload}}
+	case PlayerStatsUpdate:
+		return buildCharPackages(msg.Payload)
+	case InventoryUpdate:
+		if msg.Payload == nil {
+			return nil
+		}
+		data := map[string]interface{}{"location": "inv"}
+		for k, v := range msg.Payload {
+			data[k] = v
+		}
+		return []GMCPPackage{{Name: GMCPCharItemsList, Data: data}}
+	case NarrativeMessage, NPCMessage, OwnerMessage, QuestMessage:
+		if msg.Content == "" {
+			return nil
+		}
+		data := map[string]interface{}{
+			"channel": channelForMessageType(msg.Type),
+			"talker":  "",
+			"text":    msg.Content,
+		}
+		for _, key := range []string{"channel", "talker"} {
+			if v, ok := msg.Payload[key]; ok {
+				data[key] = v
+			}
+		}
+		return []GMCPPackage{{Name: GMCPCommChannelText, Data: data}}
+	}
+	return nil
+}
+
+// vitalsKeys are the PlayerStatsUpdate payload keys that belong in Char.Vitals.
+// Everything else is an attribute or combat stat and goes in Char.Stats.
+var vitalsKeys = map[string]bool{"hp": true, "maxhp": true, "energy": true, "maxenergy": true}
+
+// buildCharPackages splits a PlayerStatsUpdate payload into Char.Vitals and
+// Char.Stats, leaving out whichever of the two it has nothing for.
+func buildCharPackages(payload map[string]interface{}) []GMCPPackage {
+	vitals := make(map[string]interface{})
+	stats := make(map[string]interface{})
+	for k, v := range payload {
+		if vitalsKeys[k] {
+			vitals[k] = v
+		} else {
+			stats[k] = v
+		}
+	}
+	var packages []GMCPPackage
+	if len(vitals) > 0 {
+		packages = append(packages, GMCPPackage{Name: GMCPCharVitals, Data: vitals})
+	}
+	if len(stats) > 0 {
+		packages = append(packages, GMCPPackage{Name: GMCPCharStats, Data: stats})
+	}
+	return packages
+}
+
+func channelForMessageType(t SemanticMessageType) string {
+	switch t {
+	case NPCMessage:
+		return "say"
+	case OwnerMessage:
+		return "owner"
+	case QuestMessage:
+		return "quest"
+	default:
+		return "narrative"
+	}
+}
@@ -0,0 +1,29 @@
+package presentation
+
+import (
+	"encoding/json"
+)
+
+// JSONRenderer passes semantic messages through unchanged as JSON, for clients
+// such as browsers that do their own presentation.
+type JSONRenderer struct{}
+
+// NewJSONRenderer creates a new JSONRenderer.
+func NewJSONRenderer() *JSONRenderer {
+	return &JSONRenderer{}
+}
+
+// RenderMessage converts a SemanticMessage into its JSON representation.
+func (r *JSONRenderer) RenderMessage(msg SemanticMessage) string {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		// Payloads come from game code and should always marshal; fall back to the bare text.
+		data, _ = json.Marshal(SemanticMessage{Type: msg.Type, Content: msg.Content, Color: msg.Color})
+	}
+	return string(data)
+}
+
+// RenderRawString wraps a raw string in a narrative SemanticMessage with the given color.
+func (r *JSONRenderer) RenderRawString(s string, color SemanticColorType) string {
+	return r.RenderMessage(SemanticMessage{Type: NarrativeMessage, Content: s, Color: color})
+}
@@ -0,0 +1,12 @@
+package presentation
+
+import "strings"
+
+// Capitalize upper-cases the first letter of s, so that a name like "a
+// goblin" can start a sentence.
+func Capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -8,6 +8,7 @@ import (
 	"mud/internal/dal"
 	"mud/internal/models"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 )
@@ -62,6 +63,15 @@ func (s *AdminWebServer) Start() {
 	api.HandleFunc("/lore/{id}", s.handleUpdateLore).Methods("PUT")
 	api.HandleFunc("/lore/{id}", s.handleDeleteLore).Methods("DELETE")
 
+	// Item Instance Routes
+	api.HandleFunc("/item-instances", s.handleSpawnItemInstance).Methods("POST")
+	api.HandleFunc("/item-instances", s.handleListItemInstances).Methods("GET")
+	api.HandleFunc("/item-instances/{id}", s.handleGetItemInstance).Methods("GET")
+	api.HandleFunc("/item-instances/{id}", s.handleDeleteItemInstance).Methods("DELETE")
+
+	// Influence Audit Routes
+	api.HandleFunc("/influence", s.handleListInfluenceEntries).Methods("GET")
+
 	// Serve static files
 	r.PathPrefix("/").Handler(http.FileServer(http.Dir("./templates")))
 
@@ -244,4 +254,92 @@ func (s *AdminWebServer) handleUpdateLore(w http.ResponseWriter, r *http.Request
 func (s *AdminWebServer) handleDeleteLore(w http.ResponseWriter, r *http.Request) {
 	sharedCache := dal.NewCache()
 	s.handleDelete(w, r, dal.NewLoreDAL(s.db, sharedCache).DeleteLore)
-}
\ No newline at end of file
+}
+
+// Item Instance Handlers
+func (s *AdminWebServer) itemInstanceDAL() *dal.ItemInstanceDAL {
+	sharedCache := dal.NewCache()
+	return dal.NewItemInstanceDAL(s.db, sharedCache, dal.NewItemDAL(s.db, sharedCache))
+}
+
+// handleSpawnItemInstance creates a new instance of an item template at a location.
+func (s *AdminWebServer) handleSpawnItemInstance(w http.ResponseWriter, r *http.Request) {
+	var instance models.ItemInstance
+	if err := json.NewDecoder(r.Body).Decode(&instance); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch instance.LocationType {
+	case models.LocationRoom, models.LocationCharacter, models.LocationNPC, models.LocationContainer:
+	default:
+		http.Error(w, fmt.Sprintf("invalid location_type %q", instance.LocationType), http.StatusBadRequest)
+		return
+	}
+	if instance.LocationID == "" {
+		http.Error(w, "location_id is required", http.StatusBadRequest)
+		return
+	}
+	template, err := dal.NewItemDAL(s.db, dal.NewCache()).GetItemByID(instance.TemplateID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if template == nil {
+		http.Error(w, fmt.Sprintf("item template %q not found", instance.TemplateID), http.StatusBadRequest)
+		return
+	}
+	if err := s.itemInstanceDAL().CreateInstance(&instance); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(instance)
+}
+func (s *AdminWebServer) handleGetItemInstance(w http.ResponseWriter, r *http.Request) {
+	s.handleGet(w, r, func(id string) (interface{}, error) { return s.itemInstanceDAL().GetInstanceByID(id) })
+}
+func (s *AdminWebServer) handleListItemInstances(w http.ResponseWriter, r *http.Request) {
+	locationType := r.URL.Query().Get("location_type")
+	locationID := r.URL.Query().Get("location_id")
+	if locationType == "" || locationID == "" {
+		http.Error(w, "location_type and location_id are required", http.StatusBadRequest)
+		return
+	}
+	instances, err := s.itemInstanceDAL().GetInstancesByLocation(locationType, locationID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if instances == nil {
+		instances = []*models.ItemInstance{}
+	}
+	json.NewEncoder(w).Encode(instances)
+}
+func (s *AdminWebServer) handleDeleteItemInstance(w http.ResponseWriter, r *http.Request) {
+	s.handleDelete(w, r, s.itemInstanceDAL().DeleteInstance)
+}
+
+// Influence Audit Handlers
+
+// handleListInfluenceEntries lists influence credits, debits and refused
+// charges, newest first, optionally for one entity type or entity.
+func (s *AdminWebServer) handleListInfluenceEntries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := 0
+	if l := query.Get("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil {
+			http.Error(w, "limit must be a number", http.StatusBadRequest)
+			return
+		}
+	}
+	entries, err := dal.NewInfluenceDAL(s.db, dal.NewCache()).GetInfluenceEntries(query.Get("entity_type"), query.Get("entity_id"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []*models.InfluenceEntry{}
+	}
+	json.NewEncoder(w).Encode(entries)
+}
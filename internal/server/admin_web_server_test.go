@@ -324,4 +324,130 @@ func TestLoreAPI(t *testing.T) {
 	if status := rr.Code; status != http.StatusNoContent {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
 	}
-}
\ No newline at end of file
+}
+func TestItemInstanceAPI(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/items", server.handleCreateItem).Methods("POST")
+	api.HandleFunc("/item-instances", server.handleSpawnItemInstance).Methods("POST")
+	api.HandleFunc("/item-instances", server.handleListItemInstances).Methods("GET")
+	api.HandleFunc("/item-instances/{id}", server.handleGetItemInstance).Methods("GET")
+	api.HandleFunc("/item-instances/{id}", server.handleDeleteItemInstance).Methods("DELETE")
+
+	item := &models.Item{ID: "test_sword", Name: "Test Sword", Type: "weapon", Properties: "{}"}
+	body, _ := json.Marshal(item)
+	req, _ := http.NewRequest("POST", "/api/v1/items", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	// 1. Test SpawnItemInstance with an unknown template
+	instance := &models.ItemInstance{TemplateID: "no_such_item", LocationType: models.LocationRoom, LocationID: "test_room"}
+	body, _ = json.Marshal(instance)
+	req, _ = http.NewRequest("POST", "/api/v1/item-instances", bytes.NewBuffer(body))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+
+	// 2. Test SpawnItemInstance
+	instance = &models.ItemInstance{TemplateID: "test_sword", Name: "Glamdring", Properties: `{"durability": 80}`, LocationType: models.LocationRoom, LocationID: "test_room"}
+	body, _ = json.Marshal(instance)
+	req, _ = http.NewRequest("POST", "/api/v1/item-instances", bytes.NewBuffer(body))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	var spawned models.ItemInstance
+	json.Unmarshal(rr.Body.Bytes(), &spawned)
+	if spawned.ID == "" || spawned.Quantity != 1 {
+		t.Errorf("handler returned unexpected body: got %+v", spawned)
+	}
+
+	// 3. Test GetItemInstance
+	req, _ = http.NewRequest("GET", "/api/v1/item-instances/"+spawned.ID, nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var returnedInstance models.ItemInstance
+	json.Unmarshal(rr.Body.Bytes(), &returnedInstance)
+	if returnedInstance.Name != "Glamdring" || returnedInstance.Template == nil || returnedInstance.Template.Name != "Test Sword" {
+		t.Errorf("handler returned unexpected body: got %+v", returnedInstance)
+	}
+
+	// 4. Test ListItemInstances
+	req, _ = http.NewRequest("GET", "/api/v1/item-instances?location_type=room&location_id=test_room", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var instances []models.ItemInstance
+	json.Unmarshal(rr.Body.Bytes(), &instances)
+	if len(instances) != 1 || instances[0].ID != spawned.ID {
+		t.Errorf("handler returned unexpected body: got %v", rr.Body.String())
+	}
+
+	// 5. Test DeleteItemInstance
+	req, _ = http.NewRequest("DELETE", "/api/v1/item-instances/"+spawned.ID, nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusNoContent {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
+	}
+}
+
+func TestInfluenceAPI(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/influence", server.handleListInfluenceEntries).Methods("GET")
+
+	influenceDAL := dal.NewInfluenceDAL(server.db, dal.NewCache())
+	influenceDAL.CreateInfluenceEntry(&models.InfluenceEntry{EntityType: models.InfluenceOwner, EntityID: "spirit", Amount: -20, Balance: 80, Reason: "trigger_world_event"})
+	influenceDAL.CreateInfluenceEntry(&models.InfluenceEntry{EntityType: models.InfluenceQuestmaker, EntityID: "hunt", Amount: 5, Balance: 5, Reason: "regeneration"})
+
+	// 1. Test ListInfluenceEntries for one entity
+	req, _ := http.NewRequest("GET", "/api/v1/influence?entity_type=owner&entity_id=spirit", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var entries []models.InfluenceEntry
+	json.Unmarshal(rr.Body.Bytes(), &entries)
+	if len(entries) != 1 || entries[0].Reason != "trigger_world_event" || entries[0].Amount != -20 {
+		t.Errorf("handler returned unexpected body: got %v", rr.Body.String())
+	}
+
+	// 2. Test ListInfluenceEntries with a limit
+	req, _ = http.NewRequest("GET", "/api/v1/influence?limit=1", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	entries = nil
+	json.Unmarshal(rr.Body.Bytes(), &entries)
+	if len(entries) != 1 {
+		t.Errorf("handler returned unexpected body: got %v", rr.Body.String())
+	}
+
+	// 3. Test ListInfluenceEntries with a bad limit
+	req, _ = http.NewRequest("GET", "/api/v1/influence?limit=lots", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+}
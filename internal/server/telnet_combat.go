@@ -0,0 +1,128 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"mud/internal/game/combat"
+	"mud/internal/game/commands"
+	"mud/internal/models"
+	"mud/internal/presentation"
+)
+
+func (s *TelnetServer) handleKillCommand(c *client, inv *commands.Invocation) {
+	target := inv.ArgString()
+	if target == "" {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Attack whom?", Color: presentation.ColorWarning})
+		return
+	}
+	npc, err := s.combat.Engage(c.character, target)
+	if err != nil {
+		s.sendCombatError(c, err)
+		return
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf("You attack %s!", npc.Name), Color: presentation.ColorWarning})
+	s.publishAction(c, inv.Command.ActionType, npc)
+}
+
+func (s *TelnetServer) handleFleeCommand(c *client, inv *commands.Invocation) {
+	direction, err := s.combat.Flee(c.character)
+	if err != nil {
+		s.sendCombatError(c, err)
+		return
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "You flee!", Color: presentation.ColorWarning})
+	s.handleMovement(c, inv, direction)
+}
+
+func (s *TelnetServer) handleConsiderCommand(c *client, inv *commands.Invocation) {
+	target := inv.ArgString()
+	if target == "" {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Consider whom?", Color: presentation.ColorWarning})
+		return
+	}
+	_, assessment, err := s.combat.Consider(c.character, target)
+	if err != nil {
+		s.sendCombatError(c, err)
+		return
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: assessment, Color: presentation.ColorDefault})
+}
+
+// sendCombatError explains why a combat command failed.
+func (s *TelnetServer) sendCombatError(c *client, err error) {
+	var content string
+	switch {
+	case errors.Is(err, combat.ErrTargetNotFound):
+		content = "There is no one like that here."
+	case errors.Is(err, combat.ErrAlreadyFighting):
+		content = "You are already fighting!"
+	case errors.Is(err, combat.ErrNotFighting):
+		content = "You aren't fighting anyone."
+	case errors.Is(err, combat.ErrFleeFailed):
+		content = "You try to flee but can't get away!"
+	case errors.Is(err, combat.ErrNowhereToFlee):
+		content = "There is nowhere to run!"
+	default:
+		logrus.Infof("TelnetServer: Combat command failed for character %s: %v", c.character.ID, err)
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Something went wrong. Please try again.", Color: presentation.ColorError})
+		return
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: content, Color: presentation.ColorWarning})
+}
+
+// playerNotifier delivers narration, vitals and stats changes from the
+// combat, skill and effect managers to connected players.
+type playerNotifier struct {
+	s *TelnetServer
+}
+
+func (n playerNotifier) Notify(characterID, content string) {
+	if c := n.s.connection(characterID); c != nil {
+		n.s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: content, Color: presentation.ColorWarning})
+	}
+}
+
+func (n playerNotifier) VitalsChanged(character *models.PlayerCharacter) {
+	if c := n.s.connection(character.ID); c != nil {
+		c.update(vitalsOf(character))
+		n.s.sendVitals(c, character)
+	}
+}
+
+func (n playerNotifier) EffectsChanged(characterID string) {
+	if c := n.s.connection(characterID); c != nil {
+		n.s.refreshStats(c)
+	}
+}
+
+func (n playerNotifier) Respawned(character *models.PlayerCharacter) {
+	if c := n.s.connection(character.ID); c != nil {
+		setVitals, roomID := vitalsOf(character), character.CurrentRoomID
+		c.update(func(own *models.PlayerCharacter) {
+			setVitals(own)
+			own.CurrentRoomID = roomID
+		})
+		n.s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "You awaken, restored, in a familiar place.", Color: presentation.ColorDefault})
+		n.s.sendVitals(c, character)
+		n.s.renderRoom(c, roomID)
+	}
+}
+
+// vitalsOf returns a change that gives a session's character the vitals of
+// the stored one.
+func vitalsOf(character *models.PlayerCharacter) func(*models.PlayerCharacter) {
+	health, maxHealth, energy, maxEnergy := character.Health, character.MaxHealth, character.Energy, character.MaxEnergy
+	return func(own *models.PlayerCharacter) {
+		own.Health, own.MaxHealth = health, maxHealth
+		own.Energy, own.MaxEnergy = energy, maxEnergy
+	}
+}
+
+// connection returns the in-game client playing a character, if any.
+func (s *TelnetServer) connection(characterID string) *client {
+	s.connectionsMutex.RLock()
+	defer s.connectionsMutex.RUnlock()
+	return s.playerConnections[characterID]
+}
@@ -0,0 +1,388 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"mud/internal/game/commands"
+	"mud/internal/game/events"
+	"mud/internal/presentation"
+)
+
+// directions lists the movement commands and their single-letter aliases.
+var directions = []struct {
+	name  string
+	alias string
+}{
+	{"north", "n"},
+	{"south", "s"},
+	{"east", "e"},
+	{"west", "w"},
+	{"up", "u"},
+	{"down", "d"},
+}
+
+// registerCommands builds the in-game command set.
+func (s *TelnetServer) registerCommands() {
+	inGame := commands.State(StateInGame)
+
+	for _, dir := range directions {
+		direction := dir.name
+		s.commands.MustRegister(&commands.Command{
+			Name:          direction,
+			Aliases:       []string{dir.alias},
+			Help:          fmt.Sprintf("Walk %s.", direction),
+			RequiredState: inGame,
+			ActionType:    "move",
+			Handler: s.bind(func(c *client, inv *commands.Invocation) {
+				s.handleMovement(c, inv, direction)
+			}),
+		})
+	}
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "move",
+		Aliases:       []string{"go", "walk"},
+		Syntax:        "<direction>",
+		Help:          "Walk in the given direction.",
+		RequiredState: inGame,
+		ActionType:    "move",
+		Handler: s.bind(func(c *client, inv *commands.Invocation) {
+			if len(inv.Args) == 0 {
+				s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Move where? (n, s, e, w, u, d)", Color: presentation.ColorWarning})
+				return
+			}
+			s.handleMovement(c, inv, normalizeDirection(inv.Args[0]))
+		}),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "look",
+		Aliases:       []string{"l"},
+		Help:          "Describe your surroundings.",
+		RequiredState: inGame,
+		ActionType:    "observe_area",
+		Handler: s.bind(func(c *client, inv *commands.Invocation) {
+			s.renderRoomDescription(c)
+			s.publishAction(c, inv.Command.ActionType)
+		}),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "talk",
+		Aliases:       []string{"speak"},
+		Syntax:        "<target>",
+		Help:          "Start a conversation with someone nearby.",
+		RequiredState: inGame,
+		ActionType:    "talk",
+		Handler:       s.bind(s.handleTalkCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "gather",
+		Syntax:        "<item>",
+		Help:          "Gather something from your surroundings.",
+		RequiredState: inGame,
+		ActionType:    "gather_item",
+		Handler:       s.bind(s.handleGatherCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "give",
+		Syntax:        "<item> to <target>",
+		Help:          "Hand an item to someone.",
+		RequiredState: inGame,
+		ActionType:    "deliver_item",
+		Handler:       s.bind(s.handleGiveCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "get",
+		Aliases:       []string{"take"},
+		Syntax:        "<item>",
+		Help:          "Pick up an item lying nearby.",
+		RequiredState: inGame,
+		ActionType:    "get_item",
+		Handler:       s.bind(s.handleGetCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "drop",
+		Syntax:        "<item>",
+		Help:          "Put down an item you are carrying.",
+		RequiredState: inGame,
+		ActionType:    "drop_item",
+		Handler:       s.bind(s.handleDropCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "examine",
+		Aliases:       []string{"x"},
+		Syntax:        "<item>",
+		Help:          "Look closely at an item.",
+		RequiredState: inGame,
+		ActionType:    "observe_area",
+		Handler:       s.bind(s.handleExamineCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "inventory",
+		Aliases:       []string{"i", "inv"},
+		Help:          "List what you are carrying and using.",
+		RequiredState: inGame,
+		Handler:       s.bind(s.handleInventoryCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "wear",
+		Syntax:        "<item>",
+		Help:          "Wear a piece of armor or clothing.",
+		RequiredState: inGame,
+		ActionType:    "equip_item",
+		Handler:       s.bind(s.handleWearCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "wield",
+		Syntax:        "<item>",
+		Help:          "Ready a weapon.",
+		RequiredState: inGame,
+		ActionType:    "equip_item",
+		Handler:       s.bind(s.handleWieldCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "remove",
+		Syntax:        "<item|slot>",
+		Help:          "Stop wearing or wielding something.",
+		RequiredState: inGame,
+		ActionType:    "unequip_item",
+		Handler:       s.bind(s.handleRemoveCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "open",
+		Syntax:        "<direction>",
+		Help:          "Open a door.",
+		RequiredState: inGame,
+		ActionType:    "open_door",
+		Handler:       s.bind(s.handleOpenCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "close",
+		Syntax:        "<direction>",
+		Help:          "Close a door.",
+		RequiredState: inGame,
+		ActionType:    "close_door",
+		Handler:       s.bind(s.handleCloseCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "lock",
+		Syntax:        "<direction>",
+		Help:          "Lock a closed door with its key.",
+		RequiredState: inGame,
+		ActionType:    "lock_door",
+		Handler:       s.bind(s.handleLockCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "unlock",
+		Syntax:        "<direction>",
+		Help:          "Unlock a door with its key.",
+		RequiredState: inGame,
+		ActionType:    "unlock_door",
+		Handler:       s.bind(s.handleUnlockCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "pick",
+		Syntax:        "<direction>",
+		Help:          "Try to pick the lock on a door.",
+		RequiredState: inGame,
+		ActionType:    "tamper_lock",
+		Handler:       s.bind(s.handlePickCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "kill",
+		Aliases:       []string{"attack"},
+		Syntax:        "<target>",
+		Help:          "Start a fight with someone nearby.",
+		RequiredState: inGame,
+		ActionType:    "attack",
+		Handler:       s.bind(s.handleKillCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "flee",
+		Help:          "Try to escape from a fight through a random exit.",
+		RequiredState: inGame,
+		ActionType:    "flee",
+		Handler:       s.bind(s.handleFleeCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "consider",
+		Aliases:       []string{"con"},
+		Syntax:        "<target>",
+		Help:          "Judge how a fight with someone nearby would go.",
+		RequiredState: inGame,
+		Handler:       s.bind(s.handleConsiderCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "use",
+		Syntax:        "<skill> [target]",
+		Help:          "Use one of your skills, naming a target if the skill needs one.",
+		RequiredState: inGame,
+		Handler:       s.bind(s.handleUseCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "choose",
+		Syntax:        "<number|skill>",
+		Help:          "Pick a skill offered to you on reaching a new class level.",
+		RequiredState: inGame,
+		Handler:       s.bind(s.handleChooseCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "score",
+		Aliases:       []string{"stats"},
+		Help:          "Show your attributes, armor, damage and vitals.",
+		RequiredState: inGame,
+		Handler:       s.bind(s.handleScoreCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "skills",
+		Help:          "List your skills with their mastery, cooldowns and origins.",
+		RequiredState: inGame,
+		Handler:       s.bind(s.handleSkillsCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "practice",
+		Aliases:       []string{"train"},
+		Syntax:        "[skill]",
+		Help:          "Spend a practice point to improve a skill with a trainer nearby, or see what they teach.",
+		RequiredState: inGame,
+		Handler:       s.bind(s.handlePracticeCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "help",
+		Aliases:       []string{"?"},
+		Syntax:        "[command]",
+		Help:          "Show help for all commands or for one command.",
+		RequiredState: inGame,
+		Handler:       s.bind(s.handleHelpCommand),
+	})
+
+	s.commands.MustRegister(&commands.Command{
+		Name:          "commands",
+		Help:          "List the commands you can use.",
+		RequiredState: inGame,
+		Handler:       s.bind(s.handleCommandsCommand),
+	})
+}
+
+// bind adapts a client-typed handler to the registry's Handler signature.
+func (s *TelnetServer) bind(h func(c *client, inv *commands.Invocation)) commands.Handler {
+	return func(session interface{}, inv *commands.Invocation) {
+		if c, ok := session.(*client); ok {
+			h(c, inv)
+		}
+	}
+}
+
+func (s *TelnetServer) handleInGameInput(c *client, input string) {
+	if strings.TrimSpace(input) == "" {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "What would you like to do?", Color: presentation.ColorDefault})
+		return
+	}
+
+	inv, err := s.commands.Parse(input, commands.State(c.state))
+	if err != nil {
+		s.sendUnknownCommand(c, input, err)
+		return
+	}
+	inv.Command.Handler(c, inv)
+	if c.state == StateInGame {
+		s.refreshStats(c)
+	}
+}
+
+// sendUnknownCommand explains why input could not be matched to a command.
+func (s *TelnetServer) sendUnknownCommand(c *client, input string, err error) {
+	verb := strings.Fields(input)[0]
+
+	if ambiguous, ok := err.(*commands.AmbiguousCommandError); ok {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf("'%s' is ambiguous. Did you mean: %s?", verb, strings.Join(ambiguous.Candidates, ", ")), Color: presentation.ColorWarning})
+		return
+	}
+
+	msg := fmt.Sprintf("Unknown command '%s'.", verb)
+	if suggestions := s.commands.Suggest(verb, commands.State(c.state)); len(suggestions) > 0 {
+		msg += fmt.Sprintf(" Did you mean: %s?", strings.Join(suggestions, ", "))
+	} else {
+		msg += " Type 'commands' for a list of commands."
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: msg, Color: presentation.ColorWarning})
+}
+
+func (s *TelnetServer) handleHelpCommand(c *client, inv *commands.Invocation) {
+	state := commands.State(c.state)
+	if len(inv.Args) == 0 {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "\n--- Commands ---\n" + s.commands.Summary(state) + "\nType 'help <command>' for details.\n", Color: presentation.ColorDefault})
+		return
+	}
+
+	cmd, err := s.commands.Lookup(inv.Args[0], state)
+	if err != nil {
+		s.sendUnknownCommand(c, inv.Args[0], err)
+		return
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: s.commands.HelpText(cmd), Color: presentation.ColorDefault})
+}
+
+func (s *TelnetServer) handleCommandsCommand(c *client, inv *commands.Invocation) {
+	var names []string
+	for _, cmd := range s.commands.Commands(commands.State(c.state)) {
+		names = append(names, cmd.Name)
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Available commands: " + strings.Join(names, ", "), Color: presentation.ColorDefault})
+}
+
+// publishAction publishes an ActionEvent for the client's character in its current room.
+func (s *TelnetServer) publishAction(c *client, actionType string, targets ...interface{}) {
+	room, err := s.dal.RoomDAL.GetRoomByID(c.character.CurrentRoomID)
+	if err != nil || room == nil {
+		logrus.Infof("TelnetServer: Room %s not found for character %s or error: %v", c.character.CurrentRoomID, c.character.ID, err)
+		return
+	}
+
+	actionEvent := &events.ActionEvent{
+		Player:     c.character,
+		ActionType: actionType,
+		Room:       room,
+		Timestamp:  time.Now(),
+		Targets:    targets,
+	}
+	s.eventBus.Publish(events.ActionEventType, actionEvent)
+}
+
+// normalizeDirection expands a single-letter direction to its full name.
+func normalizeDirection(direction string) string {
+	direction = strings.ToLower(direction)
+	for _, dir := range directions {
+		if direction == dir.alias {
+			return dir.name
+		}
+	}
+	return direction
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"mud/internal/game/commands"
+	"mud/internal/game/doors"
+	"mud/internal/models"
+	"mud/internal/presentation"
+)
+
+func (s *TelnetServer) handleOpenCommand(c *client, inv *commands.Invocation) {
+	s.handleDoor(c, inv, "open", s.doors.Open, "You open the door %s.")
+}
+
+func (s *TelnetServer) handleCloseCommand(c *client, inv *commands.Invocation) {
+	s.handleDoor(c, inv, "close", s.doors.Close, "You close the door %s.")
+}
+
+func (s *TelnetServer) handleLockCommand(c *client, inv *commands.Invocation) {
+	s.handleDoor(c, inv, "lock", s.doors.Lock, "You lock the door %s.")
+}
+
+func (s *TelnetServer) handleUnlockCommand(c *client, inv *commands.Invocation) {
+	s.handleDoor(c, inv, "unlock", s.doors.Unlock, "You unlock the door %s.")
+}
+
+// handlePickCommand tries to pick a lock. Every attempt, successful or not,
+// may improve the character's lockpicking.
+func (s *TelnetServer) handlePickCommand(c *client, inv *commands.Invocation) {
+	var attempted, picked bool
+	pick := func(character *models.PlayerCharacter, direction string) error {
+		err := s.doors.Pick(character, direction)
+		attempted = err == nil || errors.Is(err, doors.ErrPickFailed)
+		picked = err == nil
+		return err
+	}
+	s.handleDoor(c, inv, "pick", pick, "You pick the lock on the door %s.")
+	if attempted {
+		s.improveSkill(c.character.ID, doors.LockpickingSkillID, picked)
+	}
+}
+
+// handleDoor runs a door operation on the exit named by the first argument and
+// reports the outcome. A failed lockpicking attempt is still an action others
+// can notice, so it is published like a successful one.
+func (s *TelnetServer) handleDoor(c *client, inv *commands.Invocation, verb string, operation func(character *models.PlayerCharacter, direction string) error, success string) {
+	if len(inv.Args) == 0 {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf("%s which way?", presentation.Capitalize(verb)), Color: presentation.ColorWarning})
+		return
+	}
+	direction := normalizeDirection(inv.Args[0])
+
+	err := operation(c.character, direction)
+	switch {
+	case err == nil:
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf(success, describeDirection(direction)), Color: presentation.ColorDefault})
+	case errors.Is(err, doors.ErrPickFailed):
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "You fiddle with the lock but fail to pick it.", Color: presentation.ColorWarning})
+	default:
+		s.sendDoorError(c, err)
+		return
+	}
+	s.publishAction(c, inv.Command.ActionType, direction)
+}
+
+// sendDoorError explains why a door command failed.
+func (s *TelnetServer) sendDoorError(c *client, err error) {
+	var content string
+	switch {
+	case errors.Is(err, doors.ErrNoExit):
+		content = "You cannot go that way."
+	case errors.Is(err, doors.ErrNoDoor):
+		content = "There is no door that way."
+	case errors.Is(err, doors.ErrAlreadyOpen):
+		content = "It is already open."
+	case errors.Is(err, doors.ErrAlreadyClosed):
+		content = "It is already closed."
+	case errors.Is(err, doors.ErrLocked):
+		content = "It is locked."
+	case errors.Is(err, doors.ErrNotLocked):
+		content = "It isn't locked."
+	case errors.Is(err, doors.ErrAlreadyLocked):
+		content = "It is already locked."
+	case errors.Is(err, doors.ErrNotClosed):
+		content = "You need to close it first."
+	case errors.Is(err, doors.ErrNoLock):
+		content = "There is no keyhole."
+	case errors.Is(err, doors.ErrNoKey):
+		content = "You don't have the key."
+	case errors.Is(err, doors.ErrNoSkill):
+		content = "You don't know how to pick locks."
+	default:
+		logrus.Infof("TelnetServer: Door command failed for character %s: %v", c.character.ID, err)
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "The door won't budge. Please try again.", Color: presentation.ColorError})
+		return
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: content, Color: presentation.ColorWarning})
+}
+
+// announceRelock tells everyone beside a door that it has locked itself again.
+func (s *TelnetServer) announceRelock(roomID, direction string) {
+	msg := presentation.SemanticMessage{
+		Type:    presentation.SystemMessage,
+		Content: fmt.Sprintf("The door %s swings shut and locks.", describeDirection(direction)),
+		Color:   presentation.ColorDefault,
+	}
+	for _, c := range s.clientsInRoom(roomID) {
+		s.sendMessage(c, msg)
+	}
+}
+
+// clientsInRoom returns the in-game clients whose characters are in a room.
+func (s *TelnetServer) clientsInRoom(roomID string) []*client {
+	s.connectionsMutex.RLock()
+	defer s.connectionsMutex.RUnlock()
+
+	var clients []*client
+	for _, c := range s.playerConnections {
+		if c.character != nil && c.character.CurrentRoomID == roomID {
+			clients = append(clients, c)
+		}
+	}
+	return clients
+}
+
+// describeDirection turns a direction into a phrase like "to the east" or "above".
+func describeDirection(direction string) string {
+	switch direction {
+	case "up":
+		return "above"
+	case "down":
+		return "below"
+	default:
+		return "to the " + direction
+	}
+}
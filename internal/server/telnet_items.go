@@ -0,0 +1,275 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"mud/internal/game/commands"
+	"mud/internal/game/items"
+	"mud/internal/models"
+	"mud/internal/presentation"
+)
+
+func (s *TelnetServer) handleGetCommand(c *client, inv *commands.Invocation) {
+	query := inv.ArgString()
+	if query == "" {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Get what?", Color: presentation.ColorWarning})
+		return
+	}
+
+	item, err := s.items.PickUp(c.character, query)
+	if err != nil {
+		s.sendItemError(c, err, "You don't see that here.")
+		return
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf("You pick up %s.", describeItem(item)), Color: presentation.ColorSuccess})
+	s.sendInventory(c)
+	s.publishAction(c, inv.Command.ActionType, item)
+}
+
+func (s *TelnetServer) handleDropCommand(c *client, inv *commands.Invocation) {
+	query := inv.ArgString()
+	if query == "" {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Drop what?", Color: presentation.ColorWarning})
+		return
+	}
+
+	item, err := s.items.Drop(c.character, query)
+	if err != nil {
+		s.sendItemError(c, err, "You aren't carrying that.")
+		return
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf("You drop %s.", describeItem(item)), Color: presentation.ColorDefault})
+	s.sendInventory(c)
+	s.publishAction(c, inv.Command.ActionType, item)
+}
+
+func (s *TelnetServer) handleGatherCommand(c *client, inv *commands.Invocation) {
+	query := inv.ArgString()
+	if query == "" {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Gather what?", Color: presentation.ColorWarning})
+		return
+	}
+
+	item, err := s.items.Gather(c.character, query)
+	if err != nil {
+		s.sendItemError(c, err, "There is nothing like that to gather here.")
+		return
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf("You gather %s.", describeItem(item)), Color: presentation.ColorSuccess})
+	s.sendInventory(c)
+	s.publishAction(c, inv.Command.ActionType, item)
+}
+
+func (s *TelnetServer) handleGiveCommand(c *client, inv *commands.Invocation) {
+	// Expected format: "give <item_name> to <npc_name>"
+	parts := strings.SplitN(inv.ArgString(), " to ", 2)
+	if len(parts) != 2 {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Invalid 'give' command format. Use: give <item> to <target>.", Color: presentation.ColorWarning})
+		return
+	}
+
+	itemPart := strings.TrimSpace(parts[0])
+	targetPart := strings.TrimSpace(parts[1])
+
+	if itemPart == "" || targetPart == "" {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Invalid 'give' command. Specify both item and target.", Color: presentation.ColorWarning})
+		return
+	}
+
+	item, npc, err := s.items.Give(c.character, itemPart, targetPart)
+	if err != nil {
+		s.sendItemError(c, err, "You aren't carrying that.")
+		return
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf("You give %s to %s.", describeItem(item), npc.Name), Color: presentation.ColorDefault})
+	s.sendInventory(c)
+	s.publishAction(c, inv.Command.ActionType, item, npc)
+}
+
+func (s *TelnetServer) handleExamineCommand(c *client, inv *commands.Invocation) {
+	query := inv.ArgString()
+	if query == "" {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Examine what?", Color: presentation.ColorWarning})
+		return
+	}
+
+	item, err := s.items.Examine(c.character, query)
+	if err != nil {
+		s.sendItemError(c, err, "You don't see that here.")
+		return
+	}
+	resolved := items.Resolve(item)
+	content := fmt.Sprintf("%s\n%s\n", describeItem(item), resolved.Description)
+	if slot, ok := items.Slot(resolved); ok && slot == items.SlotWielded {
+		content += "It can be wielded.\n"
+	} else if ok {
+		content += fmt.Sprintf("It can be worn (%s).\n", slot)
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: content, Color: presentation.ColorDefault})
+	s.publishAction(c, inv.Command.ActionType, item)
+}
+
+func (s *TelnetServer) handleInventoryCommand(c *client, inv *commands.Invocation) {
+	carried, equipped, err := s.loadInventory(c)
+	if err != nil {
+		s.sendItemError(c, err, "")
+		return
+	}
+
+	var b strings.Builder
+	if len(carried) == 0 {
+		b.WriteString("You are carrying nothing.\n")
+	} else {
+		b.WriteString("You are carrying:\n")
+		for _, item := range carried {
+			b.WriteString(fmt.Sprintf("  %s\n", describeItem(item)))
+		}
+	}
+	if len(equipped) > 0 {
+		b.WriteString("You are using:\n")
+		for _, slot := range items.SortedSlots(equipped) {
+			b.WriteString(fmt.Sprintf("  <%s> %s\n", slot, describeItem(equipped[slot])))
+		}
+	}
+
+	s.sendMessage(c, presentation.SemanticMessage{
+		Type:    presentation.InventoryUpdate,
+		Content: b.String(),
+		Color:   presentation.ColorDefault,
+		Payload: inventoryPayload(carried, equipped),
+	})
+}
+
+func (s *TelnetServer) handleWearCommand(c *client, inv *commands.Invocation) {
+	s.handleEquip(c, inv, false)
+}
+
+func (s *TelnetServer) handleWieldCommand(c *client, inv *commands.Invocation) {
+	s.handleEquip(c, inv, true)
+}
+
+// handleEquip implements wear and wield, which differ only in the slots they accept.
+func (s *TelnetServer) handleEquip(c *client, inv *commands.Invocation, wield bool) {
+	query := inv.ArgString()
+	if query == "" {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: strings.ToUpper(inv.Command.Name[:1]) + inv.Command.Name[1:] + " what?", Color: presentation.ColorWarning})
+		return
+	}
+
+	item, replaced, _, err := s.items.Equip(c.character, query, wield)
+	if err != nil {
+		s.sendItemError(c, err, "You aren't carrying that.")
+		return
+	}
+	content := fmt.Sprintf("You %s %s.", inv.Command.Name, describeItem(item))
+	if replaced != nil {
+		content = fmt.Sprintf("You put away %s and %s %s.", describeItem(replaced), inv.Command.Name, describeItem(item))
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: content, Color: presentation.ColorSuccess})
+	s.sendInventory(c)
+	s.publishAction(c, inv.Command.ActionType, item)
+}
+
+func (s *TelnetServer) handleRemoveCommand(c *client, inv *commands.Invocation) {
+	query := inv.ArgString()
+	if query == "" {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Remove what?", Color: presentation.ColorWarning})
+		return
+	}
+
+	item, slot, err := s.items.Unequip(c.character, query)
+	if err != nil {
+		s.sendItemError(c, err, "")
+		return
+	}
+	content := fmt.Sprintf("You take off %s.", describeItem(item))
+	if slot == items.SlotWielded {
+		content = fmt.Sprintf("You stop wielding %s.", describeItem(item))
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: content, Color: presentation.ColorDefault})
+	s.sendInventory(c)
+	s.publishAction(c, inv.Command.ActionType, item)
+}
+
+// sendItemError explains why an item command failed. notFound is the message
+// used when the item could not be found, which depends on where the command looks.
+func (s *TelnetServer) sendItemError(c *client, err error, notFound string) {
+	var content string
+	switch {
+	case errors.Is(err, items.ErrItemNotFound):
+		content = notFound
+	case errors.Is(err, items.ErrTargetNotFound):
+		content = "There is no one like that here."
+	case errors.Is(err, items.ErrNotWearable):
+		content = "You can't wear that."
+	case errors.Is(err, items.ErrNotWieldable):
+		content = "You can't wield that."
+	case errors.Is(err, items.ErrNotEquipped):
+		content = "You aren't wearing or wielding that."
+	default:
+		logrus.Infof("TelnetServer: Item command failed for character %s: %v", c.character.ID, err)
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Something is wrong with your belongings. Please try again.", Color: presentation.ColorError})
+		return
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: content, Color: presentation.ColorWarning})
+}
+
+// sendInventory pushes the character's inventory and equipment to clients that accept structured data.
+func (s *TelnetServer) sendInventory(c *client) {
+	carried, equipped, err := s.loadInventory(c)
+	if err != nil {
+		logrus.Infof("TelnetServer: Failed to load inventory for character %s: %v", c.character.ID, err)
+		return
+	}
+	s.sendMessage(c, presentation.SemanticMessage{
+		Type:    presentation.InventoryUpdate,
+		Payload: inventoryPayload(carried, equipped),
+	})
+}
+
+func (s *TelnetServer) loadInventory(c *client) ([]*models.ItemInstance, map[string]*models.ItemInstance, error) {
+	carried, err := s.items.Inventory(c.character)
+	if err != nil {
+		return nil, nil, err
+	}
+	equipped, err := s.items.Equipment(c.character)
+	if err != nil {
+		return nil, nil, err
+	}
+	return carried, equipped, nil
+}
+
+// inventoryPayload builds the structured form of an InventoryUpdate.
+func inventoryPayload(carried []*models.ItemInstance, equipped map[string]*models.ItemInstance) map[string]interface{} {
+	itemList := make([]map[string]interface{}, 0, len(carried))
+	for _, item := range carried {
+		itemList = append(itemList, itemPayload(item))
+	}
+	equipment := make(map[string]interface{}, len(equipped))
+	for slot, item := range equipped {
+		equipment[slot] = itemPayload(item)
+	}
+	return map[string]interface{}{"items": itemList, "equipment": equipment}
+}
+
+func itemPayload(item *models.ItemInstance) map[string]interface{} {
+	resolved := items.Resolve(item)
+	return map[string]interface{}{
+		"id":       item.ID,
+		"template": item.TemplateID,
+		"name":     resolved.Name,
+		"type":     resolved.Type,
+		"quantity": item.Quantity,
+	}
+}
+
+// describeItem returns an item's name, followed by the stack size when there is more than one.
+func describeItem(item *models.ItemInstance) string {
+	if item.Quantity > 1 {
+		return fmt.Sprintf("%s (x%d)", items.Name(item), item.Quantity)
+	}
+	return items.Name(item)
+}
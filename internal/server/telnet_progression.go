@@ -0,0 +1,190 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"mud/internal/game/commands"
+	"mud/internal/game/progression"
+	"mud/internal/models"
+	"mud/internal/presentation"
+)
+
+// handleChooseCommand picks a skill offered by a choose_skill level-up
+// reward. The progression manager narrates the new skill and any further
+// choice, so only failures are sent here.
+func (s *TelnetServer) handleChooseCommand(c *client, inv *commands.Invocation) {
+	if len(inv.Args) == 0 {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Choose which skill?", Color: presentation.ColorWarning})
+		return
+	}
+	if _, err := s.progression.Choose(c.character.ID, strings.Join(inv.Args, " ")); err != nil {
+		var content string
+		switch {
+		case errors.Is(err, progression.ErrNoChoicePending):
+			content = "You have no skills to choose from."
+		case errors.Is(err, progression.ErrInvalidChoice):
+			content = "That is not one of the skills on offer."
+		default:
+			logrus.Errorf("TelnetServer: Skill choice failed for character %s: %v", c.character.ID, err)
+			s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Failed to learn that skill.", Color: presentation.ColorError})
+			return
+		}
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: content, Color: presentation.ColorWarning})
+	}
+}
+
+// handleSkillsCommand lists the character's skills with their percentage,
+// cap, cooldown and origin, followed by their practice points.
+func (s *TelnetServer) handleSkillsCommand(c *client, inv *commands.Invocation) {
+	known, err := s.progression.Skills(c.character.ID)
+	if err != nil {
+		logrus.Errorf("TelnetServer: Failed to list skills for character %s: %v", c.character.ID, err)
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Failed to list your skills.", Color: presentation.ColorError})
+		return
+	}
+	if len(known) == 0 {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "You don't know any skills.", Color: presentation.ColorDefault})
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("\n--- Skills ---\n")
+	for _, skill := range known {
+		ready := "ready"
+		if wait := s.skills.Cooldown(c.character.ID, skill.Skill.ID); wait > 0 {
+			ready = fmt.Sprintf("%s cooldown", wait.Round(time.Second))
+		}
+		origin := "unknown origin"
+		if skill.PlayerSkill.GrantedByEntityType != "" {
+			origin = fmt.Sprintf("from %s %s", skill.PlayerSkill.GrantedByEntityType, skill.PlayerSkill.GrantedByEntityID)
+		}
+		fmt.Fprintf(&b, "%-24s %3d%% (max %d%%), %s, %s\n", skill.Skill.Name, skill.PlayerSkill.Percentage, skill.Cap, ready, origin)
+	}
+	if practice := s.describePracticePoints(c.character.ID); practice != "" {
+		b.WriteString("Practice points: " + practice + "\n")
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: b.String(), Color: presentation.ColorDefault})
+}
+
+// describePracticePoints lists the character's practice points by class, like
+// "Warrior 2, Mage 1", leaving out classes without any.
+func (s *TelnetServer) describePracticePoints(characterID string) string {
+	playerClasses, err := s.dal.PlayerClassDAL.GetPlayerClassesByPlayerID(characterID)
+	if err != nil {
+		logrus.Errorf("TelnetServer: Failed to get classes for character %s: %v", characterID, err)
+		return ""
+	}
+	sort.Slice(playerClasses, func(i, j int) bool { return playerClasses[i].ClassID < playerClasses[j].ClassID })
+	var parts []string
+	for _, playerClass := range playerClasses {
+		if playerClass.PracticePoints > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", s.className(playerClass.ClassID), playerClass.PracticePoints))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// className returns a class's name, or its ID if it cannot be found.
+func (s *TelnetServer) className(classID string) string {
+	class, err := s.dal.ClassDAL.GetClassByID(classID)
+	if err != nil || class == nil {
+		return classID
+	}
+	return class.Name
+}
+
+// handlePracticeCommand spends a practice point to improve a skill with a
+// trainer in the room. Without arguments it lists what the trainer teaches.
+func (s *TelnetServer) handlePracticeCommand(c *client, inv *commands.Invocation) {
+	trainer, err := s.findTrainer(c.character.CurrentRoomID)
+	if err != nil {
+		logrus.Errorf("TelnetServer: Failed to find a trainer for character %s: %v", c.character.ID, err)
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Failed to find a trainer.", Color: presentation.ColorError})
+		return
+	}
+	if trainer == nil {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "There is no one here to train you.", Color: presentation.ColorWarning})
+		return
+	}
+	if len(inv.Args) == 0 {
+		s.sendTrainerOffer(c, trainer)
+		return
+	}
+
+	known, gained, err := s.progression.Practice(c.character.ID, trainer, strings.Join(inv.Args, " "))
+	if err != nil {
+		var content string
+		switch {
+		case errors.Is(err, progression.ErrUnknownSkill):
+			content = "You don't know that skill."
+		case errors.Is(err, progression.ErrNotTaught):
+			content = fmt.Sprintf("%s cannot teach you %s.", trainer.Name, known.Skill.Name)
+		case errors.Is(err, progression.ErrNoPracticePoints):
+			content = "You have no practice points left for that."
+		case errors.Is(err, progression.ErrSkillCapped):
+			content = fmt.Sprintf("You cannot improve %s any further until you advance in its class.", known.Skill.Name)
+		default:
+			logrus.Errorf("TelnetServer: Practice failed for character %s: %v", c.character.ID, err)
+			s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Failed to practice that skill.", Color: presentation.ColorError})
+			return
+		}
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: content, Color: presentation.ColorWarning})
+		return
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf("%s trains you in %s. You gain %d%%, reaching %d%%.", trainer.Name, known.Skill.Name, gained, known.PlayerSkill.Percentage), Color: presentation.ColorDefault})
+}
+
+// sendTrainerOffer lists the character's skills a trainer can improve and
+// the practice points the character has to spend on them.
+func (s *TelnetServer) sendTrainerOffer(c *client, trainer *models.NPC) {
+	classID := progression.TrainerClass(trainer)
+	known, err := s.progression.Skills(c.character.ID)
+	if err != nil {
+		logrus.Errorf("TelnetServer: Failed to list skills for character %s: %v", c.character.ID, err)
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Failed to list your skills.", Color: presentation.ColorError})
+		return
+	}
+	var offered []string
+	for _, skill := range known {
+		if skill.Skill.AssociatedClassID == classID {
+			offered = append(offered, fmt.Sprintf("%s (%d%%, max %d%%)", skill.Skill.Name, skill.PlayerSkill.Percentage, skill.Cap))
+		}
+	}
+	if len(offered) == 0 {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf("%s has nothing to teach you.", trainer.Name), Color: presentation.ColorDefault})
+		return
+	}
+	points := 0
+	if playerClass, err := s.dal.PlayerClassDAL.GetPlayerClassByID(c.character.ID, classID); err == nil && playerClass != nil {
+		points = playerClass.PracticePoints
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf("%s can train you in: %s. You have %d practice points as a %s.",
+		trainer.Name, strings.Join(offered, ", "), points, s.className(classID)), Color: presentation.ColorDefault})
+}
+
+// findTrainer returns a living NPC in the room who trains a class, or nil if there is none.
+func (s *TelnetServer) findTrainer(roomID string) (*models.NPC, error) {
+	npcs, err := s.dal.NpcDAL.GetNPCsByRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+	for _, npc := range npcs {
+		if npc.Health > 0 && progression.TrainerClass(npc) != "" {
+			return npc, nil
+		}
+	}
+	return nil, nil
+}
+
+// improveSkill gives a skill the character has just used a chance to
+// improve. The progression manager narrates any improvement.
+func (s *TelnetServer) improveSkill(characterID, skillID string, succeeded bool) {
+	if _, err := s.progression.Improve(characterID, skillID, succeeded); err != nil {
+		logrus.Errorf("TelnetServer: Failed to improve skill %s for character %s: %v", skillID, characterID, err)
+	}
+}
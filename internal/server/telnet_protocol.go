@@ -0,0 +1,339 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"mud/internal/presentation"
+)
+
+// Telnet command bytes (RFC 854).
+const (
+	telnetSE   byte = 240
+	telnetNOP  byte = 241
+	telnetGA   byte = 249
+	telnetSB   byte = 250
+	telnetWILL byte = 251
+	telnetWONT byte = 252
+	telnetDO   byte = 253
+	telnetDONT byte = 254
+	telnetIAC  byte = 255
+)
+
+// Telnet options supported by the server.
+const (
+	telnetOptEcho  byte = 1  // RFC 857
+	telnetOptSGA   byte = 3  // RFC 858
+	telnetOptTType byte = 24 // RFC 1091
+	telnetOptNAWS  byte = 31 // RFC 1073
+	telnetOptGMCP  byte = 201
+)
+
+// TTYPE subnegotiation codes.
+const (
+	telnetTTypeIs   byte = 0
+	telnetTTypeSend byte = 1
+)
+
+// maxSubnegotiationLength bounds the payload of a single SB sequence so a
+// misbehaving client cannot grow the buffer indefinitely.
+const maxSubnegotiationLength = 1024
+
+// telnetOptions holds the capabilities negotiated with a telnet client.
+type telnetOptions struct {
+	SuppressGoAhead bool   // Client agreed that the server suppresses go-ahead
+	ServerEcho      bool   // Server is responsible for echo; the client's local echo is off
+	NAWS            bool   // Client reports its window size
+	Width           int    // Terminal width in columns, 0 if unknown
+	Height          int    // Terminal height in rows, 0 if unknown
+	TerminalType    string // Terminal type reported via TTYPE, empty if unknown
+
+	GMCP         bool            // Client accepts GMCP out-of-band data
+	GMCPClient   string          // Client name from Core.Hello
+	GMCPVersion  string          // Client version from Core.Hello
+	GMCPSupports map[string]bool // Modules from Core.Supports; empty means everything
+}
+
+// telnetParser strips telnet protocol sequences from an input stream and
+// reports them through callbacks, leaving only the user's line input.
+type telnetParser struct {
+	onCommand        func(cmd, opt byte)
+	onSubnegotiation func(opt byte, data []byte)
+}
+
+// ReadLine reads the next line of user input from r. IAC sequences are removed
+// and dispatched to the parser's callbacks; carriage returns and NUL bytes are
+// dropped. An escaped IAC (IAC IAC) is kept as a literal 0xFF byte.
+func (p *telnetParser) ReadLine(r *bufio.Reader) (string, error) {
+	var line strings.Builder
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return line.String(), err
+		}
+
+		switch b {
+		case telnetIAC:
+			literal, err := p.readCommand(r)
+			if err != nil {
+				return line.String(), err
+			}
+			if literal {
+				line.WriteByte(telnetIAC)
+			}
+		case '\n':
+			return line.String(), nil
+		case '\r', 0:
+			// Telnet sends CR LF or CR NUL; the LF terminates the line.
+		default:
+			line.WriteByte(b)
+		}
+	}
+}
+
+// readCommand consumes the remainder of an IAC sequence. It returns true when
+// the sequence was an escaped literal IAC byte.
+func (p *telnetParser) readCommand(r *bufio.Reader) (bool, error) {
+	cmd, err := r.ReadByte()
+	if err != nil {
+		return false, err
+	}
+
+	switch cmd {
+	case telnetIAC:
+		return true, nil
+	case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+		opt, err := r.ReadByte()
+		if err != nil {
+			return false, err
+		}
+		if p.onCommand != nil {
+			p.onCommand(cmd, opt)
+		}
+	case telnetSB:
+		opt, data, err := readSubnegotiation(r)
+		if err != nil {
+			return false, err
+		}
+		if p.onSubnegotiation != nil {
+			p.onSubnegotiation(opt, data)
+		}
+	default:
+		// NOP, GA, AYT and the other single-byte commands carry no data.
+	}
+	return false, nil
+}
+
+// readSubnegotiation reads an SB payload up to and including the closing IAC SE.
+func readSubnegotiation(r *bufio.Reader) (byte, []byte, error) {
+	opt, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var data []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return opt, data, err
+		}
+		if b == telnetIAC {
+			next, err := r.ReadByte()
+			if err != nil {
+				return opt, data, err
+			}
+			if next == telnetSE {
+				return opt, data, nil
+			}
+			if next != telnetIAC {
+				// Malformed sequence; drop the stray command byte.
+				continue
+			}
+		}
+		if len(data) < maxSubnegotiationLength {
+			data = append(data, b)
+		}
+	}
+}
+
+// startNegotiation offers the options the server wants when a client connects.
+func (s *TelnetServer) startNegotiation(c *client) {
+	s.sendTelnetCommand(c, telnetWILL, telnetOptSGA)
+	s.sendTelnetCommand(c, telnetDO, telnetOptNAWS)
+	s.sendTelnetCommand(c, telnetDO, telnetOptTType)
+	s.sendTelnetCommand(c, telnetWILL, telnetOptGMCP)
+}
+
+// handleTelnetCommand answers a WILL/WONT/DO/DONT received from the client.
+// Replies are only sent when the option state changes, so negotiation cannot loop.
+func (s *TelnetServer) handleTelnetCommand(c *client, cmd, opt byte) {
+	switch opt {
+	case telnetOptSGA:
+		switch cmd {
+		case telnetDO:
+			c.options.SuppressGoAhead = true
+		case telnetDONT:
+			c.options.SuppressGoAhead = false
+		}
+	case telnetOptEcho:
+		switch cmd {
+		case telnetDO:
+			c.options.ServerEcho = c.echoRequested
+		case telnetDONT:
+			c.options.ServerEcho = false
+		}
+	case telnetOptNAWS:
+		switch cmd {
+		case telnetWILL:
+			c.options.NAWS = true
+		case telnetWONT:
+			c.options.NAWS = false
+		}
+	case telnetOptGMCP:
+		// GMCP state is read by sendMessage, so update it under the write lock.
+		c.writeMutex.Lock()
+		switch cmd {
+		case telnetDO:
+			c.options.GMCP = true
+		case telnetDONT:
+			c.options.GMCP = false
+		}
+		c.writeMutex.Unlock()
+	case telnetOptTType:
+		if cmd == telnetWILL && c.options.TerminalType == "" {
+			s.sendTelnetBytes(c, []byte{telnetIAC, telnetSB, telnetOptTType, telnetTTypeSend, telnetIAC, telnetSE})
+		}
+	default:
+		// Refuse anything we don't understand.
+		switch cmd {
+		case telnetWILL:
+			s.sendTelnetCommand(c, telnetDONT, opt)
+		case telnetDO:
+			s.sendTelnetCommand(c, telnetWONT, opt)
+		}
+	}
+}
+
+// handleTelnetSubnegotiation records data sent in an SB sequence.
+func (s *TelnetServer) handleTelnetSubnegotiation(c *client, opt byte, data []byte) {
+	switch opt {
+	case telnetOptNAWS:
+		if len(data) == 4 {
+			c.options.NAWS = true
+			c.options.Width = int(data[0])<<8 | int(data[1])
+			c.options.Height = int(data[2])<<8 | int(data[3])
+		}
+	case telnetOptTType:
+		if len(data) > 1 && data[0] == telnetTTypeIs {
+			c.options.TerminalType = string(data[1:])
+		}
+	case telnetOptGMCP:
+		s.handleGMCPMessage(c, data)
+	}
+}
+
+// handleGMCPMessage processes the Core package messages a client sends after
+// GMCP is enabled. Other packages are ignored.
+func (s *TelnetServer) handleGMCPMessage(c *client, data []byte) {
+	name, body := string(data), ""
+	if idx := strings.IndexByte(name, ' '); idx >= 0 {
+		name, body = name[:idx], strings.TrimSpace(name[idx+1:])
+	}
+
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	switch strings.ToLower(name) {
+	case "core.hello":
+		var hello struct {
+			Client  string `json:"client"`
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal([]byte(body), &hello); err != nil {
+			logrus.Infof("TelnetServer: Invalid GMCP Core.Hello from %s: %v", c.conn.RemoteAddr(), err)
+			return
+		}
+		c.options.GMCPClient = hello.Client
+		c.options.GMCPVersion = hello.Version
+	case "core.supports.set", "core.supports.add", "core.supports.remove":
+		var modules []string
+		if err := json.Unmarshal([]byte(body), &modules); err != nil {
+			logrus.Infof("TelnetServer: Invalid GMCP %s from %s: %v", name, c.conn.RemoteAddr(), err)
+			return
+		}
+		if strings.EqualFold(name, "core.supports.set") || c.options.GMCPSupports == nil {
+			c.options.GMCPSupports = make(map[string]bool)
+		}
+		remove := strings.EqualFold(name, "core.supports.remove")
+		for _, module := range modules {
+			// Entries look like "Char 1"; the version is not used.
+			moduleName := strings.Fields(module)
+			if len(moduleName) == 0 {
+				continue
+			}
+			if remove {
+				delete(c.options.GMCPSupports, moduleName[0])
+			} else {
+				c.options.GMCPSupports[moduleName[0]] = true
+			}
+		}
+	}
+}
+
+// writeGMCPLocked writes the GMCP packages describing msg to the client's
+// buffer. The caller must hold c.writeMutex and flush the writer.
+func (s *TelnetServer) writeGMCPLocked(c *client, msg presentation.SemanticMessage) {
+	if !c.options.GMCP {
+		return
+	}
+	for _, pkg := range presentation.BuildGMCPPackages(msg) {
+		if len(c.options.GMCPSupports) > 0 && !c.options.GMCPSupports[pkg.Module()] && !c.options.GMCPSupports[pkg.Name] {
+			continue
+		}
+		payload, err := pkg.Encode()
+		if err != nil {
+			logrus.Infof("TelnetServer: %v", err)
+			continue
+		}
+		c.writer.Write([]byte{telnetIAC, telnetSB, telnetOptGMCP})
+		c.writer.Write(bytes.ReplaceAll(payload, []byte{telnetIAC}, []byte{telnetIAC, telnetIAC}))
+		c.writer.Write([]byte{telnetIAC, telnetSE})
+	}
+}
+
+// hideInput asks the client to stop echoing locally, e.g. while a password is typed.
+func (s *TelnetServer) hideInput(c *client) {
+	c.echoRequested = true
+	s.sendTelnetCommand(c, telnetWILL, telnetOptEcho)
+}
+
+// showInput restores the client's local echo after hideInput.
+func (s *TelnetServer) showInput(c *client) {
+	if !c.echoRequested {
+		return
+	}
+	c.echoRequested = false
+	c.options.ServerEcho = false
+	s.sendTelnetCommand(c, telnetWONT, telnetOptEcho)
+	// The user's Enter key was not echoed, so move to a fresh line ourselves.
+	s.sendTelnetBytes(c, []byte("\r\n"))
+}
+
+func (s *TelnetServer) sendTelnetCommand(c *client, cmd, opt byte) {
+	s.sendTelnetBytes(c, []byte{telnetIAC, cmd, opt})
+}
+
+func (s *TelnetServer) sendTelnetBytes(c *client, data []byte) {
+	if c.ws != nil {
+		return // WebSocket clients have no telnet option state
+	}
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	if _, err := c.writer.Write(data); err != nil {
+		return
+	}
+	c.writer.Flush()
+}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTelnetParser_StripsNegotiation(t *testing.T) {
+	var commands [][2]byte
+	var subnegotiations []byte
+	var naws []byte
+	parser := &telnetParser{
+		onCommand: func(cmd, opt byte) { commands = append(commands, [2]byte{cmd, opt}) },
+		onSubnegotiation: func(opt byte, data []byte) {
+			subnegotiations = append(subnegotiations, opt)
+			if opt == telnetOptNAWS {
+				naws = data
+			}
+		},
+	}
+
+	input := []byte{telnetIAC, telnetWILL, telnetOptNAWS}
+	input = append(input, []byte("lo")...)
+	input = append(input, telnetIAC, telnetSB, telnetOptNAWS, 0, 80, 0, 24, telnetIAC, telnetSE)
+	input = append(input, telnetIAC, telnetNOP)
+	input = append(input, []byte("ok\r\n")...)
+	input = append(input, []byte("a")...)
+	input = append(input, telnetIAC, telnetIAC)
+	input = append(input, []byte("b\r\x00\n")...)
+
+	reader := bufio.NewReader(bytes.NewReader(input))
+
+	line, err := parser.ReadLine(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "look", line)
+	assert.Equal(t, [][2]byte{{telnetWILL, telnetOptNAWS}}, commands)
+	assert.Equal(t, []byte{telnetOptNAWS}, subnegotiations)
+	assert.Equal(t, []byte{0, 80, 0, 24}, naws)
+
+	line, err = parser.ReadLine(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "a\xffb", line, "Escaped IAC should be kept as a literal byte")
+}
+
+func TestTelnetParser_EscapedIACInSubnegotiation(t *testing.T) {
+	var payload []byte
+	parser := &telnetParser{
+		onSubnegotiation: func(opt byte, data []byte) { payload = data },
+	}
+
+	input := []byte{telnetIAC, telnetSB, telnetOptNAWS, 0, telnetIAC, telnetIAC, 0, 50, telnetIAC, telnetSE, '\n'}
+	line, err := parser.ReadLine(bufio.NewReader(bytes.NewReader(input)))
+	assert.NoError(t, err)
+	assert.Equal(t, "", line)
+	assert.Equal(t, []byte{0, 255, 0, 50}, payload)
+}
+
+func TestTelnetServer_Negotiation(t *testing.T) {
+	_, renderer, port, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	assert.NoError(t, err, "Failed to connect to Telnet server")
+	defer conn.Close()
+
+	// The server offers SGA and asks for NAWS and TTYPE on connect.
+	received := readUntil(t, conn, []byte{telnetIAC, telnetDO, telnetOptTType})
+	assert.Contains(t, string(received), string([]byte{telnetIAC, telnetWILL, telnetOptSGA}))
+	assert.Contains(t, string(received), string([]byte{telnetIAC, telnetDO, telnetOptNAWS}))
+
+	// Agree to TTYPE and expect a SEND request.
+	_, err = conn.Write([]byte{telnetIAC, telnetWILL, telnetOptTType, telnetIAC, telnetWILL, telnetOptNAWS})
+	assert.NoError(t, err)
+	readUntil(t, conn, []byte{telnetIAC, telnetSB, telnetOptTType, telnetTTypeSend, telnetIAC, telnetSE})
+
+	reply := []byte{telnetIAC, telnetSB, telnetOptTType, telnetTTypeIs}
+	reply = append(reply, []byte("XTERM")...)
+	reply = append(reply, telnetIAC, telnetSE)
+	reply = append(reply, telnetIAC, telnetSB, telnetOptNAWS, 0, 120, 0, 40, telnetIAC, telnetSE)
+	_, err = conn.Write(reply)
+	assert.NoError(t, err)
+
+	// Entering the password prompt turns off the client's local echo.
+	write(t, conn, "1")
+	assertEventuallyContains(t, renderer, "[system_message] Enter username: \n")
+	write(t, conn, "testuser")
+	readUntil(t, conn, []byte{telnetIAC, telnetWILL, telnetOptEcho})
+	write(t, conn, "password")
+	readUntil(t, conn, []byte{telnetIAC, telnetWONT, telnetOptEcho})
+
+	// Unknown options are refused.
+	_, err = conn.Write([]byte{telnetIAC, telnetDO, 99})
+	assert.NoError(t, err)
+	readUntil(t, conn, []byte{telnetIAC, telnetWONT, 99})
+}
+
+func TestTelnetServer_NegotiatedOptionsStoredOnClient(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	go func() {
+		// Drain replies so writes on the pipe don't block.
+		buf := make([]byte, 256)
+		for {
+			if _, err := clientConn.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	s := &TelnetServer{}
+	c := &client{conn: serverConn, writer: bufio.NewWriter(serverConn)}
+
+	s.handleTelnetCommand(c, telnetDO, telnetOptSGA)
+	s.handleTelnetCommand(c, telnetWILL, telnetOptNAWS)
+	s.handleTelnetSubnegotiation(c, telnetOptNAWS, []byte{0, 132, 0, 43})
+	s.handleTelnetSubnegotiation(c, telnetOptTType, append([]byte{telnetTTypeIs}, []byte("ANSI")...))
+
+	assert.True(t, c.options.SuppressGoAhead)
+	assert.True(t, c.options.NAWS)
+	assert.Equal(t, 132, c.options.Width)
+	assert.Equal(t, 43, c.options.Height)
+	assert.Equal(t, "ANSI", c.options.TerminalType)
+
+	// Echo is only taken over when the server asked for it.
+	s.handleTelnetCommand(c, telnetDO, telnetOptEcho)
+	assert.False(t, c.options.ServerEcho)
+	s.hideInput(c)
+	s.handleTelnetCommand(c, telnetDO, telnetOptEcho)
+	assert.True(t, c.options.ServerEcho)
+	s.showInput(c)
+	assert.False(t, c.options.ServerEcho)
+}
+
+// readUntil reads from conn until the given byte sequence has been seen.
+func readUntil(t *testing.T, conn net.Conn, want []byte) []byte {
+	t.Helper()
+	var received []byte
+	buf := make([]byte, 1024)
+	deadline := time.Now().Add(5 * time.Second)
+	for !bytes.Contains(received, want) {
+		conn.SetReadDeadline(deadline)
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("Did not receive %v before error %v; got %q", want, err, received)
+		}
+		received = append(received, buf[:n]...)
+	}
+	conn.SetReadDeadline(time.Time{})
+	return received
+}
+
+func TestTelnetServer_GMCP(t *testing.T) {
+	_, renderer, port, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	assert.NoError(t, err, "Failed to connect to Telnet server")
+	defer conn.Close()
+
+	readUntil(t, conn, []byte{telnetIAC, telnetWILL, telnetOptGMCP})
+
+	handshake := []byte{telnetIAC, telnetDO, telnetOptGMCP}
+	handshake = append(handshake, gmcpFrame(`Core.Hello {"client":"Mudlet","version":"4.17"}`)...)
+	handshake = append(handshake, gmcpFrame(`Core.Supports.Set ["Char 1", "Room 1"]`)...)
+	_, err = conn.Write(handshake)
+	assert.NoError(t, err)
+
+	// Log in with the seeded account and character.
+	write(t, conn, "1")
+	write(t, conn, "test")
+	write(t, conn, "password")
+	assertEventuallyContains(t, renderer, "--- Character Selection ---")
+	write(t, conn, "1")
+	assertEventuallyContains(t, renderer, "[system_message] Welcome, TestPlayer!\n")
+
+	// Room.Info is sent last; its keys are marshalled in sorted order.
+	received := readUntil(t, conn, []byte(`"num":"bag_end"}`))
+	assert.Contains(t, string(received), `Char.Vitals {"energy":100,"hp":100,"maxenergy":100,"maxhp":100}`)
+	assert.Contains(t, string(received), `Char.Stats {"armor":0,"charisma":10,"constitution":10,"damage":2,"dexterity":10,"intelligence":10,"strength":10,"wisdom":10}`)
+	assert.Contains(t, string(received), `Char.Items.List {"equipment":{},"items":[],"location":"inv"}`)
+	assert.Contains(t, string(received), `Room.Info {"area":`)
+	assert.Contains(t, string(received), `"name":"Bag End, Hobbiton"`)
+
+	// Structured-only messages are not rendered as text.
+	assert.NotContains(t, renderer.AllMessages(), "[player_stats_update]")
+}
+
+// gmcpFrame wraps a GMCP message in IAC SB ... IAC SE.
+func gmcpFrame(message string) []byte {
+	frame := []byte{telnetIAC, telnetSB, telnetOptGMCP}
+	frame = append(frame, []byte(message)...)
+	return append(frame, telnetIAC, telnetSE)
+}
@@ -2,20 +2,33 @@ package server
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 	"mud/internal/dal"
 	"mud/internal/game"
+	"mud/internal/game/combat"
+	"mud/internal/game/effects"
+	"mud/internal/game/commands"
+	"mud/internal/game/doors"
 	"mud/internal/game/events"
+	"mud/internal/game/items"
+	"mud/internal/game/progression"
+	"mud/internal/game/quests"
+	"mud/internal/game/scheduler"
+	"mud/internal/game/skills"
+	"mud/internal/game/stats"
 	"mud/internal/models"
 	"mud/internal/presentation"
 )
@@ -37,6 +50,9 @@ const (
 	StateInGame
 )
 
+// startingRoomID is where new characters begin and slain characters respawn.
+const startingRoomID = "bag_end"
+
 // client represents a connected client.
 type client struct {
 	conn net.Conn
@@ -46,6 +62,44 @@ type client struct {
 	character     *models.PlayerCharacter
 	tempUsername  string
 	tempPassword  string
+	tempCharacterName string
+	tempRaceID        string
+	options       telnetOptions // Capabilities negotiated with the telnet client
+	echoRequested bool          // Server asked the client to stop local echo
+	writeMutex    sync.Mutex    // Serialises writes from the input loop and event handlers
+
+	ws       *websocket.Conn               // Set for WebSocket sessions instead of using writer
+	renderer game.TelnetRendererInterface // Renderer for WebSocket sessions
+
+	stats      map[string]int // Stats last sent to the client
+	statsMutex sync.Mutex     // Guards stats against concurrent refreshes
+
+	updates      []func(character *models.PlayerCharacter) // Changes the game managers made to the stored character
+	updatesMutex sync.Mutex                                // Guards updates, which are queued from other goroutines
+}
+
+// update queues a change the game managers made to the stored character, for
+// the session to make to its own. The managers run on other goroutines, so
+// they never change the session's character themselves.
+func (c *client) update(change func(character *models.PlayerCharacter)) {
+	c.updatesMutex.Lock()
+	defer c.updatesMutex.Unlock()
+	c.updates = append(c.updates, change)
+}
+
+// applyUpdates makes the queued changes to the session's character. It is
+// only called from the session's own goroutine.
+func (c *client) applyUpdates() {
+	c.updatesMutex.Lock()
+	updates := c.updates
+	c.updates = nil
+	c.updatesMutex.Unlock()
+	if c.character == nil {
+		return
+	}
+	for _, change := range updates {
+		change(c.character)
+	}
 }
 
 // TelnetServer represents the Telnet server for the MUD.
@@ -56,12 +110,23 @@ type TelnetServer struct {
 	dal                *dal.DAL
 	llmService         game.LLMServiceInterface
 	playerConnections  map[string]*client // Map characterID to client
+	commands           *commands.Registry
+	scheduler          *scheduler.Scheduler
+	items              *items.Manager
+	doors              *doors.Manager
+	combat             *combat.Manager
+	skills             *skills.Manager
+	effects            *effects.Manager
+	stats              *stats.Manager
+	progression        *progression.Manager
+	quests             *quests.Manager
 	connectionsMutex   sync.RWMutex
 	Ready              chan bool
 }
 
-// NewTelnetServer creates a new TelnetServer.
-func NewTelnetServer(listener net.Listener, renderer game.TelnetRendererInterface, eventBus *events.EventBus, dal *dal.DAL, llmService game.LLMServiceInterface) *TelnetServer {
+// NewTelnetServer creates a new TelnetServer. The world's periodic and
+// delayed jobs run on sched, which the server runs while it is started.
+func NewTelnetServer(listener net.Listener, renderer game.TelnetRendererInterface, eventBus *events.EventBus, dal *dal.DAL, llmService game.LLMServiceInterface, sched *scheduler.Scheduler) *TelnetServer {
 	s := &TelnetServer{
 		listener:          listener,
 		renderer:          renderer,
@@ -69,8 +134,42 @@ func NewTelnetServer(listener net.Listener, renderer game.TelnetRendererInterfac
 		dal:               dal,
 		llmService:        llmService,
 		playerConnections: make(map[string]*client),
+		commands:          commands.NewRegistry(),
+		scheduler:         sched,
+		items:             items.NewManager(dal.RoomDAL, dal.ItemDAL, dal.ItemInstanceDAL, dal.NpcDAL),
+		doors:             doors.NewManager(dal.RoomDAL, dal.ItemInstanceDAL, dal.PlayerSkillDAL, sched),
 		Ready:             make(chan bool),
 	}
+	s.doors.OnRelock = s.announceRelock
+	s.effects = effects.NewManager(dal.StatusEffectDAL, playerNotifier{s})
+	s.stats = stats.NewManager(dal.RaceDAL, dal.ClassDAL, dal.PlayerClassDAL, dal.ItemInstanceDAL, s.effects)
+	s.combat = combat.NewManager(dal.PlayerCharacterDAL, dal.NpcDAL, dal.RoomDAL, s.stats, eventBus, playerNotifier{s}, startingRoomID)
+	s.skills = skills.NewManager(dal.PlayerCharacterDAL, dal.SkillDAL, dal.PlayerSkillDAL, dal.PlayerClassDAL, dal.NpcDAL, s.stats, dal.RoomDAL, eventBus, s.combat, playerNotifier{s}, s.effects)
+	s.progression = progression.NewManager(dal.ClassDAL, dal.PlayerClassDAL, dal.SkillDAL, dal.PlayerSkillDAL, playerNotifier{s})
+	s.quests = quests.NewManager(dal.QuestDAL, dal.PlayerQuestState, dal.NpcDAL, playerNotifier{s})
+	s.registerCommands()
+
+	// Award class experience for the actions players take, and begin the
+	// quests they set off. Levelling up can raise a character's stats, so
+	// refresh them afterwards.
+	actionChannel := make(chan interface{}, 100)
+	s.eventBus.Subscribe(events.ActionEventType, actionChannel)
+	go func() {
+		for event := range actionChannel {
+			if ae, ok := event.(*events.ActionEvent); ok {
+				s.progression.HandleActionEvent(ae)
+				s.quests.HandleActionEvent(ae)
+				if ae.Player == nil {
+					continue
+				}
+				if c := s.connection(ae.Player.ID); c != nil {
+					s.refreshStats(c)
+				}
+			} else {
+				logrus.Infof("TelnetServer: Received unexpected event type on ActionEventType: %T", event)
+			}
+		}
+	}()
 
 	// Subscribe to PlayerMessageEvent
 	playerMessageChannel := make(chan interface{}, 100) // Buffered channel
@@ -105,6 +204,13 @@ func NewTelnetServer(listener net.Listener, renderer game.TelnetRendererInterfac
 func (s *TelnetServer) Start() {
 	defer s.listener.Close()
 	logrus.Infof("Telnet server listening on port %d\n", s.listener.Addr().(*net.TCPAddr).Port)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.combat.Schedule(s.scheduler)
+	s.skills.Schedule(s.scheduler)
+	s.effects.Schedule(s.scheduler)
+	go s.scheduler.Run(ctx)
 	s.Ready <- true
 
 	for {
@@ -127,20 +233,21 @@ func (s *TelnetServer) Start() {
 // handleConnection manages a single Telnet client connection.
 func (s *TelnetServer) handleConnection(c *client) {
 	defer func() {
-		if c.character != nil {
-			s.connectionsMutex.Lock()
-			delete(s.playerConnections, c.character.ID)
-			s.connectionsMutex.Unlock()
-		}
+		s.unregisterClient(c)
 		logrus.Infof("Client %s disconnected: %v\n", c.conn.RemoteAddr(), c.conn.Close())
 	}()
 
 	logrus.Infof("New Telnet connection from %s\n", c.conn.RemoteAddr())
+	s.startNegotiation(c)
 	s.showWelcomeMenu(c)
 
 	reader := bufio.NewReader(c.conn)
+	parser := &telnetParser{
+		onCommand:        func(cmd, opt byte) { s.handleTelnetCommand(c, cmd, opt) },
+		onSubnegotiation: func(opt byte, data []byte) { s.handleTelnetSubnegotiation(c, opt, data) },
+	}
 	for {
-		input, err := reader.ReadString('\n')
+		input, err := parser.ReadLine(reader)
 		if err != nil {
 			logrus.Infof("Client %s disconnected: %v\n", c.conn.RemoteAddr(), err)
 			return
@@ -150,7 +257,22 @@ func (s *TelnetServer) handleConnection(c *client) {
 	}
 }
 
+// unregisterClient removes a disconnecting client from the player connection map.
+func (s *TelnetServer) unregisterClient(c *client) {
+	if c.character == nil {
+		return
+	}
+	s.combat.Disengage(c.character.ID)
+	s.skills.Forget(c.character.ID)
+	s.connectionsMutex.Lock()
+	if s.playerConnections[c.character.ID] == c {
+		delete(s.playerConnections, c.character.ID)
+	}
+	s.connectionsMutex.Unlock()
+}
+
 func (s *TelnetServer) handleInput(c *client, input string) {
+	c.applyUpdates()
 	switch c.state {
 	case StateWelcome:
 		s.handleWelcomeInput(c, input)
@@ -169,9 +291,9 @@ func (s *TelnetServer) handleInput(c *client, input string) {
 	case StateCharacterCreationName:
 		s.handleCharacterCreationName(c, input)
 	case StateCharacterCreationRace:
-		// s.handleCharacterCreationRace(c, input)
+		s.handleCharacterCreationRace(c, input)
 	case StateCharacterCreationProfession:
-		// s.handleCharacterCreationProfession(c, input)
+		s.handleCharacterCreationProfession(c, input)
 	case StateInGame:
 		s.handleInGameInput(c, input)
 	}
@@ -204,9 +326,11 @@ func (s *TelnetServer) handleLoginUsername(c *client, username string) {
 	c.tempUsername = username
 	c.state = StateLoginPassword
 	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Enter password: ", Color: presentation.ColorDefault})
+	s.hideInput(c)
 }
 
 func (s *TelnetServer) handleLoginPassword(c *client, password string) {
+	s.showInput(c)
 	account, err := s.dal.PlayerAccountDAL.Authenticate(c.tempUsername, password)
 	if err != nil || account == nil {
 		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Invalid username or password.", Color: presentation.ColorError})
@@ -243,12 +367,15 @@ func (s *TelnetServer) handleCreateUsername(c *client, username string) {
 	c.tempUsername = username
 	c.state = StateCreateAccountPassword
 	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Enter password: ", Color: presentation.ColorDefault})
+	s.hideInput(c)
 }
 
 func (s *TelnetServer) handleCreatePassword(c *client, password string) {
+	s.showInput(c)
 	if len(password) < 6 {
 		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Password must be at least 6 characters.", Color: presentation.ColorError})
 		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Enter password: ", Color: presentation.ColorDefault})
+		s.hideInput(c)
 		return
 	}
 	c.tempPassword = password
@@ -327,113 +454,251 @@ func (s *TelnetServer) handleCharacterCreationName(c *client, name string) {
 		return
 	}
 
-	// In a real MUD, you'd check for name uniqueness here.
+	existing, err := s.dal.PlayerCharacterDAL.GetCharacterByName(name)
+	if err != nil {
+		logrus.Infof("Error checking character name: %v", err)
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "An error occurred. Please try again.", Color: presentation.ColorError})
+		s.showCharacterSelection(c)
+		return
+	}
+	if existing != nil {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "That name is already taken.", Color: presentation.ColorError})
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Enter character name: ", Color: presentation.ColorDefault})
+		return
+	}
+
+	c.tempCharacterName = name
+	s.showRaceSelection(c)
+}
+
+func (s *TelnetServer) showRaceSelection(c *client) {
+	races, err := s.getSortedRaces()
+	if err != nil || len(races) == 0 {
+		logrus.Infof("Error getting races: %v", err)
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Error loading races.", Color: presentation.ColorError})
+		s.showCharacterSelection(c)
+		return
+	}
+
+	c.state = StateCharacterCreationRace
+	var msg strings.Builder
+	msg.WriteString("\n--- Choose a Race ---\n")
+	for i, race := range races {
+		msg.WriteString(fmt.Sprintf("%d. %s - %s\n", i+1, race.Name, race.Description))
+		msg.WriteString(fmt.Sprintf("   Base stats: %s\n", formatBaseStats(race.BaseStats)))
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: msg.String(), Color: presentation.ColorDefault})
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Choose your race: ", Color: presentation.ColorDefault})
+}
+
+func (s *TelnetServer) handleCharacterCreationRace(c *client, input string) {
+	races, err := s.getSortedRaces()
+	if err != nil {
+		logrus.Infof("Error getting races for selection: %v", err)
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Error loading races.", Color: presentation.ColorError})
+		s.showCharacterSelection(c)
+		return
+	}
+
+	var selected *models.Race
+	if idx, err := strconv.Atoi(input); err == nil && idx >= 1 && idx <= len(races) {
+		selected = races[idx-1]
+	} else {
+		for _, race := range races {
+			if strings.EqualFold(race.ID, input) || strings.EqualFold(race.Name, input) {
+				selected = race
+				break
+			}
+		}
+	}
+	if selected == nil {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Invalid race. Please enter a number or race name.", Color: presentation.ColorError})
+		s.showRaceSelection(c)
+		return
+	}
+
+	c.tempRaceID = selected.ID
+	s.showProfessionSelection(c)
+}
+
+func (s *TelnetServer) showProfessionSelection(c *client) {
+	professions, err := s.getSortedProfessions()
+	if err != nil || len(professions) == 0 {
+		logrus.Infof("Error getting professions: %v", err)
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Error loading professions.", Color: presentation.ColorError})
+		s.showCharacterSelection(c)
+		return
+	}
+
+	c.state = StateCharacterCreationProfession
+	var msg strings.Builder
+	msg.WriteString("\n--- Choose a Profession ---\n")
+	for i, prof := range professions {
+		msg.WriteString(fmt.Sprintf("%d. %s - %s\n", i+1, prof.Name, prof.Description))
+		var skills []string
+		for _, skillInfo := range prof.BaseSkills {
+			skillName := skillInfo.SkillID
+			if skill, err := s.dal.SkillDAL.GetSkillByID(skillInfo.SkillID); err == nil && skill != nil {
+				skillName = skill.Name
+			}
+			skills = append(skills, fmt.Sprintf("%s %d%%", skillName, skillInfo.Percentage))
+		}
+		if len(skills) > 0 {
+			msg.WriteString(fmt.Sprintf("   Starting skills: %s\n", strings.Join(skills, ", ")))
+		}
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: msg.String(), Color: presentation.ColorDefault})
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Choose your profession: ", Color: presentation.ColorDefault})
+}
+
+func (s *TelnetServer) handleCharacterCreationProfession(c *client, input string) {
+	professions, err := s.getSortedProfessions()
+	if err != nil {
+		logrus.Infof("Error getting professions for selection: %v", err)
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Error loading professions.", Color: presentation.ColorError})
+		s.showCharacterSelection(c)
+		return
+	}
+
+	var selected *models.Profession
+	if idx, err := strconv.Atoi(input); err == nil && idx >= 1 && idx <= len(professions) {
+		selected = professions[idx-1]
+	} else {
+		for _, prof := range professions {
+			if strings.EqualFold(prof.ID, input) || strings.EqualFold(prof.Name, input) {
+				selected = prof
+				break
+			}
+		}
+	}
+	if selected == nil {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Invalid profession. Please enter a number or profession name.", Color: presentation.ColorError})
+		s.showProfessionSelection(c)
+		return
+	}
 
 	character := &models.PlayerCharacter{
 		ID:              uuid.New().String(),
 		PlayerAccountID: c.account.ID,
-		Name:            name,
-		RaceID:          "human",     // Placeholder
-		ProfessionID:    "warrior",   // Placeholder
-		CurrentRoomID:   "bag_end",
+		Name:            c.tempCharacterName,
+		RaceID:          c.tempRaceID,
+		ProfessionID:    selected.ID,
+		CurrentRoomID:   startingRoomID,
 		Health:          100,
 		MaxHealth:       100,
+		Energy:          100,
+		MaxEnergy:       100,
 		Inventory:       "[]",
 		VisitedRoomIDs:  "[\"bag_end\"]",
 		CreatedAt:       time.Now(),
 	}
 
-	err := s.dal.PlayerCharacterDAL.CreateCharacter(character)
+	err = s.dal.PlayerCharacterDAL.CreateCharacter(character)
 	if err != nil {
 		logrus.Infof("Error creating character: %v", err)
+		// The name may have been claimed by someone else since it was checked.
+		if existing, _ := s.dal.PlayerCharacterDAL.GetCharacterByName(character.Name); existing != nil {
+			s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "That name is already taken.", Color: presentation.ColorError})
+			c.state = StateCharacterCreationName
+			s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Enter character name: ", Color: presentation.ColorDefault})
+			return
+		}
 		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Failed to create character.", Color: presentation.ColorError})
 		s.showCharacterSelection(c)
 		return
 	}
 
+	// Grant the profession's starting skills.
+	for _, skillInfo := range selected.BaseSkills {
+		playerSkill := &models.PlayerSkill{
+			PlayerID:            character.ID,
+			SkillID:             skillInfo.SkillID,
+			Percentage:          skillInfo.Percentage,
+			GrantedByEntityType: "Profession",
+			GrantedByEntityID:   selected.ID,
+		}
+		if err := s.dal.PlayerSkillDAL.CreatePlayerSkill(playerSkill); err != nil {
+			logrus.Errorf("Error granting starting skill %s to character %s: %v", skillInfo.SkillID, character.ID, err)
+		}
+	}
+
+	c.tempCharacterName = ""
+	c.tempRaceID = ""
 	c.character = character
 	s.enterGame(c)
-}
-
-func (s *TelnetServer) enterGame(c *client) {
-	c.state = StateInGame
-	s.connectionsMutex.Lock()
-	s.playerConnections[c.character.ID] = c
-	s.connectionsMutex.Unlock()
 
-	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf("Welcome, %s!", c.character.Name), Color: presentation.ColorSuccess})
-	s.renderRoomDescription(c)
+	// A class named after the profession is joined as the starting class,
+	// once the player is in the game to hear about its first rewards.
+	if _, err := s.progression.Join(character.ID, selected.ID); err != nil && !errors.Is(err, progression.ErrUnknownClass) {
+		logrus.Errorf("Error joining starting class %s for character %s: %v", selected.ID, character.ID, err)
+	}
 }
 
-func (s *TelnetServer) handleInGameInput(c *client, input string) {
-	processedInput := fmt.Sprintf("You typed: %s", input)
-	echoMsg := presentation.SemanticMessage{
-		Type:    presentation.SystemMessage,
-		Content: processedInput,
-		Color:   presentation.ColorDefault,
+// getSortedRaces returns all races ordered by name so that menu numbers are stable.
+func (s *TelnetServer) getSortedRaces() ([]*models.Race, error) {
+	races, err := s.dal.RaceDAL.GetAllRaces()
+	if err != nil {
+		return nil, err
 	}
-	s.sendMessage(c, echoMsg)
+	sort.Slice(races, func(i, j int) bool { return races[i].Name < races[j].Name })
+	return races, nil
+}
 
-	parts := strings.Fields(input)
-	if len(parts) == 0 {
-		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "What would you like to do?", Color: presentation.ColorDefault})
-		return
+// getSortedProfessions returns all professions ordered by name so that menu numbers are stable.
+func (s *TelnetServer) getSortedProfessions() ([]*models.Profession, error) {
+	professions, err := s.dal.ProfessionDAL.GetAllProfessions()
+	if err != nil {
+		return nil, err
 	}
+	sort.Slice(professions, func(i, j int) bool { return professions[i].Name < professions[j].Name })
+	return professions, nil
+}
 
-	actionType := parts[0]
-	var target string
-	if len(parts) > 1 {
-		target = strings.Join(parts[1:], " ")
+// formatBaseStats renders a stat map as "name value" pairs in a stable order.
+func formatBaseStats(stats map[string]int) string {
+	keys := make([]string, 0, len(stats))
+	for k := range stats {
+		keys = append(keys, k)
 	}
-
-	// Handle movement commands
-	switch strings.ToLower(actionType) {
-	case "n", "s", "e", "w", "u", "d":
-		s.handleMovement(c, actionType)
-	case "move":
-		if len(parts) > 1 {
-			s.handleMovement(c, parts[1])
-		} else {
-			s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Move where? (n, s, e, w, u, d)", Color: presentation.ColorWarning})
-		}
-	case "look":
-		s.renderRoomDescription(c)
-	case "talk":
-		if target == "" {
-			s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Talk to whom?", Color: presentation.ColorWarning})
-			return
-		}
-		s.handleTalkCommand(c, target)
-	case "gather":
-		if target == "" {
-			s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Gather what?", Color: presentation.ColorWarning})
-			return
-		}
-		s.handleGatherCommand(c, target)
-	case "give":
-		s.handleGiveCommand(c, input)
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s %d", k, stats[k]))
 	}
+	return strings.Join(parts, ", ")
+}
 
-	room, err := s.dal.RoomDAL.GetRoomByID(c.character.CurrentRoomID)
-	if err != nil || room == nil {
-		logrus.Infof("TelnetServer: Room %s not found for character %s or error: %v", c.character.CurrentRoomID, c.character.ID, err)
-		// Fallback to a default room
-		room, _ = s.dal.RoomDAL.GetRoomByID("bag_end")
-		if room == nil {
-			logrus.Fatalf("TelnetServer: Default room 'bag_end' not found. Database not seeded?")
-		}
-	}
+func (s *TelnetServer) enterGame(c *client) {
+	c.state = StateInGame
+	s.connectionsMutex.Lock()
+	s.playerConnections[c.character.ID] = c
+	s.connectionsMutex.Unlock()
 
-	actionEvent := &events.ActionEvent{
-		Player:     c.character,
-		ActionType: actionType,
-		Room:       room,
-		Timestamp:  time.Now(),
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf("Welcome, %s!", c.character.Name), Color: presentation.ColorSuccess})
+	s.sendVitals(c, c.character)
+	s.refreshStats(c)
+	s.sendInventory(c)
+	s.renderRoomDescription(c)
+	s.skills.Recover(c.character)
+	if err := s.progression.AnnounceChoices(c.character.ID); err != nil {
+		logrus.Errorf("TelnetServer: Failed to announce skill choices for character %s: %v", c.character.ID, err)
 	}
-	s.eventBus.Publish(events.ActionEventType, actionEvent)
 }
 
-func (s *TelnetServer) handleMovement(c *client, direction string) {
+// sendVitals pushes a character's vitals to clients that accept structured data.
+func (s *TelnetServer) sendVitals(c *client, character *models.PlayerCharacter) {
+	s.sendMessage(c, presentation.SemanticMessage{
+		Type: presentation.PlayerStatsUpdate,
+		Payload: map[string]interface{}{
+			"hp":        character.Health,
+			"maxhp":     character.MaxHealth,
+			"energy":    character.Energy,
+			"maxenergy": character.MaxEnergy,
+		},
+	})
+}
+
+func (s *TelnetServer) handleMovement(c *client, inv *commands.Invocation, direction string) {
 	room, err := s.dal.RoomDAL.GetRoomByID(c.character.CurrentRoomID)
 	if err != nil || room == nil {
 		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "You are in a strange place and cannot move.", Color: presentation.ColorError})
@@ -457,15 +722,24 @@ func (s *TelnetServer) handleMovement(c *client, direction string) {
 		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "That way is locked.", Color: presentation.ColorWarning})
 		return
 	}
+	if exit.IsClosed {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "The door is closed.", Color: presentation.ColorWarning})
+		return
+	}
+
+	if s.combat.InCombat(c.character.ID) {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "You are fighting for your life! Try to flee.", Color: presentation.ColorWarning})
+		return
+	}
 
 	// Update player's current room
-	c.character.CurrentRoomID = exit.TargetRoomID
-	err = s.dal.PlayerCharacterDAL.UpdateCharacter(c.character)
+	err = s.dal.PlayerCharacterDAL.UpdateCharacterRoom(c.character.ID, exit.TargetRoomID)
 	if err != nil {
 		logrus.Infof("Error updating character room: %v", err)
 		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "An error occurred while moving.", Color: presentation.ColorError})
 		return
 	}
+	c.character.CurrentRoomID = exit.TargetRoomID
 
 	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf("You move %s.", direction), Color: presentation.ColorDefault})
 	s.renderRoomDescription(c)
@@ -473,7 +747,7 @@ func (s *TelnetServer) handleMovement(c *client, direction string) {
 	// Publish a "move" action event
 	actionEvent := &events.ActionEvent{
 		Player:     c.character,
-		ActionType: "move",
+		ActionType: inv.Command.ActionType,
 		Room:       room, // Old room
 		Timestamp:  time.Now(),
 		Targets:    []interface{}{exit.TargetRoomID}, // Target is the new room ID
@@ -481,85 +755,26 @@ func (s *TelnetServer) handleMovement(c *client, direction string) {
 	s.eventBus.Publish(events.ActionEventType, actionEvent)
 }
 
-func (s *TelnetServer) handleTalkCommand(c *client, target string) {
-	// For now, just acknowledge the talk command and publish an event.
-	// Actual NPC interaction logic will be handled by the SentientEntityManager.
-	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf("You try to talk to %s.", target), Color: presentation.ColorDefault})
-
-	room, err := s.dal.RoomDAL.GetRoomByID(c.character.CurrentRoomID)
-	if err != nil || room == nil {
-		logrus.Infof("TelnetServer: Room %s not found for character %s or error: %v", c.character.CurrentRoomID, c.character.ID, err)
+func (s *TelnetServer) handleTalkCommand(c *client, inv *commands.Invocation) {
+	target := inv.ArgString()
+	if target == "" {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Talk to whom?", Color: presentation.ColorWarning})
 		return
 	}
 
-	actionEvent := &events.ActionEvent{
-		Player:     c.character,
-		ActionType: "talk",
-		Room:       room,
-		Timestamp:  time.Now(),
-		Targets:    []interface{}{target}, // Target is the NPC name/ID
-	}
-	s.eventBus.Publish(events.ActionEventType, actionEvent)
-}
-
-func (s *TelnetServer) handleGatherCommand(c *client, target string) {
-	// For now, just acknowledge the gather command and publish an event.
-	// Actual item gathering logic (e.g., checking if item exists in room, adding to inventory)
-	// will be handled by a game logic service.
-	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf("You attempt to gather %s.", target), Color: presentation.ColorDefault})
-
-	room, err := s.dal.RoomDAL.GetRoomByID(c.character.CurrentRoomID)
-	if err != nil || room == nil {
-		logrus.Infof("TelnetServer: Room %s not found for character %s or error: %v", c.character.CurrentRoomID, c.character.ID, err)
-		return
-	}
-
-	actionEvent := &events.ActionEvent{
-		Player:     c.character,
-		ActionType: "gather_item", // Specific action type for gathering
-		Room:       room,
-		Timestamp:  time.Now(),
-		Targets:    []interface{}{target}, // Target is the item name/ID
-	}
-	s.eventBus.Publish(events.ActionEventType, actionEvent)
+	// For now, just acknowledge the talk command and publish an event.
+	// Actual NPC interaction logic will be handled by the SentientEntityManager.
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf("You try to talk to %s.", target), Color: presentation.ColorDefault})
+	s.publishAction(c, inv.Command.ActionType, target) // Target is the NPC name/ID
 }
 
-func (s *TelnetServer) handleGiveCommand(c *client, input string) {
-	// Expected format: "give <item_name> to <npc_name>"
-	parts := strings.SplitN(input, " to ", 2)
-	if len(parts) != 2 {
-		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Invalid 'give' command format. Use: give <item> to <target>.", Color: presentation.ColorWarning})
-		return
-	}
-
-	itemPart := strings.TrimSpace(strings.TrimPrefix(parts[0], "give"))
-	targetPart := strings.TrimSpace(parts[1])
-
-	if itemPart == "" || targetPart == "" {
-		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Invalid 'give' command. Specify both item and target.", Color: presentation.ColorWarning})
-		return
-	}
-
-	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: fmt.Sprintf("You try to give %s to %s.", itemPart, targetPart), Color: presentation.ColorDefault})
-
-	room, err := s.dal.RoomDAL.GetRoomByID(c.character.CurrentRoomID)
-	if err != nil || room == nil {
-		logrus.Infof("TelnetServer: Room %s not found for character %s or error: %v", c.character.CurrentRoomID, c.character.ID, err)
-		return
-	}
-
-	actionEvent := &events.ActionEvent{
-		Player:     c.character,
-		ActionType: "deliver_item", // Specific action type for giving/delivering
-		Room:       room,
-		Timestamp:  time.Now(),
-		Targets:    []interface{}{itemPart, targetPart}, // Target is item and NPC
-	}
-	s.eventBus.Publish(events.ActionEventType, actionEvent)
+func (s *TelnetServer) renderRoomDescription(c *client) {
+	s.renderRoom(c, c.character.CurrentRoomID)
 }
 
-func (s *TelnetServer) renderRoomDescription(c *client) {
-	room, err := s.dal.RoomDAL.GetRoomByID(c.character.CurrentRoomID)
+// renderRoom describes a room to the client.
+func (s *TelnetServer) renderRoom(c *client, roomID string) {
+	room, err := s.dal.RoomDAL.GetRoomByID(roomID)
 	if err != nil || room == nil {
 		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "You are in a void. There is nothing to see here.", Color: presentation.ColorError})
 		return
@@ -569,10 +784,14 @@ func (s *TelnetServer) renderRoomDescription(c *client) {
 	json.Unmarshal([]byte(room.Exits), &exits) // Error handling already done in handleMovement
 
 	var exitDescriptions []string
+	exitTargets := make(map[string]string)
 	for dir, exit := range exits {
+		exitTargets[dir] = exit.TargetRoomID
 		status := ""
 		if exit.IsLocked {
 			status = " (locked)"
+		} else if exit.IsClosed {
+			status = " (closed)"
 		}
 		exitDescriptions = append(exitDescriptions, fmt.Sprintf("%s (%s%s)", dir, exit.TargetRoomID, status))
 	}
@@ -584,29 +803,65 @@ func (s *TelnetServer) renderRoomDescription(c *client) {
 	)
 
 	// List NPCs in the room
+	var npcNames []string
 	npcs, err := s.dal.NpcDAL.GetNPCsByRoom(room.ID)
-	if err == nil && len(npcs) > 0 {
-		roomDesc += "NPCs present: "
-		var npcNames []string
+	if err == nil {
 		for _, npc := range npcs {
-			npcNames = append(npcNames, npc.Name)
+			if npc.Health > 0 { // Slain NPCs are gone until they respawn
+				npcNames = append(npcNames, npc.Name)
+			}
+		}
+	}
+	if len(npcNames) > 0 {
+		roomDesc += "NPCs present: " + strings.Join(npcNames, ", ") + "\n"
+	}
+
+	// List items lying in the room
+	var itemNames []string
+	roomItems, err := s.items.RoomItems(room.ID)
+	if err == nil && len(roomItems) > 0 {
+		for _, item := range roomItems {
+			itemNames = append(itemNames, describeItem(item))
 		}
-		roomDesc += strings.Join(npcNames, ", ") + "\n"
+		roomDesc += "Items here: " + strings.Join(itemNames, ", ") + "\n"
 	}
 
-	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.RoomUpdate, Content: roomDesc, Color: presentation.ColorDefault})
+	s.sendMessage(c, presentation.SemanticMessage{
+		Type:    presentation.RoomUpdate,
+		Content: roomDesc,
+		Color:   presentation.ColorDefault,
+		Payload: map[string]interface{}{
+			"num":   room.ID,
+			"name":  room.Name,
+			"area":  room.TerritoryID,
+			"exits": exitTargets,
+			"npcs":  npcNames,
+			"items": itemNames,
+		},
+	})
 }
 
 // sendMessage sends a SemanticMessage to a specific connection.
+// Messages with no Content carry only structured data and are delivered solely
+// over GMCP, when the client negotiated it.
 func (s *TelnetServer) sendMessage(c *client, msg presentation.SemanticMessage) {
-	rendered := s.renderer.RenderMessage(msg)
-	logrus.Infof("Attempting to send to %s: %s", c.conn.RemoteAddr(), rendered)
-	_, err := c.writer.WriteString(rendered + "\n")
-	if err != nil {
-		logrus.Infof("Failed to write message to buffer for %s: %v", c.conn.RemoteAddr(), err)
+	if c.ws != nil {
+		s.sendWebSocketMessage(c, msg)
 		return
 	}
-	err = c.writer.Flush()
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	if msg.Content != "" {
+		rendered := s.renderer.RenderMessage(msg)
+		logrus.Infof("Attempting to send to %s: %s", c.conn.RemoteAddr(), rendered)
+		_, err := c.writer.WriteString(rendered + "\n")
+		if err != nil {
+			logrus.Infof("Failed to write message to buffer for %s: %v", c.conn.RemoteAddr(), err)
+			return
+		}
+	}
+	s.writeGMCPLocked(c, msg)
+	err := c.writer.Flush()
 	if err != nil {
 		logrus.Infof("Failed to flush buffer for %s: %v", c.conn.RemoteAddr(), err)
 	} else {
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"os"
@@ -16,7 +17,9 @@ import (
 	"mud/internal/game/actionsignificance"
 	"mud/internal/game/events"
 	"mud/internal/game/globalobserver"
+	"mud/internal/game/influence"
 	"mud/internal/game/perception"
+	"mud/internal/game/scheduler"
 	"mud/internal/game/sentiententitymanager"
 	"mud/internal/llm"
 	"mud/internal/mocks"
@@ -97,10 +100,11 @@ func setupTestEnvironment(t *testing.T) (*TelnetServer, *mocks.TestRenderer, str
 	eventBus := events.NewEventBus()
 
 	// 6. Initialize Perception Filter
-	perceptionFilter := perception.NewPerceptionFilter(dals.RoomDAL, dals.RaceDAL, dals.ProfessionDAL)
+	perceptionFilter := perception.NewPerceptionFilter(dals.RoomDAL, dals.RaceDAL, dals.ProfessionDAL, dals.SkillDAL, dals.PlayerSkillDAL, dals.StatusEffectDAL, nil)
 
 	// 7. Initialize Tool Dispatcher
-	toolDispatcher := NewToolDispatcher(dals)
+	ledger := influence.NewLedger(dals.OwnerDAL, dals.QuestmakerDAL, dals.QuestOwnerDAL, dals.InfluenceDAL)
+	toolDispatcher := NewToolDispatcher(dals, ledger, eventBus)
 
 	// 8. Initialize Telnet Renderer
 	telnetRenderer := mocks.NewTestRenderer()
@@ -121,13 +125,13 @@ func setupTestEnvironment(t *testing.T) (*TelnetServer, *mocks.TestRenderer, str
 	}()
 
 	// 11. Initialize Global Observer Manager
-	globalObserverManager := globalobserver.NewGlobalObserverManager(eventBus, perceptionFilter, dals.OwnerDAL, dals.RaceDAL, dals.ProfessionDAL)
+	globalObserverManager := globalobserver.NewGlobalObserverManager(eventBus, perceptionFilter, dals.OwnerDAL, dals.RaceDAL, dals.ProfessionDAL, ledger)
 	globalObserverEventChannel := make(chan interface{}, 1000)
 	eventBus.Subscribe(events.ActionEventType, globalObserverEventChannel)
 	go func() {
 		for event := range globalObserverEventChannel {
 			if ae, ok := event.(*events.ActionEvent); ok {
-				go globalObserverManager.HandleActionEvent(ae)
+				globalObserverManager.HandleActionEvent(ae)
 			}
 		}
 	}()
@@ -136,7 +140,7 @@ func setupTestEnvironment(t *testing.T) (*TelnetServer, *mocks.TestRenderer, str
 	listener, err := net.Listen("tcp", ":0") // Listen on a random available port
 	assert.NoError(t, err, "Failed to listen on a random port")
 	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
-	telnetServer := NewTelnetServer(listener, telnetRenderer, eventBus, dals, mockLLMService)
+	telnetServer := NewTelnetServer(listener, telnetRenderer, eventBus, dals, mockLLMService, scheduler.NewScheduler(scheduler.RealClock{}))
 
 	// Start Telnet server in a goroutine
 	go telnetServer.Start()
@@ -198,16 +202,22 @@ Welcome to GoMUD!
     testCharName := "TestChar"
     write(t, conn, testCharName)
 
+    assertEventuallyContains(t, renderer, "[system_message] Choose your race: \n")
+    assertEventuallyContains(t, renderer, "Base stats: charisma")
+    write(t, conn, "human")
+
+    assertEventuallyContains(t, renderer, "[system_message] Choose your profession: \n")
+    write(t, conn, "warrior")
+
     // --- In-Game ---
     assertEventuallyContains(t, renderer, fmt.Sprintf("[system_message] Welcome, %s!\n", testCharName))
-    assertEventuallyContains(t, renderer, "[room_update] \n--- Bag End, Hobbiton ---\nA cozy hobbit-hole, warm and inviting, with a round green door. The smell of pipe-weed and fresh baking lingers in the air. A path leads east.\nExits: east ()\nNPCs present: Frodo Baggins, Samwise Gamgee\n\n")
+    assertEventuallyContains(t, renderer, "[room_update] \n--- Bag End, Hobbiton ---\nA cozy hobbit-hole, warm and inviting, with a round green door. The smell of pipe-weed and fresh baking lingers in the air. A path leads east.\nExits: east (hobbiton_path)\nNPCs present: Frodo Baggins, Samwise Gamgee\nItems here: an oak walking stick, a green travelling cloak\n\n")
 
-    // Send a command
-    command := "look"
-    write(t, conn, command)
-
-    // Read echo response
-    assertEventuallyContains(t, renderer, "[system_message] You typed: look\n")
+    // Send a command; input is no longer echoed back
+    renderer.ClearRenderedMessages()
+    write(t, conn, "l")
+    assertEventuallyContains(t, renderer, "[room_update] \n--- Bag End, Hobbiton ---")
+    assert.NotContains(t, renderer.AllMessages(), "You typed")
 }
 
 // TestTelnetServer_QuestingFlow tests a basic questing scenario.
@@ -248,15 +258,21 @@ Welcome to GoMUD!
 	testCharName := "QuestPlayer"
 	write(t, conn, testCharName)
 
+	assertEventuallyContains(t, renderer, "[system_message] Choose your race: \n")
+	write(t, conn, "hobbit")
+
+	assertEventuallyContains(t, renderer, "[system_message] Choose your profession: \n")
+	write(t, conn, "rogue")
+
 	assertEventuallyContains(t, renderer, fmt.Sprintf("[system_message] Welcome, %s!\n", testCharName))
 
 	// --- Questing Flow ---
 	// Initial room description (Bag End)
-	assertEventuallyContains(t, renderer, "[room_update] \n--- Bag End, Hobbiton ---\nA cozy hobbit-hole, warm and inviting, with a round green door. The smell of pipe-weed and fresh baking lingers in the air. A path leads east.\nExits: east ()\nNPCs present: Frodo Baggins, Samwise Gamgee\n\n")
+	assertEventuallyContains(t, renderer, "[room_update] \n--- Bag End, Hobbiton ---\nA cozy hobbit-hole, warm and inviting, with a round green door. The smell of pipe-weed and fresh baking lingers in the air. A path leads east.\nExits: east (hobbiton_path)\nNPCs present: Frodo Baggins, Samwise Gamgee\nItems here: an oak walking stick, a green travelling cloak\n\n")
 
 	// Move to Hobbiton Path to find Farmer Maggot
 	write(t, conn, "east")
-	assertEventuallyContains(t, renderer, "[system_message] You typed: east\n")
+	assertEventuallyContains(t, renderer, "[system_message] You move east.\n")
 	assertEventuallyContains(t, renderer, "[room_update] \n--- Hobbiton Path ---")
 
 	// Talk to Farmer Maggot to initiate quest
@@ -267,20 +283,63 @@ Welcome to GoMUD!
 	// Gather mushrooms (5 times)
 	for i := 0; i < 5; i++ {
 		write(t, conn, "gather mushrooms")
-		assertEventuallyContains(t, renderer, "[system_message] You attempt to gather mushrooms.\n")
+		assertEventuallyContains(t, renderer, "[system_message] You gather Farmer Maggot's Prize Mushrooms.\n")
 		// Expect a message from the questmaker/owner about progress
 		assertEventuallyContains(t, renderer, "[narrative] The Great Mushroom Hunt Controller says: Excellent! You've gathered a mushroom. Keep up the good work!\n")
 	}
 
 	// Give mushrooms to Farmer Maggot to complete quest
 	write(t, conn, "give mushrooms to farmer_maggot")
-	assertEventuallyContains(t, renderer, "[system_message] You try to give mushrooms to farmer_maggot.\n")
+	assertEventuallyContains(t, renderer, "[system_message] You give Farmer Maggot's Prize Mushrooms to Farmer Maggot.\n")
 	assertEventuallyContains(t, renderer, "[narrative] Farmer Maggot says: Splendid! You've found them all! Here's a little something for your trouble.\n")
 	assertEventuallyContains(t, renderer, "[narrative] The Shire Council says: Well done, young one! Your efforts have brought great joy to Farmer Maggot and the Shire. Your reputation here grows!\n")
 
 	// Send a final command to ensure server is still responsive
 	write(t, conn, "look")
-	assertEventuallyContains(t, renderer, `[system_message] You typed: look`)
+	assertEventuallyContains(t, renderer, "[room_update] \n--- Hobbiton Path ---")
+}
+
+// TestTelnetServer_CharacterCreationDuplicateName tests that a taken name is rejected
+// and that the chosen profession's base skills are granted to the new character.
+func TestTelnetServer_CharacterCreationDuplicateName(t *testing.T) {
+	server, renderer, port, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	assert.NoError(t, err, "Failed to connect to Telnet server")
+	defer conn.Close()
+
+	write(t, conn, "2") // Create Account
+	write(t, conn, fmt.Sprintf("dupuser_%s", uuid.New().String()[:8]))
+	write(t, conn, "password123")
+	write(t, conn, "")
+	write(t, conn, "new")
+
+	assertEventuallyContains(t, renderer, "[system_message] Enter character name: \n")
+	write(t, conn, "testplayer") // Seeded character, different case
+	assertEventuallyContains(t, renderer, "[system_message] That name is already taken.\n")
+
+	write(t, conn, "Rosie")
+	assertEventuallyContains(t, renderer, "[system_message] Choose your race: \n")
+	write(t, conn, "1") // Races are listed by name; Dwarf comes first
+	assertEventuallyContains(t, renderer, "[system_message] Choose your profession: \n")
+	write(t, conn, "mage")
+	assertEventuallyContains(t, renderer, "[system_message] Welcome, Rosie!\n")
+
+	character, err := server.dal.PlayerCharacterDAL.GetCharacterByName("Rosie")
+	assert.NoError(t, err)
+	if assert.NotNil(t, character) {
+		assert.Equal(t, "dwarf", character.RaceID)
+		assert.Equal(t, "mage", character.ProfessionID)
+
+		skills, err := server.dal.PlayerSkillDAL.GetPlayerSkillsByPlayerID(character.ID)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, skills)
+		for _, skill := range skills {
+			assert.Equal(t, "Profession", skill.GrantedByEntityType)
+			assert.Equal(t, "mage", skill.GrantedByEntityID)
+		}
+	}
 }
 
 // Helper functions for testing
@@ -297,3 +356,389 @@ func write(t *testing.T, conn net.Conn, content string) {
 	time.Sleep(500 * time.Millisecond) // Give server a moment to process
 }
 
+
+// TestTelnetServer_CommandRegistry tests help, abbreviations and unknown command handling.
+func TestTelnetServer_CommandRegistry(t *testing.T) {
+	server, renderer, port, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	actionEvents := make(chan interface{}, 100)
+	server.eventBus.Subscribe(events.ActionEventType, actionEvents)
+
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	assert.NoError(t, err, "Failed to connect to Telnet server")
+	defer conn.Close()
+
+	write(t, conn, "1")
+	write(t, conn, "test")
+	write(t, conn, "password")
+	write(t, conn, "1")
+	assertEventuallyContains(t, renderer, "[system_message] Welcome, TestPlayer!\n")
+
+	write(t, conn, "help")
+	assertEventuallyContains(t, renderer, "give <item> to <target>")
+	assertEventuallyContains(t, renderer, "Type 'help <command>' for details.")
+
+	write(t, conn, "help l")
+	assertEventuallyContains(t, renderer, "[system_message] Usage: look\nAliases: l\nDescribe your surroundings.\n")
+
+	write(t, conn, "commands")
+	assertEventuallyContains(t, renderer, "[system_message] Available commands: choose, close, commands, consider, down, drop, east, examine, flee, gather, get, give, help, inventory, kill, lock, look, move, north, open, pick, practice, remove, score, skills, south, talk, unlock, up, use, wear, west, wield\n")
+
+	write(t, conn, "g")
+	assertEventuallyContains(t, renderer, "[system_message] 'g' is ambiguous. Did you mean: gather, get, give, move?\n")
+
+	// Drain events published so far, then check unknown verbs publish nothing.
+	for len(actionEvents) > 0 {
+		<-actionEvents
+	}
+	write(t, conn, "lokk")
+	assertEventuallyContains(t, renderer, "[system_message] Unknown command 'lokk'. Did you mean: lock, look?\n")
+	write(t, conn, "xyzzy")
+	assertEventuallyContains(t, renderer, "[system_message] Unknown command 'xyzzy'. Type 'commands' for a list of commands.\n")
+	assert.Equal(t, 0, len(actionEvents), "Unknown commands should not publish action events")
+
+	// Abbreviated commands publish the command's declared ActionType.
+	write(t, conn, "loo")
+	select {
+	case event := <-actionEvents:
+		assert.Equal(t, "observe_area", event.(*events.ActionEvent).ActionType)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected an action event for look")
+	}
+}
+
+// TestTelnetServer_Items tests picking up, equipping, dropping and giving items,
+// and that each change is persisted.
+func TestTelnetServer_Items(t *testing.T) {
+	server, renderer, port, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	assert.NoError(t, err, "Failed to connect to Telnet server")
+	defer conn.Close()
+
+	write(t, conn, "1")
+	write(t, conn, "test")
+	write(t, conn, "password")
+	write(t, conn, "1")
+	assertEventuallyContains(t, renderer, "[system_message] Welcome, TestPlayer!\n")
+
+	write(t, conn, "inventory")
+	assertEventuallyContains(t, renderer, "[inventory_update] You are carrying nothing.\n")
+
+	write(t, conn, "get stick")
+	assertEventuallyContains(t, renderer, "[system_message] You pick up an oak walking stick.\n")
+	write(t, conn, "take cloak")
+	assertEventuallyContains(t, renderer, "[system_message] You pick up a green travelling cloak.\n")
+	write(t, conn, "get stick")
+	assertEventuallyContains(t, renderer, "[system_message] You don't see that here.\n")
+
+	write(t, conn, "wear stick")
+	assertEventuallyContains(t, renderer, "[system_message] You can't wear that.\n")
+	write(t, conn, "wield stick")
+	assertEventuallyContains(t, renderer, "[system_message] You wield an oak walking stick.\n")
+	write(t, conn, "wear cloak")
+	assertEventuallyContains(t, renderer, "[system_message] You wear a green travelling cloak.\n")
+
+	write(t, conn, "i")
+	assertEventuallyContains(t, renderer, "[inventory_update] You are carrying nothing.\nYou are using:\n  <cloak> a green travelling cloak\n  <wielded> an oak walking stick\n")
+
+	held, err := server.dal.ItemInstanceDAL.GetInstancesByLocation(models.LocationCharacter, "test_character")
+	assert.NoError(t, err)
+	slots := make(map[string]string)
+	for _, instance := range held {
+		slots[instance.Slot] = instance.TemplateID
+	}
+	assert.Equal(t, map[string]string{"wielded": "oak_walking_stick", "cloak": "green_travelling_cloak"}, slots)
+
+	write(t, conn, "x cloak")
+	assertEventuallyContains(t, renderer, "[system_message] a green travelling cloak\nA hooded cloak of green wool, the kind hobbits wear on long walks in wet weather.\nIt can be worn (cloak).\n")
+
+	write(t, conn, "remove wielded")
+	assertEventuallyContains(t, renderer, "[system_message] You stop wielding an oak walking stick.\n")
+	write(t, conn, "drop stick")
+	assertEventuallyContains(t, renderer, "[system_message] You drop an oak walking stick.\n")
+	write(t, conn, "look")
+	assertEventuallyContains(t, renderer, "Items here: an oak walking stick\n")
+
+	// Items given to an NPC are held by the NPC.
+	write(t, conn, "east")
+	assertEventuallyContains(t, renderer, "Items here: Gaffer's Pipe-Weed Pouch\n")
+	write(t, conn, "get pouch")
+	assertEventuallyContains(t, renderer, "[system_message] You pick up Gaffer's Pipe-Weed Pouch.\n")
+	write(t, conn, "give pouch to nobody")
+	assertEventuallyContains(t, renderer, "[system_message] There is no one like that here.\n")
+	write(t, conn, "give pouch to gaffer")
+	assertEventuallyContains(t, renderer, "[system_message] You give Gaffer's Pipe-Weed Pouch to Old Gaffer Gamgee.\n")
+
+	held, err = server.dal.ItemInstanceDAL.GetInstancesByLocation(models.LocationNPC, "gaffer_gamgee")
+	assert.NoError(t, err)
+	if assert.Len(t, held, 1) {
+		assert.Equal(t, "gaffer_pipe_weed_pouch", held[0].TemplateID)
+	}
+
+	onPath, err := server.dal.ItemInstanceDAL.GetInstancesByLocation(models.LocationRoom, "hobbiton_path")
+	assert.NoError(t, err)
+	assert.Empty(t, onPath)
+}
+
+// TestTelnetServer_Doors tests unlocking, opening, closing and locking a door,
+// and that the door blocks movement while closed.
+func TestTelnetServer_Doors(t *testing.T) {
+	server, renderer, port, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	assert.NoError(t, err, "Failed to connect to Telnet server")
+	defer conn.Close()
+
+	write(t, conn, "1")
+	write(t, conn, "test")
+	write(t, conn, "password")
+	write(t, conn, "1")
+	assertEventuallyContains(t, renderer, "[system_message] Welcome, TestPlayer!\n")
+
+	write(t, conn, "open east")
+	assertEventuallyContains(t, renderer, "[system_message] There is no door that way.\n")
+
+	for _, dir := range []string{"east", "east", "east"} {
+		write(t, conn, dir)
+	}
+	assertEventuallyContains(t, renderer, "--- The Prancing Pony Inn ---")
+	assertEventuallyContains(t, renderer, "east (prancing_pony_private_room (locked))")
+
+	write(t, conn, "east")
+	assertEventuallyContains(t, renderer, "[system_message] That way is locked.\n")
+	write(t, conn, "unlock east")
+	assertEventuallyContains(t, renderer, "[system_message] You don't have the key.\n")
+	write(t, conn, "pick e")
+	assertEventuallyContains(t, renderer, "[system_message] You don't know how to pick locks.\n")
+
+	key := &models.ItemInstance{TemplateID: "rusty_key", LocationType: models.LocationCharacter, LocationID: "test_character"}
+	assert.NoError(t, server.dal.ItemInstanceDAL.CreateInstance(key))
+
+	write(t, conn, "unlock east")
+	assertEventuallyContains(t, renderer, "[system_message] You unlock the door to the east.\n")
+	write(t, conn, "east")
+	assertEventuallyContains(t, renderer, "[system_message] The door is closed.\n")
+	write(t, conn, "open east")
+	assertEventuallyContains(t, renderer, "[system_message] You open the door to the east.\n")
+	write(t, conn, "east")
+	assertEventuallyContains(t, renderer, "--- Prancing Pony Private Room ---")
+
+	// The door is shared with the other side.
+	write(t, conn, "lock west")
+	assertEventuallyContains(t, renderer, "[system_message] You need to close it first.\n")
+	write(t, conn, "close west")
+	assertEventuallyContains(t, renderer, "[system_message] You close the door to the west.\n")
+	write(t, conn, "lock west")
+	assertEventuallyContains(t, renderer, "[system_message] You lock the door to the west.\n")
+
+	room, err := server.dal.RoomDAL.GetRoomByID("prancing_pony")
+	assert.NoError(t, err)
+	var exits map[string]models.Exit
+	assert.NoError(t, json.Unmarshal([]byte(room.Exits), &exits))
+	assert.True(t, exits["east"].IsLocked)
+	assert.True(t, exits["east"].IsClosed)
+}
+
+// TestTelnetServer_Combat tests fighting an NPC to the death. Rounds are
+// fought by calling Tick directly rather than waiting for the combat loop.
+func TestTelnetServer_Combat(t *testing.T) {
+	server, renderer, port, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	assert.NoError(t, err, "Failed to connect to Telnet server")
+	defer conn.Close()
+
+	write(t, conn, "1")
+	write(t, conn, "test")
+	write(t, conn, "password")
+	write(t, conn, "1")
+	assertEventuallyContains(t, renderer, "[system_message] Welcome, TestPlayer!\n")
+
+	write(t, conn, "flee")
+	assertEventuallyContains(t, renderer, "[system_message] You aren't fighting anyone.\n")
+	write(t, conn, "kill nobody")
+	assertEventuallyContains(t, renderer, "[system_message] There is no one like that here.\n")
+	write(t, conn, "consider frodo")
+	assertEventuallyContains(t, renderer, "[system_message] Frodo Baggins would be an easy fight.\n")
+
+	write(t, conn, "kill frodo")
+	assertEventuallyContains(t, renderer, "[system_message] You attack Frodo Baggins!\n")
+	write(t, conn, "east")
+	assertEventuallyContains(t, renderer, "[system_message] You are fighting for your life! Try to flee.\n")
+
+	for i := 0; i < 200 && !renderer.ContainsMessage("Frodo Baggins is dead!"); i++ {
+		server.combat.Tick()
+	}
+	assertEventuallyContains(t, renderer, "[system_message] Frodo Baggins is dead!\n")
+
+	frodo, err := server.dal.NpcDAL.GetNPCByID("frodo_baggins")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, frodo.Health)
+
+	renderer.ClearRenderedMessages()
+	write(t, conn, "look")
+	assertEventuallyContains(t, renderer, "NPCs present: Samwise Gamgee\n")
+}
+
+// TestTelnetServer_Skills tests using a skill on an NPC.
+func TestTelnetServer_Skills(t *testing.T) {
+	server, renderer, port, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	err := server.dal.PlayerSkillDAL.CreatePlayerSkill(&models.PlayerSkill{PlayerID: "test_character", SkillID: "fireball", Percentage: 40})
+	assert.NoError(t, err)
+
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	assert.NoError(t, err, "Failed to connect to Telnet server")
+	defer conn.Close()
+
+	write(t, conn, "1")
+	write(t, conn, "test")
+	write(t, conn, "password")
+	write(t, conn, "1")
+	assertEventuallyContains(t, renderer, "[system_message] Welcome, TestPlayer!\n")
+
+	write(t, conn, "use")
+	assertEventuallyContains(t, renderer, "[system_message] Use what?\n")
+	write(t, conn, "use juggling")
+	assertEventuallyContains(t, renderer, "[system_message] You don't know that skill.\n")
+	write(t, conn, "use fireball")
+	assertEventuallyContains(t, renderer, "[system_message] Use it on whom?\n")
+
+	write(t, conn, "use fireball frodo")
+	assertEventuallyContains(t, renderer, "[system_message] You use Fireball.\n")
+	assertEventuallyContains(t, renderer, "[system_message] Your Fireball hits Frodo Baggins for 25 damage.\n")
+	write(t, conn, "use fireball frodo")
+	assertEventuallyContains(t, renderer, "[system_message] You are not ready to use that again.\n")
+
+	character, err := server.dal.PlayerCharacterDAL.GetCharacterByID("test_character")
+	assert.NoError(t, err)
+	assert.Equal(t, 90, character.Energy)
+}
+
+func TestTelnetServer_Progression(t *testing.T) {
+	server, renderer, port, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	// A skill use short of the next mage level, and a kill and a skill use
+	// short of the next warrior level.
+	assert.NoError(t, server.dal.PlayerClassDAL.CreatePlayerClass(&models.PlayerClass{PlayerID: "test_character", ClassID: "mage", Level: 3, Experience: 295}))
+	assert.NoError(t, server.dal.PlayerClassDAL.UpdatePlayerClass(&models.PlayerClass{PlayerID: "test_character", ClassID: "warrior", Level: 2, Experience: 170}))
+	assert.NoError(t, server.dal.PlayerSkillDAL.CreatePlayerSkill(&models.PlayerSkill{PlayerID: "test_character", SkillID: "fireball", Percentage: 40}))
+	assert.NoError(t, server.dal.PlayerSkillDAL.CreatePlayerSkill(&models.PlayerSkill{PlayerID: "test_character", SkillID: "shield_block", Percentage: 20}))
+
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	assert.NoError(t, err, "Failed to connect to Telnet server")
+	defer conn.Close()
+
+	write(t, conn, "1")
+	write(t, conn, "test")
+	write(t, conn, "password")
+	write(t, conn, "1")
+	assertEventuallyContains(t, renderer, "[system_message] Welcome, TestPlayer!\n")
+
+	write(t, conn, "choose 1")
+	assertEventuallyContains(t, renderer, "[system_message] You have no skills to choose from.\n")
+
+	write(t, conn, "use fireball samwise")
+	assertEventuallyContains(t, renderer, "[system_message] Samwise Gamgee is dead!\n")
+	assertEventuallyContains(t, renderer, "[system_message] You have reached level 4 as a Mage!\n")
+	assertEventuallyContains(t, renderer, "[system_message] As a level 4 Mage you may learn one of: 1) Keen Senses, 2) Ancient Languages. Type 'choose <number>' to decide.\n")
+	write(t, conn, "choose 3")
+	assertEventuallyContains(t, renderer, "[system_message] That is not one of the skills on offer.\n")
+	write(t, conn, "choose 2")
+	assertEventuallyContains(t, renderer, "[system_message] You have learned Ancient Languages.\n")
+
+	write(t, conn, "use shield block")
+	assertEventuallyContains(t, renderer, "[system_message] You have reached level 3 as a Warrior!\n")
+	assertEventuallyContains(t, renderer, "[system_message] You have learned Keen Senses.\n")
+
+	assert.Eventually(t, func() bool {
+		playerSkill, err := server.dal.PlayerSkillDAL.GetPlayerSkillByID("test_character", "keen_senses")
+		return err == nil && playerSkill != nil && playerSkill.GrantedByEntityID == "warrior"
+	}, 5*time.Second, 100*time.Millisecond)
+	mage, err := server.dal.PlayerClassDAL.GetPlayerClassByID("test_character", "mage")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, mage.Level)
+	assert.Equal(t, 5, mage.Experience)
+}
+
+func TestTelnetServer_SkillsAndPractice(t *testing.T) {
+	server, renderer, port, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	assert.NoError(t, server.dal.PlayerSkillDAL.CreatePlayerSkill(&models.PlayerSkill{PlayerID: "test_character", SkillID: "sword_mastery", Percentage: 5, GrantedByEntityType: "Profession", GrantedByEntityID: "warrior"}))
+	assert.NoError(t, server.dal.PlayerSkillDAL.CreatePlayerSkill(&models.PlayerSkill{PlayerID: "test_character", SkillID: "keen_senses", Percentage: 30}))
+
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	assert.NoError(t, err, "Failed to connect to Telnet server")
+	defer conn.Close()
+
+	write(t, conn, "1")
+	write(t, conn, "test")
+	write(t, conn, "password")
+	write(t, conn, "1")
+	assertEventuallyContains(t, renderer, "[system_message] Welcome, TestPlayer!\n")
+
+	write(t, conn, "skills")
+	assertEventuallyContains(t, renderer, "Keen Senses               30% (max 100%), ready, unknown origin\n")
+	assertEventuallyContains(t, renderer, "Sword Mastery              5% (max 20%), ready, from Profession warrior\n")
+	assertEventuallyContains(t, renderer, "Practice points: Warrior 2\n")
+
+	write(t, conn, "practice sword")
+	assertEventuallyContains(t, renderer, "[system_message] There is no one here to train you.\n")
+
+	glorfindel, err := server.dal.NpcDAL.GetNPCByID("glorfindel")
+	assert.NoError(t, err)
+	glorfindel.CurrentRoomID = "bag_end"
+	assert.NoError(t, server.dal.NpcDAL.UpdateNPC(glorfindel))
+
+	write(t, conn, "train")
+	assertEventuallyContains(t, renderer, "[system_message] Glorfindel can train you in: Sword Mastery (5%, max 20%). You have 2 practice points as a Warrior.\n")
+	write(t, conn, "practice keen")
+	assertEventuallyContains(t, renderer, "[system_message] Glorfindel cannot teach you Keen Senses.\n")
+	write(t, conn, "practice sword")
+	assertEventuallyContains(t, renderer, "[system_message] Glorfindel trains you in Sword Mastery. You gain 15%, reaching 20%.\n")
+	write(t, conn, "practice sword")
+	assertEventuallyContains(t, renderer, "[system_message] You cannot improve Sword Mastery any further until you advance in its class.\n")
+
+	warrior, err := server.dal.PlayerClassDAL.GetPlayerClassByID("test_character", "warrior")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, warrior.PracticePoints)
+}
+
+func TestTelnetServer_Score(t *testing.T) {
+	server, renderer, port, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	// Level 2 warriors gain a point of strength.
+	assert.NoError(t, server.dal.PlayerClassDAL.UpdatePlayerClass(&models.PlayerClass{PlayerID: "test_character", ClassID: "warrior", Level: 2}))
+
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	assert.NoError(t, err, "Failed to connect to Telnet server")
+	defer conn.Close()
+
+	write(t, conn, "1")
+	write(t, conn, "test")
+	write(t, conn, "password")
+	write(t, conn, "1")
+	assertEventuallyContains(t, renderer, "[system_message] Welcome, TestPlayer!\n")
+
+	write(t, conn, "score")
+	assertEventuallyContains(t, renderer, "--- TestPlayer ---\n")
+	assertEventuallyContains(t, renderer, "Strength:       11 (+0)\n")
+	assertEventuallyContains(t, renderer, "Wisdom:         10 (+0)\n")
+	assertEventuallyContains(t, renderer, "Armor:           0\n")
+	assertEventuallyContains(t, renderer, "Damage:        d2\n")
+	assertEventuallyContains(t, renderer, "Health:        100/100\n")
+
+	_, err = server.effects.Apply(&models.StatusEffect{TargetType: models.EffectTargetCharacter, TargetID: "test_character", SourceType: "item", SourceID: "potion", Type: models.EffectTypeModifier, Key: "strength", Magnitude: 3}, time.Minute)
+	assert.NoError(t, err)
+	write(t, conn, "stats")
+	assertEventuallyContains(t, renderer, "Strength:       14 (+2)\n")
+}
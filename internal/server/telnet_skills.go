@@ -0,0 +1,52 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+	"mud/internal/game/commands"
+	"mud/internal/game/skills"
+	"mud/internal/presentation"
+)
+
+// handleUseCommand uses a skill. The skills manager narrates the effects,
+// reports the new vitals and publishes the action, so only failures are sent
+// here. Each use may improve the skill.
+func (s *TelnetServer) handleUseCommand(c *client, inv *commands.Invocation) {
+	if len(inv.Args) == 0 {
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Use what?", Color: presentation.ColorWarning})
+		return
+	}
+	skill, err := s.skills.Use(c.character, inv.Args)
+	if err != nil {
+		s.sendSkillError(c, err)
+		return
+	}
+	s.improveSkill(c.character.ID, skill.ID, true)
+}
+
+// sendSkillError explains why a skill could not be used.
+func (s *TelnetServer) sendSkillError(c *client, err error) {
+	var content string
+	switch {
+	case errors.Is(err, skills.ErrUnknownSkill):
+		content = "You don't know that skill."
+	case errors.Is(err, skills.ErrPassiveSkill):
+		content = "That skill works on its own; there is nothing to use."
+	case errors.Is(err, skills.ErrClassLevelTooLow):
+		content = "You are not experienced enough in your class to use that."
+	case errors.Is(err, skills.ErrOnCooldown):
+		content = "You are not ready to use that again."
+	case errors.Is(err, skills.ErrNotEnoughEnergy):
+		content = "You don't have enough energy."
+	case errors.Is(err, skills.ErrNoTarget):
+		content = "Use it on whom?"
+	case errors.Is(err, skills.ErrTargetNotFound):
+		content = "There is no one like that here."
+	default:
+		logrus.Infof("TelnetServer: Skill use failed for character %s: %v", c.character.ID, err)
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Nothing happens.", Color: presentation.ColorError})
+		return
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: content, Color: presentation.ColorWarning})
+}
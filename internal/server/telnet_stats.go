@@ -0,0 +1,72 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"mud/internal/game/commands"
+	"mud/internal/presentation"
+)
+
+// refreshStats pushes the character's derived stats to clients that accept
+// structured data, but only when they differ from what was last sent.
+func (s *TelnetServer) refreshStats(c *client) {
+	if c.character == nil {
+		return
+	}
+	derived, err := s.stats.ForCharacter(c.character)
+	if err != nil {
+		logrus.Errorf("TelnetServer: Failed to derive stats for character %s: %v", c.character.ID, err)
+		return
+	}
+	current := derived.Map()
+
+	c.statsMutex.Lock()
+	unchanged := sameStats(c.stats, current)
+	c.stats = current
+	c.statsMutex.Unlock()
+	if unchanged {
+		return
+	}
+
+	payload := make(map[string]interface{}, len(current))
+	for stat, value := range current {
+		payload[stat] = value
+	}
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.PlayerStatsUpdate, Payload: payload})
+}
+
+func sameStats(a, b map[string]int) bool {
+	if a == nil || len(a) != len(b) {
+		return false
+	}
+	for stat, value := range a {
+		if other, ok := b[stat]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+// handleScoreCommand shows the character's attributes with their modifiers,
+// their armor and damage, and their vitals.
+func (s *TelnetServer) handleScoreCommand(c *client, inv *commands.Invocation) {
+	derived, err := s.stats.ForCharacter(c.character)
+	if err != nil {
+		logrus.Errorf("TelnetServer: Failed to derive stats for character %s: %v", c.character.ID, err)
+		s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: "Failed to work out your stats.", Color: presentation.ColorError})
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n--- %s ---\n", c.character.Name)
+	for _, attribute := range derived.Names() {
+		fmt.Fprintf(&b, "%-14s %3d (%+d)\n", presentation.Capitalize(attribute)+":", derived.Get(attribute), derived.Modifier(attribute))
+	}
+	fmt.Fprintf(&b, "%-14s %3d\n", "Armor:", derived.Armor)
+	fmt.Fprintf(&b, "%-14s d%d\n", "Damage:", derived.Damage)
+	fmt.Fprintf(&b, "%-14s %d/%d\n", "Health:", c.character.Health, c.character.MaxHealth)
+	fmt.Fprintf(&b, "%-14s %d/%d\n", "Energy:", c.character.Energy, c.character.MaxEnergy)
+	s.sendMessage(c, presentation.SemanticMessage{Type: presentation.SystemMessage, Content: b.String(), Color: presentation.ColorDefault})
+}
@@ -2,18 +2,113 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"mud/internal/dal"
+	"mud/internal/game/effects"
+	"mud/internal/game/events"
+	"mud/internal/game/influence"
+	"mud/internal/game/items"
+	"mud/internal/game/quests"
+	"mud/internal/game/scheduler"
+	"mud/internal/game/tools"
+	"mud/internal/game/world"
 	"mud/internal/llm"
 	"mud/internal/models"
+	"sync"
+
+	"github.com/sirupsen/logrus"
 )
 
+// errInvalidToolCall marks tool call errors the LLM caused, such as missing
+// parameters or IDs that name nothing. They are reported back to it instead
+// of stopping the batch.
+var errInvalidToolCall = errors.New("invalid tool call")
+
+// invalidCall returns an errInvalidToolCall explaining what was wrong.
+func invalidCall(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", errInvalidToolCall, fmt.Sprintf(format, args...))
+}
+
 type ToolDispatcher struct {
-	dal *dal.DAL
+	dal      *dal.DAL
+	ledger   *influence.Ledger
+	eventBus *events.EventBus
+	notifier world.Notifier
+	items    *items.Manager
+	effects  *effects.Manager
+	world    *world.Manager
+	quests   *quests.Manager
+	tools    *tools.Registry
+	prompts  PromptCache
+
+	// mu is held while a batch of tool calls runs, and while expired world
+	// changes are swept. batch is the dispatcher the batch's calls run on,
+	// and changed holds the IDs of the entities its calls changed.
+	mu      sync.Mutex
+	batch   *ToolDispatcher
+	changed map[string]bool
+}
+
+// PromptCache holds the prompts entities answer players by, which tell what
+// they remember and how they behave.
+type PromptCache interface {
+	// ForgetEntity drops the prompts cached for an entity.
+	ForgetEntity(entityID string)
+}
+
+// NewToolDispatcher creates a new ToolDispatcher with every built-in tool
+// registered. Tools only change NPC effects, so its effect manager needs no
+// notifier; the world and quest managers announce their changes over the
+// event bus.
+func NewToolDispatcher(dal *dal.DAL, ledger *influence.Ledger, eventBus *events.EventBus) *ToolDispatcher {
+	td := newToolDispatcher(dal, ledger, eventBus, busNotifier{eventBus})
+	td.tools = tools.NewRegistry()
+	for _, definitions := range [][]tools.Definition{td.memoryTools(), td.questmakerTools(), td.worldTools()} {
+		for _, def := range definitions {
+			td.tools.MustRegister(def)
+		}
+	}
+	return td
+}
+
+// newToolDispatcher creates a dispatcher whose managers work through dal and
+// tell players what happens through notifier.
+func newToolDispatcher(dal *dal.DAL, ledger *influence.Ledger, eventBus *events.EventBus, notifier world.Notifier) *ToolDispatcher {
+	itemManager := items.NewManager(dal.RoomDAL, dal.ItemDAL, dal.ItemInstanceDAL, dal.NpcDAL)
+	return &ToolDispatcher{
+		dal:      dal,
+		ledger:   ledger,
+		eventBus: eventBus,
+		notifier: notifier,
+		items:    itemManager,
+		effects:  effects.NewManager(dal.StatusEffectDAL, nil),
+		world:    world.NewManager(dal.RoomDAL, dal.NpcDAL, dal.PlayerCharacterDAL, dal.WorldDAL, itemManager, notifier),
+		quests:   quests.NewManager(dal.QuestDAL, dal.PlayerQuestState, dal.NpcDAL, notifier),
+	}
+}
+
+// UsePromptCache has the dispatcher drop the cached prompts of the entities
+// its tools change, once their changes commit.
+func (td *ToolDispatcher) UsePromptCache(prompts PromptCache) {
+	td.prompts = prompts
+}
+
+// Tools returns the registry of the tools the dispatcher runs, which also
+// describes them in prompts.
+func (td *ToolDispatcher) Tools() *tools.Registry {
+	return td.tools
 }
 
-func NewToolDispatcher(dal *dal.DAL) *ToolDispatcher {
-	return &ToolDispatcher{dal: dal}
+// Schedule has the scheduler end the world events and revert the room
+// changes that tools made once they expire. A sweep waits for any batch of
+// tool calls under way.
+func (td *ToolDispatcher) Schedule(s *scheduler.Scheduler) *scheduler.Job {
+	return s.Every("world expiry", td.world.SweepInterval, func() {
+		td.mu.Lock()
+		defer td.mu.Unlock()
+		td.world.Sweep()
+	})
 }
 
 type ToolCall struct {
@@ -21,94 +116,363 @@ type ToolCall struct {
 	Parameters map[string]interface{} `json:"parameters"`
 }
 
-func (td *ToolDispatcher) Dispatch(ctx context.Context, player *models.PlayerCharacter, entity interface{}, toolCalls []llm.ToolCall) error {
+// toolMethod is a ToolDispatcher method that carries out a tool call.
+type toolMethod func(td *ToolDispatcher, player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error)
+
+// handler returns a tools.Handler that runs method on the dispatcher of the
+// batch under way, so that it works within the batch's transaction.
+func (td *ToolDispatcher) handler(method toolMethod) tools.Handler {
+	return func(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+		batch := td.batch
+		if batch == nil {
+			batch = td
+		}
+		return method(batch, player, entity, params)
+	}
+}
+
+// Dispatch executes the tool calls of one LLM response in order and reports
+// what became of each. Calls to tools the entity does not list, or may not
+// use, are forbidden, and calls to tools that do not exist or whose
+// parameters do not match the tool's schema are invalid; both are reported
+// and the rest still run. Owners,
+// Questmakers and Quest Owners pay for each valid call from their influence
+// budget before it runs; a call the budget cannot cover is refused. Calls
+// the handler finds invalid are refunded.
+//
+// The batch is a single unit of work: its changes, charges and refunds are
+// made in one transaction, and players are told what happened only once it
+// commits. Any other failure rolls the whole batch back and is returned
+// without results.
+func (td *ToolDispatcher) Dispatch(ctx context.Context, player *models.PlayerCharacter, entity interface{}, toolCalls []llm.ToolCall) ([]llm.ToolResult, error) {
+	td.mu.Lock()
+	defer td.mu.Unlock()
+
+	tx, err := td.dal.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin tool calls: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil {
+			logrus.Errorf("ToolDispatcher: %v", err)
+		}
+	}()
+	pending := &pendingNotifier{}
+	changed := make(map[string]bool)
+	td.batch = newToolDispatcher(tx.DAL, nil, td.eventBus, pending)
+	td.batch.changed = changed
+	defer func() { td.batch = nil }()
+
+	var results []llm.ToolResult
+	run := func(ledger *influence.Ledger) error {
+		if results, err = td.dispatch(ledger, player, entity, toolCalls); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+	if td.ledger != nil {
+		err = td.ledger.Within(tx.DAL, run)
+	} else {
+		err = run(nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pending.flush(busNotifier{td.eventBus})
+	if td.prompts != nil {
+		for entityID := range changed {
+			td.prompts.ForgetEntity(entityID)
+		}
+	}
+	return results, nil
+}
+
+// dispatch runs a batch of tool calls, paying for them from ledger when it is not nil.
+func (td *ToolDispatcher) dispatch(ledger *influence.Ledger, player *models.PlayerCharacter, entity interface{}, toolCalls []llm.ToolCall) ([]llm.ToolResult, error) {
+	entityType, entityID, budgeted := influence.Entity(entity)
+
+	var results []llm.ToolResult
 	for _, call := range toolCalls {
-		switch call.ToolName {
-		case "NPC_memorize":
-			if err := td.handleNPCMemorize(player, entity, call.Parameters); err != nil {
-				return err
+		result := llm.ToolResult{ToolName: call.ToolName, Status: llm.ToolStatusOK}
+		tool, err := td.tools.Authorize(entity, call.ToolName)
+		if errors.Is(err, tools.ErrForbidden) {
+			result.Status, result.Message = llm.ToolStatusForbidden, err.Error()
+			results = append(results, result)
+			continue
+		}
+		if errors.Is(err, tools.ErrUnknownTool) {
+			result.Status, result.Message = llm.ToolStatusInvalid, err.Error()
+			results = append(results, result)
+			continue
+		}
+		if err != nil {
+			return results, err
+		}
+		if err := tool.Validate(call.Parameters); err != nil {
+			result.Status, result.Message = llm.ToolStatusInvalid, err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		if budgeted && ledger != nil {
+			result.Cost = tool.Cost.Charge(call.Cost)
+			if _, err := ledger.Charge(entityType, entityID, result.Cost, call.ToolName); err != nil {
+				if !errors.Is(err, influence.ErrInsufficientBudget) {
+					return results, err
+				}
+				result.Status, result.Cost, result.Message = llm.ToolStatusRefused, 0, err.Error()
+				results = append(results, result)
+				continue
 			}
-		case "OWNER_memorize":
-			if err := td.handleOwnerMemorize(player, entity, call.Parameters); err != nil {
-				return err
+		}
+
+		data, err := tool.Handler(player, entity, call.Parameters)
+		if err != nil {
+			if !errors.Is(err, errInvalidToolCall) {
+				return results, err
 			}
-		case "OWNER_memorize_dependables":
-			if err := td.handleOwnerMemorizeDependables(player, entity, call.Parameters); err != nil {
-				return err
+			if result.Cost > 0 {
+				if _, err := ledger.Credit(entityType, entityID, result.Cost, "refund: "+call.ToolName); err != nil {
+					return results, fmt.Errorf("failed to refund %s for %s %s: %w", call.ToolName, entityType, entityID, err)
+				}
 			}
-		default:
-			return fmt.Errorf("unknown tool: %s", call.ToolName)
+			result.Status, result.Cost, result.Message = llm.ToolStatusInvalid, 0, err.Error()
+			results = append(results, result)
+			continue
 		}
+		result.Data = data
+		results = append(results, result)
 	}
 
-	return nil
+	return results, nil
 }
 
-func (td *ToolDispatcher) handleNPCMemorize(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) error {
-	npcID, ok := params["npc_id"].(string)
-	if !ok {
-		return fmt.Errorf("missing or invalid npc_id")
+// objectSchema returns the JSON Schema of a tool's parameters.
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
 	}
-	// Use player.ID directly if available, otherwise get from params
-	var playerID string
-	if player != nil {
-		playerID = player.ID
-	} else {
-		pid, ok := params["player_id"].(string)
-		if !ok {
-			return fmt.Errorf("missing or invalid player_id")
-		}
-		playerID = pid
+	return schema
+}
+
+// property returns the JSON Schema of a parameter of a simple type.
+func property(typ, description string) map[string]interface{} {
+	return map[string]interface{}{"type": typ, "description": description}
+}
+
+// stringParam returns a string parameter, or an invalid call error if it is
+// required and missing.
+func stringParam(params map[string]interface{}, name string, required bool) (string, error) {
+	value, ok := params[name].(string)
+	if required && (!ok || value == "") {
+		return "", invalidCall("missing or invalid %s", name)
 	}
+	if !ok && params[name] != nil {
+		return "", invalidCall("%s must be a string", name)
+	}
+	return value, nil
+}
 
-	memory, ok := params["memory_string"].(string)
+// numberParam returns a numeric parameter, or fallback if it is missing.
+func numberParam(params map[string]interface{}, name string, fallback float64) (float64, error) {
+	value, present := params[name]
+	if !present || value == nil {
+		return fallback, nil
+	}
+	number, ok := tools.Number(value)
 	if !ok {
-		return fmt.Errorf("missing or invalid memory_string")
+		return 0, invalidCall("%s must be a number", name)
+	}
+	return number, nil
+}
+
+// playerParam returns the ID of the player a tool call is about: the named
+// parameter, or else the player the entity is reacting to.
+func playerParam(player *models.PlayerCharacter, params map[string]interface{}, name string) (string, error) {
+	playerID, err := stringParam(params, name, player == nil)
+	if err != nil || playerID != "" {
+		return playerID, err
 	}
+	return player.ID, nil
+}
 
+// targetPlayer loads the character a tool call is about; see playerParam.
+func (td *ToolDispatcher) targetPlayer(player *models.PlayerCharacter, params map[string]interface{}, name string) (*models.PlayerCharacter, error) {
+	playerID, err := playerParam(player, params, name)
+	if err != nil {
+		return nil, err
+	}
+	if player != nil && player.ID == playerID {
+		return player, nil
+	}
+	character, err := td.dal.PlayerCharacterDAL.GetCharacterByID(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get character %s: %w", playerID, err)
+	}
+	if character == nil {
+		return nil, invalidCall("player not found: %s", playerID)
+	}
+	return character, nil
+}
+
+// targetNPC loads the NPC named by a parameter.
+func (td *ToolDispatcher) targetNPC(params map[string]interface{}, name string) (*models.NPC, error) {
+	npcID, err := stringParam(params, name, true)
+	if err != nil {
+		return nil, err
+	}
 	npc, err := td.dal.NpcDAL.GetNPCByID(npcID)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to get NPC %s: %w", npcID, err)
 	}
 	if npc == nil {
-		return fmt.Errorf("npc not found: %s", npcID)
+		return nil, invalidCall("npc not found: %s", npcID)
+	}
+	return npc, nil
+}
+
+// entityChanged records that a call changed an NPC, Owner or Questmaker, so
+// that its cached prompts are dropped once the batch commits.
+func (td *ToolDispatcher) entityChanged(entityID string) {
+	if td.changed != nil {
+		td.changed[entityID] = true
+	} else if td.prompts != nil {
+		td.prompts.ForgetEntity(entityID)
 	}
+}
+
+// tell sends a line of narration to a player.
+func (td *ToolDispatcher) tell(playerID, content string) {
+	td.notifier.Notify(playerID, content)
+}
+
+// busNotifier delivers narration to players as messages on the event bus.
+type busNotifier struct {
+	eventBus *events.EventBus
+}
+
+func (n busNotifier) Notify(characterID, content string) {
+	if n.eventBus != nil {
+		n.eventBus.Publish(events.PlayerMessageEventType, &events.PlayerMessageEvent{PlayerID: characterID, Content: content})
+	}
+}
+
+// pendingNotifier holds narration back until its batch of tool calls
+// commits, so that players are never told of changes that were rolled back.
+type pendingNotifier struct {
+	messages []events.PlayerMessageEvent
+}
+
+func (n *pendingNotifier) Notify(characterID, content string) {
+	n.messages = append(n.messages, events.PlayerMessageEvent{PlayerID: characterID, Content: content})
+}
+
+// flush delivers the held back narration in the order it was sent.
+func (n *pendingNotifier) flush(to world.Notifier) {
+	for _, message := range n.messages {
+		to.Notify(message.PlayerID, message.Content)
+	}
+	n.messages = nil
+}
 
+// memoryTools are the tools entities use to remember players.
+func (td *ToolDispatcher) memoryTools() []tools.Definition {
+	playerID := property("string", "The player the memory is about. Defaults to the player you are reacting to.")
+	memory := property("string", "What to remember about the player.")
+	return []tools.Definition{
+		{
+			Name:        "NPC_memorize",
+			Description: "Remember something about a player.",
+			Parameters: objectSchema(map[string]interface{}{
+				"player_id":     playerID,
+				"memory_string": memory,
+			}, "memory_string"),
+			Entities: []string{tools.EntityNPC},
+			Handler:  td.handler((*ToolDispatcher).handleNPCMemorize),
+		},
+		{
+			Name:        "OWNER_memorize",
+			Description: "Remember something about a player as an Owner.",
+			Parameters: objectSchema(map[string]interface{}{
+				"player_id":     playerID,
+				"memory_string": memory,
+			}, "memory_string"),
+			Entities: []string{tools.EntityOwner},
+			Cost:     tools.Cost{Min: 1, Max: 5},
+			Handler:  td.handler((*ToolDispatcher).handleOwnerMemorize),
+		},
+		{
+			Name:        "OWNER_memorize_dependables",
+			Description: "Share a memory about a player with every NPC you watch over.",
+			Parameters: objectSchema(map[string]interface{}{
+				"player_id":     playerID,
+				"memory_string": memory,
+			}, "memory_string"),
+			Entities: []string{tools.EntityOwner},
+			Cost:     tools.Cost{Min: 5, Max: 15},
+			Handler:  td.handler((*ToolDispatcher).handleOwnerMemorizeDependables),
+		},
+	}
+}
+
+// handleNPCMemorize records a memory about a player in the calling NPC.
+func (td *ToolDispatcher) handleNPCMemorize(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+	caller, ok := entity.(*models.NPC)
+	if !ok {
+		return nil, invalidCall("only an NPC can memorize as one")
+	}
+	playerID, err := playerParam(player, params, "player_id")
+	if err != nil {
+		return nil, err
+	}
+	memory, err := stringParam(params, "memory_string", true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reload the NPC so memories recorded since it was loaded are kept.
+	npc, err := td.dal.NpcDAL.GetNPCByID(caller.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get NPC %s: %w", caller.ID, err)
+	}
+	if npc == nil {
+		return nil, invalidCall("npc not found: %s", caller.ID)
+	}
 	if npc.MemoriesAboutPlayers == nil {
 		npc.MemoriesAboutPlayers = make(map[string][]string)
 	}
 	npc.MemoriesAboutPlayers[playerID] = append(npc.MemoriesAboutPlayers[playerID], memory)
 
-	return td.dal.NpcDAL.UpdateNPC(npc)
+	if err := td.dal.NpcDAL.UpdateNPC(npc); err != nil {
+		return nil, err
+	}
+	td.entityChanged(npc.ID)
+	return map[string]interface{}{"npc_id": npc.ID, "player_id": playerID}, nil
 }
 
-func (td *ToolDispatcher) handleOwnerMemorize(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) error {
-	ownerID, ok := params["owner_id"].(string)
+// handleOwnerMemorize records a memory about a player in the calling Owner.
+func (td *ToolDispatcher) handleOwnerMemorize(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+	caller, ok := entity.(*models.Owner)
 	if !ok {
-		return fmt.Errorf("missing or invalid owner_id")
+		return nil, invalidCall("only an Owner can memorize as one")
 	}
-	// Use player.ID directly if available, otherwise get from params
-	var playerID string
-	if player != nil {
-		playerID = player.ID
-	} else {
-		pid, ok := params["player_id"].(string)
-		if !ok {
-			return fmt.Errorf("missing or invalid player_id")
-		}
-		playerID = pid
+	playerID, err := playerParam(player, params, "player_id")
+	if err != nil {
+		return nil, err
 	}
-	memory, ok := params["memory_string"].(string)
-	if !ok {
-		return fmt.Errorf("missing or invalid memory_string")
+	memory, err := stringParam(params, "memory_string", true)
+	if err != nil {
+		return nil, err
 	}
 
-	owner, err := td.dal.OwnerDAL.GetOwnerByID(ownerID)
+	// Reload the Owner so memories recorded since it was loaded are kept.
+	owner, err := td.dal.OwnerDAL.GetOwnerByID(caller.ID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if owner == nil {
-		return fmt.Errorf("owner not found: %s", ownerID)
+		return nil, invalidCall("owner not found: %s", caller.ID)
 	}
 
 	if owner.MemoriesAboutPlayers == nil {
@@ -116,46 +480,46 @@ func (td *ToolDispatcher) handleOwnerMemorize(player *models.PlayerCharacter, en
 	}
 	owner.MemoriesAboutPlayers[playerID] = append(owner.MemoriesAboutPlayers[playerID], memory)
 
-	return td.dal.OwnerDAL.UpdateOwner(owner)
+	if err := td.dal.OwnerDAL.UpdateOwner(owner); err != nil {
+		return nil, err
+	}
+	td.entityChanged(owner.ID)
+	return map[string]interface{}{"owner_id": owner.ID, "player_id": playerID}, nil
 }
 
-func (td *ToolDispatcher) handleOwnerMemorizeDependables(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) error {
-	ownerID, ok := params["owner_id"].(string)
+// handleOwnerMemorizeDependables records a memory about a player in every
+// NPC the calling Owner watches over.
+func (td *ToolDispatcher) handleOwnerMemorizeDependables(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+	caller, ok := entity.(*models.Owner)
 	if !ok {
-		return fmt.Errorf("missing or invalid owner_id")
+		return nil, invalidCall("only an Owner can share memories with its NPCs")
 	}
-	// Use player.ID directly if available, otherwise get from params
-	var playerID string
-	if player != nil {
-		playerID = player.ID
-	} else {
-		pid, ok := params["player_id"].(string)
-		if !ok {
-			return fmt.Errorf("missing or invalid player_id")
-		}
-		playerID = pid
+	playerID, err := playerParam(player, params, "player_id")
+	if err != nil {
+		return nil, err
 	}
-	memory, ok := params["memory_string"].(string)
-	if !ok {
-		return fmt.Errorf("missing or invalid memory_string")
+	memory, err := stringParam(params, "memory_string", true)
+	if err != nil {
+		return nil, err
 	}
 
-	npcs, err := td.dal.NpcDAL.GetNPCsByOwner(ownerID)
+	npcs, err := td.dal.NpcDAL.GetNPCsByOwner(caller.ID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	var npcIDs []string
 	for _, npc := range npcs {
 		if npc.MemoriesAboutPlayers == nil {
 			npc.MemoriesAboutPlayers = make(map[string][]string)
 		}
 		npc.MemoriesAboutPlayers[playerID] = append(npc.MemoriesAboutPlayers[playerID], memory)
 		if err := td.dal.NpcDAL.UpdateNPC(npc); err != nil {
-			return err
+			return nil, err
 		}
+		td.entityChanged(npc.ID)
+		npcIDs = append(npcIDs, npc.ID)
 	}
 
-	return nil
+	return map[string]interface{}{"owner_id": caller.ID, "player_id": playerID, "npc_ids": npcIDs}, nil
 }
-
-
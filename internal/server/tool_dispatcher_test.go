@@ -0,0 +1,512 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"mud/internal/dal"
+	"mud/internal/game/events"
+	"mud/internal/game/influence"
+	"mud/internal/game/tools"
+	"mud/internal/llm"
+	"mud/internal/models"
+)
+
+func setupToolDispatcher(t *testing.T) (*ToolDispatcher, *dal.DAL, *influence.Ledger, chan interface{}) {
+	t.Helper()
+
+	tmpfile, err := os.CreateTemp("", "tool_dispatcher_test_*.sqlite")
+	if err != nil {
+		t.Fatalf("Failed to create temp file for test database: %v", err)
+	}
+	db, err := dal.InitDB(tmpfile.Name())
+	if err != nil {
+		t.Fatalf("Failed to initialize test database: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		os.Remove(tmpfile.Name())
+	})
+
+	dals := dal.NewDAL(db)
+	eventBus := events.NewEventBus()
+	messages := make(chan interface{}, 10)
+	eventBus.Subscribe(events.PlayerMessageEventType, messages)
+	ledger := influence.NewLedger(dals.OwnerDAL, dals.QuestmakerDAL, dals.QuestOwnerDAL, dals.InfluenceDAL)
+	return NewToolDispatcher(dals, ledger, eventBus), dals, ledger, messages
+}
+
+// toolList returns the AvailableTools of an entity that lists the named tools.
+func toolList(names ...string) []models.Tool {
+	list := make([]models.Tool, len(names))
+	for i, name := range names {
+		list[i] = models.Tool{Name: name}
+	}
+	return list
+}
+
+// nextMessage returns the content of the next message sent to a player.
+func nextMessage(t *testing.T, messages chan interface{}) string {
+	t.Helper()
+	select {
+	case event := <-messages:
+		return event.(*events.PlayerMessageEvent).Content
+	default:
+		t.Fatal("Expected a message to the player")
+		return ""
+	}
+}
+
+func TestToolDispatcher_InfluenceCosts(t *testing.T) {
+	dispatcher, dals, ledger, _ := setupToolDispatcher(t)
+	dispatcher.Tools().MustRegister(tools.Definition{
+		Name:     "read_the_stars",
+		Entities: []string{tools.EntityOwner},
+		Cost:     tools.Cost{Min: 1, Max: 1},
+		Handler: func(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+			return nil, invalidCall("the stars are hidden")
+		},
+	})
+	owner := &models.Owner{ID: "spirit", Name: "Spirit", CurrentInfluenceBudget: 10, MaxInfluenceBudget: 100, AvailableTools: toolList("OWNER_memorize", "OWNER_memorize_dependables", "read_the_stars")}
+	assert.NoError(t, dals.OwnerDAL.CreateOwner(owner))
+	player := &models.PlayerCharacter{ID: "hero", Name: "Hero"}
+	memorize := map[string]interface{}{"memory_string": "Prayed at the shrine"}
+
+	results, err := dispatcher.Dispatch(context.Background(), player, owner, []llm.ToolCall{
+		{ToolName: "OWNER_memorize", Parameters: memorize, Cost: 3},
+		{ToolName: "OWNER_memorize_dependables", Parameters: memorize, Cost: 10},
+		{ToolName: "OWNER_memorize", Parameters: memorize, Cost: 100},
+	})
+	assert.NoError(t, err)
+	memorized := map[string]interface{}{"owner_id": "spirit", "player_id": "hero"}
+	assert.Equal(t, []llm.ToolResult{
+		{ToolName: "OWNER_memorize", Status: llm.ToolStatusOK, Cost: 3, Data: memorized},
+		{ToolName: "OWNER_memorize_dependables", Status: llm.ToolStatusRefused, Message: "insufficient influence budget: it costs 10.0 and only 7.0 of 100.0 is left"},
+		{ToolName: "OWNER_memorize", Status: llm.ToolStatusOK, Cost: 5, Data: memorized},
+	}, results, "Proposed costs are kept within the tool's range")
+
+	current, _, err := ledger.Balance(models.InfluenceOwner, "spirit")
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, current)
+
+	// A call that does not match the tool's schema is reported without being
+	// charged, and the batch goes on.
+	results, err = dispatcher.Dispatch(context.Background(), player, owner, []llm.ToolCall{
+		{ToolName: "OWNER_memorize", Parameters: map[string]interface{}{"player_id": "hero"}, Cost: 1},
+		{ToolName: "OWNER_memorize", Parameters: map[string]interface{}{"memory_string": 7.0}, Cost: 1},
+		{ToolName: "OWNER_memorize", Parameters: memorize, Cost: 1},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 3) {
+		assert.Equal(t, llm.ToolResult{ToolName: "OWNER_memorize", Status: llm.ToolStatusInvalid, Message: "invalid parameters: memory_string is required"}, results[0])
+		assert.Equal(t, llm.ToolResult{ToolName: "OWNER_memorize", Status: llm.ToolStatusInvalid, Message: "invalid parameters: memory_string must be a string"}, results[1])
+		assert.Equal(t, llm.ToolStatusOK, results[2].Status)
+	}
+	current, _, err = ledger.Balance(models.InfluenceOwner, "spirit")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, current)
+
+	// A call the handler finds invalid is refunded.
+	results, err = dispatcher.Dispatch(context.Background(), player, owner, []llm.ToolCall{
+		{ToolName: "read_the_stars", Cost: 1},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, llm.ToolResult{ToolName: "read_the_stars", Status: llm.ToolStatusInvalid, Message: "invalid tool call: the stars are hidden"}, results[0])
+	}
+	current, _, err = ledger.Balance(models.InfluenceOwner, "spirit")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, current)
+
+	entries, err := dals.InfluenceDAL.GetInfluenceEntries(models.InfluenceOwner, "spirit", 0)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 6, "Every charge, refusal and refund is recorded")
+
+	// Entities may only call the tools they list and their kind may use.
+	results, err = dispatcher.Dispatch(context.Background(), player, owner, []llm.ToolCall{{ToolName: "initiate_quest", Parameters: map[string]interface{}{"quest_id": "census"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []llm.ToolResult{{ToolName: "initiate_quest", Status: llm.ToolStatusForbidden, Message: "tool not available: initiate_quest is not among the owner's tools"}}, results)
+	results, err = dispatcher.Dispatch(context.Background(), player, &models.NPC{ID: "guard", AvailableTools: toolList("OWNER_memorize")}, []llm.ToolCall{{ToolName: "OWNER_memorize", Parameters: memorize}})
+	assert.NoError(t, err)
+	assert.Equal(t, []llm.ToolResult{{ToolName: "OWNER_memorize", Status: llm.ToolStatusForbidden, Message: "tool not available: OWNER_memorize cannot be used by a npc"}}, results)
+}
+
+func TestToolDispatcher_ReportsUnknownTools(t *testing.T) {
+	dispatcher, dals, _, _ := setupToolDispatcher(t)
+	npc := &models.NPC{ID: "guard", Name: "Guard", CurrentRoomID: "gate", Inventory: []string{}, BehaviorState: "{}", AvailableTools: toolList("NPC_memorize")}
+	assert.NoError(t, dals.NpcDAL.CreateNPC(npc))
+	player := &models.PlayerCharacter{ID: "hero", Name: "Hero"}
+
+	// A made up tool is reported, and the calls around it still run.
+	results, err := dispatcher.Dispatch(context.Background(), player, npc, []llm.ToolCall{
+		{ToolName: "NPC_memorize", Parameters: map[string]interface{}{"memory_string": "Waved at the gate"}},
+		{ToolName: "summon_dragon", Parameters: map[string]interface{}{"size": "large"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []llm.ToolResult{
+		{ToolName: "NPC_memorize", Status: llm.ToolStatusOK, Data: map[string]interface{}{"npc_id": "guard", "player_id": "hero"}},
+		{ToolName: "summon_dragon", Status: llm.ToolStatusInvalid, Message: "unknown tool: summon_dragon"},
+	}, results)
+	guard, err := dals.NpcDAL.GetNPCByID("guard")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Waved at the gate"}, guard.MemoriesAboutPlayers["hero"], "The batch commits")
+}
+
+func TestToolDispatcher_MemoriesStayWithTheCaller(t *testing.T) {
+	dispatcher, dals, _, _ := setupToolDispatcher(t)
+	spirit := &models.Owner{ID: "spirit", Name: "Spirit", CurrentInfluenceBudget: 100, MaxInfluenceBudget: 100, AvailableTools: toolList("OWNER_memorize", "OWNER_memorize_dependables")}
+	rival := &models.Owner{ID: "rival", Name: "Rival", CurrentInfluenceBudget: 100, MaxInfluenceBudget: 100}
+	assert.NoError(t, dals.OwnerDAL.CreateOwner(spirit))
+	assert.NoError(t, dals.OwnerDAL.CreateOwner(rival))
+	assert.NoError(t, dals.NpcDAL.CreateNPC(&models.NPC{ID: "baker", Name: "Baker", CurrentRoomID: "square", OwnerIDs: []string{"spirit"}, Inventory: []string{}, BehaviorState: "{}"}))
+	assert.NoError(t, dals.NpcDAL.CreateNPC(&models.NPC{ID: "spy", Name: "Spy", CurrentRoomID: "square", OwnerIDs: []string{"rival"}, Inventory: []string{}, BehaviorState: "{}"}))
+	player := &models.PlayerCharacter{ID: "hero", Name: "Hero"}
+
+	// Naming another entity does not write to its memories.
+	results, err := dispatcher.Dispatch(context.Background(), player, spirit, []llm.ToolCall{
+		{ToolName: "OWNER_memorize", Parameters: map[string]interface{}{"owner_id": "rival", "memory_string": "Prayed at the shrine"}, Cost: 1},
+		{ToolName: "OWNER_memorize_dependables", Parameters: map[string]interface{}{"owner_id": "rival", "memory_string": "Helped at the harvest"}, Cost: 5},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, map[string]interface{}{"owner_id": "spirit", "player_id": "hero"}, results[0].Data)
+		assert.Equal(t, map[string]interface{}{"owner_id": "spirit", "player_id": "hero", "npc_ids": []string{"baker"}}, results[1].Data)
+	}
+
+	owner, err := dals.OwnerDAL.GetOwnerByID("spirit")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Prayed at the shrine"}, owner.MemoriesAboutPlayers["hero"])
+	owner, err = dals.OwnerDAL.GetOwnerByID("rival")
+	assert.NoError(t, err)
+	assert.Empty(t, owner.MemoriesAboutPlayers["hero"])
+	spy, err := dals.NpcDAL.GetNPCByID("spy")
+	assert.NoError(t, err)
+	assert.Empty(t, spy.MemoriesAboutPlayers["hero"])
+	baker, err := dals.NpcDAL.GetNPCByID("baker")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Helped at the harvest"}, baker.MemoriesAboutPlayers["hero"])
+}
+
+// forgettingCache records the entities whose prompts are dropped.
+type forgettingCache struct {
+	forgotten []string
+}
+
+func (c *forgettingCache) ForgetEntity(entityID string) {
+	c.forgotten = append(c.forgotten, entityID)
+}
+
+func TestToolDispatcher_ForgetsPromptsOfChangedEntities(t *testing.T) {
+	dispatcher, dals, _, _ := setupToolDispatcher(t)
+	prompts := &forgettingCache{}
+	dispatcher.UsePromptCache(prompts)
+	questmaker := &models.Questmaker{ID: "hunt", Name: "The Hunt", CurrentInfluenceBudget: 500, MaxInfluenceBudget: 500, AvailableTools: toolList("send_message", "change_npc_behavior_to_player")}
+	assert.NoError(t, dals.QuestmakerDAL.CreateQuestmaker(questmaker))
+	assert.NoError(t, dals.NpcDAL.CreateNPC(&models.NPC{ID: "ranger", Name: "Ranger", CurrentRoomID: "glade", Inventory: []string{}, BehaviorState: "{}"}))
+	player := &models.PlayerCharacter{ID: "hero", Name: "Hero", CurrentRoomID: "glade", Inventory: "[]", VisitedRoomIDs: "[]"}
+	assert.NoError(t, dals.PlayerCharacterDAL.CreateCharacter(player))
+
+	_, err := dispatcher.Dispatch(context.Background(), player, questmaker, []llm.ToolCall{{ToolName: "send_message", Parameters: map[string]interface{}{"message": "Beware."}}})
+	assert.NoError(t, err)
+	assert.Empty(t, prompts.forgotten, "Messages change no one")
+
+	_, err = dispatcher.Dispatch(context.Background(), player, questmaker, []llm.ToolCall{{ToolName: "change_npc_behavior_to_player", Parameters: map[string]interface{}{"npc_id": "ranger", "behavior_type": "hostile"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ranger"}, prompts.forgotten)
+}
+
+func TestToolDispatcher_RollsBackFailedBatch(t *testing.T) {
+	dispatcher, dals, ledger, messages := setupToolDispatcher(t)
+	dispatcher.Tools().MustRegister(tools.Definition{
+		Name:     "break_the_loom",
+		Entities: []string{tools.EntityOwner},
+		Handler: func(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+			return nil, errors.New("the loom snaps")
+		},
+	})
+	owner := &models.Owner{ID: "spirit", Name: "Spirit", CurrentInfluenceBudget: 100, MaxInfluenceBudget: 100, AvailableTools: toolList("OWNER_memorize_dependables", "spawn_entity", "break_the_loom")}
+	assert.NoError(t, dals.OwnerDAL.CreateOwner(owner))
+	assert.NoError(t, dals.RoomDAL.CreateRoom(&models.Room{ID: "square", Name: "Square", Exits: "{}", Properties: "{}"}))
+	for _, id := range []string{"baker", "miller"} {
+		assert.NoError(t, dals.NpcDAL.CreateNPC(&models.NPC{ID: id, Name: "A " + id, CurrentRoomID: "square", OwnerIDs: []string{"spirit"}, Inventory: []string{}, BehaviorState: "{}"}))
+	}
+	player := &models.PlayerCharacter{ID: "hero", Name: "Hero", CurrentRoomID: "square", Inventory: "[]", VisitedRoomIDs: "[]"}
+	assert.NoError(t, dals.PlayerCharacterDAL.CreateCharacter(player))
+	// Load the NPCs so the batch finds them in the cache.
+	_, err := dals.NpcDAL.GetNPCByID("baker")
+	assert.NoError(t, err)
+
+	batch := []llm.ToolCall{
+		{ToolName: "OWNER_memorize_dependables", Parameters: map[string]interface{}{"memory_string": "Helped at the harvest"}, Cost: 10},
+		{ToolName: "spawn_entity", Parameters: map[string]interface{}{"entity_type": "npc", "entity_id": "baker"}, Cost: 30},
+		{ToolName: "break_the_loom"},
+	}
+	results, err := dispatcher.Dispatch(context.Background(), player, owner, batch)
+	assert.EqualError(t, err, "the loom snaps")
+	assert.Nil(t, results)
+
+	for _, id := range []string{"baker", "miller"} {
+		npc, err := dals.NpcDAL.GetNPCByID(id)
+		assert.NoError(t, err)
+		assert.Empty(t, npc.MemoriesAboutPlayers["hero"], "%s keeps no memory from a rolled back batch", id)
+	}
+	npcs, err := dals.NpcDAL.GetNPCsByRoom("square")
+	assert.NoError(t, err)
+	assert.Len(t, npcs, 2, "No NPC is spawned")
+	current, _, err := ledger.Balance(models.InfluenceOwner, "spirit")
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, current, "Nothing is paid for")
+	entries, err := dals.InfluenceDAL.GetInfluenceEntries(models.InfluenceOwner, "spirit", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.Empty(t, messages, "Players are not told of changes that were rolled back")
+
+	// Without the failing call the batch commits, and players are told.
+	results, err = dispatcher.Dispatch(context.Background(), player, owner, batch[:2])
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	baker, err := dals.NpcDAL.GetNPCByID("baker")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Helped at the harvest"}, baker.MemoriesAboutPlayers["hero"])
+	assert.Equal(t, "A baker appears.", nextMessage(t, messages))
+	current, _, err = ledger.Balance(models.InfluenceOwner, "spirit")
+	assert.NoError(t, err)
+	assert.Equal(t, 60.0, current)
+}
+
+func TestToolDispatcher_DescribesTools(t *testing.T) {
+	dispatcher, _, _, _ := setupToolDispatcher(t)
+	owner := &models.Owner{ID: "spirit", LLMPromptContext: "You watch over the Shire.", AvailableTools: toolList("OWNER_memorize", "send_message", "retired_tool")}
+
+	prompt, err := llm.AssemblePrompt(&llm.PromptData{Entity: owner, Player: &models.PlayerCharacter{ID: "hero"}, Tools: dispatcher.Tools()})
+	assert.NoError(t, err)
+	assert.Contains(t, prompt, "- OWNER_memorize: Remember something about a player as an Owner.\n")
+	assert.Contains(t, prompt, `"required":["memory_string"]`, "Tools are described with their parameter schema")
+	assert.NotContains(t, prompt, "send_message", "Tools an Owner may not use are left out")
+	assert.NotContains(t, prompt, "retired_tool", "Tools that are not registered are left out")
+}
+
+func TestToolDispatcher_QuestmakerTools(t *testing.T) {
+	dispatcher, dals, ledger, messages := setupToolDispatcher(t)
+	questmaker := &models.Questmaker{ID: "hunt", Name: "The Hunt", CurrentInfluenceBudget: 500, MaxInfluenceBudget: 500, AvailableTools: toolList(
+		"send_message", "change_npc_behavior_to_player", "change_npc_stats", "grant_player_reward", "grant_passive_skill", "QUESTMAKER_memorize",
+	)}
+	assert.NoError(t, dals.QuestmakerDAL.CreateQuestmaker(questmaker))
+	assert.NoError(t, dals.RoomDAL.CreateRoom(&models.Room{ID: "glade", Name: "Glade", Exits: "{}", Properties: "{}"}))
+	ranger := &models.NPC{ID: "ranger", Name: "Ranger", CurrentRoomID: "glade", Health: 20, MaxHealth: 20, Inventory: []string{}, BehaviorState: `{"trains_class": "rogue"}`}
+	assert.NoError(t, dals.NpcDAL.CreateNPC(ranger))
+	assert.NoError(t, dals.NpcDAL.CreateNPC(&models.NPC{ID: "hermit", Name: "Hermit", CurrentRoomID: "cave", Inventory: []string{}, BehaviorState: "{}"}))
+	assert.NoError(t, dals.ItemDAL.CreateItem(&models.Item{ID: "herb", Name: "a healing herb", Type: "consumable", Properties: `{"stackable": true}`}))
+	for _, skill := range []*models.Skill{
+		{ID: "herbalism", Name: "Herbalism", Type: "passive", Category: "crafting"},
+		{ID: "firebolt", Name: "Firebolt", Type: "active", Category: "magic"},
+		{ID: "tracking", Name: "Tracking", Type: "active", Category: "survival"},
+	} {
+		assert.NoError(t, dals.SkillDAL.CreateSkill(skill))
+	}
+	player := &models.PlayerCharacter{ID: "hero", Name: "Hero", CurrentRoomID: "glade", Inventory: "[]", VisitedRoomIDs: "[]"}
+	assert.NoError(t, dals.PlayerCharacterDAL.CreateCharacter(player))
+	assert.NoError(t, dals.PlayerCharacterDAL.CreateCharacter(&models.PlayerCharacter{ID: "scout", Name: "Scout", CurrentRoomID: "glade", Inventory: "[]", VisitedRoomIDs: "[]"}))
+
+	dispatch := func(name string, params map[string]interface{}) llm.ToolResult {
+		t.Helper()
+		results, err := dispatcher.Dispatch(context.Background(), player, questmaker, []llm.ToolCall{{ToolName: name, Parameters: params}})
+		assert.NoError(t, err)
+		if !assert.Len(t, results, 1) {
+			return llm.ToolResult{}
+		}
+		return results[0]
+	}
+
+	// send_message
+	result := dispatch("send_message", map[string]interface{}{"message": "The mushrooms grow thick by the river."})
+	assert.Equal(t, llm.ToolStatusOK, result.Status)
+	assert.Equal(t, 5.0, result.Cost)
+	assert.Equal(t, "The mushrooms grow thick by the river.", nextMessage(t, messages))
+	result = dispatch("send_message", map[string]interface{}{"message": "Follow me.", "via_npc_id": "ranger"})
+	assert.Equal(t, llm.ToolStatusOK, result.Status)
+	assert.Equal(t, "Ranger says: Follow me.", nextMessage(t, messages))
+	result = dispatch("send_message", map[string]interface{}{"message": "Over here.", "target_player_id": "scout"})
+	assert.Equal(t, llm.ToolStatusOK, result.Status)
+	assert.Equal(t, "scout", result.Data["player_id"], "A named player is messaged instead of the one acting")
+	assert.Equal(t, "Over here.", nextMessage(t, messages))
+	result = dispatch("send_message", map[string]interface{}{"message": "Hello?", "target_player_id": "nobody"})
+	assert.Equal(t, llm.ToolStatusInvalid, result.Status)
+	result = dispatch("send_message", map[string]interface{}{"message": "Psst.", "via_npc_id": "hermit"})
+	assert.Equal(t, llm.ToolStatusInvalid, result.Status, "The NPC must be with the player")
+	assert.Equal(t, 0.0, result.Cost)
+
+	// change_npc_behavior_to_player keeps the NPC's other behavior.
+	result = dispatch("change_npc_behavior_to_player", map[string]interface{}{"npc_id": "ranger", "behavior_type": "Friendly", "memory_entry": "Helped with the hunt"})
+	assert.Equal(t, llm.ToolStatusOK, result.Status)
+	updated, err := dals.NpcDAL.GetNPCByID("ranger")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"trains_class": "rogue", "toward_players": {"hero": "friendly"}}`, updated.BehaviorState)
+	assert.Equal(t, []string{"Helped with the hunt"}, updated.MemoriesAboutPlayers["hero"])
+
+	// change_npc_stats
+	result = dispatch("change_npc_stats", map[string]interface{}{"npc_id": "ranger", "stat_changes": map[string]interface{}{"max_health": 10.0, "health": 5.0, "Strength": 2.0}})
+	assert.Equal(t, llm.ToolStatusOK, result.Status)
+	assert.Equal(t, map[string]interface{}{"health": 25, "max_health": 30, "strength": 2.0}, result.Data["changes"])
+	effects, err := dals.StatusEffectDAL.GetStatusEffectsByTarget(models.EffectTargetNPC, "ranger")
+	assert.NoError(t, err)
+	if assert.Len(t, effects, 1) {
+		assert.Equal(t, "strength", effects[0].Key)
+		assert.Equal(t, GrantedByQuestmaker, effects[0].SourceType)
+	}
+	result = dispatch("change_npc_stats", map[string]interface{}{"npc_id": "ranger", "stat_changes": map[string]interface{}{"luck": 1.0, "health": 5.0}})
+	assert.Equal(t, llm.ToolStatusInvalid, result.Status)
+	updated, err = dals.NpcDAL.GetNPCByID("ranger")
+	assert.NoError(t, err)
+	assert.Equal(t, 25, updated.Health, "Nothing changes when any change is invalid")
+
+	// grant_player_reward
+	result = dispatch("grant_player_reward", map[string]interface{}{"reward_type": "item", "reward_id": "herb", "quantity": 3.0})
+	assert.Equal(t, llm.ToolStatusOK, result.Status)
+	assert.Equal(t, "You receive a healing herb (x3).", nextMessage(t, messages))
+	carried, err := dals.ItemInstanceDAL.GetInstancesByLocation(models.LocationCharacter, "hero")
+	assert.NoError(t, err)
+	if assert.Len(t, carried, 1) {
+		assert.Equal(t, 3, carried[0].Quantity)
+	}
+	result = dispatch("grant_player_reward", map[string]interface{}{"reward_type": "spell", "reward_id": "firebolt"})
+	assert.Equal(t, llm.ToolStatusOK, result.Status)
+	assert.Equal(t, "You have learned Firebolt.", nextMessage(t, messages))
+	result = dispatch("grant_player_reward", map[string]interface{}{"player_id": "scout", "reward_type": "spell", "reward_id": "firebolt"})
+	assert.Equal(t, llm.ToolStatusOK, result.Status)
+	assert.Equal(t, "You have learned Firebolt.", nextMessage(t, messages))
+	known, err := dals.PlayerSkillDAL.GetPlayerSkillByID("scout", "firebolt")
+	assert.NoError(t, err)
+	assert.NotNil(t, known, "A named player is rewarded instead of the one acting")
+	result = dispatch("grant_player_reward", map[string]interface{}{"reward_type": "spell", "reward_id": "tracking"})
+	assert.Equal(t, llm.ToolStatusInvalid, result.Status)
+	result = dispatch("grant_player_reward", map[string]interface{}{"reward_type": "gold", "reward_id": "coins"})
+	assert.Equal(t, llm.ToolStatusInvalid, result.Status)
+	known, err = dals.PlayerSkillDAL.GetPlayerSkillByID("hero", "tracking")
+	assert.NoError(t, err)
+	assert.Nil(t, known, "A skill that is not a spell is not granted as one")
+
+	// grant_passive_skill
+	result = dispatch("grant_passive_skill", map[string]interface{}{"skill_id": "herbalism", "initial_percentage": 15.0})
+	assert.Equal(t, llm.ToolStatusOK, result.Status)
+	assert.Equal(t, 15, result.Data["percentage"])
+	assert.Equal(t, "You have learned Herbalism.", nextMessage(t, messages))
+	playerSkill, err := dals.PlayerSkillDAL.GetPlayerSkillByID("hero", "herbalism")
+	assert.NoError(t, err)
+	assert.Equal(t, GrantedByQuestmaker, playerSkill.GrantedByEntityType)
+	assert.Equal(t, "hunt", playerSkill.GrantedByEntityID)
+	result = dispatch("grant_passive_skill", map[string]interface{}{"player_id": "scout", "skill_id": "herbalism"})
+	assert.Equal(t, llm.ToolStatusOK, result.Status)
+	assert.Equal(t, "You have learned Herbalism.", nextMessage(t, messages))
+	playerSkill, err = dals.PlayerSkillDAL.GetPlayerSkillByID("scout", "herbalism")
+	assert.NoError(t, err)
+	assert.NotNil(t, playerSkill)
+	result = dispatch("grant_passive_skill", map[string]interface{}{"skill_id": "firebolt"})
+	assert.Equal(t, llm.ToolStatusInvalid, result.Status)
+	result = dispatch("grant_passive_skill", map[string]interface{}{"skill_id": "herbalism", "initial_percentage": 150.0})
+	assert.Equal(t, "invalid parameters: initial_percentage must be at most 100", result.Message)
+
+	// QUESTMAKER_memorize
+	result = dispatch("QUESTMAKER_memorize", map[string]interface{}{"memory_string": "Found the first mushroom"})
+	assert.Equal(t, llm.ToolStatusOK, result.Status)
+	stored, err := dals.QuestmakerDAL.GetQuestmakerByID("hunt")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Found the first mushroom"}, stored.MemoriesAboutPlayers["hero"])
+
+	// Questmaker tools are not for NPCs.
+	results, err := dispatcher.Dispatch(context.Background(), player, ranger, []llm.ToolCall{{ToolName: "send_message", Parameters: map[string]interface{}{"message": "Hi"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, llm.ToolStatusForbidden, results[0].Status)
+
+	current, _, err := ledger.Balance(models.InfluenceQuestmaker, "hunt")
+	assert.NoError(t, err)
+	assert.Equal(t, 500-5-5-5-10-15-5-5-5-20-20-5.0, current, "Only calls carried out are paid for")
+}
+
+func TestToolDispatcher_WorldTools(t *testing.T) {
+	dispatcher, dals, _, messages := setupToolDispatcher(t)
+	worldTools := toolList("initiate_quest", "trigger_world_event", "spawn_entity", "change_room_info")
+	owner := &models.Owner{ID: "council", Name: "Town Council", CurrentInfluenceBudget: 500, MaxInfluenceBudget: 500, InitiatedQuests: []string{"pony"}, AvailableTools: worldTools}
+	assert.NoError(t, dals.OwnerDAL.CreateOwner(owner))
+	questOwner := &models.QuestOwner{ID: "shadow", Name: "The Shadow", CurrentInfluenceBudget: 500, MaxInfluenceBudget: 500, AssociatedQuestmakerIDs: "[]", AvailableTools: worldTools}
+	assert.NoError(t, dals.QuestOwnerDAL.CreateQuestOwner(questOwner))
+	for _, quest := range []*models.Quest{
+		{ID: "pony", Name: "The Missing Pony", Objectives: "[]", Rewards: "[]", InfluencePointsMap: "{}"},
+		{ID: "ring", Name: "The Ring", QuestOwnerID: "shadow", Objectives: "[]", Rewards: "[]", InfluencePointsMap: "{}"},
+	} {
+		assert.NoError(t, dals.QuestDAL.CreateQuest(quest))
+	}
+	assert.NoError(t, dals.RoomDAL.CreateRoom(&models.Room{ID: "square", Name: "Square", Description: "A quiet square.", Exits: "{}", Properties: "{}"}))
+	assert.NoError(t, dals.NpcDAL.CreateNPC(&models.NPC{ID: "guard", Name: "A guard", CurrentRoomID: "gatehouse", Health: 20, MaxHealth: 20, Inventory: []string{}, BehaviorState: "{}"}))
+	player := &models.PlayerCharacter{ID: "hero", Name: "Hero", CurrentRoomID: "square", Inventory: "[]", VisitedRoomIDs: "[]"}
+	assert.NoError(t, dals.PlayerCharacterDAL.CreateCharacter(player))
+
+	dispatch := func(entity interface{}, name string, params map[string]interface{}) llm.ToolResult {
+		t.Helper()
+		results, err := dispatcher.Dispatch(context.Background(), player, entity, []llm.ToolCall{{ToolName: name, Parameters: params}})
+		assert.NoError(t, err)
+		if !assert.Len(t, results, 1) {
+			return llm.ToolResult{}
+		}
+		return results[0]
+	}
+
+	// initiate_quest
+	result := dispatch(owner, "initiate_quest", map[string]interface{}{"quest_id": "pony", "trigger_type": "on_talk_to_npc", "trigger_id": "barliman"})
+	assert.Equal(t, llm.ToolStatusOK, result.Status)
+	assert.Equal(t, models.QuestStatusOffered, result.Data["status"])
+	result = dispatch(owner, "initiate_quest", map[string]interface{}{"quest_id": "ring"})
+	assert.Equal(t, llm.ToolStatusInvalid, result.Status, "Owners only offer their own quests")
+	result = dispatch(questOwner, "initiate_quest", map[string]interface{}{"quest_id": "ring"})
+	assert.Equal(t, llm.ToolStatusOK, result.Status)
+	assert.Equal(t, "A new quest begins: The Ring.", nextMessage(t, messages))
+	result = dispatch(questOwner, "initiate_quest", map[string]interface{}{"quest_id": "ring"})
+	assert.Equal(t, llm.ToolStatusInvalid, result.Status)
+
+	// trigger_world_event
+	result = dispatch(questOwner, "trigger_world_event", map[string]interface{}{"event_type": "dark_omens", "details": "Crows gather on every roof."})
+	assert.Equal(t, llm.ToolStatusOK, result.Status)
+	assert.Equal(t, "Crows gather on every roof.", nextMessage(t, messages))
+	worldEvents, err := dals.WorldDAL.GetActiveWorldEvents()
+	assert.NoError(t, err)
+	if assert.Len(t, worldEvents, 1) {
+		assert.Equal(t, models.InfluenceQuestOwner, worldEvents[0].SourceType)
+		assert.Equal(t, "shadow", worldEvents[0].SourceID)
+	}
+	result = dispatch(questOwner, "trigger_world_event", map[string]interface{}{"event_type": "eclipse", "duration_seconds": 0.0})
+	assert.Equal(t, llm.ToolStatusInvalid, result.Status)
+
+	// spawn_entity
+	result = dispatch(owner, "spawn_entity", map[string]interface{}{"entity_type": "npc", "entity_id": "guard", "quantity": 2.0})
+	assert.Equal(t, llm.ToolStatusOK, result.Status)
+	assert.Equal(t, "A guard appears.", nextMessage(t, messages))
+	assert.Equal(t, "A guard appears.", nextMessage(t, messages))
+	npcs, err := dals.NpcDAL.GetNPCsByRoom("square")
+	assert.NoError(t, err)
+	assert.Len(t, npcs, 2, "NPCs are spawned in the player's room by default")
+	result = dispatch(owner, "spawn_entity", map[string]interface{}{"entity_type": "item", "entity_id": "gem", "location": "square"})
+	assert.Equal(t, llm.ToolStatusInvalid, result.Status)
+	assert.Equal(t, 0.0, result.Cost)
+
+	// change_room_info
+	result = dispatch(owner, "change_room_info", map[string]interface{}{"room_id": "square", "property_changes": map[string]interface{}{"description_add": "Banners hang from the windows.", "festive": true}, "duration_seconds": 600.0})
+	assert.Equal(t, llm.ToolStatusOK, result.Status)
+	assert.Equal(t, "Banners hang from the windows.", nextMessage(t, messages))
+	square, err := dals.RoomDAL.GetRoomByID("square")
+	assert.NoError(t, err)
+	assert.Equal(t, "A quiet square. Banners hang from the windows.", square.Description)
+	assert.JSONEq(t, `{"festive": true}`, square.Properties)
+	overlay, err := dals.WorldDAL.GetRoomOverlayByID(result.Data["overlay_id"].(string))
+	assert.NoError(t, err)
+	assert.Equal(t, "A quiet square.", overlay.PreviousDescription)
+	result = dispatch(owner, "change_room_info", map[string]interface{}{"room_id": "square", "property_changes": map[string]interface{}{"description": 5.0}})
+	assert.Equal(t, llm.ToolStatusInvalid, result.Status)
+	assert.Equal(t, "invalid parameters: property_changes.description must be a string", result.Message)
+
+	// World-shaping tools are not for Questmakers.
+	result = dispatch(&models.Questmaker{ID: "hunt", AvailableTools: worldTools}, "trigger_world_event", map[string]interface{}{"event_type": "storm"})
+	assert.Equal(t, llm.ToolStatusForbidden, result.Status)
+}
@@ -0,0 +1,466 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"mud/internal/game/items"
+	"mud/internal/game/stats"
+	"mud/internal/game/tools"
+	"mud/internal/models"
+)
+
+// GrantedByQuestmaker is the GrantedByEntityType of skills Questmakers grant,
+// and the SourceType of the effects they apply.
+const GrantedByQuestmaker = "Questmaker"
+
+// DefaultStatChangeDuration is how long change_npc_stats modifiers last when
+// the call does not say.
+const DefaultStatChangeDuration = time.Hour
+
+// Reward types of grant_player_reward.
+const (
+	rewardItem  = "item"
+	rewardSkill = "skill"
+	rewardSpell = "spell" // Spells are skills of the magic category
+)
+
+// questmakerTools are the tools Questmakers use to steer their quests.
+func (td *ToolDispatcher) questmakerTools() []tools.Definition {
+	playerID := property("string", "The player. Defaults to the player you are reacting to.")
+	return []tools.Definition{
+		{
+			Name:        "send_message",
+			Description: "Send a message to a player, or have an NPC in their room say it.",
+			Parameters: objectSchema(map[string]interface{}{
+				"target_player_id": playerID,
+				"message":          property("string", "The message."),
+				"via_npc_id":       property("string", "An NPC in the player's room who speaks the message."),
+			}, "message"),
+			Entities: []string{tools.EntityQuestmaker},
+			Cost:     tools.Cost{Min: 5, Max: 15},
+			Handler:  td.handler((*ToolDispatcher).handleSendMessage),
+		},
+		{
+			Name:        "change_npc_behavior_to_player",
+			Description: "Change how an NPC behaves toward a player, and optionally give it a memory of them.",
+			Parameters: objectSchema(map[string]interface{}{
+				"npc_id":        property("string", "The NPC."),
+				"player_id":     playerID,
+				"behavior_type": property("string", "How the NPC now behaves toward the player, such as friendly or hostile."),
+				"memory_entry":  property("string", "Something for the NPC to remember about the player."),
+			}, "npc_id", "behavior_type"),
+			Entities: []string{tools.EntityQuestmaker},
+			Cost:     tools.Cost{Min: 10, Max: 30},
+			Handler:  td.handler((*ToolDispatcher).handleChangeNPCBehavior),
+		},
+		{
+			Name:        "change_npc_stats",
+			Description: "Raise or lower an NPC's health, maximum health, attributes, armor or damage. Changes other than to health wear off after duration_seconds.",
+			Parameters: objectSchema(map[string]interface{}{
+				"npc_id": property("string", "The NPC."),
+				"stat_changes": map[string]interface{}{
+					"type":                 "object",
+					"description":          "The amount to add to each stat, such as {\"strength\": 2, \"health\": -5}.",
+					"minProperties":        1,
+					"additionalProperties": map[string]interface{}{"type": "number"},
+				},
+				"duration_seconds": map[string]interface{}{"type": "number", "description": "How long the changes last. Defaults to an hour.", "minimum": 1},
+			}, "npc_id", "stat_changes"),
+			Entities: []string{tools.EntityQuestmaker},
+			Cost:     tools.Cost{Min: 15, Max: 40},
+			Handler:  td.handler((*ToolDispatcher).handleChangeNPCStats),
+		},
+		{
+			Name:        "grant_player_reward",
+			Description: "Give a player items, or teach them a skill or spell.",
+			Parameters: objectSchema(map[string]interface{}{
+				"player_id":   playerID,
+				"reward_type": map[string]interface{}{"type": "string", "description": "What kind of reward it is.", "enum": []string{rewardItem, rewardSkill, rewardSpell}},
+				"reward_id":   property("string", "The item template or skill to grant."),
+				"quantity":    map[string]interface{}{"type": "integer", "description": "How many items to give. Defaults to 1.", "minimum": 1},
+			}, "reward_type", "reward_id"),
+			Entities: []string{tools.EntityQuestmaker},
+			Cost:     tools.Cost{Min: 5, Max: 50},
+			Handler:  td.handler((*ToolDispatcher).handleGrantPlayerReward),
+		},
+		{
+			Name:        "grant_passive_skill",
+			Description: "Teach a player a passive skill.",
+			Parameters: objectSchema(map[string]interface{}{
+				"player_id":          playerID,
+				"skill_id":           property("string", "The passive skill."),
+				"initial_percentage": map[string]interface{}{"type": "number", "description": "How well the player knows it, from 0 to 100. Defaults to 0.", "minimum": 0, "maximum": 100},
+			}, "skill_id"),
+			Entities: []string{tools.EntityQuestmaker},
+			Cost:     tools.Cost{Min: 20, Max: 70},
+			Handler:  td.handler((*ToolDispatcher).handleGrantPassiveSkill),
+		},
+		{
+			Name:        "QUESTMAKER_memorize",
+			Description: "Remember something about a player as a Questmaker.",
+			Parameters: objectSchema(map[string]interface{}{
+				"player_id":     playerID,
+				"memory_string": property("string", "What to remember about the player."),
+			}, "memory_string"),
+			Entities: []string{tools.EntityQuestmaker},
+			Cost:     tools.Cost{Min: 5, Max: 5},
+			Handler:  td.handler((*ToolDispatcher).handleQuestmakerMemorize),
+		},
+	}
+}
+
+// handleSendMessage delivers a message to a player, spoken by an NPC in
+// their room when via_npc_id is given.
+func (td *ToolDispatcher) handleSendMessage(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+	target, err := td.targetPlayer(player, params, "target_player_id")
+	if err != nil {
+		return nil, err
+	}
+	message, err := stringParam(params, "message", true)
+	if err != nil {
+		return nil, err
+	}
+	viaNPCID, err := stringParam(params, "via_npc_id", false)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{"player_id": target.ID}
+	if viaNPCID == "" {
+		td.tell(target.ID, message)
+		return data, nil
+	}
+	npc, err := td.targetNPC(params, "via_npc_id")
+	if err != nil {
+		return nil, err
+	}
+	if npc.CurrentRoomID != target.CurrentRoomID {
+		return nil, invalidCall("%s is not in the same room as the player", npc.Name)
+	}
+	td.tell(target.ID, fmt.Sprintf("%s says: %s", npc.Name, message))
+	data["via_npc_id"] = npc.ID
+	return data, nil
+}
+
+// handleChangeNPCBehavior sets how an NPC behaves toward a player, and can
+// give the NPC a memory of them. Behaviors are kept under "toward_players"
+// in the NPC's BehaviorState, alongside its other keys such as "trains_class".
+func (td *ToolDispatcher) handleChangeNPCBehavior(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+	npc, err := td.targetNPC(params, "npc_id")
+	if err != nil {
+		return nil, err
+	}
+	playerID, err := playerParam(player, params, "player_id")
+	if err != nil {
+		return nil, err
+	}
+	behavior, err := stringParam(params, "behavior_type", true)
+	if err != nil {
+		return nil, err
+	}
+	memory, err := stringParam(params, "memory_entry", false)
+	if err != nil {
+		return nil, err
+	}
+	behavior = strings.ToLower(strings.TrimSpace(behavior))
+
+	state := make(map[string]interface{})
+	if strings.TrimSpace(npc.BehaviorState) != "" {
+		if err := json.Unmarshal([]byte(npc.BehaviorState), &state); err != nil {
+			return nil, fmt.Errorf("failed to parse behavior state of NPC %s: %w", npc.ID, err)
+		}
+	}
+	toward, _ := state["toward_players"].(map[string]interface{})
+	if toward == nil {
+		toward = make(map[string]interface{})
+	}
+	toward[playerID] = behavior
+	state["toward_players"] = toward
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode behavior state of NPC %s: %w", npc.ID, err)
+	}
+	npc.BehaviorState = string(encoded)
+
+	if memory != "" {
+		if npc.MemoriesAboutPlayers == nil {
+			npc.MemoriesAboutPlayers = make(map[string][]string)
+		}
+		npc.MemoriesAboutPlayers[playerID] = append(npc.MemoriesAboutPlayers[playerID], memory)
+	}
+
+	if err := td.dal.NpcDAL.UpdateNPC(npc); err != nil {
+		return nil, fmt.Errorf("failed to update NPC %s: %w", npc.ID, err)
+	}
+	td.entityChanged(npc.ID)
+	return map[string]interface{}{"npc_id": npc.ID, "player_id": playerID, "behavior_type": behavior}, nil
+}
+
+// handleChangeNPCStats changes an NPC's health and maximum health directly,
+// and its attributes, armor and damage through modifier effects that last
+// duration_seconds. Every change in stat_changes is added to the current value.
+func (td *ToolDispatcher) handleChangeNPCStats(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+	npc, err := td.targetNPC(params, "npc_id")
+	if err != nil {
+		return nil, err
+	}
+	changes, _ := params["stat_changes"].(map[string]interface{})
+	seconds, err := numberParam(params, "duration_seconds", DefaultStatChangeDuration.Seconds())
+	if err != nil {
+		return nil, err
+	}
+
+	// Check every change before making any.
+	amounts := make(map[string]int, len(changes))
+	for key, value := range changes {
+		amount, _ := tools.Number(value)
+		key = strings.ToLower(key)
+		if !modifiableNPCStat(key) {
+			return nil, invalidCall("unknown stat: %s", key)
+		}
+		amounts[key] += int(math.Round(amount))
+	}
+
+	applied := make(map[string]interface{}, len(amounts))
+	healthAmount, changesHealth := amounts["health"]
+	maxHealthAmount, changesMaxHealth := amounts["max_health"]
+	if changesHealth || changesMaxHealth {
+		if npc.Health <= 0 {
+			return nil, invalidCall("%s is dead", npc.Name)
+		}
+		npc.MaxHealth = max(1, npc.MaxHealth+maxHealthAmount)
+		npc.Health = max(1, min(npc.Health+healthAmount, npc.MaxHealth))
+		applied["health"], applied["max_health"] = npc.Health, npc.MaxHealth
+		if err := td.dal.NpcDAL.UpdateNPC(npc); err != nil {
+			return nil, fmt.Errorf("failed to update NPC %s: %w", npc.ID, err)
+		}
+		td.entityChanged(npc.ID)
+	}
+
+	sourceID := ""
+	if questmaker, ok := entity.(*models.Questmaker); ok {
+		sourceID = questmaker.ID
+	}
+	for _, key := range sortedStatKeys(amounts) {
+		if key == "health" || key == "max_health" {
+			continue
+		}
+		effect, err := td.effects.Apply(&models.StatusEffect{
+			TargetType: models.EffectTargetNPC,
+			TargetID:   npc.ID,
+			SourceType: GrantedByQuestmaker,
+			SourceID:   sourceID,
+			Type:       models.EffectTypeModifier,
+			Key:        key,
+			Magnitude:  float64(amounts[key]),
+		}, time.Duration(seconds*float64(time.Second)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to change %s of NPC %s: %w", key, npc.ID, err)
+		}
+		applied[key] = effect.TotalMagnitude()
+	}
+	return map[string]interface{}{"npc_id": npc.ID, "changes": applied}, nil
+}
+
+// modifiableNPCStat reports whether change_npc_stats can change a stat.
+func modifiableNPCStat(key string) bool {
+	switch key {
+	case "health", "max_health", stats.Armor, stats.Damage:
+		return true
+	}
+	for _, attribute := range stats.Attributes {
+		if key == attribute {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedStatKeys(amounts map[string]int) []string {
+	keys := make([]string, 0, len(amounts))
+	for key := range amounts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// handleGrantPlayerReward gives a player items, or teaches them a skill or spell.
+func (td *ToolDispatcher) handleGrantPlayerReward(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+	target, err := td.targetPlayer(player, params, "player_id")
+	if err != nil {
+		return nil, err
+	}
+	rewardType, err := stringParam(params, "reward_type", true)
+	if err != nil {
+		return nil, err
+	}
+	rewardID, err := stringParam(params, "reward_id", true)
+	if err != nil {
+		return nil, err
+	}
+	quantity, err := numberParam(params, "quantity", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{"player_id": target.ID, "reward_type": rewardType, "reward_id": rewardID}
+	switch rewardType {
+	case rewardItem:
+		spawned, err := td.items.Spawn(&models.ItemInstance{
+			TemplateID:   rewardID,
+			Quantity:     int(quantity),
+			LocationType: models.LocationCharacter,
+			LocationID:   target.ID,
+		})
+		if errors.Is(err, items.ErrUnknownTemplate) {
+			return nil, invalidCall("item not found: %s", rewardID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := items.Resolve(spawned).Name
+		if quantity > 1 {
+			td.tell(target.ID, fmt.Sprintf("You receive %s (x%d).", name, int(quantity)))
+		} else {
+			td.tell(target.ID, fmt.Sprintf("You receive %s.", name))
+		}
+		data["item_instance_id"] = spawned.ID
+		data["quantity"] = int(quantity)
+	case rewardSkill, rewardSpell:
+		skill, err := td.skillParam(params, "reward_id")
+		if err != nil {
+			return nil, err
+		}
+		if rewardType == rewardSpell && skill.Category != "magic" {
+			return nil, invalidCall("%s is not a spell", rewardID)
+		}
+		if _, err := td.rewardSkill(target, entity, skill, 0); err != nil {
+			return nil, err
+		}
+		data["skill_name"] = skill.Name
+	default:
+		return nil, invalidCall("unknown reward_type %q; expected item, skill or spell", rewardType)
+	}
+	return data, nil
+}
+
+// handleGrantPassiveSkill teaches a player a passive skill, starting at
+// initial_percentage.
+func (td *ToolDispatcher) handleGrantPassiveSkill(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+	target, err := td.targetPlayer(player, params, "player_id")
+	if err != nil {
+		return nil, err
+	}
+	percentage, err := numberParam(params, "initial_percentage", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	skill, err := td.skillParam(params, "skill_id")
+	if err != nil {
+		return nil, err
+	}
+	if skill.Type != "passive" {
+		return nil, invalidCall("%s is not a passive skill", skill.ID)
+	}
+	playerSkill, err := td.rewardSkill(target, entity, skill, int(percentage))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"player_id": target.ID, "skill_id": skill.ID, "percentage": playerSkill.Percentage}, nil
+}
+
+// skillParam loads the skill named by a parameter.
+func (td *ToolDispatcher) skillParam(params map[string]interface{}, name string) (*models.Skill, error) {
+	skillID, err := stringParam(params, name, true)
+	if err != nil {
+		return nil, err
+	}
+	skill, err := td.dal.SkillDAL.GetSkillByID(skillID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get skill %s: %w", skillID, err)
+	}
+	if skill == nil {
+		return nil, invalidCall("skill not found: %s", skillID)
+	}
+	return skill, nil
+}
+
+// rewardSkill teaches a character a skill at percentage, or raises it to
+// percentage if they already know it at less, and tells them. It returns the
+// character's PlayerSkill.
+func (td *ToolDispatcher) rewardSkill(character *models.PlayerCharacter, entity interface{}, skill *models.Skill, percentage int) (*models.PlayerSkill, error) {
+	known, err := td.dal.PlayerSkillDAL.GetPlayerSkillByID(character.ID, skill.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get skill %s for character %s: %w", skill.ID, character.ID, err)
+	}
+	if known != nil {
+		if known.Percentage < percentage {
+			known.Percentage = percentage
+			if err := td.dal.PlayerSkillDAL.UpdatePlayerSkill(known); err != nil {
+				return nil, fmt.Errorf("failed to update skill %s for character %s: %w", skill.ID, character.ID, err)
+			}
+			td.tell(character.ID, fmt.Sprintf("Your %s improves.", skill.Name))
+		}
+		return known, nil
+	}
+
+	grantedBy := ""
+	if questmaker, ok := entity.(*models.Questmaker); ok {
+		grantedBy = questmaker.ID
+	}
+	playerSkill := &models.PlayerSkill{
+		PlayerID:            character.ID,
+		SkillID:             skill.ID,
+		Percentage:          percentage,
+		GrantedByEntityType: GrantedByQuestmaker,
+		GrantedByEntityID:   grantedBy,
+	}
+	if err := td.dal.PlayerSkillDAL.CreatePlayerSkill(playerSkill); err != nil {
+		return nil, fmt.Errorf("failed to grant skill %s to character %s: %w", skill.ID, character.ID, err)
+	}
+	td.tell(character.ID, fmt.Sprintf("You have learned %s.", skill.Name))
+	return playerSkill, nil
+}
+
+// handleQuestmakerMemorize records a memory about a player in the calling
+// Questmaker's own memories.
+func (td *ToolDispatcher) handleQuestmakerMemorize(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+	caller, ok := entity.(*models.Questmaker)
+	if !ok {
+		return nil, invalidCall("only a Questmaker can memorize as one")
+	}
+	playerID, err := playerParam(player, params, "player_id")
+	if err != nil {
+		return nil, err
+	}
+	memory, err := stringParam(params, "memory_string", true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reload the Questmaker so memories recorded since it was loaded are kept.
+	questmaker, err := td.dal.QuestmakerDAL.GetQuestmakerByID(caller.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get questmaker %s: %w", caller.ID, err)
+	}
+	if questmaker == nil {
+		return nil, invalidCall("questmaker not found: %s", caller.ID)
+	}
+	if questmaker.MemoriesAboutPlayers == nil {
+		questmaker.MemoriesAboutPlayers = make(map[string][]string)
+	}
+	questmaker.MemoriesAboutPlayers[playerID] = append(questmaker.MemoriesAboutPlayers[playerID], memory)
+	if err := td.dal.QuestmakerDAL.UpdateQuestmaker(questmaker); err != nil {
+		return nil, fmt.Errorf("failed to update questmaker %s: %w", questmaker.ID, err)
+	}
+	td.entityChanged(questmaker.ID)
+	return map[string]interface{}{"questmaker_id": questmaker.ID, "player_id": playerID}, nil
+}
@@ -0,0 +1,325 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"mud/internal/game/influence"
+	"mud/internal/game/items"
+	"mud/internal/game/quests"
+	"mud/internal/game/tools"
+	"mud/internal/game/world"
+	"mud/internal/models"
+)
+
+// DefaultWorldEventDuration is how long trigger_world_event events last when
+// the call does not say.
+const DefaultWorldEventDuration = time.Hour
+
+// MaxSpawnQuantity is the most NPCs a single spawn_entity call can create.
+const MaxSpawnQuantity = 10
+
+// Entity types of spawn_entity.
+const (
+	spawnNPC  = "npc"
+	spawnItem = "item"
+)
+
+// worldTools are the tools Owners and Quest Owners use to shape the world.
+func (td *ToolDispatcher) worldTools() []tools.Definition {
+	shapers := []string{tools.EntityOwner, tools.EntityQuestOwner}
+	return []tools.Definition{
+		{
+			Name:        "initiate_quest",
+			Description: "Offer a player one of your quests. With a trigger_type the quest begins when the player talks to the NPC, enters the room or handles the item named by trigger_id; without one it begins at once.",
+			Parameters: objectSchema(map[string]interface{}{
+				"quest_id":         property("string", "The quest."),
+				"target_player_id": property("string", "The player. Defaults to the player you are reacting to."),
+				"trigger_type": map[string]interface{}{
+					"type":        "string",
+					"description": "What starts the quest.",
+					"enum":        []string{models.QuestTriggerTalkToNPC, models.QuestTriggerEnterRoom, models.QuestTriggerInteractItem},
+				},
+				"trigger_id": property("string", "The NPC, room or item that starts the quest."),
+			}, "quest_id"),
+			Entities: shapers,
+			Cost:     tools.Cost{Min: 5, Max: 15},
+			Handler:  td.handler((*ToolDispatcher).handleInitiateQuest),
+		},
+		{
+			Name:        "trigger_world_event",
+			Description: "Start a world event across the world, or in one room or territory, and announce it to the players there.",
+			Parameters: objectSchema(map[string]interface{}{
+				"event_type":       property("string", "The kind of event, such as storm or festival."),
+				"details":          property("string", "What the players there are told."),
+				"room_id":          property("string", "The room it happens in."),
+				"territory_id":     property("string", "The territory it happens in."),
+				"duration_seconds": map[string]interface{}{"type": "number", "description": "How long it lasts. Defaults to an hour.", "minimum": 1},
+			}, "event_type"),
+			Entities: shapers,
+			Cost:     tools.Cost{Min: 20, Max: 50},
+			Handler:  td.handler((*ToolDispatcher).handleTriggerWorldEvent),
+		},
+		{
+			Name:        "spawn_entity",
+			Description: "Create copies of an NPC, or items from a template, in a room.",
+			Parameters: objectSchema(map[string]interface{}{
+				"entity_type": map[string]interface{}{"type": "string", "description": "What to create.", "enum": []string{spawnNPC, spawnItem}},
+				"entity_id":   property("string", "The NPC to copy or the item template."),
+				"location":    property("string", "The room. Defaults to the player's room."),
+				"quantity":    map[string]interface{}{"type": "integer", "description": "How many to create. Defaults to 1.", "minimum": 1},
+			}, "entity_type", "entity_id"),
+			Entities: shapers,
+			Cost:     tools.Cost{Min: 25, Max: 60},
+			Handler:  td.handler((*ToolDispatcher).handleSpawnEntity),
+		},
+		{
+			Name:        "change_room_info",
+			Description: "Change a room's description and properties, until reverted or for duration_seconds.",
+			Parameters: objectSchema(map[string]interface{}{
+				"room_id": property("string", "The room. Defaults to the player's room."),
+				"property_changes": map[string]interface{}{
+					"type":          "object",
+					"description":   "description replaces the description, description_add adds to it, and any other key sets that room property; null removes it.",
+					"minProperties": 1,
+					"properties": map[string]interface{}{
+						"description":     property("string", "The new description."),
+						"description_add": property("string", "Text added to the description."),
+					},
+				},
+				"duration_seconds": map[string]interface{}{"type": "number", "description": "How long the change lasts. Defaults to until it is reverted.", "minimum": 0},
+			}, "property_changes"),
+			Entities: shapers,
+			Cost:     tools.Cost{Min: 10, Max: 30},
+			Handler:  td.handler((*ToolDispatcher).handleChangeRoomInfo),
+		},
+	}
+}
+
+// worldError reports errors caused by a world-shaping call's parameters,
+// such as rooms or quests that do not exist, as invalid calls.
+func worldError(err error) error {
+	for _, invalid := range []error{
+		world.ErrNoDuration, world.ErrUnknownRoom, world.ErrUnknownNPC, world.ErrNoChanges, items.ErrUnknownTemplate,
+		quests.ErrUnknownQuest, quests.ErrUnknownTrigger, quests.ErrQuestTaken,
+	} {
+		if errors.Is(err, invalid) {
+			return invalidCall("%v", err)
+		}
+	}
+	return err
+}
+
+// roomParam returns the room a world-shaping call is about: the named
+// parameter, or else the room of the player the entity is reacting to.
+func roomParam(player *models.PlayerCharacter, params map[string]interface{}, name string) (string, error) {
+	roomID, err := stringParam(params, name, false)
+	if err != nil || roomID != "" {
+		return roomID, err
+	}
+	if player == nil || player.CurrentRoomID == "" {
+		return "", invalidCall("missing or invalid %s", name)
+	}
+	return player.CurrentRoomID, nil
+}
+
+// durationParam returns a duration given in seconds, or fallback if it is missing.
+func durationParam(params map[string]interface{}, name string, fallback time.Duration) (time.Duration, error) {
+	seconds, err := numberParam(params, name, fallback.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// handleInitiateQuest offers a quest to a player. With a trigger_type the
+// quest waits until the player talks to the NPC, enters the room or handles
+// the item named by trigger_id; without one it begins at once. Owners may
+// only offer the quests listed as theirs, and Quest Owners the quests they
+// supervise.
+func (td *ToolDispatcher) handleInitiateQuest(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+	questID, err := stringParam(params, "quest_id", true)
+	if err != nil {
+		return nil, err
+	}
+	playerID, err := playerParam(player, params, "target_player_id")
+	if err != nil {
+		return nil, err
+	}
+	triggerType, err := stringParam(params, "trigger_type", false)
+	if err != nil {
+		return nil, err
+	}
+	triggerID, err := stringParam(params, "trigger_id", false)
+	if err != nil {
+		return nil, err
+	}
+
+	quest, err := td.dal.QuestDAL.GetQuestByID(questID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quest %s: %w", questID, err)
+	}
+	if quest == nil {
+		return nil, invalidCall("quest not found: %s", questID)
+	}
+	switch e := entity.(type) {
+	case *models.Owner:
+		offered := false
+		for _, id := range e.InitiatedQuests {
+			offered = offered || id == quest.ID
+		}
+		if !offered {
+			return nil, invalidCall("%s cannot offer quest %s", e.Name, quest.ID)
+		}
+	case *models.QuestOwner:
+		if quest.QuestOwnerID != e.ID {
+			return nil, invalidCall("%s does not supervise quest %s", e.Name, quest.ID)
+		}
+	}
+
+	var trigger *models.QuestTrigger
+	if triggerType != "" {
+		trigger = &models.QuestTrigger{Type: triggerType, ID: triggerID}
+	}
+	state, err := td.quests.Offer(playerID, quest.ID, trigger)
+	if err != nil {
+		return nil, worldError(err)
+	}
+	return map[string]interface{}{"quest_id": quest.ID, "player_id": playerID, "status": state.Status}, nil
+}
+
+// handleTriggerWorldEvent starts a world event, across the world or in one
+// room or territory, and announces it to the players there.
+func (td *ToolDispatcher) handleTriggerWorldEvent(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+	eventType, err := stringParam(params, "event_type", true)
+	if err != nil {
+		return nil, err
+	}
+	details, err := stringParam(params, "details", false)
+	if err != nil {
+		return nil, err
+	}
+	roomID, err := stringParam(params, "room_id", false)
+	if err != nil {
+		return nil, err
+	}
+	territoryID, err := stringParam(params, "territory_id", false)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := durationParam(params, "duration_seconds", DefaultWorldEventDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceType, sourceID, _ := influence.Entity(entity)
+	event, err := td.world.TriggerEvent(&models.WorldEvent{
+		EventType:   eventType,
+		Details:     details,
+		SourceType:  sourceType,
+		SourceID:    sourceID,
+		RoomID:      roomID,
+		TerritoryID: territoryID,
+	}, duration)
+	if err != nil {
+		return nil, worldError(err)
+	}
+	return map[string]interface{}{"event_id": event.ID, "ends_at": event.EndsAt.Format(time.RFC3339)}, nil
+}
+
+// handleSpawnEntity creates NPCs, copied from an existing NPC, or items from
+// a template in a room: the location parameter, or else the player's room.
+func (td *ToolDispatcher) handleSpawnEntity(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+	entityType, err := stringParam(params, "entity_type", true)
+	if err != nil {
+		return nil, err
+	}
+	templateID, err := stringParam(params, "entity_id", true)
+	if err != nil {
+		return nil, err
+	}
+	roomID, err := roomParam(player, params, "location")
+	if err != nil {
+		return nil, err
+	}
+	quantity, err := numberParam(params, "quantity", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{"room_id": roomID}
+	switch entityType {
+	case spawnNPC:
+		if quantity > MaxSpawnQuantity {
+			return nil, invalidCall("at most %d NPCs can be spawned at once", MaxSpawnQuantity)
+		}
+		var npcIDs []string
+		for i := 0; i < int(quantity); i++ {
+			npc, err := td.world.SpawnNPC(templateID, roomID)
+			if err != nil {
+				return nil, worldError(err)
+			}
+			npcIDs = append(npcIDs, npc.ID)
+		}
+		data["npc_ids"] = npcIDs
+	case spawnItem:
+		instance, err := td.world.SpawnItem(templateID, roomID, int(quantity))
+		if err != nil {
+			return nil, worldError(err)
+		}
+		data["item_instance_id"] = instance.ID
+	default:
+		return nil, invalidCall("unknown entity_type: %s", entityType)
+	}
+	return data, nil
+}
+
+// handleChangeRoomInfo changes a room's description and properties until the
+// change is reverted, or for duration_seconds when given. In
+// property_changes, "description" replaces the description,
+// "description_add" adds to it, and any other key sets that room property;
+// null removes it.
+func (td *ToolDispatcher) handleChangeRoomInfo(player *models.PlayerCharacter, entity interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+	roomID, err := roomParam(player, params, "room_id")
+	if err != nil {
+		return nil, err
+	}
+	changes, _ := params["property_changes"].(map[string]interface{})
+	duration, err := durationParam(params, "duration_seconds", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceType, sourceID, _ := influence.Entity(entity)
+	overlay := &models.RoomOverlay{RoomID: roomID, SourceType: sourceType, SourceID: sourceID}
+	properties := make(map[string]interface{})
+	for key, value := range changes {
+		switch key {
+		case "description":
+			overlay.Description, _ = value.(string)
+		case "description_add":
+			overlay.DescriptionAdd, _ = value.(string)
+		default:
+			properties[key] = value
+		}
+	}
+	if len(properties) > 0 {
+		encoded, err := json.Marshal(properties)
+		if err != nil {
+			return nil, invalidCall("property_changes cannot be stored: %v", err)
+		}
+		overlay.Properties = string(encoded)
+	}
+
+	overlay, err = td.world.ChangeRoom(overlay, duration)
+	if err != nil {
+		return nil, worldError(err)
+	}
+	data := map[string]interface{}{"overlay_id": overlay.ID, "room_id": overlay.RoomID}
+	if !overlay.ExpiresAt.IsZero() {
+		data["expires_at"] = overlay.ExpiresAt.Format(time.RFC3339)
+	}
+	return data, nil
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"mud/internal/game"
+	"mud/internal/presentation"
+)
+
+// WebSocketGateway lets browser clients play through the TelnetServer's session
+// machinery. Each text frame is treated as one or more lines of input, and every
+// outgoing SemanticMessage is sent as a text frame rendered by the gateway's renderer.
+type WebSocketGateway struct {
+	port         string
+	telnetServer *TelnetServer
+	renderer     game.TelnetRendererInterface
+	upgrader     websocket.Upgrader
+
+	// AllowedOrigins are the origins, such as "https://play.example.com", of
+	// pages on other hosts that may open sessions. Pages served from the
+	// gateway's own host always may.
+	AllowedOrigins []string
+}
+
+// NewWebSocketGateway creates a new WebSocketGateway that shares sessions and
+// player connections with telnetServer.
+func NewWebSocketGateway(port string, telnetServer *TelnetServer, renderer game.TelnetRendererInterface) *WebSocketGateway {
+	g := &WebSocketGateway{
+		port:         port,
+		telnetServer: telnetServer,
+		renderer:     renderer,
+	}
+	g.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     g.checkOrigin,
+	}
+	return g
+}
+
+// checkOrigin lets a request open a session if it comes from a page on the
+// gateway's own host or an allowed origin, so that other sites cannot play
+// with their visitors' logins. Requests without an Origin do not come from
+// browsers, and are let through.
+func (g *WebSocketGateway) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range g.AllowedOrigins {
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// Start begins listening for WebSocket connections on /ws.
+func (g *WebSocketGateway) Start() {
+	mux := http.NewServeMux()
+	mux.Handle("/ws", g)
+	logrus.Infof("WebSocket gateway listening on port %s", g.port)
+	if err := http.ListenAndServe(":"+g.port, mux); err != nil {
+		logrus.Fatalf("WebSocket gateway failed: %v", err)
+	}
+}
+
+// ServeHTTP upgrades the request to a WebSocket and runs the session until the client disconnects.
+func (g *WebSocketGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws, err := g.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logrus.Infof("WebSocket upgrade failed for %s: %v", r.RemoteAddr, err)
+		return
+	}
+
+	s := g.telnetServer
+	c := &client{conn: ws.UnderlyingConn(), ws: ws, renderer: g.renderer, state: StateWelcome}
+	defer func() {
+		s.unregisterClient(c)
+		logrus.Infof("WebSocket client %s disconnected: %v", r.RemoteAddr, ws.Close())
+	}()
+
+	logrus.Infof("New WebSocket connection from %s", r.RemoteAddr)
+	s.showWelcomeMenu(c)
+
+	for {
+		messageType, data, err := ws.ReadMessage()
+		if err != nil {
+			logrus.Infof("WebSocket client %s disconnected: %v", r.RemoteAddr, err)
+			return
+		}
+		if messageType != websocket.TextMessage {
+			continue
+		}
+		input := strings.TrimRight(parseWebSocketInput(data), "\r\n")
+		for _, line := range strings.Split(input, "\n") {
+			s.handleInput(c, strings.TrimSpace(line))
+		}
+	}
+}
+
+// parseWebSocketInput accepts either a raw command line or a JSON object of the
+// form {"input": "look"}.
+func parseWebSocketInput(data []byte) string {
+	var frame struct {
+		Input *string `json:"input"`
+	}
+	if err := json.Unmarshal(data, &frame); err == nil && frame.Input != nil {
+		return *frame.Input
+	}
+	return string(data)
+}
+
+// sendWebSocketMessage writes msg to a WebSocket client as a single text frame.
+func (s *TelnetServer) sendWebSocketMessage(c *client, msg presentation.SemanticMessage) {
+	rendered := c.renderer.RenderMessage(msg)
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	if err := c.ws.WriteMessage(websocket.TextMessage, []byte(rendered)); err != nil {
+		logrus.Infof("Failed to write WebSocket message to %s: %v", c.conn.RemoteAddr(), err)
+	}
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"mud/internal/game/events"
+	"mud/internal/presentation"
+)
+
+// TestWebSocketGateway_LoginAndPlay tests that a browser session logs in through the
+// same flow as telnet, receives unchanged semantic messages and joins playerConnections.
+func TestWebSocketGateway_LoginAndPlay(t *testing.T) {
+	telnetServer, _, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	gateway := NewWebSocketGateway("", telnetServer, presentation.NewJSONRenderer())
+	httpServer := httptest.NewServer(gateway)
+	defer httpServer.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), nil)
+	assert.NoError(t, err, "Failed to connect to WebSocket gateway")
+	defer ws.Close()
+
+	msg := readSemanticMessage(t, ws, func(m presentation.SemanticMessage) bool {
+		return strings.Contains(m.Content, "Welcome to GoMUD!")
+	})
+	assert.Equal(t, presentation.SystemMessage, msg.Type)
+	assert.Equal(t, presentation.ColorSuccess, msg.Color)
+
+	sendWebSocketInput(t, ws, "1")
+	readSemanticMessage(t, ws, contentIs("Enter username: "))
+	sendWebSocketInput(t, ws, `{"input": "test"}`)
+	readSemanticMessage(t, ws, contentIs("Enter password: "))
+	sendWebSocketInput(t, ws, "password")
+	readSemanticMessage(t, ws, func(m presentation.SemanticMessage) bool {
+		return strings.Contains(m.Content, "--- Character Selection ---")
+	})
+	sendWebSocketInput(t, ws, "1")
+	readSemanticMessage(t, ws, contentIs("Welcome, TestPlayer!"))
+
+	// Structured updates arrive with their payload intact.
+	vitals := readSemanticMessage(t, ws, func(m presentation.SemanticMessage) bool {
+		return m.Type == presentation.PlayerStatsUpdate
+	})
+	assert.Equal(t, float64(100), vitals.Payload["hp"])
+
+	room := readSemanticMessage(t, ws, func(m presentation.SemanticMessage) bool {
+		return m.Type == presentation.RoomUpdate
+	})
+	assert.Contains(t, room.Content, "--- Bag End, Hobbiton ---")
+	assert.Equal(t, "bag_end", room.Payload["num"])
+
+	// The session is registered like a telnet player, so world events reach it.
+	assert.Eventually(t, func() bool {
+		telnetServer.connectionsMutex.RLock()
+		defer telnetServer.connectionsMutex.RUnlock()
+		_, found := telnetServer.playerConnections["test_character"]
+		return found
+	}, 5*time.Second, 100*time.Millisecond)
+
+	telnetServer.eventBus.Publish(events.PlayerMessageEventType, &events.PlayerMessageEvent{PlayerID: "test_character", Content: "Frodo waves."})
+	narrative := readSemanticMessage(t, ws, contentIs("Frodo waves."))
+	assert.Equal(t, presentation.NarrativeMessage, narrative.Type)
+
+	// Disconnecting removes the session from playerConnections.
+	ws.Close()
+	assert.Eventually(t, func() bool {
+		telnetServer.connectionsMutex.RLock()
+		defer telnetServer.connectionsMutex.RUnlock()
+		_, found := telnetServer.playerConnections["test_character"]
+		return !found
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func contentIs(content string) func(presentation.SemanticMessage) bool {
+	return func(m presentation.SemanticMessage) bool { return m.Content == content }
+}
+
+func sendWebSocketInput(t *testing.T, ws *websocket.Conn, input string) {
+	t.Helper()
+	assert.NoError(t, ws.WriteMessage(websocket.TextMessage, []byte(input)), "Failed to write to WebSocket")
+}
+
+// readSemanticMessage reads frames until one decodes to a message accepted by match.
+func readSemanticMessage(t *testing.T, ws *websocket.Conn, match func(presentation.SemanticMessage) bool) presentation.SemanticMessage {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	defer ws.SetReadDeadline(time.Time{})
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			t.Fatalf("Did not receive expected message: %v", err)
+		}
+		var msg presentation.SemanticMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("Received non-JSON frame %q: %v", data, err)
+		}
+		if match(msg) {
+			return msg
+		}
+	}
+}
+
+func TestWebSocketGateway_CheckOrigin(t *testing.T) {
+	gateway := NewWebSocketGateway("", nil, presentation.NewJSONRenderer())
+	gateway.AllowedOrigins = []string{"https://play.example.com"}
+	request := func(origin string) *http.Request {
+		r := httptest.NewRequest("GET", "http://mud.example.com:4001/ws", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		return r
+	}
+
+	assert.True(t, gateway.checkOrigin(request("")), "Clients that are not browsers send no origin")
+	assert.True(t, gateway.checkOrigin(request("http://mud.example.com:4001")), "Pages served by the gateway's host")
+	assert.True(t, gateway.checkOrigin(request("https://PLAY.example.com")))
+	assert.False(t, gateway.checkOrigin(request("https://evil.example.com")))
+	assert.False(t, gateway.checkOrigin(request("http://mud.example.com:8080")), "Another port is another origin")
+}
@@ -0,0 +1,103 @@
+package testutils
+
+import (
+	"sync"
+
+	"mud/internal/models"
+)
+
+type message struct {
+	characterID string
+	content     string
+}
+
+// RecordingNotifier records what the game managers tell characters, for
+// tests to check. It satisfies each manager's Notifier, and its zero value
+// is ready to use.
+type RecordingNotifier struct {
+	mu            sync.Mutex
+	messages      []message
+	respawned     []string
+	effectChanges map[string]int
+}
+
+func (n *RecordingNotifier) Notify(characterID, content string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, message{characterID: characterID, content: content})
+}
+
+func (n *RecordingNotifier) VitalsChanged(character *models.PlayerCharacter) {}
+
+func (n *RecordingNotifier) Respawned(character *models.PlayerCharacter) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.respawned = append(n.respawned, character.ID)
+}
+
+func (n *RecordingNotifier) EffectsChanged(characterID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.effectChanges == nil {
+		n.effectChanges = make(map[string]int)
+	}
+	n.effectChanges[characterID]++
+}
+
+// Messages returns every message told so far, oldest first.
+func (n *RecordingNotifier) Messages() []string {
+	return n.collect("", false)
+}
+
+// MessagesFor returns the messages told to a character so far, oldest first.
+func (n *RecordingNotifier) MessagesFor(characterID string) []string {
+	return n.collect(characterID, false)
+}
+
+// Take returns every message told since the last Take, oldest first, and
+// forgets them.
+func (n *RecordingNotifier) Take() []string {
+	return n.collect("", true)
+}
+
+// TakeFor returns the messages told to a character since the last Take, and
+// forgets them.
+func (n *RecordingNotifier) TakeFor(characterID string) []string {
+	return n.collect(characterID, true)
+}
+
+// RespawnedIDs returns the characters respawned so far, in order.
+func (n *RecordingNotifier) RespawnedIDs() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.respawned...)
+}
+
+// EffectChanges returns how many times a character was told their effects
+// changed.
+func (n *RecordingNotifier) EffectChanges(characterID string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.effectChanges[characterID]
+}
+
+// collect returns the messages for a character, or for everyone if
+// characterID is empty, removing them if take is set.
+func (n *RecordingNotifier) collect(characterID string, take bool) []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var contents []string
+	kept := n.messages[:0]
+	for _, m := range n.messages {
+		if characterID != "" && m.characterID != characterID {
+			kept = append(kept, m)
+			continue
+		}
+		contents = append(contents, m.content)
+		if !take {
+			kept = append(kept, m)
+		}
+	}
+	n.messages = kept
+	return contents
+}
@@ -0,0 +1,32 @@
+// Package testdal provides DALs on throwaway databases for tests. It is kept
+// apart from testutils, which the dal package's own tests use.
+package testdal
+
+import (
+	"os"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"mud/internal/dal"
+)
+
+// New creates a DAL on an empty SQLite database in a temporary file,
+// which is closed and removed when the test ends.
+func New(t *testing.T) *dal.DAL {
+	t.Helper()
+
+	tmpfile, err := os.CreateTemp("", "mud_test_*.sqlite")
+	if err != nil {
+		t.Fatalf("Failed to create temp file for test database: %v", err)
+	}
+	tmpfile.Close()
+	db, err := dal.InitDB(tmpfile.Name())
+	if err != nil {
+		t.Fatalf("Failed to initialize test database: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		os.Remove(tmpfile.Name())
+	})
+	return dal.NewDAL(db)
+}
@@ -9,10 +9,15 @@ import (
 	"mud/internal/dal"
 
 	"mud/internal/game/actionsignificance"
+	"mud/internal/game/effects"
 	"mud/internal/game/events"
 	"mud/internal/game/globalobserver"
+	"mud/internal/game/influence"
+	"mud/internal/game/llmqueue"
 	"mud/internal/game/perception"
+	"mud/internal/game/scheduler"
 	"mud/internal/game/sentiententitymanager"
+	"mud/internal/game/stats"
 	"mud/internal/llm"
 	"mud/internal/presentation"
 	"mud/internal/server"
@@ -195,15 +200,27 @@ func main() {
 	// Initialize LLM Service
 	llmClient := llm.NewClient()
 	llmService := llm.NewLLMService(llmClient, dals)
+	if err := llmService.ConfigureFromEnv(); err != nil {
+		logrus.Fatalf("Failed to configure LLM providers: %v", err)
+	}
 
-	// Initialize Tool Dispatcher
-	toolDispatcher := server.NewToolDispatcher(dals)
+	// Initialize the influence ledger, which pays for tool calls and
+	// regenerates budgets on the world scheduler
+	ledger := influence.NewLedger(dals.OwnerDAL, dals.QuestmakerDAL, dals.QuestOwnerDAL, dals.InfluenceDAL)
 
 	// Initialize Event Bus
 	eventBus := events.NewEventBus()
 
-	// Initialize Perception Filter
-	perceptionFilter := perception.NewPerceptionFilter(dals.RoomDAL, dals.RaceDAL, dals.ProfessionDAL)
+	// Initialize Tool Dispatcher
+	toolDispatcher := server.NewToolDispatcher(dals, ledger, eventBus)
+	llmService.UseTools(toolDispatcher.Tools())
+	llmService.UseDispatcher(toolDispatcher)
+	toolDispatcher.UsePromptCache(llmService)
+
+	// Initialize Perception Filter. Observers' stats only read the active
+	// effects, so their effect manager needs no notifier.
+	statsManager := stats.NewManager(dals.RaceDAL, dals.ClassDAL, dals.PlayerClassDAL, dals.ItemInstanceDAL, effects.NewManager(dals.StatusEffectDAL, nil))
+	perceptionFilter := perception.NewPerceptionFilter(dals.RoomDAL, dals.RaceDAL, dals.ProfessionDAL, dals.SkillDAL, dals.PlayerSkillDAL, dals.StatusEffectDAL, statsManager)
 
 	// Initialize Sentient Entity Manager
 	telnetRenderer := presentation.NewTelnetRenderer()
@@ -211,6 +228,9 @@ func main() {
 
 	// Initialize Action Significance Monitor
 	actionMonitor := actionsignificance.NewMonitor(eventBus, perceptionFilter, dals.NpcDAL, dals.OwnerDAL, dals.QuestmakerDAL, sentientEntityManager)
+	// Reactions wait their turn on a bounded LLM work queue, so a crowded
+	// room cannot flood the providers with calls
+	actionMonitor.UseQueue(llmqueue.NewQueue(scheduler.RealClock{}))
 	actionMonitorEventChannel := make(chan interface{}, 500)
 	eventBus.Subscribe(events.ActionEventType, actionMonitorEventChannel)
 	go func() {
@@ -223,14 +243,16 @@ func main() {
 		}
 	}()
 
-	// Initialize Global Observer Manager
-	globalObserverManager := globalobserver.NewGlobalObserverManager(eventBus, perceptionFilter, dals.OwnerDAL, dals.RaceDAL, dals.ProfessionDAL)
+	// Initialize Global Observer Manager. It is fed from this one subscription,
+	// an event at a time, so that each action credits observers once and
+	// bursts of actions wait in the channel rather than piling up goroutines
+	globalObserverManager := globalobserver.NewGlobalObserverManager(eventBus, perceptionFilter, dals.OwnerDAL, dals.RaceDAL, dals.ProfessionDAL, ledger)
 	globalObserverEventChannel := make(chan interface{}, 100)
 	eventBus.Subscribe(events.ActionEventType, globalObserverEventChannel)
 	go func() {
 		for event := range globalObserverEventChannel {
 			if ae, ok := event.(*events.ActionEvent); ok {
-				go globalObserverManager.HandleActionEvent(ae)
+				globalObserverManager.HandleActionEvent(ae)
 			} else {
 				logrus.Errorf("main: received unexpected event type on ActionEventType for GlobalObserverManager: %T", event)
 			}
@@ -244,13 +266,26 @@ func main() {
 	if err != nil {
 		logrus.Fatalf("Failed to listen on port 4000: %v", err)
 	}
-	telnetServer := server.NewTelnetServer(listener, telnetRenderer, eventBus, dals, llmService)
+	// Every periodic and delayed job in the world runs on one scheduler, which
+	// the telnet server runs while it is up
+	worldScheduler := scheduler.NewScheduler(scheduler.RealClock{})
+	telnetServer := server.NewTelnetServer(listener, telnetRenderer, eventBus, dals, llmService, worldScheduler)
+	ledger.Schedule(worldScheduler)
+	toolDispatcher.Schedule(worldScheduler)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		telnetServer.Start()
 	}()
 
+	// Start WebSocket gateway in a goroutine; browser sessions share the Telnet server's world
+	webSocketGateway := server.NewWebSocketGateway("4001", telnetServer, presentation.NewJSONRenderer())
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		webSocketGateway.Start()
+	}()
+
 	// Start Admin Web server in a goroutine
 	adminWebServer := server.NewAdminWebServer("8080", db) // Using port 8080 for admin
 	wg.Add(1)
@@ -7,8 +7,9 @@ DB_FILE="./mud.db"
 
 cleanup() {
     echo "Cleaning up..."
-    # Force kill any processes using ports 4000 or 8080
+    # Force kill any processes using ports 4000, 4001 or 8080
     lsof -ti:4000 | xargs -r kill -9 || true
+    lsof -ti:4001 | xargs -r kill -9 || true
     lsof -ti:8080 | xargs -r kill -9 || true
     if [ -n "$SERVER_PID" ]; then
         echo "Killing server process $SERVER_PID"